@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/joho/godotenv"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/database"
+	"github.com/bankaceh/bas-portal-api/internal/loadtest"
+)
+
+// seed fills a non-production database with synthetic data for load testing
+func main() {
+	credentials := flag.Int("credentials", 100000, "number of partner credentials to create")
+	usageEvents := flag.Int("usage-events", 1000000, "number of hourly API key usage rows to create")
+	batchSize := flag.Int("batch-size", 1000, "rows per insert statement")
+	seed := flag.Int64("seed", 1, "random seed, the same seed produces the same dataset")
+	purge := flag.Bool("purge", false, "delete previously seeded data instead of seeding")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	cfg := config.Load()
+	if cfg.Env == "production" {
+		log.Fatal("Refusing to seed synthetic data into a production database")
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	if err := database.Migrate(db); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	if *purge {
+		deleted, err := loadtest.Purge(db)
+		if err != nil {
+			log.Fatalf("Failed to purge synthetic data: %v", err)
+		}
+		log.Printf("✅ Purged %d synthetic users with their credentials, API keys and usage", deleted)
+		return
+	}
+
+	result, err := loadtest.Seed(db, cfg, loadtest.SeedOptions{
+		Credentials: *credentials,
+		UsageEvents: *usageEvents,
+		BatchSize:   *batchSize,
+		Seed:        *seed,
+	})
+	if err != nil {
+		log.Fatalf("Failed to seed synthetic data: %v", err)
+	}
+
+	log.Printf("✅ Seeded %d users, %d partner credentials, %d API keys and %d usage events in %s",
+		result.Users, result.Credentials, result.APIKeys, result.UsageEvents, result.Duration)
+}
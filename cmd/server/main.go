@@ -2,19 +2,32 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/joho/godotenv"
 
+	"github.com/bankaceh/bas-portal-api/internal/breach"
+	"github.com/bankaceh/bas-portal-api/internal/captcha"
+	"github.com/bankaceh/bas-portal-api/internal/chaos"
 	"github.com/bankaceh/bas-portal-api/internal/config"
 	"github.com/bankaceh/bas-portal-api/internal/database"
+	"github.com/bankaceh/bas-portal-api/internal/events"
 	"github.com/bankaceh/bas-portal-api/internal/handlers"
+	"github.com/bankaceh/bas-portal-api/internal/jobs"
+	"github.com/bankaceh/bas-portal-api/internal/jwtkeys"
+	"github.com/bankaceh/bas-portal-api/internal/mailer"
 	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/redisstore"
 	"github.com/bankaceh/bas-portal-api/internal/repository"
 	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/bankaceh/bas-portal-api/internal/sms"
+	"github.com/bankaceh/bas-portal-api/internal/storage"
 )
 
 // @title BAS Portal API
@@ -45,6 +58,12 @@ func main() {
 
 	// Load configuration
 	cfg := config.Load()
+	if err := cfg.ValidateSecrets(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// New rows in the original tables get UUIDv7 IDs once opted in
+	models.UseTimeOrderedIDsEverywhere = cfg.TimeOrderedIDsEverywhere
 
 	// Initialize database
 	db, err := database.Connect(cfg)
@@ -57,38 +76,209 @@ func main() {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// Rate limit counters live in Redis when configured so every instance sees the same counts
+	var rateCounter services.RateCounter
+	if cfg.RedisURL != "" {
+		store, err := redisstore.New(cfg.RedisURL, "bas:ratelimit:")
+		if err != nil {
+			log.Fatalf("Failed to connect to Redis: %v", err)
+		}
+		rateCounter = store
+	} else {
+		log.Println("REDIS_URL not set, login and API key rate limits are kept in memory per instance")
+	}
+
+	// Domain events: services publish, subsystems subscribe
+	bus := events.NewBus()
+	bus.Subscribe("*", events.LogAudit)
+	if cfg.EventsRedisStream != "" {
+		if cfg.RedisURL == "" {
+			log.Fatal("EVENTS_REDIS_STREAM requires REDIS_URL")
+		}
+		transport, err := events.NewRedisStreamTransport(cfg.RedisURL, cfg.EventsRedisStream, 100000)
+		if err != nil {
+			log.Fatalf("Failed to connect event transport to Redis: %v", err)
+		}
+		bus.AddTransport(transport)
+	}
+
+	// Failure injection for resilience drills, never enabled in production
+	var injector *chaos.Injector
+	if cfg.Env != "production" {
+		injector = chaos.NewInjector()
+		if err := injector.InstallDBHooks(db); err != nil {
+			log.Fatalf("Failed to install chaos hooks: %v", err)
+		}
+		if rateCounter != nil {
+			rateCounter = injector.WrapCounter(rateCounter)
+		}
+	}
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
+	userIdentityRepo := repository.NewUserIdentityRepository(db)
 	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	apiKeyUsageRepo := repository.NewAPIKeyUsageRepository(db)
 	partnerCredRepo := repository.NewPartnerCredentialRepository(db)
+	organizationRepo := repository.NewOrganizationRepository(db)
+	orgInvitationRepo := repository.NewOrganizationInvitationRepository(db)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+	notificationPreferenceRepo := repository.NewNotificationPreferenceRepository(db)
+	companyRepo := repository.NewCompanyRepository(db)
+	oneTimeTokenRepo := repository.NewOneTimeTokenRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	accessRequestRepo := repository.NewAccessRequestRepository(db)
+	campaignRepo := repository.NewEmailCampaignRepository(db)
+	operationRepo := repository.NewOperationRepository(db)
+	domainRuleRepo := repository.NewEmailDomainRuleRepository(db)
+	invitationRepo := repository.NewInvitationCodeRepository(db)
+	securityWebhookRepo := repository.NewSecurityWebhookRepository(db)
+	apiKeyWebhookRepo := repository.NewAPIKeyWebhookRepository(db)
+	knownDeviceRepo := repository.NewKnownDeviceRepository(db)
+	smsCodeRepo := repository.NewSMSCodeRepository(db)
+	quotaRepo := repository.NewQuotaRepository(db)
+	expiryReminderRepo := repository.NewExpiryReminderRepository(db)
+	secretLinkRepo := repository.NewSecretLinkRepository(db)
+	serviceAccountRepo := repository.NewServiceAccountRepository(db)
+
+	// Load token signing keys
+	jwtKeys, err := jwtkeys.Load(cfg.JWTSigningKeys, cfg.JWTSecret, cfg.JWTIssuer, cfg.JWTAudience)
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	// Initialize mailer, upload storage, captcha verifier and breached password checker
+	mail := mailer.New(cfg)
+	texts := sms.New(cfg)
+	uploads := storage.New(cfg)
+	privateUploads := storage.NewPrivate(cfg)
+	captchaVerifier := captcha.New(cfg)
+	breachChecker, err := breach.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load breached password list: %v", err)
+	}
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo, cfg)
+	sessionService := services.NewSessionService(sessionRepo, cfg)
+	domainPolicyService := services.NewDomainPolicyService(domainRuleRepo, bus, cfg)
+	invitationService := services.NewInvitationService(invitationRepo, bus)
+	securityWebhookService := services.NewSecurityWebhookService(securityWebhookRepo, cfg)
+	for _, name := range services.SecurityWebhookEvents {
+		bus.Subscribe(name, securityWebhookService.Deliver)
+	}
+	organizationService := services.NewOrganizationService(organizationRepo, userRepo, apiKeyRepo, partnerCredRepo, bus)
+	companyService := services.NewCompanyService(companyRepo, organizationService, userRepo, privateUploads, mail, bus, cfg)
+	orgInvitationService := services.NewOrganizationInvitationService(orgInvitationRepo, organizationService, userRepo, mail, bus, cfg)
+	serviceAccountService := services.NewServiceAccountService(serviceAccountRepo, organizationService, bus)
+	serviceAccountService.Subscribe(bus)
+	quotaService := services.NewQuotaService(quotaRepo, userRepo, organizationRepo, cfg)
+	phoneService := services.NewPhoneService(userRepo, smsCodeRepo, sessionService, texts)
+	authService := services.NewAuthService(userRepo, userIdentityRepo, oneTimeTokenRepo, sessionService, domainPolicyService, invitationService, orgInvitationService, phoneService, breachChecker, mail, jwtKeys, bus, cfg)
+	identityService := services.NewIdentityService(userRepo, userIdentityRepo, sessionService, breachChecker, bus)
+	notificationPreferenceService := services.NewNotificationPreferenceService(notificationPreferenceRepo, userRepo)
+	securityNotificationService := services.NewSecurityNotificationService(userRepo, knownDeviceRepo, authService, notificationPreferenceService, mail)
+	securityNotificationService.Subscribe(bus)
 	userService := services.NewUserService(userRepo)
-	apiKeyService := services.NewAPIKeyService(apiKeyRepo)
-	partnerCredService := services.NewPartnerCredentialService(partnerCredRepo)
+	secretLinkService := services.NewSecretLinkService(secretLinkRepo, userRepo, mail, bus, cfg)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, apiKeyUsageRepo, organizationService, quotaService, secretLinkService, rateCounter, bus, cfg)
+	apiKeyWebhookService := services.NewAPIKeyWebhookService(apiKeyWebhookRepo, apiKeyRepo, cfg)
+	apiKeyWebhookService.Subscribe(bus)
+	partnerCredService := services.NewPartnerCredentialService(partnerCredRepo, userRepo, sessionService, organizationService, quotaService, secretLinkService, companyService, notificationPreferenceService, mail, bus, cfg)
+	expiryReminderService := services.NewExpiryReminderService(expiryReminderRepo, apiKeyRepo, partnerCredRepo, notificationPreferenceService, mail, cfg)
+	accessRequestService := services.NewAccessRequestService(accessRequestRepo, captchaVerifier, mail, cfg)
+	campaignService := services.NewCampaignService(campaignRepo, userRepo, mail, cfg)
+	operationService := services.NewOperationService(operationRepo)
+	dashboardService := services.NewDashboardService(userRepo, apiKeyRepo, partnerCredRepo, sessionRepo, quotaService)
+	checkupService := services.NewSecurityCheckupService(userRepo, apiKeyRepo, partnerCredRepo, cfg)
+	accountService := services.NewAccountService(userRepo, apiKeyRepo, partnerCredRepo, sessionService, bus, cfg)
+	roleService := services.NewRoleService(userRepo, bus, cfg)
+	suspensionService := services.NewSuspensionService(userRepo, sessionService, mail, bus)
+	approvalService := services.NewApprovalService(userRepo, mail, bus, cfg)
+	approvalService.Subscribe(bus)
+	incidentService := services.NewIncidentService(userRepo, sessionService, authService, mail, bus)
+	auditService := services.NewAuditService(auditLogRepo)
+	auditService.Subscribe(bus)
+	adminStatsService := services.NewAdminStatsService(userRepo, apiKeyRepo, partnerCredRepo, auditLogRepo)
+	profilePictureService := services.NewProfilePictureService(uploads, cfg)
+	dataExportService := services.NewDataExportService(operationService, userRepo, apiKeyRepo, partnerCredRepo, auditLogRepo, privateUploads)
+	if err := roleService.BootstrapAdmins(); err != nil {
+		log.Fatalf("Failed to promote ADMIN_EMAILS: %v", err)
+	}
+	if err := partnerCredService.HashLegacySecrets(); err != nil {
+		log.Fatalf("Failed to hash partner client secrets: %v", err)
+	}
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService)
-	userHandler := handlers.NewUserHandler(userService)
+	authHandler := handlers.NewAuthHandler(authService, identityService, cfg.FrontendURL, cfg.Env == "production", cfg.AuthCookieMode)
+	userHandler := handlers.NewUserHandler(userService, dashboardService, checkupService, accountService, profilePictureService)
 	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	apiKeyWebhookHandler := handlers.NewAPIKeyWebhookHandler(apiKeyWebhookService)
 	partnerCredHandler := handlers.NewPartnerCredentialHandler(partnerCredService)
+	secretLinkHandler := handlers.NewSecretLinkHandler(secretLinkService)
+	organizationHandler := handlers.NewOrganizationHandler(organizationService, orgInvitationService)
+	accessRequestHandler := handlers.NewAccessRequestHandler(accessRequestService)
+	campaignHandler := handlers.NewCampaignHandler(campaignService)
+	jwksHandler := handlers.NewJWKSHandler(jwtKeys)
+	operationHandler := handlers.NewOperationHandler(operationService)
+	domainPolicyHandler := handlers.NewDomainPolicyHandler(domainPolicyService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
+	securityWebhookHandler := handlers.NewSecurityWebhookHandler(securityWebhookService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	suspensionHandler := handlers.NewSuspensionHandler(suspensionService)
+	approvalHandler := handlers.NewApprovalHandler(approvalService)
+	incidentHandler := handlers.NewIncidentHandler(incidentService)
+	adminAPIKeyHandler := handlers.NewAdminAPIKeyHandler(apiKeyService)
+	quotaHandler := handlers.NewQuotaHandler(quotaService)
+	serviceAccountHandler := handlers.NewServiceAccountHandler(serviceAccountService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	adminStatsHandler := handlers.NewAdminStatsHandler(adminStatsService)
+	notificationPreferenceHandler := handlers.NewNotificationPreferenceHandler(notificationPreferenceService)
+	companyHandler := handlers.NewCompanyHandler(companyService)
+	dataExportHandler := handlers.NewDataExportHandler(dataExportService)
+	phoneHandler := handlers.NewPhoneHandler(phoneService)
+
+	// Start background jobs
+	scheduler := jobs.NewScheduler()
+	scheduler.Register("email-campaigns", 30*time.Second, campaignService.SendDueCampaigns)
+	scheduler.Register("credential-inactivity", time.Hour, partnerCredService.EnforceInactivityPolicy)
+	scheduler.Register("api-key-expiry", 5*time.Minute, apiKeyService.DeactivateExpiredKeys)
+	scheduler.Register("expiry-reminders", time.Hour, expiryReminderService.SendReminders)
+	scheduler.Register("secret-links", time.Hour, secretLinkService.DeleteExpired)
+	scheduler.Register("api-key-usage", 15*time.Second, apiKeyService.FlushUsage)
+	scheduler.Register("api-key-usage-retention", 24*time.Hour, apiKeyService.PruneUsage)
+	scheduler.Register("operations", 2*time.Second, operationService.RunPending)
+	scheduler.Register("account-anonymization", time.Hour, accountService.AnonymizeDeletedAccounts)
+	scheduler.Start()
 
 	// Create Fiber app
-	app := fiber.New(fiber.Config{
+	fiberConfig := fiber.Config{
 		AppName:      "BAS Portal API v1.0",
 		ErrorHandler: handlers.ErrorHandler,
-	})
+		BodyLimit:    max(fiber.DefaultBodyLimit, int(max(profilePictureService.MaxBytes(), companyService.MaxDocumentBytes()))+1024*1024), // Room for multipart overhead
+	}
+	// Behind a load balancer, take the client IP from its header, but only on requests
+	// that come from the load balancer so clients cannot spoof it
+	if len(cfg.TrustedProxies) > 0 {
+		fiberConfig.ProxyHeader = cfg.ProxyHeader
+		fiberConfig.EnableTrustedProxyCheck = true
+		fiberConfig.TrustedProxies = cfg.TrustedProxies
+		fiberConfig.EnableIPValidation = true
+	}
+	app := fiber.New(fiberConfig)
 
 	// Middleware
 	app.Use(recover.New())
+	app.Use(requestid.New())
 	app.Use(logger.New())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:5173, http://localhost:3001, http://127.0.0.1:5173, http://127.0.0.1:4173",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, " + middleware.CSRFHeader,
 		AllowMethods:     "GET, POST, PUT, DELETE, OPTIONS",
 		AllowCredentials: true,
 	}))
+	if injector != nil {
+		app.Use(middleware.FaultInjection(injector))
+	}
 
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -98,41 +288,257 @@ func main() {
 		})
 	})
 
+	// Uploaded files, when they are kept on local disk
+	if cfg.S3Endpoint == "" {
+		app.Static("/uploads", cfg.UploadDir)
+	}
+
+	// Token signing keys for gateways that validate portal tokens
+	app.Get("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
+	// Admin and debug routes are only reachable from office/VPN ranges. A typo in the list
+	// stops the server rather than opening them up.
+	adminAllowlist, err := middleware.IPAllowlist(cfg.AdminIPAllowlist, auditService)
+	if err != nil {
+		log.Fatalf("Invalid ADMIN_IP_ALLOWLIST: %v", err)
+	}
+
 	// API v1 routes
 	api := app.Group("/api/v1")
+	if cfg.AuthCookieMode {
+		api.Use(middleware.CSRF(handlers.RefreshTokenCookie))
+	}
 
 	// Auth routes (public)
 	auth := api.Group("/auth")
-	auth.Post("/register", authHandler.Register)
-	auth.Post("/login", authHandler.Login)
+	credentialLimit := middleware.CredentialRateLimit(cfg.LoginRateLimit, time.Duration(cfg.LoginRateLimitWindowSeconds)*time.Second, rateCounter)
+	auth.Post("/register", credentialLimit, authHandler.Register)
+	auth.Post("/login", credentialLimit, authHandler.Login)
+	auth.Post("/mfa/verify", credentialLimit, authHandler.VerifyMFA)
 	auth.Get("/google", authHandler.GoogleLogin)
 	auth.Get("/google/callback", authHandler.GoogleCallback)
 	auth.Post("/refresh", authHandler.RefreshToken)
+	auth.Post("/logout", authHandler.Logout)
+	auth.Get("/csrf", authHandler.GetCSRFToken)
+	auth.Post("/magic-link",
+		middleware.RateLimit(cfg.AuthEmailRateLimit, time.Hour),
+		authHandler.RequestMagicLink,
+	)
+	auth.Post("/magic-link/verify", authHandler.VerifyMagicLink)
+	auth.Post("/verify-email", authHandler.VerifyEmail)
+	auth.Post("/resend-verification",
+		middleware.RateLimit(cfg.AuthEmailRateLimit, time.Hour),
+		authHandler.ResendVerification,
+	)
+	auth.Post("/forgot-password",
+		middleware.RateLimit(cfg.AuthEmailRateLimit, time.Hour),
+		authHandler.ForgotPassword,
+	)
+	auth.Post("/reset-password", authHandler.ResetPassword)
+	auth.Post("/lock-account", authHandler.LockAccount)
+
+	// Public routes (no authentication)
+	public := api.Group("/public")
+	public.Post("/access-request",
+		middleware.RateLimit(cfg.AccessRequestRateLimit, time.Hour),
+		accessRequestHandler.SubmitAccessRequest,
+	)
+	public.Post("/unsubscribe", campaignHandler.Unsubscribe)
+	public.Post("/secrets/retrieve",
+		middleware.RateLimit(cfg.AccessRequestRateLimit, time.Hour),
+		secretLinkHandler.RetrieveSecret,
+	)
+
+	// Organization invitations are answered with the emailed token; accepting needs sign-in
+	orgInvitations := api.Group("/organization-invitations")
+	orgInvitations.Post("/lookup", organizationHandler.PreviewInvitation)
+	orgInvitations.Post("/decline", organizationHandler.DeclineInvitation)
+
+	// Debug routes for resilience drills (non-production only)
+	if injector != nil {
+		debugHandler := handlers.NewDebugHandler(injector)
+		debug := api.Group("/debug",
+			adminAllowlist,
+			middleware.JWTAuth(jwtKeys, sessionService, suspensionService, serviceAccountService, apiKeyService),
+			// Only signed-in admins, never service account tokens or API keys
+			middleware.RestrictServiceAccounts(nil),
+			middleware.RequireRole(roleService, models.RoleAdmin),
+			middleware.AuditTrail(auditService),
+		)
+		debug.Get("/chaos", debugHandler.GetChaosState)
+		debug.Delete("/chaos", debugHandler.ResetChaos)
+		debug.Put("/chaos/db-latency", debugHandler.SetDBLatency)
+		debug.Put("/chaos/redis", debugHandler.SetRedisDropped)
+		debug.Post("/chaos/faults", debugHandler.AddFault)
+		debug.Delete("/chaos/faults", debugHandler.RemoveFault)
+		debug.Get("/loadtest/k6", debugHandler.GetK6Scenario)
+		log.Println("⚠️  Debug chaos endpoints are enabled")
+	}
+
+	// Admin routes are only reachable from office/VPN ranges
+	api.Use("/admin", adminAllowlist)
 
 	// Protected routes
-	protected := api.Group("", middleware.JWTAuth(cfg.JWTSecret))
+	protected := api.Group("",
+		middleware.JWTAuth(jwtKeys, sessionService, suspensionService, serviceAccountService, apiKeyService),
+		// Service account tokens and API keys only reach the routes their scopes cover
+		middleware.RestrictServiceAccounts(map[string]string{
+			"/api/v1/api-keys":            models.PermissionManageAPIKeys,
+			"/api/v1/partner-credentials": models.PermissionManageCredentials,
+		}),
+		// An expired password only lets the user see their profile and change it
+		middleware.RequireCurrentPassword("GET /api/v1/users/me", "PUT /api/v1/users/me/password"),
+		middleware.AuditTrail(auditService),
+	)
+
+	// Optionally hold back key and credential creation until the email is verified
+	requireVerified := func(c *fiber.Ctx) error { return c.Next() }
+	if cfg.RequireVerifiedEmail {
+		requireVerified = middleware.RequireVerifiedEmail(userService)
+	}
+
+	// Pending and rejected accounts cannot create keys or credentials. Accounts only start
+	// pending when REGISTRATION_REQUIRE_APPROVAL is on, so this is always checked.
+	requireApproved := middleware.RequireApproved(approvalService)
 
 	// User routes
 	users := protected.Group("/users")
 	users.Get("/me", userHandler.GetProfile)
 	users.Put("/me", userHandler.UpdateProfile)
+	users.Patch("/me/metadata", userHandler.UpdateMetadata)
+	users.Put("/me/phone", phoneHandler.SetPhone)
+	users.Post("/me/phone/verify", phoneHandler.VerifyPhone)
+	users.Post("/me/mfa/sms", phoneHandler.EnableSMSMFA)
+	users.Delete("/me/mfa/sms", phoneHandler.DisableSMSMFA)
+	users.Post("/me/profile-picture", userHandler.UploadProfilePicture)
+	users.Delete("/me", userHandler.DeleteAccount)
+	users.Get("/me/dashboard", userHandler.GetDashboard)
+	users.Get("/me/activity", auditHandler.ListActivity)
+	users.Put("/me/password", authHandler.ChangePassword)
+	users.Get("/me/identities", authHandler.ListIdentities)
+	users.Post("/me/identities", authHandler.LinkIdentity)
+	users.Delete("/me/identities/:provider", authHandler.UnlinkIdentity)
+	users.Get("/me/security-checkup", userHandler.GetSecurityCheckup)
+	users.Get("/me/preferences", notificationPreferenceHandler.GetPreferences)
+	users.Put("/me/preferences", notificationPreferenceHandler.UpdatePreferences)
+	users.Post("/me/export", dataExportHandler.StartExport)
+	users.Get("/me/export", dataExportHandler.DownloadExport)
+	users.Get("/me/api-key-webhook", apiKeyWebhookHandler.GetWebhook)
+	users.Put("/me/api-key-webhook", apiKeyWebhookHandler.ConfigureWebhook)
+	users.Delete("/me/api-key-webhook", apiKeyWebhookHandler.DeleteWebhook)
+	users.Post("/me/api-key-webhook/test", apiKeyWebhookHandler.TestWebhook)
 
 	// API Key routes
 	apiKeys := protected.Group("/api-keys")
 	apiKeys.Get("/", apiKeyHandler.ListKeys)
-	apiKeys.Post("/", apiKeyHandler.CreateKey)
+	apiKeys.Post("/", requireVerified, requireApproved, apiKeyHandler.CreateKey)
+	apiKeys.Put("/:id", apiKeyHandler.UpdateKey)
+	apiKeys.Get("/:id/usage", apiKeyHandler.GetUsage)
 	apiKeys.Delete("/:id", apiKeyHandler.RevokeKey)
+	apiKeys.Post("/revoke-all", apiKeyHandler.RevokeAllKeys)
+	apiKeys.Post("/verify", apiKeyHandler.VerifyKey)
+	apiKeys.Post("/:id/rotate", requireVerified, requireApproved, apiKeyHandler.RotateKey)
 
 	// Partner Credential routes (SNAP API)
 	partnerCreds := protected.Group("/partner-credentials")
 	partnerCreds.Get("/", partnerCredHandler.ListCredentials)
 	partnerCreds.Get("/:id", partnerCredHandler.GetCredential)
-	partnerCreds.Post("/", partnerCredHandler.CreateCredential)
+	partnerCreds.Post("/", requireVerified, requireApproved, partnerCredHandler.CreateCredential)
 	partnerCreds.Put("/:id", partnerCredHandler.UpdateCredential)
 	partnerCreds.Put("/:id/public-key", partnerCredHandler.UpdatePublicKey)
 	partnerCreds.Post("/:id/regenerate-secret", partnerCredHandler.RegenerateSecret)
+	partnerCreds.Post("/:id/reactivate", partnerCredHandler.ReactivateCredential)
 	partnerCreds.Delete("/:id", partnerCredHandler.DeleteCredential)
 
+	// Organization routes
+	orgs := protected.Group("/organizations")
+	orgs.Get("/", organizationHandler.ListOrganizations)
+	orgs.Post("/", requireVerified, organizationHandler.CreateOrganization)
+	orgs.Get("/:id", organizationHandler.GetOrganization)
+	orgs.Put("/:id", organizationHandler.RenameOrganization)
+	orgs.Delete("/:id", organizationHandler.DeleteOrganization)
+	orgs.Get("/:id/invitations", organizationHandler.ListInvitations)
+	orgs.Post("/:id/invitations", requireVerified, organizationHandler.InviteMember)
+	orgs.Delete("/:id/invitations/:invitationId", organizationHandler.RevokeInvitation)
+	orgs.Put("/:id/members/:userId", organizationHandler.UpdateMember)
+	orgs.Delete("/:id/members/:userId", organizationHandler.RemoveMember)
+	orgs.Get("/:id/service-accounts", serviceAccountHandler.ListServiceAccounts)
+	orgs.Post("/:id/service-accounts", requireVerified, requireApproved, serviceAccountHandler.CreateServiceAccount)
+	orgs.Delete("/:id/service-accounts/:accountId", serviceAccountHandler.DeleteServiceAccount)
+	orgs.Get("/:id/service-accounts/:accountId/tokens", serviceAccountHandler.ListTokens)
+	orgs.Post("/:id/service-accounts/:accountId/tokens", serviceAccountHandler.CreateToken)
+	orgs.Delete("/:id/service-accounts/:accountId/tokens/:tokenId", serviceAccountHandler.RevokeToken)
+	protected.Post("/organization-invitations/accept", organizationHandler.AcceptInvitation)
+
+	// Company profile and KYC documents, personal or per organization (?organizationId=)
+	company := protected.Group("/company")
+	company.Get("/", companyHandler.GetCompany)
+	company.Put("/", companyHandler.SaveCompany)
+	company.Post("/documents", companyHandler.UploadDocument)
+	company.Post("/submit", requireVerified, companyHandler.SubmitCompany)
+
+	// Long-running operation status
+	protected.Get("/operations/:id", operationHandler.GetOperation)
+
+	// Admin routes are for bank staff; settings and roles are admin-only
+	admin := protected.Group("/admin", middleware.RequireRole(roleService, models.RoleOperator, models.RoleAdmin))
+	adminOnly := middleware.RequireRole(roleService, models.RoleAdmin)
+
+	campaigns := admin.Group("/campaigns")
+	campaigns.Get("/", campaignHandler.ListCampaigns)
+	campaigns.Post("/", campaignHandler.CreateCampaign)
+	campaigns.Get("/:id", campaignHandler.GetCampaign)
+	campaigns.Get("/:id/preview", campaignHandler.PreviewCampaign)
+	campaigns.Post("/:id/schedule", campaignHandler.ScheduleCampaign)
+	campaigns.Post("/:id/send", campaignHandler.SendCampaign)
+
+	emailDomains := admin.Group("/settings/email-domains", adminOnly)
+	emailDomains.Get("/", domainPolicyHandler.GetPolicy)
+	emailDomains.Get("/history", domainPolicyHandler.GetHistory)
+	emailDomains.Post("/", domainPolicyHandler.AddRule)
+	emailDomains.Delete("/:id", domainPolicyHandler.DeleteRule)
+
+	securityWebhook := admin.Group("/settings/security-webhook", adminOnly)
+	securityWebhook.Get("/", securityWebhookHandler.GetWebhook)
+	securityWebhook.Put("/", securityWebhookHandler.ConfigureWebhook)
+	securityWebhook.Delete("/", securityWebhookHandler.DeleteWebhook)
+	securityWebhook.Post("/test", securityWebhookHandler.TestWebhook)
+
+	invitations := admin.Group("/invitations")
+	invitations.Get("/", invitationHandler.ListInvitations)
+	invitations.Post("/", invitationHandler.CreateInvitation)
+	invitations.Delete("/:id", invitationHandler.RevokeInvitation)
+
+	admin.Get("/staff", adminOnly, roleHandler.ListStaff)
+	admin.Put("/users/:id/role", adminOnly, roleHandler.AssignRole)
+	admin.Get("/users/pending", approvalHandler.ListPending)
+	admin.Post("/users/:id/approve", approvalHandler.ApproveUser)
+	admin.Post("/users/:id/reject", approvalHandler.RejectUser)
+	admin.Post("/users/:id/suspend", adminOnly, suspensionHandler.SuspendUser)
+	admin.Post("/users/:id/reactivate", adminOnly, suspensionHandler.ReactivateUser)
+	admin.Get("/users/:id/suspensions", adminOnly, suspensionHandler.ListSuspensions)
+	admin.Post("/users/:id/logout", adminOnly, incidentHandler.ForceLogout)
+	admin.Post("/users/:id/require-password-reset", adminOnly, incidentHandler.RequirePasswordReset)
+	admin.Post("/users/:id/api-keys/revoke-all", adminOnly, adminAPIKeyHandler.RevokeAllKeys)
+	admin.Get("/users/:id/quota", adminOnly, quotaHandler.GetUserQuota)
+	admin.Put("/users/:id/quota", adminOnly, quotaHandler.UpdateUserQuota)
+	admin.Get("/organizations/:id/quota", adminOnly, quotaHandler.GetOrganizationQuota)
+	admin.Put("/organizations/:id/quota", adminOnly, quotaHandler.UpdateOrganizationQuota)
+	admin.Get("/audit-logs", adminOnly, auditHandler.ListAuditLogs)
+	admin.Get("/stats", adminOnly, adminStatsHandler.GetStats)
+
+	adminAPIKeys := admin.Group("/api-keys", adminOnly)
+	adminAPIKeys.Get("/", adminAPIKeyHandler.ListKeys)
+	adminAPIKeys.Post("/:id/revoke", adminAPIKeyHandler.RevokeKey)
+	adminAPIKeys.Post("/:id/reactivate", adminAPIKeyHandler.ReactivateKey)
+
+	companies := admin.Group("/companies")
+	companies.Get("/", companyHandler.ListCompanies)
+	companies.Get("/:id", companyHandler.GetCompanyForReview)
+	companies.Get("/:id/documents/:documentId", companyHandler.DownloadDocument)
+	companies.Post("/:id/approve", companyHandler.ApproveCompany)
+	companies.Post("/:id/reject", companyHandler.RejectCompany)
+
 	// Start server
 	port := cfg.Port
 	if port == "" {
@@ -0,0 +1,151 @@
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+)
+
+// commonPasswords is the built-in offline list of widely breached passwords
+//
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// Checker reports whether a password appears in known data breaches
+type Checker interface {
+	IsBreached(password string) (bool, error)
+}
+
+// New returns a Have I Been Pwned range client backed by the offline list, or only the
+// offline list when the online check is disabled
+func New(cfg *config.Config) (Checker, error) {
+	offline, err := loadOfflineList(cfg.BreachedPasswordsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.BreachedPasswordCheckOnline {
+		log.Println("Online breached password check disabled, using the offline list only")
+		return offline, nil
+	}
+
+	return &PwnedPasswordsClient{
+		rangeURL: strings.TrimRight(cfg.PwnedPasswordsURL, "/") + "/",
+		client:   &http.Client{Timeout: 3 * time.Second},
+		fallback: offline,
+	}, nil
+}
+
+// PwnedPasswordsClient checks passwords against the Pwned Passwords range API. Only the
+// first five hex characters of the SHA-1 hash leave the server (k-anonymity).
+type PwnedPasswordsClient struct {
+	rangeURL string
+	client   *http.Client
+	fallback *OfflineList
+}
+
+// IsBreached reports whether the password is in the Pwned Passwords corpus. When the API
+// is unreachable it falls back to the offline list instead of failing the signup.
+func (c *PwnedPasswordsClient) IsBreached(password string) (bool, error) {
+	if breached, _ := c.fallback.IsBreached(password); breached {
+		return true, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	breached, err := c.lookup(prefix, suffix)
+	if err != nil {
+		log.Printf("Pwned Passwords lookup failed, using offline list only: %v", err)
+		return false, nil
+	}
+	return breached, nil
+}
+
+// lookup fetches the hash suffixes sharing prefix and looks for suffix among them
+func (c *PwnedPasswordsClient) lookup(prefix, suffix string) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, c.rangeURL+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	// Padding hides the real number of matches from anyone watching response sizes
+	req.Header.Set("Add-Padding", "true")
+	req.Header.Set("User-Agent", "bas-portal-api")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		// Lines are SUFFIX:COUNT; padding entries have a count of 0
+		candidate, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if candidate == suffix && count != "0" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// OfflineList checks passwords against a fixed list held in memory
+type OfflineList struct {
+	passwords map[string]struct{}
+}
+
+// IsBreached reports whether the password is on the list
+func (l *OfflineList) IsBreached(password string) (bool, error) {
+	_, found := l.passwords[strings.ToLower(password)]
+	return found, nil
+}
+
+// loadOfflineList combines the built-in list with an optional file of one password per line
+func loadOfflineList(path string) (*OfflineList, error) {
+	list := &OfflineList{passwords: make(map[string]struct{})}
+	if err := list.add(strings.NewReader(commonPasswords)); err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open breached passwords file: %w", err)
+		}
+		defer file.Close()
+
+		if err := list.add(file); err != nil {
+			return nil, fmt.Errorf("failed to read breached passwords file: %w", err)
+		}
+	}
+
+	return list, nil
+}
+
+// add reads one password per line, skipping blanks and # comments
+func (l *OfflineList) add(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		l.passwords[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
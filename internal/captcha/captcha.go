@@ -0,0 +1,75 @@
+package captcha
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+)
+
+// Verifier checks captcha responses submitted by clients
+type Verifier interface {
+	Verify(token, remoteIP string) (bool, error)
+}
+
+// New returns a siteverify client when a captcha secret is configured,
+// otherwise a verifier that accepts every request (development only)
+func New(cfg *config.Config) Verifier {
+	if cfg.CaptchaSecret == "" {
+		log.Println("CAPTCHA_SECRET not set, captcha verification is disabled")
+		return &NoopVerifier{}
+	}
+
+	return &SiteVerifyClient{
+		secret:    cfg.CaptchaSecret,
+		verifyURL: cfg.CaptchaVerifyURL,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SiteVerifyClient verifies tokens against an hCaptcha/reCAPTCHA compatible siteverify endpoint
+type SiteVerifyClient struct {
+	secret    string
+	verifyURL string
+	client    *http.Client
+}
+
+// Verify reports whether the captcha token is valid
+func (v *SiteVerifyClient) Verify(token, remoteIP string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+
+	form := url.Values{
+		"secret":   {v.secret},
+		"response": {token},
+		"remoteip": {remoteIP},
+	}
+
+	resp, err := v.client.PostForm(v.verifyURL, form)
+	if err != nil {
+		return false, fmt.Errorf("captcha verification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("invalid captcha verification response: %w", err)
+	}
+
+	return result.Success, nil
+}
+
+// NoopVerifier accepts every captcha token
+type NoopVerifier struct{}
+
+// Verify always succeeds
+func (v *NoopVerifier) Verify(token, remoteIP string) (bool, error) {
+	return true, nil
+}
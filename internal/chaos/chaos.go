@@ -0,0 +1,182 @@
+package chaos
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxDBLatency caps injected database latency so a drill cannot wedge the server
+const MaxDBLatency = 30 * time.Second
+
+// ErrRedisDropped is returned by wrapped Redis clients while a Redis outage is injected
+var ErrRedisDropped = errors.New("chaos: Redis connection dropped")
+
+// Fault forces requests under a path prefix to fail with the given status code
+type Fault struct {
+	PathPrefix string `json:"pathPrefix"`
+	StatusCode int    `json:"statusCode"`
+}
+
+// State is a snapshot of the currently injected failures
+type State struct {
+	DBLatencyMs  int64   `json:"dbLatencyMs"`
+	RedisDropped bool    `json:"redisDropped"`
+	Faults       []Fault `json:"faults"`
+}
+
+// Injector holds failures injected during resilience drills. It is safe for concurrent use.
+type Injector struct {
+	mu           sync.RWMutex
+	dbLatency    time.Duration
+	redisDropped bool
+	faults       map[string]int
+}
+
+// NewInjector creates an Injector with nothing injected
+func NewInjector() *Injector {
+	return &Injector{faults: make(map[string]int)}
+}
+
+// SetDBLatency delays every database statement by d; zero turns it off
+func (i *Injector) SetDBLatency(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	if d > MaxDBLatency {
+		d = MaxDBLatency
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.dbLatency = d
+}
+
+// DBLatency returns the currently injected database latency
+func (i *Injector) DBLatency() time.Duration {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.dbLatency
+}
+
+// SetRedisDropped makes every wrapped Redis call fail as if the connection was lost, or
+// restores them
+func (i *Injector) SetRedisDropped(dropped bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.redisDropped = dropped
+}
+
+// RedisDropped reports whether a Redis outage is injected
+func (i *Injector) RedisDropped() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.redisDropped
+}
+
+// AddFault makes requests under pathPrefix fail with statusCode
+func (i *Injector) AddFault(pathPrefix string, statusCode int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.faults[pathPrefix] = statusCode
+}
+
+// RemoveFault stops failing requests under pathPrefix
+func (i *Injector) RemoveFault(pathPrefix string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.faults, pathPrefix)
+}
+
+// FaultFor returns the status code to fail a request path with, preferring the longest matching prefix
+func (i *Injector) FaultFor(path string) (int, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	matched, statusCode := "", 0
+	for prefix, code := range i.faults {
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(matched) {
+			matched, statusCode = prefix, code
+		}
+	}
+	return statusCode, matched != ""
+}
+
+// Reset clears every injected failure
+func (i *Injector) Reset() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.dbLatency = 0
+	i.redisDropped = false
+	i.faults = make(map[string]int)
+}
+
+// State returns a snapshot of the injected failures
+func (i *Injector) State() State {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	faults := make([]Fault, 0, len(i.faults))
+	for prefix, code := range i.faults {
+		faults = append(faults, Fault{PathPrefix: prefix, StatusCode: code})
+	}
+	sort.Slice(faults, func(a, b int) bool { return faults[a].PathPrefix < faults[b].PathPrefix })
+
+	return State{DBLatencyMs: i.dbLatency.Milliseconds(), RedisDropped: i.redisDropped, Faults: faults}
+}
+
+// InstallDBHooks registers GORM callbacks that apply the injected latency before every statement
+func (i *Injector) InstallDBHooks(db *gorm.DB) error {
+	delay := func(*gorm.DB) {
+		if d := i.DBLatency(); d > 0 {
+			time.Sleep(d)
+		}
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("chaos:create_latency", delay); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("chaos:query_latency", delay); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("chaos:update_latency", delay); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("chaos:delete_latency", delay); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("chaos:row_latency", delay); err != nil {
+		return err
+	}
+	return callbacks.Raw().Before("gorm:raw").Register("chaos:raw_latency", delay)
+}
+
+// Counter is a Redis-backed counter such as redisstore.Storage
+type Counter interface {
+	Incr(key string, exp time.Duration) (int64, error)
+}
+
+// WrapCounter returns a Counter that fails with ErrRedisDropped while a Redis outage is
+// injected, so drills can check how rate limits and replay checks behave without Redis
+func (i *Injector) WrapCounter(counter Counter) Counter {
+	return &droppableCounter{injector: i, counter: counter}
+}
+
+// droppableCounter fails its calls while the injector drops Redis
+type droppableCounter struct {
+	injector *Injector
+	counter  Counter
+}
+
+// Incr implements Counter
+func (d *droppableCounter) Incr(key string, exp time.Duration) (int64, error) {
+	if d.injector.RedisDropped() {
+		return 0, ErrRedisDropped
+	}
+	return d.counter.Incr(key, exp)
+}
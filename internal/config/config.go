@@ -1,15 +1,27 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+)
+
+// Development defaults for secret keys. They are public, so ValidateSecrets refuses them
+// in production.
+const (
+	defaultJWTSecret                 = "default-secret-change-me"
+	defaultClientSecretHashKey       = "default-client-secret-key-change-me"
+	defaultClientSecretEncryptionKey = "default-client-secret-encryption-key-change-me"
 )
 
 // Config holds all configuration for the application
 type Config struct {
 	// Server
-	Port string
-	Env  string
+	Port           string
+	Env            string
+	TrustedProxies []string // Load balancer addresses whose ProxyHeader gives the client IP
+	ProxyHeader    string
 
 	// Database
 	DBHost     string
@@ -22,6 +34,32 @@ type Config struct {
 	// JWT
 	JWTSecret      string
 	JWTExpiryHours int
+	JWTSigningKeys []string // "kid=path" PEM entries, first one signs
+	JWTIssuer      string
+	JWTAudience    string
+
+	// Sessions
+	SessionIdleTimeoutMinutes int
+	MaxSessionsPerUser        int // Oldest sessions are revoked beyond this, 0 for no limit
+
+	// Refresh tokens are kept in httpOnly cookies, with CSRF checks, instead of the body
+	AuthCookieMode bool
+
+	// Redis, shared by all API instances for rate limit counters
+	RedisURL string
+
+	// Domain events are also appended to this Redis stream when set
+	EventsRedisStream string
+
+	// Login and registration attempts per client IP and email
+	LoginRateLimit              int
+	LoginRateLimitWindowSeconds int
+
+	// Magic link, verification and password reset emails per client IP per hour
+	AuthEmailRateLimit int
+
+	// Identifiers
+	TimeOrderedIDsEverywhere bool // Also use UUIDv7 for users, API keys and partner credentials
 
 	// Google OAuth
 	GoogleClientID     string
@@ -30,15 +68,138 @@ type Config struct {
 
 	// Frontend
 	FrontendURL string
+
+	// Admin
+	AdminIPAllowlist  []string
+	AdminEmails       []string // Promoted to the admin role at startup
+	AdminEmailDomains []string // When set, operator and admin roles require an email in one of these domains
+
+	// Registration email domains, admins can add more rules at runtime
+	RegistrationAllowedDomains  []string
+	RegistrationBlockedDomains  []string
+	RegistrationInviteOnly      bool // Registration needs an admin-issued invitation code
+	RegistrationRequireApproval bool // New accounts wait for staff approval before creating keys and credentials
+	BlockDisposableEmailDomains bool
+
+	// Partner credential inactivity policy
+	CredentialInactivityWarnDays    int
+	CredentialInactivitySuspendDays int
+
+	// Key for the HMAC digests partner client secrets are stored as. Changing it
+	// invalidates every client secret.
+	ClientSecretHashKey string
+
+	// Key partner client secrets are encrypted with, for verifying SNAP symmetric signatures
+	// once SNAP endpoints exist. Changing it means every client secret must be regenerated.
+	ClientSecretEncryptionKey string
+
+	// How long a rotated API key keeps working next to its replacement
+	APIKeyRotationGraceHours int
+
+	// Requests per minute for API keys without their own limit
+	APIKeyDefaultRateLimit int
+
+	// Quotas for users and organizations that staff have not given their own
+	DefaultMaxAPIKeys     int
+	DefaultMaxCredentials int
+
+	// Email campaigns
+	CampaignBatchSize            int
+	CampaignBatchIntervalSeconds int
+
+	// Public access requests
+	PartnershipEmail       string
+	AccessRequestRateLimit int
+	CaptchaSecret          string
+	CaptchaVerifyURL       string
+
+	// SMTP
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// SMS gateway for phone verification and SMS two-factor codes
+	SMSGatewayURL   string
+	SMSGatewayToken string
+	SMSSender       string
+
+	// Magic link
+	MagicLinkExpiryMinutes int
+
+	// Email verification
+	EmailVerificationExpiryHours int
+	RequireVerifiedEmail         bool
+
+	// Password reset
+	PasswordResetExpiryMinutes int
+
+	// Passwords older than this must be changed before the account can be used, 0 to disable
+	PasswordMaxAgeDays int
+
+	// Breached password check
+	BreachedPasswordCheckOnline bool
+	PwnedPasswordsURL           string
+	BreachedPasswordsFile       string
+
+	// Account deletion
+	AccountDeletionGraceDays int
+
+	// File storage, on local disk unless an S3 (or MinIO) endpoint is set
+	UploadDir        string
+	UploadPublicURL  string // Base URL uploaded files are served from, derived when empty
+	PrivateUploadDir string // Never served, for KYC documents
+	S3Endpoint       string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKey      string
+	S3SecretKey      string
+
+	// Profile pictures
+	ProfilePictureMaxMB int
+
+	// Company KYC documents
+	CompanyDocumentMaxMB int
 }
 
 // Load reads configuration from environment variables
 func Load() *Config {
 	jwtExpiry, _ := strconv.Atoi(getEnv("JWT_EXPIRY_HOURS", "24"))
+	sessionIdleTimeout, _ := strconv.Atoi(getEnv("SESSION_IDLE_TIMEOUT_MINUTES", "30"))
+	maxSessions, _ := strconv.Atoi(getEnv("MAX_SESSIONS_PER_USER", "5"))
+	authCookieMode, _ := strconv.ParseBool(getEnv("AUTH_COOKIE_MODE", "false"))
+	loginRateLimit, _ := strconv.Atoi(getEnv("LOGIN_RATE_LIMIT", "5"))
+	loginRateLimitWindow, _ := strconv.Atoi(getEnv("LOGIN_RATE_LIMIT_WINDOW_SECONDS", "60"))
+	authEmailRateLimit, _ := strconv.Atoi(getEnv("AUTH_EMAIL_RATE_LIMIT", "5"))
+	magicLinkExpiry, _ := strconv.Atoi(getEnv("MAGIC_LINK_EXPIRY_MINUTES", "15"))
+	emailVerificationExpiry, _ := strconv.Atoi(getEnv("EMAIL_VERIFICATION_EXPIRY_HOURS", "24"))
+	requireVerifiedEmail, _ := strconv.ParseBool(getEnv("REQUIRE_VERIFIED_EMAIL", "false"))
+	timeOrderedIDs, _ := strconv.ParseBool(getEnv("UUIDV7_ALL_TABLES", "false"))
+	passwordResetExpiry, _ := strconv.Atoi(getEnv("PASSWORD_RESET_EXPIRY_MINUTES", "30"))
+	inactivityWarnDays, _ := strconv.Atoi(getEnv("CREDENTIAL_INACTIVITY_WARN_DAYS", "60"))
+	inactivitySuspendDays, _ := strconv.Atoi(getEnv("CREDENTIAL_INACTIVITY_SUSPEND_DAYS", "90"))
+	apiKeyRotationGrace, _ := strconv.Atoi(getEnv("API_KEY_ROTATION_GRACE_HOURS", "24"))
+	apiKeyRateLimit, _ := strconv.Atoi(getEnv("API_KEY_RATE_LIMIT", "120"))
+	defaultMaxAPIKeys, _ := strconv.Atoi(getEnv("DEFAULT_MAX_API_KEYS", "10"))
+	defaultMaxCredentials, _ := strconv.Atoi(getEnv("DEFAULT_MAX_CREDENTIALS", "5"))
+	campaignBatchSize, _ := strconv.Atoi(getEnv("CAMPAIGN_BATCH_SIZE", "50"))
+	campaignBatchInterval, _ := strconv.Atoi(getEnv("CAMPAIGN_BATCH_INTERVAL_SECONDS", "10"))
+	accessRequestRateLimit, _ := strconv.Atoi(getEnv("ACCESS_REQUEST_RATE_LIMIT", "5"))
+	blockDisposable, _ := strconv.ParseBool(getEnv("BLOCK_DISPOSABLE_EMAIL_DOMAINS", "true"))
+	registrationInviteOnly, _ := strconv.ParseBool(getEnv("REGISTRATION_INVITE_ONLY", "false"))
+	registrationRequireApproval, _ := strconv.ParseBool(getEnv("REGISTRATION_REQUIRE_APPROVAL", "false"))
+	accountDeletionGrace, _ := strconv.Atoi(getEnv("ACCOUNT_DELETION_GRACE_DAYS", "30"))
+	passwordMaxAge, _ := strconv.Atoi(getEnv("PASSWORD_MAX_AGE_DAYS", "0"))
+	breachedPasswordCheckOnline, _ := strconv.ParseBool(getEnv("BREACHED_PASSWORD_CHECK_ONLINE", "true"))
+	profilePictureMaxMB, _ := strconv.Atoi(getEnv("PROFILE_PICTURE_MAX_MB", "2"))
+	companyDocumentMaxMB, _ := strconv.Atoi(getEnv("COMPANY_DOCUMENT_MAX_MB", "10"))
 
 	return &Config{
-		Port: getEnv("PORT", "3000"),
-		Env:  getEnv("ENV", "development"),
+		Port:           getEnv("PORT", "3000"),
+		Env:            getEnv("ENV", "development"),
+		TrustedProxies: getEnvList("TRUSTED_PROXIES", ""),
+		ProxyHeader:    getEnv("PROXY_HEADER", "X-Real-IP"),
 
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
@@ -47,15 +208,125 @@ func Load() *Config {
 		DBName:     getEnv("DB_NAME", "bas_portal"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 
-		JWTSecret:      getEnv("JWT_SECRET", "default-secret-change-me"),
+		JWTSecret:      getEnv("JWT_SECRET", defaultJWTSecret),
 		JWTExpiryHours: jwtExpiry,
+		JWTSigningKeys: getEnvList("JWT_SIGNING_KEYS", ""),
+		JWTIssuer:      getEnv("JWT_ISSUER", "bas-portal-api"),
+		JWTAudience:    getEnv("JWT_AUDIENCE", "bas-portal"),
+
+		SessionIdleTimeoutMinutes: sessionIdleTimeout,
+		MaxSessionsPerUser:        maxSessions,
+
+		AuthCookieMode: authCookieMode,
+
+		RedisURL: getEnv("REDIS_URL", ""),
+
+		EventsRedisStream: getEnv("EVENTS_REDIS_STREAM", ""),
+
+		LoginRateLimit:              loginRateLimit,
+		LoginRateLimitWindowSeconds: loginRateLimitWindow,
+
+		AuthEmailRateLimit: authEmailRateLimit,
+
+		TimeOrderedIDsEverywhere: timeOrderedIDs,
 
 		GoogleClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
 		GoogleClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
 		GoogleRedirectURL:  getEnv("GOOGLE_REDIRECT_URL", "http://localhost:3000/api/v1/auth/google/callback"),
 
 		FrontendURL: getEnv("FRONTEND_URL", "http://localhost:5173"),
+
+		AdminIPAllowlist:  getEnvList("ADMIN_IP_ALLOWLIST", "127.0.0.1/32,::1/128"),
+		AdminEmails:       getEnvList("ADMIN_EMAILS", ""),
+		AdminEmailDomains: getEnvList("ADMIN_EMAIL_DOMAINS", ""),
+
+		RegistrationAllowedDomains:  getEnvList("REGISTRATION_ALLOWED_DOMAINS", ""),
+		RegistrationBlockedDomains:  getEnvList("REGISTRATION_BLOCKED_DOMAINS", ""),
+		RegistrationInviteOnly:      registrationInviteOnly,
+		RegistrationRequireApproval: registrationRequireApproval,
+		BlockDisposableEmailDomains: blockDisposable,
+
+		CredentialInactivityWarnDays:    inactivityWarnDays,
+		CredentialInactivitySuspendDays: inactivitySuspendDays,
+
+		ClientSecretHashKey:       getEnv("CLIENT_SECRET_HASH_KEY", defaultClientSecretHashKey),
+		ClientSecretEncryptionKey: getEnv("CLIENT_SECRET_ENCRYPTION_KEY", defaultClientSecretEncryptionKey),
+
+		APIKeyRotationGraceHours: apiKeyRotationGrace,
+		APIKeyDefaultRateLimit:   apiKeyRateLimit,
+
+		DefaultMaxAPIKeys:     defaultMaxAPIKeys,
+		DefaultMaxCredentials: defaultMaxCredentials,
+
+		CampaignBatchSize:            campaignBatchSize,
+		CampaignBatchIntervalSeconds: campaignBatchInterval,
+
+		PartnershipEmail:       getEnv("PARTNERSHIP_EMAIL", "partnership@bankaceh.co.id"),
+		AccessRequestRateLimit: accessRequestRateLimit,
+		CaptchaSecret:          getEnv("CAPTCHA_SECRET", ""),
+		CaptchaVerifyURL:       getEnv("CAPTCHA_VERIFY_URL", "https://hcaptcha.com/siteverify"),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "BAS Developer Portal <no-reply@bankaceh.co.id>"),
+
+		SMSGatewayURL:   getEnv("SMS_GATEWAY_URL", ""),
+		SMSGatewayToken: getEnv("SMS_GATEWAY_TOKEN", ""),
+		SMSSender:       getEnv("SMS_SENDER", "BANKACEH"),
+
+		MagicLinkExpiryMinutes: magicLinkExpiry,
+
+		EmailVerificationExpiryHours: emailVerificationExpiry,
+		RequireVerifiedEmail:         requireVerifiedEmail,
+
+		PasswordResetExpiryMinutes: passwordResetExpiry,
+
+		PasswordMaxAgeDays: passwordMaxAge,
+
+		BreachedPasswordCheckOnline: breachedPasswordCheckOnline,
+		PwnedPasswordsURL:           getEnv("PWNED_PASSWORDS_URL", "https://api.pwnedpasswords.com/range/"),
+		BreachedPasswordsFile:       getEnv("BREACHED_PASSWORDS_FILE", ""),
+
+		AccountDeletionGraceDays: accountDeletionGrace,
+
+		UploadDir:        getEnv("UPLOAD_DIR", "./uploads"),
+		UploadPublicURL:  getEnv("UPLOAD_PUBLIC_URL", ""),
+		PrivateUploadDir: getEnv("PRIVATE_UPLOAD_DIR", "./private-uploads"),
+		S3Endpoint:       getEnv("S3_ENDPOINT", ""),
+		S3Region:         getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:         getEnv("S3_BUCKET", ""),
+		S3AccessKey:      getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:      getEnv("S3_SECRET_KEY", ""),
+
+		ProfilePictureMaxMB: profilePictureMaxMB,
+
+		CompanyDocumentMaxMB: companyDocumentMaxMB,
+	}
+}
+
+// ValidateSecrets returns an error when ENV is production and a secret key is unset or
+// still its development default. JWT_SECRET keys the OAuth state and campaign links, and
+// the client secret keys protect stored partner secrets.
+func (c *Config) ValidateSecrets() error {
+	if c.Env != "production" {
+		return nil
+	}
+
+	secrets := []struct {
+		name, value, fallback string
+	}{
+		{"JWT_SECRET", c.JWTSecret, defaultJWTSecret},
+		{"CLIENT_SECRET_HASH_KEY", c.ClientSecretHashKey, defaultClientSecretHashKey},
+		{"CLIENT_SECRET_ENCRYPTION_KEY", c.ClientSecretEncryptionKey, defaultClientSecretEncryptionKey},
 	}
+	for _, secret := range secrets {
+		if secret.value == "" || secret.value == secret.fallback {
+			return fmt.Errorf("%s must be set to a private value in production", secret.name)
+		}
+	}
+	return nil
 }
 
 func getEnv(key, defaultValue string) string {
@@ -64,3 +335,14 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList reads a comma-separated list, dropping empty entries
+func getEnvList(key, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,42 @@
+package config
+
+import "testing"
+
+func TestValidateSecrets(t *testing.T) {
+	private := Config{
+		Env:                       "production",
+		JWTSecret:                 "jwt-secret",
+		ClientSecretHashKey:       "hash-key",
+		ClientSecretEncryptionKey: "encryption-key",
+	}
+
+	tests := []struct {
+		name    string
+		change  func(*Config)
+		wantErr bool
+	}{
+		{"private keys", func(*Config) {}, false},
+		{"default JWT secret", func(c *Config) { c.JWTSecret = defaultJWTSecret }, true},
+		{"empty JWT secret", func(c *Config) { c.JWTSecret = "" }, true},
+		{"default hash key", func(c *Config) { c.ClientSecretHashKey = defaultClientSecretHashKey }, true},
+		{"empty hash key", func(c *Config) { c.ClientSecretHashKey = "" }, true},
+		{"default encryption key", func(c *Config) { c.ClientSecretEncryptionKey = defaultClientSecretEncryptionKey }, true},
+		{"empty encryption key", func(c *Config) { c.ClientSecretEncryptionKey = "" }, true},
+		{"defaults in development", func(c *Config) {
+			c.Env = "development"
+			c.JWTSecret = defaultJWTSecret
+			c.ClientSecretHashKey = defaultClientSecretHashKey
+			c.ClientSecretEncryptionKey = ""
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := private
+			tt.change(&cfg)
+			if err := cfg.ValidateSecrets(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSecrets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
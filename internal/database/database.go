@@ -46,12 +46,49 @@ func Migrate(db *gorm.DB) error {
 	err := db.AutoMigrate(
 		&models.User{},
 		&models.APIKey{},
+		&models.APIKeyUsage{},
+		&models.APIKeyWebhook{},
 		&models.PartnerCredential{},
+		&models.OneTimeToken{},
+		&models.Session{},
+		&models.AccessRequest{},
+		&models.EmailCampaign{},
+		&models.EmailCampaignDelivery{},
+		&models.Operation{},
+		&models.EmailDomainRule{},
+		&models.InvitationCode{},
+		&models.SecurityWebhook{},
+		&models.KnownDevice{},
+		&models.UserIdentity{},
+		&models.Organization{},
+		&models.OrganizationMember{},
+		&models.OrganizationInvitation{},
+		&models.UserSuspension{},
+		&models.AuditLog{},
+		&models.NotificationPreference{},
+		&models.CompanyProfile{},
+		&models.CompanyDocument{},
+		&models.SMSCode{},
+		&models.Quota{},
+		&models.ExpiryReminder{},
+		&models.SecretLink{},
+		&models.ServiceAccount{},
+		&models.ServiceAccountToken{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	// Google logins used to live on the users row; copy them into identities (idempotent)
+	err = db.Exec(`
+		INSERT INTO user_identities (id, user_id, provider, provider_id, email, created_at)
+		SELECT gen_random_uuid(), id, provider, provider_id, email, created_at FROM users
+		WHERE provider = ? AND provider_id <> '' AND deleted_at IS NULL
+		ON CONFLICT DO NOTHING`, models.IdentityProviderGoogle).Error
+	if err != nil {
+		return fmt.Errorf("failed to backfill user identities: %w", err)
+	}
+
 	log.Println("✅ Migrations completed successfully")
 	return nil
 }
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Helpers for changing large tables without blocking the portal. The conventions are:
+//   - build indexes with CreateIndexConcurrently, never through AutoMigrate tags, on hot tables
+//   - add columns as nullable without defaults, backfill them in batches, then tighten constraints
+//   - while renaming or moving a column, keep both in sync with RegisterDualWrite until readers switch
+//   - wrap remaining DDL in WithLockTimeout so it gives up instead of queueing behind long queries
+
+// IndexOptions describes an index to build online
+type IndexOptions struct {
+	Name    string
+	Table   string
+	Columns []string
+	Unique  bool
+	Where   string // Optional partial index predicate
+}
+
+// CreateIndexConcurrently builds an index without taking a write lock on the table.
+// A previous failed concurrent build leaves an INVALID index behind; it is dropped and rebuilt.
+func CreateIndexConcurrently(db *gorm.DB, opts IndexOptions) error {
+	var valid *bool
+	err := db.Raw(`
+		SELECT i.indisvalid FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indexrelid
+		WHERE c.relname = ?`, opts.Name).Scan(&valid).Error
+	if err != nil {
+		return err
+	}
+
+	if valid != nil && *valid {
+		return nil
+	}
+	if valid != nil {
+		log.Printf("Dropping invalid index %s left by an earlier build", opts.Name)
+		if err := db.Exec("DROP INDEX CONCURRENTLY IF EXISTS " + quoteIdent(opts.Name)).Error; err != nil {
+			return err
+		}
+	}
+
+	columns := make([]string, len(opts.Columns))
+	for i, column := range opts.Columns {
+		columns[i] = quoteIdent(column)
+	}
+
+	unique := ""
+	if opts.Unique {
+		unique = "UNIQUE "
+	}
+	sql := fmt.Sprintf("CREATE %sINDEX CONCURRENTLY IF NOT EXISTS %s ON %s (%s)",
+		unique, quoteIdent(opts.Name), quoteIdent(opts.Table), strings.Join(columns, ", "))
+	if opts.Where != "" {
+		sql += " WHERE " + opts.Where
+	}
+
+	start := time.Now()
+	if err := db.Exec(sql).Error; err != nil {
+		return fmt.Errorf("failed to build index %s: %w", opts.Name, err)
+	}
+	log.Printf("✅ Built index %s in %s", opts.Name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// BackfillOptions describes a throttled batch update
+type BackfillOptions struct {
+	Table     string
+	Set       string        // SET clause, e.g. "full_name_normalized = lower(full_name)"
+	Where     string        // Rows still needing the change, e.g. "full_name_normalized IS NULL"
+	BatchSize int           // Rows per batch, defaults to 1000
+	Pause     time.Duration // Sleep between batches to leave headroom for live traffic
+	MaxRows   int64         // Stop after this many rows, 0 means no limit
+}
+
+// Backfill updates matching rows in small batches so each statement holds row locks only briefly.
+// The Where clause must stop matching a row once it has been updated, or the backfill never ends.
+func Backfill(db *gorm.DB, opts BackfillOptions, args ...interface{}) (int64, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+
+	sql := fmt.Sprintf(
+		"UPDATE %[1]s SET %[2]s WHERE ctid IN (SELECT ctid FROM %[1]s WHERE %[3]s LIMIT %[4]d FOR UPDATE SKIP LOCKED)",
+		quoteIdent(opts.Table), opts.Set, opts.Where, opts.BatchSize,
+	)
+
+	var total int64
+	for {
+		result := db.Exec(sql, args...)
+		if result.Error != nil {
+			return total, fmt.Errorf("backfill of %s failed after %d rows: %w", opts.Table, total, result.Error)
+		}
+
+		total += result.RowsAffected
+		if result.RowsAffected == 0 || (opts.MaxRows > 0 && total >= opts.MaxRows) {
+			break
+		}
+
+		log.Printf("Backfilled %d rows of %s", total, opts.Table)
+		time.Sleep(opts.Pause)
+	}
+	return total, nil
+}
+
+// WithLockTimeout runs fn in a transaction that aborts if a lock cannot be acquired within timeout,
+// so DDL fails fast instead of blocking every query queued behind it
+func WithLockTimeout(db *gorm.DB, timeout time.Duration, fn func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL lock_timeout = '%dms'", timeout.Milliseconds())).Error; err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// RegisterDualWrite copies a model field into a second column on every create and save of table,
+// keeping the old and new columns consistent while a column is renamed or moved. Updates that
+// bypass the model (UpdateColumn, raw SQL) and batch inserts are not covered and must be backfilled.
+func RegisterDualWrite(db *gorm.DB, table, fromColumn, toColumn string) error {
+	name := fmt.Sprintf("dual_write:%s.%s->%s", table, fromColumn, toColumn)
+	copyColumn := func(tx *gorm.DB) {
+		stmt := tx.Statement
+		// Batch inserts hand us a slice; only single-record writes are mirrored
+		if stmt.Schema == nil || stmt.Table != table || stmt.ReflectValue.Kind() != reflect.Struct {
+			return
+		}
+
+		from := stmt.Schema.LookUpField(fromColumn)
+		if from == nil || stmt.Schema.LookUpField(toColumn) == nil {
+			return
+		}
+
+		if value, isZero := from.ValueOf(stmt.Context, stmt.ReflectValue); !isZero {
+			stmt.SetColumn(toColumn, value)
+		}
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register(name+":create", copyColumn); err != nil {
+		return err
+	}
+	return callbacks.Update().Before("gorm:update").Register(name+":update", copyColumn)
+}
+
+// quoteIdent quotes a PostgreSQL identifier
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,118 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// queueSize is how many published events may wait for dispatch before Publish blocks
+const queueSize = 1024
+
+// Event is a typed domain event. Name is stable and dotted, e.g. "user.registered".
+type Event interface {
+	EventName() string
+}
+
+// Envelope wraps an event with the metadata every subscriber and transport sees
+type Envelope struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Event      Event     `json:"data"`
+}
+
+// Handler reacts to a published event. Errors are logged; they never reach the publisher.
+type Handler func(envelope Envelope) error
+
+// Transport forwards events out of the process, e.g. to a Redis stream
+type Transport interface {
+	Forward(envelope Envelope) error
+}
+
+// Bus delivers domain events from the services that publish them to the subsystems
+// that subscribe, in publish order, on a background worker so publishers never wait
+// on audit, webhooks or notifications. A nil *Bus drops events.
+type Bus struct {
+	mu         sync.RWMutex
+	handlers   map[string][]Handler
+	transports []Transport
+	queue      chan Envelope
+}
+
+// NewBus creates a bus and starts its dispatch worker
+func NewBus() *Bus {
+	b := &Bus{
+		handlers: make(map[string][]Handler),
+		queue:    make(chan Envelope, queueSize),
+	}
+	go b.run()
+	return b
+}
+
+// Subscribe registers a handler for events with the given name, or "*" for every event
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// AddTransport forwards every event through the transport as well
+func (b *Bus) AddTransport(transport Transport) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.transports = append(b.transports, transport)
+}
+
+// Publish queues an event for delivery
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	b.queue <- Envelope{
+		ID:         uuid.New(),
+		Name:       event.EventName(),
+		OccurredAt: time.Now().UTC(),
+		Event:      event,
+	}
+}
+
+// run dispatches queued events one at a time
+func (b *Bus) run() {
+	for envelope := range b.queue {
+		b.dispatch(envelope)
+	}
+}
+
+// dispatch hands an event to its subscribers and transports, isolating their failures
+func (b *Bus) dispatch(envelope Envelope) {
+	b.mu.RLock()
+	handlers := append(append([]Handler{}, b.handlers[envelope.Name]...), b.handlers["*"]...)
+	transports := b.transports
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		b.call(envelope, handler)
+	}
+	for _, transport := range transports {
+		if err := transport.Forward(envelope); err != nil {
+			log.Printf("Failed to forward event %s %s: %v", envelope.Name, envelope.ID, err)
+		}
+	}
+}
+
+// call runs one handler, recovering from panics so one subscriber can't stop the bus
+func (b *Bus) call(envelope Envelope, handler Handler) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event handler panicked on %s %s: %v", envelope.Name, envelope.ID, r)
+		}
+	}()
+
+	if err := handler(envelope); err != nil {
+		log.Printf("Event handler failed on %s %s: %v", envelope.Name, envelope.ID, err)
+	}
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LogAudit writes every event to the application log as an audit line
+func LogAudit(envelope Envelope) error {
+	data, err := json.Marshal(envelope.Event)
+	if err != nil {
+		return err
+	}
+	log.Printf("[audit] %s %s", envelope.Name, data)
+	return nil
+}
+
+// RedisStreamTransport appends events to a Redis stream for consumers outside the API,
+// e.g. a webhook dispatcher or the data warehouse loader
+type RedisStreamTransport struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+}
+
+// NewRedisStreamTransport connects to Redis at url and writes to stream, trimming it to
+// roughly maxLen entries
+func NewRedisStreamTransport(url, stream string, maxLen int64) (*RedisStreamTransport, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &RedisStreamTransport{client: client, stream: stream, maxLen: maxLen}, nil
+}
+
+// Forward appends the event to the stream
+func (t *RedisStreamTransport) Forward(envelope Envelope) error {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	return t.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: t.stream,
+		MaxLen: t.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"name":  envelope.Name,
+			"event": data,
+		},
+	}).Err()
+}
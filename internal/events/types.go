@@ -0,0 +1,335 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserRegistered is published when a new account is created
+type UserRegistered struct {
+	UserID   uuid.UUID `json:"userId"`
+	Provider string    `json:"provider"` // local, google
+}
+
+func (UserRegistered) EventName() string { return "user.registered" }
+
+// LoginSucceeded is published when a user signs in
+type LoginSucceeded struct {
+	UserID    uuid.UUID `json:"userId"`
+	Method    string    `json:"method"` // password, google, magic_link
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+}
+
+func (LoginSucceeded) EventName() string { return "auth.login_succeeded" }
+
+// LoginFailed is published when a password login is rejected
+type LoginFailed struct {
+	Email     string `json:"email"`
+	Reason    string `json:"reason"` // unknown_email, wrong_password
+	IP        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+}
+
+func (LoginFailed) EventName() string { return "auth.login_failed" }
+
+// PasswordChanged is published when a user changes or resets their password
+type PasswordChanged struct {
+	UserID uuid.UUID `json:"userId"`
+	Reset  bool      `json:"reset"` // true when set through a reset link
+}
+
+func (PasswordChanged) EventName() string { return "user.password_changed" }
+
+// UserDeleted is published when a user deletes their account
+type UserDeleted struct {
+	UserID    uuid.UUID `json:"userId"`
+	GraceDays int       `json:"graceDays"`
+}
+
+func (UserDeleted) EventName() string { return "user.deleted" }
+
+// UserAnonymized is published when a deleted account's personal data is scrubbed
+type UserAnonymized struct {
+	UserID uuid.UUID `json:"userId"`
+}
+
+func (UserAnonymized) EventName() string { return "user.anonymized" }
+
+// APIKeyCreated is published when a user creates an API key
+type APIKeyCreated struct {
+	UserID      uuid.UUID `json:"userId"`
+	KeyID       uuid.UUID `json:"keyId"`
+	Environment string    `json:"environment"`
+}
+
+func (APIKeyCreated) EventName() string { return "api_key.created" }
+
+// APIKeyRevoked is published when a user revokes an API key
+type APIKeyRevoked struct {
+	UserID uuid.UUID `json:"userId"`
+	KeyID  uuid.UUID `json:"keyId"`
+}
+
+func (APIKeyRevoked) EventName() string { return "api_key.revoked" }
+
+// APIKeyForceRevoked is published when staff revoke a user's API key
+type APIKeyForceRevoked struct {
+	UserID    uuid.UUID `json:"userId"` // The key's owner
+	KeyID     uuid.UUID `json:"keyId"`
+	Reason    string    `json:"reason"`
+	RevokedBy uuid.UUID `json:"revokedBy"`
+}
+
+func (APIKeyForceRevoked) EventName() string { return "api_key.force_revoked" }
+
+// APIKeyReactivated is published when an admin turns a revoked key back on
+type APIKeyReactivated struct {
+	UserID        uuid.UUID `json:"userId"` // The key's owner
+	KeyID         uuid.UUID `json:"keyId"`
+	Reason        string    `json:"reason"`
+	ReactivatedBy uuid.UUID `json:"reactivatedBy"`
+}
+
+func (APIKeyReactivated) EventName() string { return "api_key.reactivated" }
+
+// APIKeyRotated is published when a key is replaced by a new one. The old key keeps
+// working until GraceEndsAt.
+type APIKeyRotated struct {
+	UserID      uuid.UUID `json:"userId"`
+	KeyID       uuid.UUID `json:"keyId"`
+	NewKeyID    uuid.UUID `json:"newKeyId"`
+	GraceEndsAt time.Time `json:"graceEndsAt"`
+}
+
+func (APIKeyRotated) EventName() string { return "api_key.rotated" }
+
+// APIKeyExpired is published when the scheduler deactivates a key past its expiry
+type APIKeyExpired struct {
+	UserID uuid.UUID `json:"userId"`
+	KeyID  uuid.UUID `json:"keyId"`
+}
+
+func (APIKeyExpired) EventName() string { return "api_key.expired" }
+
+// DomainRuleAdded is published when an admin adds a sign-up email domain rule
+type DomainRuleAdded struct {
+	AdminID uuid.UUID `json:"adminId"`
+	RuleID  uuid.UUID `json:"ruleId"`
+	Domain  string    `json:"domain"`
+	Type    string    `json:"type"`
+}
+
+func (DomainRuleAdded) EventName() string { return "domain_rule.added" }
+
+// DomainRuleRemoved is published when an admin removes a sign-up email domain rule
+type DomainRuleRemoved struct {
+	AdminID uuid.UUID `json:"adminId"`
+	RuleID  uuid.UUID `json:"ruleId"`
+	Domain  string    `json:"domain"`
+	Type    string    `json:"type"`
+}
+
+func (DomainRuleRemoved) EventName() string { return "domain_rule.removed" }
+
+// InvitationCreated is published when an admin issues an invitation code
+type InvitationCreated struct {
+	AdminID      uuid.UUID `json:"adminId"`
+	InvitationID uuid.UUID `json:"invitationId"`
+	CodePrefix   string    `json:"codePrefix"`
+	MaxUses      int       `json:"maxUses"`
+}
+
+func (InvitationCreated) EventName() string { return "invitation.created" }
+
+// InvitationRevoked is published when an admin revokes an invitation code
+type InvitationRevoked struct {
+	AdminID      uuid.UUID `json:"adminId"`
+	InvitationID uuid.UUID `json:"invitationId"`
+}
+
+func (InvitationRevoked) EventName() string { return "invitation.revoked" }
+
+// ClientSecretRegenerated is published when a partner credential gets a new client secret
+type ClientSecretRegenerated struct {
+	UserID       uuid.UUID `json:"userId"`
+	CredentialID uuid.UUID `json:"credentialId"`
+	ClientID     string    `json:"clientId"`
+}
+
+func (ClientSecretRegenerated) EventName() string { return "partner_credential.secret_regenerated" }
+
+// PublicKeyChanged is published when a partner credential's public key is replaced
+type PublicKeyChanged struct {
+	UserID       uuid.UUID `json:"userId"`
+	CredentialID uuid.UUID `json:"credentialId"`
+	ClientID     string    `json:"clientId"`
+	Fingerprint  string    `json:"fingerprint"`
+}
+
+func (PublicKeyChanged) EventName() string { return "partner_credential.public_key_changed" }
+
+// AccountLocked is published when a user locks their account from a security email
+type AccountLocked struct {
+	UserID uuid.UUID `json:"userId"`
+}
+
+func (AccountLocked) EventName() string { return "user.locked" }
+
+// IdentityLinked is published when a user links an external login to their account
+type IdentityLinked struct {
+	UserID   uuid.UUID `json:"userId"`
+	Provider string    `json:"provider"`
+	Email    string    `json:"email"`
+}
+
+func (IdentityLinked) EventName() string { return "user.identity_linked" }
+
+// IdentityUnlinked is published when a user removes a sign-in method from their account
+type IdentityUnlinked struct {
+	UserID   uuid.UUID `json:"userId"`
+	Provider string    `json:"provider"`
+}
+
+func (IdentityUnlinked) EventName() string { return "user.identity_unlinked" }
+
+// RoleChanged is published when an admin changes a user's role
+type RoleChanged struct {
+	UserID       uuid.UUID `json:"userId"`
+	Role         string    `json:"role"`
+	PreviousRole string    `json:"previousRole"`
+	ChangedBy    uuid.UUID `json:"changedBy"` // uuid.Nil when promoted from ADMIN_EMAILS at startup
+}
+
+func (RoleChanged) EventName() string { return "user.role_changed" }
+
+// UserSuspended is published when an admin suspends an account
+type UserSuspended struct {
+	UserID      uuid.UUID `json:"userId"`
+	Reason      string    `json:"reason"`
+	SuspendedBy uuid.UUID `json:"suspendedBy"`
+}
+
+func (UserSuspended) EventName() string { return "user.suspended" }
+
+// UserReactivated is published when an admin lifts a suspension
+type UserReactivated struct {
+	UserID        uuid.UUID `json:"userId"`
+	Reason        string    `json:"reason"`
+	ReactivatedBy uuid.UUID `json:"reactivatedBy"`
+}
+
+func (UserReactivated) EventName() string { return "user.reactivated" }
+
+// UserSessionsRevoked is published when an admin signs a user out of every session
+type UserSessionsRevoked struct {
+	UserID    uuid.UUID `json:"userId"`
+	RevokedBy uuid.UUID `json:"revokedBy"`
+}
+
+func (UserSessionsRevoked) EventName() string { return "user.sessions_revoked" }
+
+// PasswordResetRequired is published when an admin requires a user to reset their password
+type PasswordResetRequired struct {
+	UserID     uuid.UUID `json:"userId"`
+	Reason     string    `json:"reason"`
+	RequiredBy uuid.UUID `json:"requiredBy"`
+}
+
+func (PasswordResetRequired) EventName() string { return "user.password_reset_required" }
+
+// UserApproved is published when staff approve a pending account
+type UserApproved struct {
+	UserID     uuid.UUID `json:"userId"`
+	ApprovedBy uuid.UUID `json:"approvedBy"`
+}
+
+func (UserApproved) EventName() string { return "user.approved" }
+
+// UserRejected is published when staff reject a pending account
+type UserRejected struct {
+	UserID     uuid.UUID `json:"userId"`
+	Reason     string    `json:"reason"`
+	RejectedBy uuid.UUID `json:"rejectedBy"`
+}
+
+func (UserRejected) EventName() string { return "user.rejected" }
+
+// OrganizationCreated is published when a user creates an organization
+type OrganizationCreated struct {
+	OrganizationID uuid.UUID `json:"organizationId"`
+	UserID         uuid.UUID `json:"userId"`
+	Name           string    `json:"name"`
+}
+
+func (OrganizationCreated) EventName() string { return "organization.created" }
+
+// OrganizationDeleted is published when an owner deletes an organization
+type OrganizationDeleted struct {
+	OrganizationID uuid.UUID `json:"organizationId"`
+	UserID         uuid.UUID `json:"userId"`
+}
+
+func (OrganizationDeleted) EventName() string { return "organization.deleted" }
+
+// OrganizationMemberChanged is published when a member is added, changes role or leaves.
+// Role is empty when the member was removed.
+type OrganizationMemberChanged struct {
+	OrganizationID uuid.UUID `json:"organizationId"`
+	UserID         uuid.UUID `json:"userId"`
+	Role           string    `json:"role"`
+	ChangedBy      uuid.UUID `json:"changedBy"`
+}
+
+func (OrganizationMemberChanged) EventName() string { return "organization.member_changed" }
+
+// OrganizationInvitationSent is published when an owner invites someone to an organization
+type OrganizationInvitationSent struct {
+	OrganizationID uuid.UUID `json:"organizationId"`
+	InvitationID   uuid.UUID `json:"invitationId"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	InvitedBy      uuid.UUID `json:"invitedBy"`
+}
+
+func (OrganizationInvitationSent) EventName() string { return "organization.invitation_sent" }
+
+// OrganizationInvitationDeclined is published when an invitee turns an invitation down
+type OrganizationInvitationDeclined struct {
+	OrganizationID uuid.UUID `json:"organizationId"`
+	InvitationID   uuid.UUID `json:"invitationId"`
+	Email          string    `json:"email"`
+}
+
+func (OrganizationInvitationDeclined) EventName() string { return "organization.invitation_declined" }
+
+// CompanySubmitted is published when a company profile enters the KYC review queue
+type CompanySubmitted struct {
+	CompanyID      uuid.UUID  `json:"companyId"`
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+	SubmittedBy    uuid.UUID  `json:"submittedBy"`
+}
+
+func (CompanySubmitted) EventName() string { return "company.submitted" }
+
+// CompanyReviewed is published when bank staff verify or reject a company profile
+type CompanyReviewed struct {
+	CompanyID  uuid.UUID `json:"companyId"`
+	Status     string    `json:"status"` // verified, rejected
+	Reason     string    `json:"reason,omitempty"`
+	ReviewedBy uuid.UUID `json:"reviewedBy"`
+}
+
+func (CompanyReviewed) EventName() string { return "company.reviewed" }
+
+// SecretLinkRetrieved is published when a single-use link to a new API key or client
+// secret is opened
+type SecretLinkRetrieved struct {
+	UserID       uuid.UUID `json:"userId"`       // Who created the link
+	ResourceType string    `json:"resourceType"` // api_key, partner_credential
+	ResourceID   uuid.UUID `json:"resourceId"`
+	IP           string    `json:"ip"`
+}
+
+func (SecretLinkRetrieved) EventName() string { return "secret_link.retrieved" }
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/mail"
+
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AccessRequestHandler handles public access request endpoints
+type AccessRequestHandler struct {
+	service *services.AccessRequestService
+}
+
+// NewAccessRequestHandler creates a new AccessRequestHandler
+func NewAccessRequestHandler(service *services.AccessRequestService) *AccessRequestHandler {
+	return &AccessRequestHandler{service: service}
+}
+
+// SubmitAccessRequest godoc
+// @Summary Request API access
+// @Description Submit a "contact sales / request access" form (no authentication required)
+// @Tags Public
+// @Accept json
+// @Produce json
+// @Param input body services.AccessRequestInput true "Access request data"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /public/access-request [post]
+func (h *AccessRequestHandler) SubmitAccessRequest(c *fiber.Ctx) error {
+	var input services.AccessRequestInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if input.Name == "" || input.Company == "" || input.Email == "" || input.UseCase == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Name, company, email, and use case are required",
+		})
+	}
+
+	if _, err := mail.ParseAddress(input.Email); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid email address",
+		})
+	}
+
+	if _, err := h.service.Submit(input, c.IP()); err != nil {
+		if errors.Is(err, services.ErrCaptchaFailed) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Captcha verification failed",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to submit access request",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Thank you, our partnership team will contact you shortly",
+	})
+}
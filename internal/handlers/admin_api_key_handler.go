@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AdminAPIKeyHandler handles staff actions on any user's API keys
+type AdminAPIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+}
+
+// NewAdminAPIKeyHandler creates a new AdminAPIKeyHandler
+func NewAdminAPIKeyHandler(apiKeyService *services.APIKeyService) *AdminAPIKeyHandler {
+	return &AdminAPIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// ListKeys godoc
+// @Summary Search API keys
+// @Description List API keys across all users and organizations, newest first, with their owners. prefix matches the start of the key, so the first characters of a leaked key find it
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param userId query string false "Owner user ID"
+// @Param organizationId query string false "Organization ID"
+// @Param prefix query string false "Start of the key, e.g. bas_live_1a2b"
+// @Param environment query string false "sandbox or production"
+// @Param active query string false "true or false"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Keys to skip"
+// @Success 200 {object} services.AdminKeyPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/api-keys [get]
+func (h *AdminAPIKeyHandler) ListKeys(c *fiber.Ctx) error {
+	var query services.AdminKeyQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid query parameters",
+		})
+	}
+
+	page, err := h.apiKeyService.SearchKeys(query)
+	if err != nil {
+		return h.adminAPIKeyError(c, err, "Failed to retrieve API keys")
+	}
+
+	return c.JSON(page)
+}
+
+// RevokeKey godoc
+// @Summary Revoke any API key
+// @Description Revoke a user's or organization's API key for compliance or incident response. The owner is notified with the reason
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "API Key ID"
+// @Param input body services.AdminRevokeKeyInput true "Reason"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/api-keys/{id}/revoke [post]
+func (h *AdminAPIKeyHandler) RevokeKey(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	keyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid API key ID",
+		})
+	}
+
+	var input services.AdminRevokeKeyInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if err := h.apiKeyService.AdminRevokeKey(adminID, keyID, input); err != nil {
+		return h.adminAPIKeyError(c, err, "Failed to revoke API key")
+	}
+
+	middleware.Audit(c, "api_key.force_revoke", "api_key", keyID.String(), nil, input)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ReactivateKey godoc
+// @Summary Reactivate a revoked API key
+// @Description Undo an accidental revocation so the partner does not have to rotate their integrations. Keys that expired, were rotated or were revoked over 30 days ago cannot be reactivated, and the owner's quota applies. The owner is notified
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "API Key ID"
+// @Param input body services.ReactivateKeyInput true "Reason"
+// @Success 200 {object} models.APIKeyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/api-keys/{id}/reactivate [post]
+func (h *AdminAPIKeyHandler) ReactivateKey(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	keyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid API key ID",
+		})
+	}
+
+	var input services.ReactivateKeyInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	key, err := h.apiKeyService.ReactivateKey(adminID, keyID, input)
+	if err != nil {
+		return h.adminAPIKeyError(c, err, "Failed to reactivate API key")
+	}
+
+	middleware.Audit(c, "api_key.reactivate", "api_key", keyID.String(), nil, input)
+	return c.JSON(key)
+}
+
+// RevokeAllKeys godoc
+// @Summary Revoke all of a user's API keys
+// @Description Deactivate every active personal API key the user owns, e.g. during a security incident. Keys they created for organizations are not affected
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/users/{id}/api-keys/revoke-all [post]
+func (h *AdminAPIKeyHandler) RevokeAllKeys(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid user ID",
+		})
+	}
+
+	revoked, err := h.apiKeyService.AdminRevokeAllKeys(adminID, userID)
+	if err != nil {
+		return h.adminAPIKeyError(c, err, "Failed to revoke API keys")
+	}
+
+	middleware.Audit(c, "api_key.revoke_all", "user", userID.String(), nil, fiber.Map{"revoked": revoked})
+	return c.JSON(fiber.Map{
+		"message": "API keys revoked",
+		"revoked": revoked,
+	})
+}
+
+// adminAPIKeyError maps API key errors on staff routes to responses
+func (h *AdminAPIKeyHandler) adminAPIKeyError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidKeySearch):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "userId and organizationId must be UUIDs, environment sandbox or production, active true or false, limit 1-200 and offset not negative",
+		})
+	case errors.Is(err, services.ErrReactivationReason), errors.Is(err, services.ErrRevocationReason):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Reason must be 3-500 characters",
+		})
+	case errors.Is(err, services.ErrKeyNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "API key not found",
+		})
+	case errors.Is(err, services.ErrKeyNotActive):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "API key is already revoked or expired",
+		})
+	case errors.Is(err, services.ErrKeyNotRevoked):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "API key is not revoked",
+		})
+	case errors.Is(err, services.ErrKeyNotReactivatable):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "API key expired, was rotated, was revoked over 30 days ago or its owner is gone, so it cannot be reactivated",
+		})
+	case errors.Is(err, services.ErrMaxKeysReached):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "The owner's API key quota is reached, raise it or ask them to revoke a key",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
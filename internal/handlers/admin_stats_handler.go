@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminStatsHandler handles the admin dashboard statistics endpoint
+type AdminStatsHandler struct {
+	service *services.AdminStatsService
+}
+
+// NewAdminStatsHandler creates a new AdminStatsHandler
+func NewAdminStatsHandler(service *services.AdminStatsService) *AdminStatsHandler {
+	return &AdminStatsHandler{service: service}
+}
+
+// GetStats godoc
+// @Summary Get portal statistics
+// @Description Totals and trends for the admin dashboard: users and weekly signups over the last 12 weeks, API keys and partner credentials per environment, and failed password logins. Refreshed at most once a minute
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} services.AdminStatsResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/stats [get]
+func (h *AdminStatsHandler) GetStats(c *fiber.Ctx) error {
+	stats, err := h.service.GetStats()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to retrieve statistics",
+		})
+	}
+
+	return c.JSON(stats)
+}
@@ -21,30 +21,50 @@ func NewAPIKeyHandler(apiKeyService *services.APIKeyService) *APIKeyHandler {
 
 // ListKeys godoc
 // @Summary List API keys
-// @Description Get all API keys for the authenticated user
+// @Description Get the authenticated user's personal API keys, or an organization's keys. Active keys are listed newest first unless filtered or sorted otherwise
 // @Tags API Keys
 // @Security BearerAuth
 // @Produce json
+// @Param organizationId query string false "Organization ID"
+// @Param environment query string false "sandbox or production"
+// @Param tag query string false "Only keys with this tag"
+// @Param active query string false "true (default) or false for revoked and expired keys"
+// @Param sort query string false "createdAt, name, lastUsedAt or expiresAt; prefix with - for descending"
 // @Success 200 {array} models.APIKeyResponse
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Router /api-keys [get]
 func (h *APIKeyHandler) ListKeys(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 
-	keys, err := h.apiKeyService.ListKeys(userID)
+	orgID, err := organizationQuery(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
-			Error:   "Internal Server Error",
-			Message: "Failed to retrieve API keys",
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
 		})
 	}
 
+	var query services.ListKeysQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid query parameters",
+		})
+	}
+
+	keys, err := h.apiKeyService.ListKeys(userID, orgID, query)
+	if err != nil {
+		return h.apiKeyError(c, err, "Failed to retrieve API keys")
+	}
+
 	return c.JSON(keys)
 }
 
 // CreateKey godoc
 // @Summary Create API key
-// @Description Generate a new API key
+// @Description Generate a new API key, for the user or for an organization they can edit. An optional expiresAt (RFC 3339, within two years) makes the key stop working at that time. Scopes let a personal key call the portal API with X-API-Key. Set retrievalLink to get a single-use link to the key, valid for 24 hours, instead of the key
 // @Tags API Keys
 // @Security BearerAuth
 // @Accept json
@@ -53,6 +73,8 @@ func (h *APIKeyHandler) ListKeys(c *fiber.Ctx) error {
 // @Success 201 {object} models.APIKeyCreateResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 409 {object} ErrorResponse
 // @Router /api-keys [post]
 func (h *APIKeyHandler) CreateKey(c *fiber.Ctx) error {
@@ -84,23 +106,102 @@ func (h *APIKeyHandler) CreateKey(c *fiber.Ctx) error {
 		})
 	}
 
-	response, err := h.apiKeyService.CreateKey(userID, input)
+	response, err := h.apiKeyService.CreateKey(userID, input, keyCaller(c))
 	if err != nil {
-		if errors.Is(err, services.ErrMaxKeysReached) {
-			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
-				Error:   "Conflict",
-				Message: "Maximum number of API keys reached (10)",
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
-			Error:   "Internal Server Error",
-			Message: "Failed to create API key",
-		})
+		return h.apiKeyError(c, err, "Failed to create API key")
 	}
 
+	middleware.Audit(c, "api_key.create", "api_key", response.ID.String(), nil, response.APIKeyResponse)
 	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
+// UpdateKey godoc
+// @Summary Update API key
+// @Description Replace the name, description, expiry, rate limit and IP allowlist of a key the user owns, or one of an organization they can edit. Omitted expiresAt, rateLimit and ipAllowlist are cleared. Rotated keys cannot be changed
+// @Tags API Keys
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "API Key ID"
+// @Param input body services.UpdateKeyInput true "API key settings"
+// @Success 200 {object} models.APIKeyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api-keys/{id} [put]
+func (h *APIKeyHandler) UpdateKey(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	keyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid API key ID",
+		})
+	}
+
+	var input services.UpdateKeyInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	key, err := h.apiKeyService.UpdateKey(keyID, userID, input, keyCaller(c))
+	if err != nil {
+		return h.apiKeyError(c, err, "Failed to update API key")
+	}
+
+	middleware.Audit(c, "api_key.update", "api_key", keyID.String(), nil, key)
+	return c.JSON(key)
+}
+
+// GetUsage godoc
+// @Summary Get API key usage
+// @Description Request and error counts for a key per hour (ranges up to 7 days) or day (up to 90 days), one bucket per interval. Requests answered with a 4xx or 5xx count as errors. Usage is recorded for requests made with X-API-Key and kept for 90 days
+// @Tags API Keys
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "API Key ID"
+// @Param from query string false "Start, RFC 3339 (default 7 days before to)"
+// @Param to query string false "End, RFC 3339 (default now)"
+// @Param interval query string false "hour (default) or day"
+// @Success 200 {object} models.APIKeyUsageSeries
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api-keys/{id}/usage [get]
+func (h *APIKeyHandler) GetUsage(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	keyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid API key ID",
+		})
+	}
+
+	var query services.UsageQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid query parameters",
+		})
+	}
+
+	series, err := h.apiKeyService.UsageSeries(keyID, userID, query)
+	if err != nil {
+		return h.apiKeyError(c, err, "Failed to retrieve API key usage")
+	}
+
+	return c.JSON(series)
+}
+
 // RevokeKey godoc
 // @Summary Revoke API key
 // @Description Deactivate an existing API key
@@ -110,7 +211,9 @@ func (h *APIKeyHandler) CreateKey(c *fiber.Ctx) error {
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /api-keys/{id} [delete]
 func (h *APIKeyHandler) RevokeKey(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
@@ -131,11 +234,226 @@ func (h *APIKeyHandler) RevokeKey(c *fiber.Ctx) error {
 				Message: "API key not found",
 			})
 		}
+		if errors.Is(err, services.ErrOrgPermissionDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Your organization role does not allow this",
+			})
+		}
+		if errors.Is(err, services.ErrKeyNotActive) {
+			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+				Error:   "Conflict",
+				Message: "API key is already revoked",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Error:   "Internal Server Error",
 			Message: "Failed to revoke API key",
 		})
 	}
 
+	middleware.Audit(c, "api_key.revoke", "api_key", keyID.String(), nil, nil)
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// VerifyKey godoc
+// @Summary Verify API key
+// @Description Look up a key by its value and return its metadata (status, scopes, environment, expiry), to debug an integration. Only keys the user owns or can see in their organizations are found
+// @Tags API Keys
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.VerifyKeyInput true "Key value"
+// @Success 200 {object} services.KeyVerification
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api-keys/verify [post]
+func (h *APIKeyHandler) VerifyKey(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var input services.VerifyKeyInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	verification, err := h.apiKeyService.VerifyKey(userID, input)
+	if err != nil {
+		return h.apiKeyError(c, err, "Failed to verify API key")
+	}
+
+	return c.JSON(verification)
+}
+
+// RevokeAllKeys godoc
+// @Summary Revoke all API keys
+// @Description Deactivate every active personal API key at once, for when keys may have leaked. Keys created for organizations are not affected
+// @Tags API Keys
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Router /api-keys/revoke-all [post]
+func (h *APIKeyHandler) RevokeAllKeys(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	revoked, err := h.apiKeyService.RevokeAllKeys(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to revoke API keys",
+		})
+	}
+
+	middleware.Audit(c, "api_key.revoke_all", "user", userID.String(), nil, fiber.Map{"revoked": revoked})
+	return c.JSON(fiber.Map{
+		"message": "API keys revoked",
+		"revoked": revoked,
+	})
+}
+
+// RotateKey godoc
+// @Summary Rotate API key
+// @Description Issue a new key with the same name, environment and owner. The old key keeps working for graceHours (0-168, default API_KEY_ROTATION_GRACE_HOURS) and then expires, so integrations can switch without downtime. The new key is shown once, or behind a single-use link when retrievalLink is set
+// @Tags API Keys
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "API Key ID"
+// @Param input body services.RotateKeyInput false "Rotation options"
+// @Success 201 {object} models.APIKeyCreateResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateKey(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	keyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid API key ID",
+		})
+	}
+
+	var input services.RotateKeyInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Invalid request body",
+			})
+		}
+	}
+
+	response, err := h.apiKeyService.RotateKey(keyID, userID, input, keyCaller(c))
+	if err != nil {
+		return h.apiKeyError(c, err, "Failed to rotate API key")
+	}
+
+	middleware.Audit(c, "api_key.rotate", "api_key", keyID.String(), nil, response.APIKeyResponse)
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// keyCaller describes a service account token or X-API-Key caller, whose keys may not
+// have more access than it has, or returns nil for a signed-in user
+func keyCaller(c *fiber.Ctx) *services.KeyCaller {
+	if !middleware.IsServiceAccount(c) && middleware.GetAPIKeyID(c) == uuid.Nil {
+		return nil
+	}
+	return &services.KeyCaller{
+		Permissions: middleware.GetPermissions(c),
+		KeyID:       middleware.GetAPIKeyID(c),
+	}
+}
+
+// apiKeyError maps API key errors to responses
+func (h *APIKeyHandler) apiKeyError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidUsageQuery):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "from and to must be RFC 3339 times with from first, interval hour (up to 7 days) or day (up to 90 days)",
+		})
+	case errors.Is(err, services.ErrInvalidKeyName):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Name must be 1-100 characters and description at most 500",
+		})
+	case errors.Is(err, services.ErrInvalidKeyExpiry):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Expiry must be in the future and within two years",
+		})
+	case errors.Is(err, services.ErrInvalidKeyGrace):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Grace period must be 0-168 hours",
+		})
+	case errors.Is(err, services.ErrInvalidRateLimit):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Rate limit must be 1-10000 requests per minute",
+		})
+	case errors.Is(err, services.ErrInvalidIPAllowlist):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "IP allowlist entries must be IP addresses or CIDR ranges, at most 50",
+		})
+	case errors.Is(err, services.ErrInvalidKeyTags):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Tags must be 1-32 lowercase letters, digits, '-', '_', '.' or ':', at most 10",
+		})
+	case errors.Is(err, services.ErrInvalidKeyListQuery):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "environment must be sandbox or production, active true or false, and sort createdAt, name, lastUsedAt or expiresAt, optionally prefixed with -",
+		})
+	case errors.Is(err, services.ErrInvalidKeyScope):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Scopes must be api_keys:manage or credentials:manage",
+		})
+	case errors.Is(err, services.ErrKeyNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "API key not found",
+		})
+	case errors.Is(err, services.ErrOrganizationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Organization not found",
+		})
+	case errors.Is(err, services.ErrOrgPermissionDenied):
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error:   "Forbidden",
+			Message: "Your organization role does not allow this",
+		})
+	case errors.Is(err, services.ErrMaxKeysReached):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "API key quota reached, revoke a key or ask BAS to raise the quota",
+		})
+	case errors.Is(err, services.ErrKeyAlreadyRotated):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "API key has been rotated, use its replacement instead",
+		})
+	case errors.Is(err, services.ErrKeyExceedsCaller):
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error:   "Forbidden",
+			Message: "A token or API key can only manage keys with its own scopes, and an API key only keys within its own IP allowlist, rate limit and expiry",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
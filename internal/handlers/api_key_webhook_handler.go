@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyWebhookHandler handles users' API key lifecycle webhooks
+type APIKeyWebhookHandler struct {
+	service *services.APIKeyWebhookService
+}
+
+// NewAPIKeyWebhookHandler creates a new APIKeyWebhookHandler
+func NewAPIKeyWebhookHandler(service *services.APIKeyWebhookService) *APIKeyWebhookHandler {
+	return &APIKeyWebhookHandler{service: service}
+}
+
+// GetWebhook godoc
+// @Summary Get API key webhook
+// @Description Get the endpoint that receives signed events when the user's API keys are created, rotated, revoked or expire
+// @Tags API Keys
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} services.APIKeyWebhookResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/me/api-key-webhook [get]
+func (h *APIKeyWebhookHandler) GetWebhook(c *fiber.Ctx) error {
+	webhook, err := h.service.GetWebhook(middleware.GetUserID(c))
+	if err != nil {
+		return h.handleError(c, err, "Failed to retrieve API key webhook")
+	}
+
+	return c.JSON(webhook)
+}
+
+// ConfigureWebhook godoc
+// @Summary Configure API key webhook
+// @Description Set the endpoint for signed API key events. Events cover every key the user owns, including organization keys they created. The signing secret is generated on first setup or with rotateSecret and is only returned in that response. Deliveries carry X-BAS-Signature: t=<unix>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+// @Tags API Keys
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.ConfigureAPIKeyWebhookInput true "Webhook endpoint"
+// @Success 200 {object} services.APIKeyWebhookResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /users/me/api-key-webhook [put]
+func (h *APIKeyWebhookHandler) ConfigureWebhook(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var input services.ConfigureAPIKeyWebhookInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	webhook, err := h.service.ConfigureWebhook(userID, input)
+	if err != nil {
+		return h.handleError(c, err, "Failed to configure API key webhook")
+	}
+
+	middleware.Audit(c, "user.configure_api_key_webhook", "user", userID.String(), nil, fiber.Map{
+		"url":           webhook.URL,
+		"secretRotated": webhook.Secret != "",
+	})
+	return c.JSON(webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Remove API key webhook
+// @Description Stop delivering API key events
+// @Tags API Keys
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/me/api-key-webhook [delete]
+func (h *APIKeyWebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	if err := h.service.DeleteWebhook(userID); err != nil {
+		return h.handleError(c, err, "Failed to remove API key webhook")
+	}
+
+	middleware.Audit(c, "user.delete_api_key_webhook", "user", userID.String(), nil, nil)
+	return c.JSON(fiber.Map{
+		"message": "API key webhook removed",
+	})
+}
+
+// TestWebhook godoc
+// @Summary Test API key webhook
+// @Description Send a signed webhook.test event to the configured endpoint and report whether it was accepted
+// @Tags API Keys
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Router /users/me/api-key-webhook/test [post]
+func (h *APIKeyWebhookHandler) TestWebhook(c *fiber.Ctx) error {
+	if err := h.service.SendTest(middleware.GetUserID(c)); err != nil {
+		if errors.Is(err, services.ErrAPIKeyWebhookNotFound) {
+			return h.handleError(c, err, "")
+		}
+		return c.Status(fiber.StatusBadGateway).JSON(ErrorResponse{
+			Error:   "Bad Gateway",
+			Message: "Webhook endpoint did not accept the test event: " + err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Test event delivered",
+	})
+}
+
+// handleError maps API key webhook service errors to HTTP responses
+func (h *APIKeyWebhookHandler) handleError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrAPIKeyWebhookNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "API key webhook is not configured",
+		})
+	case errors.Is(err, services.ErrInvalidWebhookURL):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Webhook URL must be an absolute https URL",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
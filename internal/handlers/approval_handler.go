@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ApprovalHandler handles staff review of new accounts
+type ApprovalHandler struct {
+	service *services.ApprovalService
+}
+
+// NewApprovalHandler creates a new ApprovalHandler
+func NewApprovalHandler(service *services.ApprovalService) *ApprovalHandler {
+	return &ApprovalHandler{service: service}
+}
+
+// ListPending godoc
+// @Summary List accounts awaiting approval
+// @Description New accounts waiting for staff approval, the longest waiting first. Accounts only wait when REGISTRATION_REQUIRE_APPROVAL is on
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.UserResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/users/pending [get]
+func (h *ApprovalHandler) ListPending(c *fiber.Ctx) error {
+	users, err := h.service.ListPending()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to retrieve pending accounts",
+		})
+	}
+
+	return c.JSON(users)
+}
+
+// ApproveUser godoc
+// @Summary Approve an account
+// @Description Let a pending account create API keys and partner credentials. The user is emailed
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/users/{id}/approve [post]
+func (h *ApprovalHandler) ApproveUser(c *fiber.Ctx) error {
+	staffID := middleware.GetUserID(c)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid user ID",
+		})
+	}
+
+	user, err := h.service.Approve(staffID, userID)
+	if err != nil {
+		return h.approvalError(c, err, "Failed to approve account")
+	}
+
+	middleware.Audit(c, "user.approve", "user", userID.String(), nil, user)
+	return c.JSON(user)
+}
+
+// RejectUser godoc
+// @Summary Reject an account
+// @Description Decline a pending account. The reason is recorded and emailed to the user, who can still sign in but cannot create API keys or partner credentials
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param input body services.RejectAccountInput true "Reason"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/users/{id}/reject [post]
+func (h *ApprovalHandler) RejectUser(c *fiber.Ctx) error {
+	staffID := middleware.GetUserID(c)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid user ID",
+		})
+	}
+
+	var input services.RejectAccountInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	user, err := h.service.Reject(staffID, userID, input)
+	if err != nil {
+		return h.approvalError(c, err, "Failed to reject account")
+	}
+
+	middleware.Audit(c, "user.reject", "user", userID.String(), nil, user)
+	return c.JSON(user)
+}
+
+// approvalError maps approval errors to responses
+func (h *ApprovalHandler) approvalError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrApprovalReasonLength):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Reason must be 3-500 characters",
+		})
+	case errors.Is(err, services.ErrCannotReviewOwnAccount):
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error:   "Forbidden",
+			Message: "You cannot review your own account",
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "User not found",
+		})
+	case errors.Is(err, services.ErrAccountNotPending):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "Account is not waiting for approval",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
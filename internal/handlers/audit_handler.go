@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditHandler handles audit log endpoints
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// ListAuditLogs godoc
+// @Summary Search the audit log
+// @Description List recorded mutations newest first, optionally filtered by actor, action, resource, request ID and time range
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param actorId query string false "Actor user ID"
+// @Param action query string false "Action, e.g. api_key.create"
+// @Param resourceType query string false "Resource type, e.g. partner_credential"
+// @Param resourceId query string false "Resource ID"
+// @Param requestId query string false "Request ID (X-Request-ID)"
+// @Param from query string false "Earliest time, RFC 3339"
+// @Param to query string false "Latest time (exclusive), RFC 3339"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Entries to skip"
+// @Success 200 {object} services.AuditLogPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/audit-logs [get]
+func (h *AuditHandler) ListAuditLogs(c *fiber.Ctx) error {
+	var query services.AuditLogQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid query parameters",
+		})
+	}
+
+	page, err := h.service.ListAuditLogs(query)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAuditQuery) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "actorId must be a UUID, from and to RFC 3339 times, limit 1-200 and offset not negative",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to retrieve audit logs",
+		})
+	}
+
+	return c.JSON(page)
+}
+
+// ListActivity godoc
+// @Summary Get my activity
+// @Description The signed-in user's own sign-ins and changes (keys created, secrets regenerated, profile updates), newest first
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param offset query int false "Entries to skip"
+// @Success 200 {object} services.ActivityPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /users/me/activity [get]
+func (h *AuditHandler) ListActivity(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var query services.ActivityQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid query parameters",
+		})
+	}
+
+	page, err := h.service.ListActivity(userID, query)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidActivityQuery) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "limit must be 1-100 and offset not negative",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to retrieve activity",
+		})
+	}
+
+	return c.JSON(page)
+}
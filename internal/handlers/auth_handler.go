@@ -2,19 +2,41 @@ package handlers
 
 import (
 	"errors"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/models"
 	"github.com/bankaceh/bas-portal-api/internal/services"
 	"github.com/gofiber/fiber/v2"
 )
 
+// oauthStateCookie carries the signed OAuth state and PKCE verifier between login and callback
+const oauthStateCookie = "bas_oauth_state"
+
+// RefreshTokenCookie holds the refresh token in cookie mode. It is only sent to /auth routes.
+const RefreshTokenCookie = "bas_refresh_token"
+
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
-	authService *services.AuthService
+	authService     *services.AuthService
+	identityService *services.IdentityService
+	frontendURL     string
+	secureCookie    bool
+	cookieMode      bool
 }
 
-// NewAuthHandler creates a new AuthHandler
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
+// NewAuthHandler creates a new AuthHandler. secureCookie marks cookies HTTPS-only and
+// cookieMode returns refresh tokens as httpOnly cookies instead of in the body.
+func NewAuthHandler(authService *services.AuthService, identityService *services.IdentityService, frontendURL string, secureCookie, cookieMode bool) *AuthHandler {
+	return &AuthHandler{
+		authService:     authService,
+		identityService: identityService,
+		frontendURL:     frontendURL,
+		secureCookie:    secureCookie,
+		cookieMode:      cookieMode,
+	}
 }
 
 // Register godoc
@@ -26,7 +48,9 @@ func NewAuthHandler(authService *services.AuthService) *AuthHandler {
 // @Param input body services.RegisterInput true "Registration data"
 // @Success 201 {object} services.AuthResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	var input services.RegisterInput
@@ -60,18 +84,54 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 				Message: "Email already registered",
 			})
 		}
+		if errors.Is(err, services.ErrWeakPassword) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Password must be between 8 and 72 characters",
+			})
+		}
+		if errors.Is(err, services.ErrBreachedPassword) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "This password has appeared in a data breach, please choose a different one",
+			})
+		}
+		if errors.Is(err, services.ErrEmailDomainNotAllowed) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Registration is not open to this email domain",
+			})
+		}
+		if errors.Is(err, services.ErrInvitationRequired) || errors.Is(err, services.ErrInvalidInvitation) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "A valid invitation code is required to register",
+			})
+		}
+		if errors.Is(err, services.ErrOrgInvitationInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Organization invitation is invalid, expired or no longer pending",
+			})
+		}
+		if errors.Is(err, services.ErrOrgInvitationEmailMismatch) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Register with the email address the organization invitation was sent to",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Error:   "Internal Server Error",
 			Message: "Failed to register user",
 		})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(response)
+	return h.sendAuthResponse(c, fiber.StatusCreated, response)
 }
 
 // Login godoc
 // @Summary Login user
-// @Description Authenticate with email and password
+// @Description Authenticate with email and password. Accounts with SMS two-factor sign-in get mfaRequired and an mfaToken instead of tokens; finish with POST /auth/mfa/verify
 // @Tags Authentication
 // @Accept json
 // @Produce json
@@ -79,6 +139,8 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 // @Success 200 {object} services.AuthResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	var input services.LoginInput
@@ -96,7 +158,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		})
 	}
 
-	response, err := h.authService.Login(input)
+	response, err := h.authService.Login(input, clientInfo(c))
 	if err != nil {
 		if errors.Is(err, services.ErrInvalidCredentials) {
 			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
@@ -104,13 +166,122 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 				Message: "Invalid email or password",
 			})
 		}
+		if errors.Is(err, services.ErrAccountLocked) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Account is locked, reset your password to unlock it",
+			})
+		}
+		if errors.Is(err, services.ErrAccountSuspended) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Account is suspended, please contact support",
+			})
+		}
+		if errors.Is(err, services.ErrPasswordResetRequired) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "A password reset is required, use the link we emailed or request a new one",
+			})
+		}
+		if mfaErr := mfaCodeError(c, err); mfaErr != nil {
+			return mfaErr
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Error:   "Internal Server Error",
 			Message: "Failed to login",
 		})
 	}
 
-	return c.JSON(response)
+	return h.sendAuthResponse(c, fiber.StatusOK, response)
+}
+
+// VerifyMFA godoc
+// @Summary Complete a two-factor sign-in
+// @Description Exchange the mfaToken from a sign-in response and the 6-digit code texted to the user for portal tokens. A code expires after 5 minutes or 5 wrong guesses
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param input body services.VerifyMFAInput true "MFA token and code"
+// @Success 200 {object} services.AuthResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /auth/mfa/verify [post]
+func (h *AuthHandler) VerifyMFA(c *fiber.Ctx) error {
+	var input services.VerifyMFAInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if input.MFAToken == "" || input.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "MFA token and code are required",
+		})
+	}
+
+	response, err := h.authService.VerifyMFA(input, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidToken) {
+			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+				Error:   "Unauthorized",
+				Message: "Sign-in has expired, please sign in again",
+			})
+		}
+		if errors.Is(err, services.ErrInvalidSMSCode) {
+			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+				Error:   "Unauthorized",
+				Message: "Invalid or expired code",
+			})
+		}
+		if errors.Is(err, services.ErrAccountLocked) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Account is locked, reset your password to unlock it",
+			})
+		}
+		if errors.Is(err, services.ErrAccountSuspended) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Account is suspended, please contact support",
+			})
+		}
+		if errors.Is(err, services.ErrPasswordResetRequired) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "A password reset is required, use the link we emailed or request a new one",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to verify code",
+		})
+	}
+
+	return h.sendAuthResponse(c, fiber.StatusOK, response)
+}
+
+// mfaCodeError answers a failure to text a sign-in code, or returns nil for other errors
+func mfaCodeError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrSMSCodeTooSoon):
+		c.Set(fiber.HeaderRetryAfter, "60")
+		return c.Status(fiber.StatusTooManyRequests).JSON(ErrorResponse{
+			Error:   "Too Many Requests",
+			Message: "A sign-in code was sent recently, please wait a minute before signing in again",
+		})
+	case errors.Is(err, services.ErrSMSDeliveryFailed):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(ErrorResponse{
+			Error:   "Service Unavailable",
+			Message: "The sign-in code could not be texted, please try again later",
+		})
+	}
+	return nil
 }
 
 // GoogleLogin godoc
@@ -119,27 +290,46 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 // @Tags Authentication
 // @Produce json
 // @Success 302 {string} string "Redirect to Google"
+// @Failure 503 {object} ErrorResponse
 // @Router /auth/google [get]
 func (h *AuthHandler) GoogleLogin(c *fiber.Ctx) error {
-	// TODO: Implement Google OAuth redirect
-	// For now, return a placeholder
-	return c.JSON(fiber.Map{
-		"message": "Google OAuth not yet implemented",
-		"hint":    "Configure GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET in .env",
-	})
+	login, err := h.authService.GoogleAuthURL()
+	if err != nil {
+		if errors.Is(err, services.ErrGoogleNotConfigured) {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(ErrorResponse{
+				Error:   "Service Unavailable",
+				Message: "Google login is not configured",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to start Google login",
+		})
+	}
+
+	h.setOAuthStateCookie(c, login.StateCookie, services.OAuthStateTTL)
+	return c.Redirect(login.AuthURL, fiber.StatusFound)
 }
 
 // GoogleCallback godoc
 // @Summary Handle Google OAuth callback
-// @Description Processes Google OAuth callback and returns tokens
+// @Description Processes Google OAuth callback and redirects to the frontend with tokens in the URL fragment, or with linked=google when the flow was started from POST /users/me/identities
 // @Tags Authentication
 // @Produce json
 // @Param code query string true "OAuth authorization code"
-// @Success 200 {object} services.AuthResponse
+// @Param state query string true "OAuth state issued by /auth/google"
+// @Success 302 {string} string "Redirect to frontend"
 // @Failure 400 {object} ErrorResponse
 // @Router /auth/google/callback [get]
 func (h *AuthHandler) GoogleCallback(c *fiber.Ctx) error {
-	// TODO: Implement Google OAuth callback handling
+	// The state cookie is single-use whatever the outcome
+	stateCookie := c.Cookies(oauthStateCookie)
+	h.setOAuthStateCookie(c, "", -time.Hour)
+
+	if c.Query("error") != "" {
+		return h.redirectToFrontend(c, url.Values{"error": {"google_auth_cancelled"}})
+	}
+
 	code := c.Query("code")
 	if code == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
@@ -148,25 +338,96 @@ func (h *AuthHandler) GoogleCallback(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Google OAuth callback not yet implemented",
+	result, err := h.authService.GoogleCallback(code, c.Query("state"), stateCookie, clientInfo(c))
+	if err != nil {
+		reason := "google_auth_failed"
+		if errors.Is(err, services.ErrGoogleEmailNotVerified) {
+			reason = "google_email_not_verified"
+		} else if errors.Is(err, services.ErrInvalidOAuthState) {
+			reason = "invalid_state"
+		} else if errors.Is(err, services.ErrEmailDomainNotAllowed) {
+			reason = "email_domain_not_allowed"
+		} else if errors.Is(err, services.ErrInvitationRequired) {
+			reason = "invitation_required"
+		} else if errors.Is(err, services.ErrAccountLocked) {
+			reason = "account_locked"
+		} else if errors.Is(err, services.ErrAccountSuspended) {
+			reason = "account_suspended"
+		} else if errors.Is(err, services.ErrPasswordResetRequired) {
+			reason = "password_reset_required"
+		} else if errors.Is(err, services.ErrLinkRequired) {
+			reason = "account_exists_link_required"
+		} else if errors.Is(err, services.ErrIdentityInUse) {
+			reason = "identity_in_use"
+		} else if errors.Is(err, services.ErrSMSCodeTooSoon) {
+			reason = "mfa_code_recently_sent"
+		} else if errors.Is(err, services.ErrSMSDeliveryFailed) {
+			reason = "mfa_sms_failed"
+		}
+		return h.redirectToFrontend(c, url.Values{"error": {reason}})
+	}
+
+	if result.Linked {
+		return h.redirectToFrontend(c, url.Values{"linked": {"google"}})
+	}
+
+	if result.Auth.MFARequired {
+		return h.redirectToFrontend(c, url.Values{
+			"mfaToken": {result.Auth.MFAToken},
+			"mfaPhone": {result.Auth.MFAPhone},
+		})
+	}
+
+	// Tokens go in the fragment so they never reach server logs
+	fragment := url.Values{
+		"accessToken": {result.Auth.AccessToken},
+		"expiresIn":   {strconv.Itoa(result.Auth.ExpiresIn)},
+	}
+	if h.cookieMode {
+		if err := h.setAuthCookies(c, result.Auth); err != nil {
+			return h.redirectToFrontend(c, url.Values{"error": {"google_auth_failed"}})
+		}
+		fragment.Set("csrfToken", result.Auth.CSRFToken)
+	} else {
+		fragment.Set("refreshToken", result.Auth.RefreshToken)
+	}
+	return h.redirectToFrontend(c, fragment)
+}
+
+// redirectToFrontend sends the browser back to the SPA's OAuth callback page
+func (h *AuthHandler) redirectToFrontend(c *fiber.Ctx, fragment url.Values) error {
+	return c.Redirect(h.frontendURL+"/auth/google/callback#"+fragment.Encode(), fiber.StatusFound)
+}
+
+// setOAuthStateCookie stores or, with a negative ttl, clears the OAuth state cookie.
+// SameSite=Lax still sends it on the top-level redirect back from Google.
+func (h *AuthHandler) setOAuthStateCookie(c *fiber.Ctx, value string, ttl time.Duration) {
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthStateCookie,
+		Value:    value,
+		Path:     "/api/v1/auth/google",
+		Expires:  time.Now().Add(ttl),
+		HTTPOnly: true,
+		Secure:   h.secureCookie,
+		SameSite: fiber.CookieSameSiteLaxMode,
 	})
 }
 
 // RefreshToken godoc
 // @Summary Refresh access token
-// @Description Get a new access token using a refresh token
+// @Description Get a new access token using a refresh token. In cookie mode the token is read from the refresh cookie and the X-CSRF-Token header is required
 // @Tags Authentication
 // @Accept json
 // @Produce json
-// @Param input body RefreshTokenInput true "Refresh token"
+// @Param input body RefreshTokenInput false "Refresh token (omit in cookie mode)"
 // @Success 200 {object} services.AuthResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Router /auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
-	var input RefreshTokenInput
-	if err := c.BodyParser(&input); err != nil {
+	input, err := h.refreshTokenInput(c)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
 			Error:   "Bad Request",
 			Message: "Invalid request body",
@@ -182,16 +443,742 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 
 	response, err := h.authService.RefreshToken(input.RefreshToken)
 	if err != nil {
+		if h.cookieMode {
+			h.clearAuthCookies(c)
+		}
+		if errors.Is(err, services.ErrSessionExpired) {
+			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+				Error:   "Unauthorized",
+				Message: "Session expired, please log in again",
+			})
+		}
+		if errors.Is(err, services.ErrAccountLocked) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Account is locked, reset your password to unlock it",
+			})
+		}
+		if errors.Is(err, services.ErrAccountSuspended) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Account is suspended, please contact support",
+			})
+		}
+		if errors.Is(err, services.ErrPasswordResetRequired) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "A password reset is required, use the link we emailed or request a new one",
+			})
+		}
 		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
 			Error:   "Unauthorized",
 			Message: "Invalid refresh token",
 		})
 	}
 
-	return c.JSON(response)
+	return h.sendAuthResponse(c, fiber.StatusOK, response)
 }
 
 // RefreshTokenInput represents refresh token request
 type RefreshTokenInput struct {
 	RefreshToken string `json:"refreshToken"`
 }
+
+// refreshTokenInput reads the refresh token from the body, falling back to the refresh
+// cookie in cookie mode, where the body may be empty
+func (h *AuthHandler) refreshTokenInput(c *fiber.Ctx) (RefreshTokenInput, error) {
+	var input RefreshTokenInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return input, err
+		}
+	}
+	if input.RefreshToken == "" && h.cookieMode {
+		input.RefreshToken = c.Cookies(RefreshTokenCookie)
+	}
+	return input, nil
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description End the session of the given refresh token (or the refresh cookie in cookie mode) and clear the auth cookies
+// @Tags Authentication
+// @Accept json
+// @Param input body RefreshTokenInput false "Refresh token (omit in cookie mode)"
+// @Success 204 "Logged out"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	input, err := h.refreshTokenInput(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	// An invalid or already expired token leaves nothing to end
+	if input.RefreshToken != "" {
+		if err := h.authService.Logout(input.RefreshToken); err != nil && !errors.Is(err, services.ErrInvalidToken) {
+			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Error:   "Internal Server Error",
+				Message: "Failed to log out",
+			})
+		}
+	}
+
+	if h.cookieMode {
+		h.clearAuthCookies(c)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetCSRFToken godoc
+// @Summary Get the CSRF token
+// @Description In cookie mode, return the CSRF token to send as X-CSRF-Token on state-changing requests, e.g. after a page reload. A new token is issued if the cookie is missing
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /auth/csrf [get]
+func (h *AuthHandler) GetCSRFToken(c *fiber.Ctx) error {
+	token := c.Cookies(middleware.CSRFCookie)
+	if token == "" {
+		var err error
+		if token, err = middleware.NewCSRFToken(); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Error:   "Internal Server Error",
+				Message: "Failed to issue CSRF token",
+			})
+		}
+		h.setCookie(c, middleware.CSRFCookie, token, "/", time.Time{}, false)
+	}
+
+	return c.JSON(fiber.Map{"csrfToken": token})
+}
+
+// RequestMagicLink godoc
+// @Summary Request a magic login link
+// @Description Email a single-use, short-lived login link to the account owner
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param input body services.MagicLinkInput true "Account email"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/magic-link [post]
+func (h *AuthHandler) RequestMagicLink(c *fiber.Ctx) error {
+	var input services.MagicLinkInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if input.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Email is required",
+		})
+	}
+
+	if err := h.authService.RequestMagicLink(input); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to send magic link",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "If an account exists for this email, a login link has been sent",
+	})
+}
+
+// VerifyMagicLink godoc
+// @Summary Exchange a magic link for tokens
+// @Description Validate a magic link token and return portal tokens
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param input body services.VerifyMagicLinkInput true "Magic link token"
+// @Success 200 {object} services.AuthResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /auth/magic-link/verify [post]
+func (h *AuthHandler) VerifyMagicLink(c *fiber.Ctx) error {
+	var input services.VerifyMagicLinkInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if input.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Token is required",
+		})
+	}
+
+	response, err := h.authService.VerifyMagicLink(input, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidToken) || errors.Is(err, services.ErrUserNotFound) {
+			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+				Error:   "Unauthorized",
+				Message: "Invalid or expired magic link",
+			})
+		}
+		if errors.Is(err, services.ErrAccountLocked) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Account is locked, reset your password to unlock it",
+			})
+		}
+		if errors.Is(err, services.ErrAccountSuspended) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Account is suspended, please contact support",
+			})
+		}
+		if errors.Is(err, services.ErrPasswordResetRequired) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "A password reset is required, use the link we emailed or request a new one",
+			})
+		}
+		if mfaErr := mfaCodeError(c, err); mfaErr != nil {
+			return mfaErr
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to verify magic link",
+		})
+	}
+
+	return h.sendAuthResponse(c, fiber.StatusOK, response)
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirm ownership of the account email using the token from the verification email
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param input body services.VerifyEmailInput true "Verification token"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	var input services.VerifyEmailInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if input.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Token is required",
+		})
+	}
+
+	user, err := h.authService.VerifyEmail(input)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidToken) || errors.Is(err, services.ErrUserNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Invalid or expired verification link",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to verify email",
+		})
+	}
+
+	return c.JSON(user)
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Email a new verification link to an unverified account
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param input body services.ResendVerificationInput true "Account email"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /auth/resend-verification [post]
+func (h *AuthHandler) ResendVerification(c *fiber.Ctx) error {
+	var input services.ResendVerificationInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if input.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Email is required",
+		})
+	}
+
+	if err := h.authService.ResendVerification(input); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to send verification email",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "If an unverified account exists for this email, a verification link has been sent",
+	})
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a time-limited password reset link to the account owner
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param input body services.ForgotPasswordInput true "Account email"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	var input services.ForgotPasswordInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if input.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Email is required",
+		})
+	}
+
+	if err := h.authService.ForgotPassword(input); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to send password reset email",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "If an account exists for this email, a password reset link has been sent",
+	})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password using the emailed reset token. All existing sessions are signed out
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param input body services.ResetPasswordInput true "Reset token and new password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var input services.ResetPasswordInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if input.Token == "" || input.NewPassword == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Token and new password are required",
+		})
+	}
+
+	if len(input.NewPassword) < 8 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Password must be at least 8 characters",
+		})
+	}
+
+	if err := h.authService.ResetPassword(input); err != nil {
+		if errors.Is(err, services.ErrInvalidToken) || errors.Is(err, services.ErrUserNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Invalid or expired password reset link",
+			})
+		}
+		if errors.Is(err, services.ErrWeakPassword) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Password must be between 8 and 72 characters",
+			})
+		}
+		if errors.Is(err, services.ErrBreachedPassword) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "This password has appeared in a data breach, please choose a different one",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to reset password",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Password has been reset, please log in with your new password",
+	})
+}
+
+// LockAccount godoc
+// @Summary Lock account ("this wasn't me")
+// @Description Lock the account using the token from a security notification email. All sessions are signed out and sign-in is blocked until the password is reset
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param input body services.LockAccountInput true "Lock token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/lock-account [post]
+func (h *AuthHandler) LockAccount(c *fiber.Ctx) error {
+	var input services.LockAccountInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if input.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Token is required",
+		})
+	}
+
+	if err := h.authService.LockAccount(input); err != nil {
+		if errors.Is(err, services.ErrInvalidToken) || errors.Is(err, services.ErrUserNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Invalid or expired link",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to lock account",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Your account has been locked. Reset your password to unlock it",
+	})
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Replace the password after confirming the current one. All sessions are signed out and new tokens are returned for this device
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.ChangePasswordInput true "Current and new password"
+// @Success 200 {object} services.AuthResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /users/me/password [put]
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var input services.ChangePasswordInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if input.CurrentPassword == "" || input.NewPassword == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Current and new password are required",
+		})
+	}
+
+	response, err := h.authService.ChangePassword(userID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPasswordConfirmationFailed):
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Current password is incorrect",
+			})
+		case errors.Is(err, services.ErrWeakPassword):
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Password must be between 8 and 72 characters",
+			})
+		case errors.Is(err, services.ErrBreachedPassword):
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "This password has appeared in a data breach, please choose a different one",
+			})
+		case errors.Is(err, services.ErrSamePassword):
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "New password must differ from the current password",
+			})
+		case errors.Is(err, services.ErrUserNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+				Error:   "Not Found",
+				Message: "User not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to change password",
+		})
+	}
+
+	middleware.Audit(c, "user.change_password", "user", userID.String(), nil, nil)
+	return h.sendAuthResponse(c, fiber.StatusOK, response)
+}
+
+// ListIdentities godoc
+// @Summary List sign-in methods
+// @Description List the password and external logins (e.g. Google) that can sign in to the account
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} services.IdentityResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /users/me/identities [get]
+func (h *AuthHandler) ListIdentities(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	identities, err := h.identityService.ListIdentities(userID)
+	if err != nil {
+		return h.identityError(c, err)
+	}
+
+	return c.JSON(identities)
+}
+
+// LinkIdentity godoc
+// @Summary Add a sign-in method
+// @Description With provider "google", returns a Google consent URL that links the chosen Google account and redirects back with linked=google. The current password is required, or a recent sign-in for accounts without one. With provider "local", sets a password on an account that only signs in with Google (recent sign-in required)
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.LinkIdentityInput true "Provider and password"
+// @Success 200 {object} map[string]string
+// @Success 204 "Password set"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users/me/identities [post]
+func (h *AuthHandler) LinkIdentity(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	sessionID := middleware.GetSessionID(c)
+
+	var input services.LinkIdentityInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	switch input.Provider {
+	case models.IdentityProviderGoogle:
+		login, err := h.authService.StartGoogleLink(userID, sessionID, input.Password)
+		if err != nil {
+			return h.identityError(c, err)
+		}
+		h.setOAuthStateCookie(c, login.StateCookie, services.OAuthStateTTL)
+		return c.JSON(fiber.Map{"authUrl": login.AuthURL})
+
+	case models.IdentityProviderLocal:
+		if input.Password == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Password is required",
+			})
+		}
+		if err := h.identityService.SetPassword(userID, sessionID, input.Password); err != nil {
+			return h.identityError(c, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	return h.identityError(c, services.ErrUnsupportedProvider)
+}
+
+// UnlinkIdentity godoc
+// @Summary Remove a sign-in method
+// @Description Unlink Google ("google") or remove the password ("local") after confirming the current password. The last sign-in method cannot be removed
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider (local or google)"
+// @Param input body services.UnlinkIdentityInput true "Current password"
+// @Success 204 "Removed"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users/me/identities/{provider} [delete]
+func (h *AuthHandler) UnlinkIdentity(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var input services.UnlinkIdentityInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if err := h.identityService.Unlink(userID, c.Params("provider"), input); err != nil {
+		return h.identityError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// identityError maps sign-in method errors to HTTP responses
+func (h *AuthHandler) identityError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrUnsupportedProvider):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Provider must be local or google",
+		})
+	case errors.Is(err, services.ErrGoogleNotConfigured):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(ErrorResponse{
+			Error:   "Service Unavailable",
+			Message: "Google login is not configured",
+		})
+	case errors.Is(err, services.ErrPasswordConfirmationFailed):
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error:   "Forbidden",
+			Message: "Current password is incorrect",
+		})
+	case errors.Is(err, services.ErrRecentLoginRequired):
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error:   "Forbidden",
+			Message: "Please sign in again to confirm this change",
+		})
+	case errors.Is(err, services.ErrIdentityAlreadyLinked):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "This sign-in method is already set up",
+		})
+	case errors.Is(err, services.ErrLastIdentity):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "Keep a password and another sign-in method before removing one",
+		})
+	case errors.Is(err, services.ErrIdentityNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "This sign-in method is not set up",
+		})
+	case errors.Is(err, services.ErrWeakPassword):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Password must be between 8 and 72 characters",
+		})
+	case errors.Is(err, services.ErrBreachedPassword):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "This password has appeared in a data breach, please choose a different one",
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "User not found",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: "Failed to update sign-in methods",
+	})
+}
+
+// sendAuthResponse writes issued tokens to the client. In cookie mode the refresh token is
+// moved out of the body into an httpOnly cookie, paired with a fresh CSRF token.
+func (h *AuthHandler) sendAuthResponse(c *fiber.Ctx, status int, response *services.AuthResponse) error {
+	if h.cookieMode && !response.MFARequired {
+		if err := h.setAuthCookies(c, response); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Error:   "Internal Server Error",
+				Message: "Failed to start session",
+			})
+		}
+	}
+	return c.Status(status).JSON(response)
+}
+
+// setAuthCookies sets the refresh and CSRF cookies and swaps the refresh token in the
+// response for the CSRF token
+func (h *AuthHandler) setAuthCookies(c *fiber.Ctx, response *services.AuthResponse) error {
+	csrfToken, err := middleware.NewCSRFToken()
+	if err != nil {
+		return err
+	}
+
+	h.setCookie(c, RefreshTokenCookie, response.RefreshToken, "/api/v1/auth", response.RefreshExpiresAt, true)
+	h.setCookie(c, middleware.CSRFCookie, csrfToken, "/", response.RefreshExpiresAt, false)
+
+	response.RefreshToken = ""
+	response.CSRFToken = csrfToken
+	return nil
+}
+
+// clearAuthCookies removes the refresh and CSRF cookies
+func (h *AuthHandler) clearAuthCookies(c *fiber.Ctx) {
+	expired := time.Now().Add(-time.Hour)
+	h.setCookie(c, RefreshTokenCookie, "", "/api/v1/auth", expired, true)
+	h.setCookie(c, middleware.CSRFCookie, "", "/", expired, false)
+}
+
+// setCookie writes a SameSite=Strict auth cookie. The CSRF cookie is readable by scripts
+// (httpOnly false) so same-origin frontends can echo it in the header.
+func (h *AuthHandler) setCookie(c *fiber.Ctx, name, value, path string, expires time.Time, httpOnly bool) {
+	c.Cookie(&fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Expires:  expires,
+		HTTPOnly: httpOnly,
+		Secure:   h.secureCookie,
+		SameSite: fiber.CookieSameSiteStrictMode,
+	})
+}
+
+// clientInfo describes the caller for security events
+func clientInfo(c *fiber.Ctx) services.ClientInfo {
+	return services.ClientInfo{
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+}
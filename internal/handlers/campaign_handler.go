@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CampaignHandler handles bulk email campaign endpoints
+type CampaignHandler struct {
+	service *services.CampaignService
+}
+
+// NewCampaignHandler creates a new CampaignHandler
+func NewCampaignHandler(service *services.CampaignService) *CampaignHandler {
+	return &CampaignHandler{service: service}
+}
+
+// ListCampaigns godoc
+// @Summary List email campaigns
+// @Description Get all bulk email campaigns with delivery stats
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.EmailCampaign
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/campaigns [get]
+func (h *CampaignHandler) ListCampaigns(c *fiber.Ctx) error {
+	campaigns, err := h.service.ListCampaigns()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to retrieve email campaigns",
+		})
+	}
+
+	return c.JSON(campaigns)
+}
+
+// CreateCampaign godoc
+// @Summary Create email campaign
+// @Description Create a draft bulk email to all subscribed developers. The body is a text template supporting {{.Name}} and {{.Email}}
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.CreateCampaignInput true "Campaign data"
+// @Success 201 {object} models.EmailCampaign
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/campaigns [post]
+func (h *CampaignHandler) CreateCampaign(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	var input services.CreateCampaignInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if input.Subject == "" || input.Body == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Subject and body are required",
+		})
+	}
+
+	campaign, err := h.service.CreateCampaign(adminID, input)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTemplate) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Body is not a valid template",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to create email campaign",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(campaign)
+}
+
+// GetCampaign godoc
+// @Summary Get email campaign
+// @Description Get a bulk email campaign with its delivery stats
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Campaign ID"
+// @Success 200 {object} models.EmailCampaign
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/campaigns/{id} [get]
+func (h *CampaignHandler) GetCampaign(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid campaign ID",
+		})
+	}
+
+	campaign, err := h.service.GetCampaign(id)
+	if err != nil {
+		return h.handleError(c, err, "Failed to retrieve email campaign")
+	}
+
+	return c.JSON(campaign)
+}
+
+// PreviewCampaign godoc
+// @Summary Preview email campaign
+// @Description Render a campaign as the requesting admin would receive it, with the recipient count
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Campaign ID"
+// @Success 200 {object} services.CampaignPreview
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/campaigns/{id}/preview [get]
+func (h *CampaignHandler) PreviewCampaign(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid campaign ID",
+		})
+	}
+
+	preview, err := h.service.PreviewCampaign(id, adminID)
+	if err != nil {
+		return h.handleError(c, err, "Failed to preview email campaign")
+	}
+
+	return c.JSON(preview)
+}
+
+// ScheduleCampaign godoc
+// @Summary Schedule email campaign
+// @Description Queue a campaign to be sent at the given time
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Campaign ID"
+// @Param input body services.ScheduleCampaignInput true "Schedule data"
+// @Success 200 {object} models.EmailCampaign
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/campaigns/{id}/schedule [post]
+func (h *CampaignHandler) ScheduleCampaign(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid campaign ID",
+		})
+	}
+
+	var input services.ScheduleCampaignInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if input.ScheduledAt == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Scheduled time is required",
+		})
+	}
+
+	campaign, err := h.service.ScheduleCampaign(id, input)
+	if err != nil {
+		return h.handleError(c, err, "Failed to schedule email campaign")
+	}
+
+	return c.JSON(campaign)
+}
+
+// SendCampaign godoc
+// @Summary Send email campaign
+// @Description Start sending a campaign immediately in throttled batches
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Campaign ID"
+// @Success 202 {object} models.EmailCampaign
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/campaigns/{id}/send [post]
+func (h *CampaignHandler) SendCampaign(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid campaign ID",
+		})
+	}
+
+	campaign, err := h.service.SendCampaign(id)
+	if err != nil {
+		return h.handleError(c, err, "Failed to send email campaign")
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(campaign)
+}
+
+// Unsubscribe godoc
+// @Summary Unsubscribe from bulk emails
+// @Description Opt out of admin email campaigns using the token from an email footer
+// @Tags Public
+// @Accept json
+// @Produce json
+// @Param input body services.UnsubscribeInput true "Unsubscribe token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Router /public/unsubscribe [post]
+func (h *CampaignHandler) Unsubscribe(c *fiber.Ctx) error {
+	var input services.UnsubscribeInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if err := h.service.Unsubscribe(input); err != nil {
+		if errors.Is(err, services.ErrInvalidUnsubscribeToken) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Invalid unsubscribe link",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to unsubscribe",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "You have been unsubscribed from BAS Developer Portal announcements",
+	})
+}
+
+// handleError maps campaign service errors to HTTP responses
+func (h *CampaignHandler) handleError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrCampaignNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Email campaign not found",
+		})
+	case errors.Is(err, services.ErrCampaignNotEditable):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "Email campaign is already sending or sent",
+		})
+	case errors.Is(err, services.ErrInvalidTemplate):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body is not a valid template",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
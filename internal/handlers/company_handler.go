@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CompanyHandler handles company profiles, KYC documents and their review by bank staff
+type CompanyHandler struct {
+	service *services.CompanyService
+}
+
+// NewCompanyHandler creates a new CompanyHandler
+func NewCompanyHandler(service *services.CompanyService) *CompanyHandler {
+	return &CompanyHandler{service: service}
+}
+
+// GetCompany godoc
+// @Summary Get company profile
+// @Description Get the company profile and KYC status of the user, or of an organization with ?organizationId=
+// @Tags Company
+// @Security BearerAuth
+// @Produce json
+// @Param organizationId query string false "Organization ID"
+// @Success 200 {object} models.CompanyProfile
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /company [get]
+func (h *CompanyHandler) GetCompany(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, err := organizationQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	profile, err := h.service.GetCompany(userID, orgID)
+	if err != nil {
+		return h.companyError(c, err, "Failed to retrieve company profile")
+	}
+
+	return c.JSON(profile)
+}
+
+// SaveCompany godoc
+// @Summary Save company profile
+// @Description Create or update the legal details of the company (organization owners only). Changing a verified or rejected profile makes it a draft that must be submitted again; profiles under review cannot be changed
+// @Tags Company
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param organizationId query string false "Organization ID"
+// @Param input body services.CompanyProfileInput true "Company details"
+// @Success 200 {object} models.CompanyProfile
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /company [put]
+func (h *CompanyHandler) SaveCompany(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, err := organizationQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	var input services.CompanyProfileInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	profile, err := h.service.SaveCompany(userID, orgID, input)
+	if err != nil {
+		return h.companyError(c, err, "Failed to save company profile")
+	}
+
+	middleware.Audit(c, "company.save", "company", profile.ID.String(), nil, input)
+	return c.JSON(profile)
+}
+
+// UploadDocument godoc
+// @Summary Upload KYC document
+// @Description Upload a business license or NPWP document as PDF, JPEG or PNG (multipart fields "type" and "file"). Documents are stored privately and only bank staff can read them
+// @Tags Company
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param organizationId query string false "Organization ID"
+// @Param type formData string true "business_license or npwp"
+// @Param file formData file true "Document"
+// @Success 201 {object} models.CompanyDocument
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Router /company/documents [post]
+func (h *CompanyHandler) UploadDocument(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, err := organizationQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "A document is required in the file field",
+		})
+	}
+	if file.Size > h.service.MaxDocumentBytes() {
+		return h.companyError(c, services.ErrCompanyDocumentTooLarge, "")
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return h.companyError(c, err, "Failed to upload document")
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, h.service.MaxDocumentBytes()+1))
+	if err != nil {
+		return h.companyError(c, err, "Failed to upload document")
+	}
+
+	document, err := h.service.UploadDocument(userID, orgID, c.FormValue("type"), file.Filename, data)
+	if err != nil {
+		return h.companyError(c, err, "Failed to upload document")
+	}
+
+	middleware.Audit(c, "company.upload_document", "company", document.CompanyID.String(), nil, document)
+	return c.Status(fiber.StatusCreated).JSON(document)
+}
+
+// SubmitCompany godoc
+// @Summary Submit company for verification
+// @Description Send the company profile to bank staff for KYC review (organization owners only). The business license and NPWP documents must be uploaded first
+// @Tags Company
+// @Security BearerAuth
+// @Produce json
+// @Param organizationId query string false "Organization ID"
+// @Success 200 {object} models.CompanyProfile
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /company/submit [post]
+func (h *CompanyHandler) SubmitCompany(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, err := organizationQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	profile, err := h.service.SubmitCompany(userID, orgID)
+	if err != nil {
+		return h.companyError(c, err, "Failed to submit company profile")
+	}
+
+	middleware.Audit(c, "company.submit", "company", profile.ID.String(), nil, nil)
+	return c.JSON(profile)
+}
+
+// ListCompanies godoc
+// @Summary KYC review queue
+// @Description List company profiles by status, pending (the review queue, longest waiting first) by default
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "draft, pending, verified or rejected"
+// @Success 200 {array} models.CompanyProfile
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/companies [get]
+func (h *CompanyHandler) ListCompanies(c *fiber.Ctx) error {
+	profiles, err := h.service.ListCompanies(c.Query("status"))
+	if err != nil {
+		return h.companyError(c, err, "Failed to retrieve companies")
+	}
+
+	return c.JSON(profiles)
+}
+
+// GetCompanyForReview godoc
+// @Summary Get company for review
+// @Description Get a company profile with its documents
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Company ID"
+// @Success 200 {object} models.CompanyProfile
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/companies/{id} [get]
+func (h *CompanyHandler) GetCompanyForReview(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid company ID",
+		})
+	}
+
+	profile, err := h.service.GetCompanyForReview(id)
+	if err != nil {
+		return h.companyError(c, err, "Failed to retrieve company profile")
+	}
+
+	return c.JSON(profile)
+}
+
+// DownloadDocument godoc
+// @Summary Download KYC document
+// @Description Download a company document for review
+// @Tags Admin
+// @Security BearerAuth
+// @Produce application/pdf,image/jpeg,image/png
+// @Param id path string true "Company ID"
+// @Param documentId path string true "Document ID"
+// @Success 200 {file} binary
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/companies/{id}/documents/{documentId} [get]
+func (h *CompanyHandler) DownloadDocument(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid company ID",
+		})
+	}
+	documentID, err := uuid.Parse(c.Params("documentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid document ID",
+		})
+	}
+
+	document, data, err := h.service.DownloadDocument(id, documentID)
+	if err != nil {
+		return h.companyError(c, err, "Failed to download document")
+	}
+
+	c.Set(fiber.HeaderContentType, document.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", document.FileName))
+	c.Set("X-Content-Type-Options", "nosniff")
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Send(data)
+}
+
+// ApproveCompany godoc
+// @Summary Verify company
+// @Description Approve a pending company profile so its owner can create production credentials. The owner is emailed
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Company ID"
+// @Success 200 {object} models.CompanyProfile
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/companies/{id}/approve [post]
+func (h *CompanyHandler) ApproveCompany(c *fiber.Ctx) error {
+	reviewerID := middleware.GetUserID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid company ID",
+		})
+	}
+
+	profile, err := h.service.ApproveCompany(reviewerID, id)
+	if err != nil {
+		return h.companyError(c, err, "Failed to verify company")
+	}
+
+	middleware.Audit(c, "company.approve", "company", id.String(), nil, nil)
+	return c.JSON(profile)
+}
+
+// RejectCompany godoc
+// @Summary Reject company
+// @Description Reject a pending company profile with a reason, which is emailed to the owner. They can fix the profile and submit it again
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Company ID"
+// @Param input body services.CompanyReviewInput true "Reason"
+// @Success 200 {object} models.CompanyProfile
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/companies/{id}/reject [post]
+func (h *CompanyHandler) RejectCompany(c *fiber.Ctx) error {
+	reviewerID := middleware.GetUserID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid company ID",
+		})
+	}
+
+	var input services.CompanyReviewInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	profile, err := h.service.RejectCompany(reviewerID, id, input)
+	if err != nil {
+		return h.companyError(c, err, "Failed to reject company")
+	}
+
+	middleware.Audit(c, "company.reject", "company", id.String(), nil, input)
+	return c.JSON(profile)
+}
+
+// companyError maps company service errors to HTTP responses
+func (h *CompanyHandler) companyError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidCompanyProfile):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Legal name and address are required, the NIB must have 13 digits and the NPWP 15 or 16",
+		})
+	case errors.Is(err, services.ErrInvalidCompanyDocument):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Document type must be 'business_license' or 'npwp'",
+		})
+	case errors.Is(err, services.ErrCompanyDocumentsMissing):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Upload the business license and NPWP documents before submitting",
+		})
+	case errors.Is(err, services.ErrCompanyReasonLength):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Reason must be 3-500 characters",
+		})
+	case errors.Is(err, services.ErrInvalidCompanyStatus):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Status must be 'draft', 'pending', 'verified' or 'rejected'",
+		})
+	case errors.Is(err, services.ErrCompanyDocumentTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(ErrorResponse{
+			Error:   "Payload Too Large",
+			Message: fmt.Sprintf("Documents must be at most %d MB", h.service.MaxDocumentBytes()/(1024*1024)),
+		})
+	case errors.Is(err, services.ErrUnsupportedDocumentType):
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(ErrorResponse{
+			Error:   "Unsupported Media Type",
+			Message: "Documents must be PDF, JPEG or PNG files",
+		})
+	case errors.Is(err, services.ErrCompanyNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Company profile not found",
+		})
+	case errors.Is(err, services.ErrCompanyDocumentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Document not found",
+		})
+	case errors.Is(err, services.ErrOrganizationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Organization not found",
+		})
+	case errors.Is(err, services.ErrOrgPermissionDenied):
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error:   "Forbidden",
+			Message: "Your organization role does not allow this",
+		})
+	case errors.Is(err, services.ErrCompanyUnderReview):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "The company profile is under review and cannot be changed",
+		})
+	case errors.Is(err, services.ErrCompanyNotSubmittable):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "The company profile is already under review or verified",
+		})
+	case errors.Is(err, services.ErrCompanyNotPending):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "The company profile is not awaiting review",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// DataExportHandler handles personal data export requests
+type DataExportHandler struct {
+	service *services.DataExportService
+}
+
+// NewDataExportHandler creates a new DataExportHandler
+func NewDataExportHandler(service *services.DataExportService) *DataExportHandler {
+	return &DataExportHandler{service: service}
+}
+
+// StartExport godoc
+// @Summary Request a personal data export
+// @Description Start building a ZIP of the user's profile, API key and partner credential metadata and audit history. Poll the operation in the Location header, then download the archive from GET /users/me/export. If an export is already being prepared it is returned instead
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 202 {object} models.OperationResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /users/me/export [post]
+func (h *DataExportHandler) StartExport(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	operation, err := h.service.StartExport(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to start data export",
+		})
+	}
+
+	middleware.Audit(c, "user.request_data_export", "operation", operation.ID.String(), nil, nil)
+	return acceptedOperation(c, operation)
+}
+
+// DownloadExport godoc
+// @Summary Download personal data export
+// @Description Download the archive of the latest finished export. Archives are kept for 7 days
+// @Tags Users
+// @Security BearerAuth
+// @Produce application/zip
+// @Success 200 {file} binary
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Router /users/me/export [get]
+func (h *DataExportHandler) DownloadExport(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	fileName, data, err := h.service.Download(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDataExportNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+				Error:   "Not Found",
+				Message: "No data export has been requested",
+			})
+		case errors.Is(err, services.ErrDataExportInProgress):
+			c.Set(fiber.HeaderRetryAfter, "2")
+			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+				Error:   "Conflict",
+				Message: "Your data export is still being prepared",
+			})
+		case errors.Is(err, services.ErrDataExportFailed):
+			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+				Error:   "Not Found",
+				Message: "Your last data export failed, please request a new one",
+			})
+		case errors.Is(err, services.ErrDataExportExpired):
+			return c.Status(fiber.StatusGone).JSON(ErrorResponse{
+				Error:   "Gone",
+				Message: "Your data export has expired, please request a new one",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to retrieve data export",
+		})
+	}
+
+	middleware.Audit(c, "user.download_data_export", "user", userID.String(), nil, nil)
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Send(data)
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/chaos"
+	"github.com/bankaceh/bas-portal-api/internal/loadtest"
+	"github.com/gofiber/fiber/v2"
+)
+
+// DebugHandler exposes failure injection endpoints for resilience drills.
+// It is only registered outside production.
+type DebugHandler struct {
+	injector *chaos.Injector
+}
+
+// NewDebugHandler creates a new DebugHandler
+func NewDebugHandler(injector *chaos.Injector) *DebugHandler {
+	return &DebugHandler{injector: injector}
+}
+
+// DBLatencyInput represents a database latency injection request
+type DBLatencyInput struct {
+	LatencyMs int64 `json:"latencyMs" validate:"min=0"`
+}
+
+// RedisDropInput represents a Redis outage injection request
+type RedisDropInput struct {
+	Dropped bool `json:"dropped"`
+}
+
+// GetChaosState godoc
+// @Summary Get injected failures
+// @Description List the database latency, Redis outage and route faults currently injected (non-production only)
+// @Tags Debug
+// @Produce json
+// @Success 200 {object} chaos.State
+// @Router /debug/chaos [get]
+func (h *DebugHandler) GetChaosState(c *fiber.Ctx) error {
+	return c.JSON(h.injector.State())
+}
+
+// SetDBLatency godoc
+// @Summary Inject database latency
+// @Description Delay every database statement by the given number of milliseconds; 0 turns it off (non-production only)
+// @Tags Debug
+// @Accept json
+// @Produce json
+// @Param input body DBLatencyInput true "Latency in milliseconds"
+// @Success 200 {object} chaos.State
+// @Failure 400 {object} ErrorResponse
+// @Router /debug/chaos/db-latency [put]
+func (h *DebugHandler) SetDBLatency(c *fiber.Ctx) error {
+	var input DBLatencyInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	latency := time.Duration(input.LatencyMs) * time.Millisecond
+	if latency < 0 || latency > chaos.MaxDBLatency {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Latency must be between 0 and 30000 milliseconds",
+		})
+	}
+
+	h.injector.SetDBLatency(latency)
+	return c.JSON(h.injector.State())
+}
+
+// SetRedisDropped godoc
+// @Summary Inject a Redis outage
+// @Description Make every Redis counter call (rate limits) fail as if the connection was lost, or restore them (non-production only)
+// @Tags Debug
+// @Accept json
+// @Produce json
+// @Param input body RedisDropInput true "Whether Redis is dropped"
+// @Success 200 {object} chaos.State
+// @Failure 400 {object} ErrorResponse
+// @Router /debug/chaos/redis [put]
+func (h *DebugHandler) SetRedisDropped(c *fiber.Ctx) error {
+	var input RedisDropInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	h.injector.SetRedisDropped(input.Dropped)
+	return c.JSON(h.injector.State())
+}
+
+// AddFault godoc
+// @Summary Force errors on a route
+// @Description Make every request whose path starts with the prefix fail with the given 5xx status (non-production only)
+// @Tags Debug
+// @Accept json
+// @Produce json
+// @Param input body chaos.Fault true "Route prefix and status code"
+// @Success 200 {object} chaos.State
+// @Failure 400 {object} ErrorResponse
+// @Router /debug/chaos/faults [post]
+func (h *DebugHandler) AddFault(c *fiber.Ctx) error {
+	var input chaos.Fault
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if !strings.HasPrefix(input.PathPrefix, "/") {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Path prefix must start with /",
+		})
+	}
+
+	// Never let a drill lock us out of the controls that end it
+	if strings.HasPrefix(input.PathPrefix, "/api/v1/debug") || strings.HasPrefix("/api/v1/debug", input.PathPrefix) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Faults cannot be injected on the debug routes",
+		})
+	}
+
+	if input.StatusCode == 0 {
+		input.StatusCode = fiber.StatusInternalServerError
+	}
+	if input.StatusCode < 500 || input.StatusCode > 599 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Status code must be a 5xx code",
+		})
+	}
+
+	h.injector.AddFault(input.PathPrefix, input.StatusCode)
+	return c.JSON(h.injector.State())
+}
+
+// RemoveFault godoc
+// @Summary Stop forcing errors on a route
+// @Description Remove a previously injected route fault (non-production only)
+// @Tags Debug
+// @Produce json
+// @Param pathPrefix query string true "Route prefix"
+// @Success 200 {object} chaos.State
+// @Failure 400 {object} ErrorResponse
+// @Router /debug/chaos/faults [delete]
+func (h *DebugHandler) RemoveFault(c *fiber.Ctx) error {
+	pathPrefix := c.Query("pathPrefix")
+	if pathPrefix == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "pathPrefix is required",
+		})
+	}
+
+	h.injector.RemoveFault(pathPrefix)
+	return c.JSON(h.injector.State())
+}
+
+// ResetChaos godoc
+// @Summary Clear all injected failures
+// @Description Remove injected database latency, the Redis outage and every route fault (non-production only)
+// @Tags Debug
+// @Produce json
+// @Success 200 {object} chaos.State
+// @Router /debug/chaos [delete]
+func (h *DebugHandler) ResetChaos(c *fiber.Ctx) error {
+	h.injector.Reset()
+	return c.JSON(h.injector.State())
+}
+
+// GetK6Scenario godoc
+// @Summary Generate a k6 load test scenario
+// @Description Render a k6 script that logs in as accounts created by cmd/seed and exercises the list and lookup endpoints (non-production only)
+// @Tags Debug
+// @Produce plain
+// @Param vus query int false "Concurrent virtual users" default(50)
+// @Param duration query string false "k6 duration" default(5m)
+// @Param users query int false "Number of seeded accounts to log in as; more than LOGIN_RATE_LIMIT needs a higher limit" default(5)
+// @Success 200 {string} string "k6 script"
+// @Failure 500 {object} ErrorResponse
+// @Router /debug/loadtest/k6 [get]
+func (h *DebugHandler) GetK6Scenario(c *fiber.Ctx) error {
+	script, err := loadtest.K6Script(loadtest.ScenarioOptions{
+		BaseURL:  c.BaseURL() + "/api/v1",
+		VUs:      c.QueryInt("vus"),
+		Duration: c.Query("duration"),
+		Users:    c.QueryInt("users"),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to generate k6 scenario",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/javascript")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="bas-portal-load.js"`)
+	return c.SendString(script)
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// DomainPolicyHandler handles admin management of sign-up email domain rules
+type DomainPolicyHandler struct {
+	service *services.DomainPolicyService
+}
+
+// NewDomainPolicyHandler creates a new DomainPolicyHandler
+func NewDomainPolicyHandler(service *services.DomainPolicyService) *DomainPolicyHandler {
+	return &DomainPolicyHandler{service: service}
+}
+
+// GetPolicy godoc
+// @Summary Get sign-up domain policy
+// @Description List the email domain allow and block rules from configuration and admin settings
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} services.DomainPolicy
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/settings/email-domains [get]
+func (h *DomainPolicyHandler) GetPolicy(c *fiber.Ctx) error {
+	policy, err := h.service.GetPolicy()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to retrieve email domain policy",
+		})
+	}
+
+	return c.JSON(policy)
+}
+
+// GetHistory godoc
+// @Summary Get sign-up domain rule history
+// @Description List every email domain rule ever added, including removed ones and who changed them
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.EmailDomainRule
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/settings/email-domains/history [get]
+func (h *DomainPolicyHandler) GetHistory(c *fiber.Ctx) error {
+	rules, err := h.service.ListHistory()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to retrieve email domain rule history",
+		})
+	}
+
+	return c.JSON(rules)
+}
+
+// AddRule godoc
+// @Summary Add sign-up domain rule
+// @Description Allow or block registrations from an email domain and its subdomains. Once any allow rule exists, only allowed domains can register
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.CreateDomainRuleInput true "Domain rule"
+// @Success 201 {object} models.EmailDomainRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/settings/email-domains [post]
+func (h *DomainPolicyHandler) AddRule(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	var input services.CreateDomainRuleInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	rule, err := h.service.AddRule(adminID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidDomain):
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "A valid domain and a type of allow or block are required",
+			})
+		case errors.Is(err, services.ErrDomainRuleExists):
+			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+				Error:   "Conflict",
+				Message: "A rule for this domain already exists",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to add email domain rule",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// DeleteRule godoc
+// @Summary Remove sign-up domain rule
+// @Description Remove an admin-managed email domain rule. Rules from configuration cannot be removed here
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/settings/email-domains/{id} [delete]
+func (h *DomainPolicyHandler) DeleteRule(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid rule ID",
+		})
+	}
+
+	if err := h.service.DeleteRule(adminID, id); err != nil {
+		if errors.Is(err, services.ErrDomainRuleNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+				Error:   "Not Found",
+				Message: "Email domain rule not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to remove email domain rule",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Email domain rule removed",
+	})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// IncidentHandler handles admin actions taken on compromised accounts
+type IncidentHandler struct {
+	service *services.IncidentService
+}
+
+// NewIncidentHandler creates a new IncidentHandler
+func NewIncidentHandler(service *services.IncidentService) *IncidentHandler {
+	return &IncidentHandler{service: service}
+}
+
+// ForceLogout godoc
+// @Summary Sign a user out everywhere
+// @Description Revoke every session of the user. Their refresh tokens and access tokens stop working immediately
+// @Tags Admin
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/users/{id}/logout [post]
+func (h *IncidentHandler) ForceLogout(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid user ID",
+		})
+	}
+
+	if err := h.service.ForceLogout(adminID, userID); err != nil {
+		return h.incidentError(c, err, "Failed to sign user out")
+	}
+
+	middleware.Audit(c, "user.force_logout", "user", userID.String(), nil, nil)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RequirePasswordReset godoc
+// @Summary Require a password reset
+// @Description Sign the user out everywhere and refuse sign-in until they choose a new password. A reset link is emailed with the optional reason
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param input body services.RequirePasswordResetInput false "Reason"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/users/{id}/require-password-reset [post]
+func (h *IncidentHandler) RequirePasswordReset(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid user ID",
+		})
+	}
+
+	var input services.RequirePasswordResetInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Invalid request body",
+			})
+		}
+	}
+
+	user, err := h.service.RequirePasswordReset(adminID, userID, input)
+	if err != nil {
+		return h.incidentError(c, err, "Failed to require a password reset")
+	}
+
+	middleware.Audit(c, "user.require_password_reset", "user", userID.String(), nil, input)
+	return c.JSON(user)
+}
+
+// incidentError maps incident response errors to responses
+func (h *IncidentHandler) incidentError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrIncidentReasonLength):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Reason must be at most 500 characters",
+		})
+	case errors.Is(err, services.ErrServiceAccountNoSignIn):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Service accounts do not sign in, revoke their tokens instead",
+		})
+	case errors.Is(err, services.ErrCannotResetOwnPassword):
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error:   "Forbidden",
+			Message: "You cannot require a reset of your own password",
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "User not found",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
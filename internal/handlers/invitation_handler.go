@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// InvitationHandler handles admin management of registration invitation codes
+type InvitationHandler struct {
+	service *services.InvitationService
+}
+
+// NewInvitationHandler creates a new InvitationHandler
+func NewInvitationHandler(service *services.InvitationService) *InvitationHandler {
+	return &InvitationHandler{service: service}
+}
+
+// ListInvitations godoc
+// @Summary List invitation codes
+// @Description List registration invitation codes with their usage. Codes themselves are only shown on creation
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.InvitationCode
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/invitations [get]
+func (h *InvitationHandler) ListInvitations(c *fiber.Ctx) error {
+	invitations, err := h.service.ListInvitations()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to retrieve invitation codes",
+		})
+	}
+
+	return c.JSON(invitations)
+}
+
+// CreateInvitation godoc
+// @Summary Create invitation code
+// @Description Issue a registration invitation code. Defaults to a single use and 14 days expiry. The code is returned only in this response
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.CreateInvitationInput true "Invitation settings"
+// @Success 201 {object} services.CreatedInvitation
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/invitations [post]
+func (h *InvitationHandler) CreateInvitation(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	var input services.CreateInvitationInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Invalid request body",
+			})
+		}
+	}
+
+	invitation, err := h.service.CreateInvitation(adminID, input)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInvitationInput) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "maxUses must be 1-1000 and expiryDays 1-365",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to create invitation code",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(invitation)
+}
+
+// RevokeInvitation godoc
+// @Summary Revoke invitation code
+// @Description Stop an invitation code from being used. Accounts already registered with it are unaffected
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Invitation code ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/invitations/{id} [delete]
+func (h *InvitationHandler) RevokeInvitation(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid invitation code ID",
+		})
+	}
+
+	if err := h.service.RevokeInvitation(adminID, id); err != nil {
+		if errors.Is(err, services.ErrInvitationNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+				Error:   "Not Found",
+				Message: "Invitation code not found or already revoked",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to revoke invitation code",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Invitation code revoked",
+	})
+}
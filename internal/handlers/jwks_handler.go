@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/jwtkeys"
+	"github.com/gofiber/fiber/v2"
+)
+
+// JWKSHandler publishes the public keys used to sign portal tokens
+type JWKSHandler struct {
+	keys *jwtkeys.KeySet
+}
+
+// NewJWKSHandler creates a new JWKSHandler
+func NewJWKSHandler(keys *jwtkeys.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys for validating portal access tokens, selected by the token's kid header. Empty when tokens are signed with HS256
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} jwtkeys.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(h.keys.JWKS())
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationPreferenceHandler handles the user's notification settings
+type NotificationPreferenceHandler struct {
+	service *services.NotificationPreferenceService
+}
+
+// NewNotificationPreferenceHandler creates a new NotificationPreferenceHandler
+func NewNotificationPreferenceHandler(service *services.NotificationPreferenceService) *NotificationPreferenceHandler {
+	return &NotificationPreferenceHandler{service: service}
+}
+
+// GetPreferences godoc
+// @Summary Get notification preferences
+// @Description Which optional emails the user receives: key and credential expiry notices, security alerts and product announcements
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.NotificationPreferencesResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/me/preferences [get]
+func (h *NotificationPreferenceHandler) GetPreferences(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	preferences, err := h.service.GetPreferences(userID)
+	if err != nil {
+		return h.preferenceError(c, err, "Failed to retrieve notification preferences")
+	}
+
+	return c.JSON(preferences)
+}
+
+// UpdatePreferences godoc
+// @Summary Update notification preferences
+// @Description Turn optional emails on or off. Omitted fields are left unchanged. Verification, password reset, invitation and suspension emails are always sent
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.UpdateNotificationPreferencesInput true "Preferences"
+// @Success 200 {object} models.NotificationPreferencesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/me/preferences [put]
+func (h *NotificationPreferenceHandler) UpdatePreferences(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var input services.UpdateNotificationPreferencesInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	before, err := h.service.GetPreferences(userID)
+	if err != nil {
+		return h.preferenceError(c, err, "Failed to update notification preferences")
+	}
+
+	preferences, err := h.service.UpdatePreferences(userID, input)
+	if err != nil {
+		return h.preferenceError(c, err, "Failed to update notification preferences")
+	}
+
+	middleware.Audit(c, "user.update_notification_preferences", "user", userID.String(), before, preferences)
+	return c.JSON(preferences)
+}
+
+// preferenceError maps notification preference errors to HTTP responses
+func (h *NotificationPreferenceHandler) preferenceError(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, services.ErrUserNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "User not found",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
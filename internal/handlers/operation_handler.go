@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// OperationHandler handles long-running operation status endpoints
+type OperationHandler struct {
+	service *services.OperationService
+}
+
+// NewOperationHandler creates a new OperationHandler
+func NewOperationHandler(service *services.OperationService) *OperationHandler {
+	return &OperationHandler{service: service}
+}
+
+// GetOperation godoc
+// @Summary Get operation status
+// @Description Poll the progress and result of a long-running operation
+// @Tags Operations
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Operation ID"
+// @Success 200 {object} models.OperationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /operations/{id} [get]
+func (h *OperationHandler) GetOperation(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid operation ID",
+		})
+	}
+
+	operation, err := h.service.GetOperation(id, userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Operation not found",
+		})
+	}
+
+	// Tell pollers how long to wait before asking again
+	if !operation.Done {
+		c.Set(fiber.HeaderRetryAfter, "2")
+	}
+	return c.JSON(operation)
+}
+
+// acceptedOperation answers a request whose work continues in the background with
+// 202 Accepted and a Location header pointing at the operation's status endpoint
+func acceptedOperation(c *fiber.Ctx, operation *models.Operation) error {
+	c.Location("/api/v1/operations/" + operation.ID.String())
+	return c.Status(fiber.StatusAccepted).JSON(operation.ToResponse())
+}
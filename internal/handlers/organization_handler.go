@@ -0,0 +1,550 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// OrganizationHandler handles organization, membership and invitation endpoints
+type OrganizationHandler struct {
+	service     *services.OrganizationService
+	invitations *services.OrganizationInvitationService
+}
+
+// NewOrganizationHandler creates a new OrganizationHandler
+func NewOrganizationHandler(service *services.OrganizationService, invitations *services.OrganizationInvitationService) *OrganizationHandler {
+	return &OrganizationHandler{service: service, invitations: invitations}
+}
+
+// ListOrganizations godoc
+// @Summary List organizations
+// @Description List the organizations the authenticated user belongs to, with their role in each
+// @Tags Organizations
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.OrganizationResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /organizations [get]
+func (h *OrganizationHandler) ListOrganizations(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgs, err := h.service.ListOrganizations(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to retrieve organizations",
+		})
+	}
+
+	return c.JSON(orgs)
+}
+
+// CreateOrganization godoc
+// @Summary Create organization
+// @Description Create an organization with the authenticated user as its owner
+// @Tags Organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.OrganizationInput true "Organization data"
+// @Success 201 {object} models.OrganizationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /organizations [post]
+func (h *OrganizationHandler) CreateOrganization(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var input services.OrganizationInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	org, err := h.service.CreateOrganization(userID, input)
+	if err != nil {
+		return h.organizationError(c, err, "Failed to create organization")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(org)
+}
+
+// GetOrganization godoc
+// @Summary Get organization
+// @Description Get an organization and its members
+// @Tags Organizations
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {object} services.OrganizationDetail
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /organizations/{id} [get]
+func (h *OrganizationHandler) GetOrganization(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	org, err := h.service.GetOrganization(userID, orgID)
+	if err != nil {
+		return h.organizationError(c, err, "Failed to retrieve organization")
+	}
+
+	return c.JSON(org)
+}
+
+// RenameOrganization godoc
+// @Summary Rename organization
+// @Description Change an organization's name (owners only)
+// @Tags Organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param input body services.OrganizationInput true "Organization data"
+// @Success 200 {object} models.OrganizationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /organizations/{id} [put]
+func (h *OrganizationHandler) RenameOrganization(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	var input services.OrganizationInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	org, err := h.service.RenameOrganization(userID, orgID, input)
+	if err != nil {
+		return h.organizationError(c, err, "Failed to rename organization")
+	}
+
+	return c.JSON(org)
+}
+
+// DeleteOrganization godoc
+// @Summary Delete organization
+// @Description Delete an organization (owners only). Its API keys and partner credentials must be revoked or deleted first
+// @Tags Organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /organizations/{id} [delete]
+func (h *OrganizationHandler) DeleteOrganization(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	if err := h.service.DeleteOrganization(userID, orgID); err != nil {
+		return h.organizationError(c, err, "Failed to delete organization")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// UpdateMember godoc
+// @Summary Change member role
+// @Description Change an organization member's role (owners only)
+// @Tags Organizations
+// @Security BearerAuth
+// @Accept json
+// @Param id path string true "Organization ID"
+// @Param userId path string true "Member user ID"
+// @Param input body services.UpdateMemberInput true "New role"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /organizations/{id}/members/{userId} [put]
+func (h *OrganizationHandler) UpdateMember(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, memberID, err := memberParams(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization or user ID",
+		})
+	}
+
+	var input services.UpdateMemberInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if err := h.service.UpdateMemberRole(userID, orgID, memberID, input); err != nil {
+		return h.organizationError(c, err, "Failed to update member")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RemoveMember godoc
+// @Summary Remove organization member
+// @Description Remove a member from an organization (owners only). Any member may remove themselves to leave
+// @Tags Organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param userId path string true "Member user ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /organizations/{id}/members/{userId} [delete]
+func (h *OrganizationHandler) RemoveMember(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, memberID, err := memberParams(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization or user ID",
+		})
+	}
+
+	if err := h.service.RemoveMember(userID, orgID, memberID); err != nil {
+		return h.organizationError(c, err, "Failed to remove member")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// InviteMember godoc
+// @Summary Invite a member
+// @Description Email an invitation to join the organization with a role (owners only). Inviting the same address again replaces the earlier link
+// @Tags Organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param input body services.InviteMemberInput true "Invitation data"
+// @Success 201 {object} models.OrganizationInvitationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /organizations/{id}/invitations [post]
+func (h *OrganizationHandler) InviteMember(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	var input services.InviteMemberInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if input.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Email is required",
+		})
+	}
+
+	invitation, err := h.invitations.Invite(userID, orgID, input)
+	if err != nil {
+		return h.organizationError(c, err, "Failed to send invitation")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(invitation)
+}
+
+// ListInvitations godoc
+// @Summary List pending invitations
+// @Description List an organization's pending invitations (owners only)
+// @Tags Organizations
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {array} models.OrganizationInvitationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /organizations/{id}/invitations [get]
+func (h *OrganizationHandler) ListInvitations(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	invitations, err := h.invitations.ListInvitations(userID, orgID)
+	if err != nil {
+		return h.organizationError(c, err, "Failed to retrieve invitations")
+	}
+
+	return c.JSON(invitations)
+}
+
+// RevokeInvitation godoc
+// @Summary Revoke an invitation
+// @Description Cancel a pending invitation so its link stops working (owners only)
+// @Tags Organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param invitationId path string true "Invitation ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /organizations/{id}/invitations/{invitationId} [delete]
+func (h *OrganizationHandler) RevokeInvitation(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+	invitationID, err := uuid.Parse(c.Params("invitationId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid invitation ID",
+		})
+	}
+
+	if err := h.invitations.RevokeInvitation(userID, orgID, invitationID); err != nil {
+		return h.organizationError(c, err, "Failed to revoke invitation")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// PreviewInvitation godoc
+// @Summary Look up an invitation
+// @Description Show the organization, role and email of a pending invitation, and whether an account already uses that email (sign in to accept) or not (register with the token)
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param input body services.OrgInvitationTokenInput true "Invitation token"
+// @Success 200 {object} services.OrgInvitationPreview
+// @Failure 400 {object} ErrorResponse
+// @Router /organization-invitations/lookup [post]
+func (h *OrganizationHandler) PreviewInvitation(c *fiber.Ctx) error {
+	var input services.OrgInvitationTokenInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	preview, err := h.invitations.PreviewInvitation(input)
+	if err != nil {
+		return h.organizationError(c, err, "Failed to look up invitation")
+	}
+
+	return c.JSON(preview)
+}
+
+// AcceptInvitation godoc
+// @Summary Accept an invitation
+// @Description Join the organization as the signed-in user. The invitation must have been sent to the user's email
+// @Tags Organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.OrgInvitationTokenInput true "Invitation token"
+// @Success 200 {object} models.OrganizationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /organization-invitations/accept [post]
+func (h *OrganizationHandler) AcceptInvitation(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var input services.OrgInvitationTokenInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	org, err := h.invitations.AcceptInvitation(userID, input)
+	if err != nil {
+		return h.organizationError(c, err, "Failed to accept invitation")
+	}
+
+	return c.JSON(org)
+}
+
+// DeclineInvitation godoc
+// @Summary Decline an invitation
+// @Description Turn down a pending invitation. No sign-in is needed; the emailed token is enough
+// @Tags Organizations
+// @Accept json
+// @Param input body services.OrgInvitationTokenInput true "Invitation token"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Router /organization-invitations/decline [post]
+func (h *OrganizationHandler) DeclineInvitation(c *fiber.Ctx) error {
+	var input services.OrgInvitationTokenInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if err := h.invitations.DeclineInvitation(input); err != nil {
+		return h.organizationError(c, err, "Failed to decline invitation")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// organizationError maps organization service errors to HTTP responses
+func (h *OrganizationHandler) organizationError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidOrganization):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Organization name must be 2-100 characters",
+		})
+	case errors.Is(err, services.ErrInvalidOrgRole):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Role must be owner, editor or viewer",
+		})
+	case errors.Is(err, services.ErrOrgPermissionDenied):
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error:   "Forbidden",
+			Message: "Your organization role does not allow this",
+		})
+	case errors.Is(err, services.ErrOrganizationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Organization not found",
+		})
+	case errors.Is(err, services.ErrOrgMemberNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Organization member not found",
+		})
+	case errors.Is(err, services.ErrOrgInvitationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Invitation not found",
+		})
+	case errors.Is(err, services.ErrOrgInvitationInvalid):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invitation is invalid, expired or no longer pending",
+		})
+	case errors.Is(err, services.ErrOrgInvitationEmailMismatch):
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error:   "Forbidden",
+			Message: "This invitation was sent to a different email address",
+		})
+	case errors.Is(err, services.ErrOrgMemberExists):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "User is already a member of this organization",
+		})
+	case errors.Is(err, services.ErrLastOrgOwner):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "An organization must keep at least one owner",
+		})
+	case errors.Is(err, services.ErrOrganizationNotEmpty):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "Revoke the organization's API keys and delete its partner credentials first",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
+
+// memberParams parses the organization and member IDs from the route
+func memberParams(c *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	orgID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	memberID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return orgID, memberID, nil
+}
+
+// organizationQuery parses the optional organizationId query parameter used to list
+// organization-owned resources
+func organizationQuery(c *fiber.Ctx) (*uuid.UUID, error) {
+	raw := c.Query("organizationId")
+	if raw == "" {
+		return nil, nil
+	}
+	orgID, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &orgID, nil
+}
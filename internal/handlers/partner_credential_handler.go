@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/models"
 	"github.com/bankaceh/bas-portal-api/internal/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -21,18 +22,36 @@ func NewPartnerCredentialHandler(service *services.PartnerCredentialService) *Pa
 
 // ListCredentials godoc
 // @Summary List partner credentials
-// @Description Get all SNAP partner credentials for the authenticated user
+// @Description Get the authenticated user's personal SNAP partner credentials, or an organization's credentials
 // @Tags Partner Credentials
 // @Security BearerAuth
 // @Produce json
+// @Param organizationId query string false "Organization ID"
 // @Success 200 {array} models.PartnerCredentialResponse
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Router /partner-credentials [get]
 func (h *PartnerCredentialHandler) ListCredentials(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 
-	credentials, err := h.service.ListCredentials(userID)
+	orgID, err := organizationQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	credentials, err := h.service.ListCredentials(userID, orgID)
 	if err != nil {
+		if errors.Is(err, services.ErrOrganizationNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+				Error:   "Not Found",
+				Message: "Organization not found",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Error:   "Internal Server Error",
 			Message: "Failed to retrieve partner credentials",
@@ -51,6 +70,7 @@ func (h *PartnerCredentialHandler) ListCredentials(c *fiber.Ctx) error {
 // @Param id path string true "Credential ID"
 // @Success 200 {object} models.PartnerCredentialDetailResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /partner-credentials/{id} [get]
 func (h *PartnerCredentialHandler) GetCredential(c *fiber.Ctx) error {
@@ -73,6 +93,12 @@ func (h *PartnerCredentialHandler) GetCredential(c *fiber.Ctx) error {
 				Message: "Partner credential not found",
 			})
 		}
+		if errors.Is(err, services.ErrOrgPermissionDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Your organization role does not allow this",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Error:   "Internal Server Error",
 			Message: "Failed to retrieve partner credential",
@@ -84,7 +110,7 @@ func (h *PartnerCredentialHandler) GetCredential(c *fiber.Ctx) error {
 
 // CreateCredential godoc
 // @Summary Create partner credential
-// @Description Create a new SNAP partner credential with auto-generated Client ID and Secret
+// @Description Create a new SNAP partner credential with auto-generated Client ID and Secret. Set retrievalLink to get a single-use link to the secret, valid for 24 hours, instead of the secret
 // @Tags Partner Credentials
 // @Security BearerAuth
 // @Accept json
@@ -93,6 +119,8 @@ func (h *PartnerCredentialHandler) GetCredential(c *fiber.Ctx) error {
 // @Success 201 {object} models.PartnerCredentialCreateResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 409 {object} ErrorResponse
 // @Router /partner-credentials [post]
 func (h *PartnerCredentialHandler) CreateCredential(c *fiber.Ctx) error {
@@ -125,13 +153,43 @@ func (h *PartnerCredentialHandler) CreateCredential(c *fiber.Ctx) error {
 		if errors.Is(err, services.ErrMaxCredentialsReached) {
 			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
 				Error:   "Conflict",
-				Message: "Maximum number of partner credentials reached (5)",
+				Message: "Partner credential quota reached, delete a credential or ask BAS to raise the quota",
+			})
+		}
+		if errors.Is(err, services.ErrOrganizationNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+				Error:   "Not Found",
+				Message: "Organization not found",
+			})
+		}
+		if errors.Is(err, services.ErrOrgPermissionDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Your organization role does not allow this",
+			})
+		}
+		if errors.Is(err, services.ErrCompanyNotVerified) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Production credentials require a verified company profile",
 			})
 		}
 		if errors.Is(err, services.ErrInvalidPublicKey) {
 			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
 				Error:   "Bad Request",
-				Message: "Invalid public key format. Please provide a valid PEM-encoded RSA public key",
+				Message: "Invalid public key format. Please provide a valid PEM-encoded RSA, ECDSA P-256 or Ed25519 public key",
+			})
+		}
+		if errors.Is(err, services.ErrInvalidCallbackURL) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Callback URL must be a valid http or https URL",
+			})
+		}
+		if errors.Is(err, services.ErrInsecureCallbackURL) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Production callback URLs must use HTTPS with TLS 1.2 or newer and a valid certificate",
 			})
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
@@ -140,6 +198,7 @@ func (h *PartnerCredentialHandler) CreateCredential(c *fiber.Ctx) error {
 		})
 	}
 
+	middleware.Audit(c, "partner_credential.create", "partner_credential", response.ID.String(), nil, response.PartnerCredentialResponse)
 	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
@@ -155,6 +214,7 @@ func (h *PartnerCredentialHandler) CreateCredential(c *fiber.Ctx) error {
 // @Success 200 {object} models.PartnerCredentialResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /partner-credentials/{id} [put]
 func (h *PartnerCredentialHandler) UpdateCredential(c *fiber.Ctx) error {
@@ -184,6 +244,9 @@ func (h *PartnerCredentialHandler) UpdateCredential(c *fiber.Ctx) error {
 		})
 	}
 
+	// Captured for the audit log
+	before, _ := h.service.GetCredential(id, userID)
+
 	response, err := h.service.UpdateCredential(id, userID, input)
 	if err != nil {
 		if errors.Is(err, services.ErrCredentialNotFound) {
@@ -192,18 +255,43 @@ func (h *PartnerCredentialHandler) UpdateCredential(c *fiber.Ctx) error {
 				Message: "Partner credential not found",
 			})
 		}
+		if errors.Is(err, services.ErrOrgPermissionDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Your organization role does not allow this",
+			})
+		}
+		if errors.Is(err, services.ErrCompanyNotVerified) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Production credentials require a verified company profile",
+			})
+		}
+		if errors.Is(err, services.ErrInvalidCallbackURL) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Callback URL must be a valid http or https URL",
+			})
+		}
+		if errors.Is(err, services.ErrInsecureCallbackURL) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Production callback URLs must use HTTPS with TLS 1.2 or newer and a valid certificate",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Error:   "Internal Server Error",
 			Message: "Failed to update partner credential",
 		})
 	}
 
+	middleware.Audit(c, "partner_credential.update", "partner_credential", id.String(), credentialAuditValues(before), response)
 	return c.JSON(response)
 }
 
 // UpdatePublicKey godoc
 // @Summary Update public key
-// @Description Update the RSA public key for a SNAP partner credential
+// @Description Update the RSA, ECDSA P-256 or Ed25519 public key for a SNAP partner credential
 // @Tags Partner Credentials
 // @Security BearerAuth
 // @Accept json
@@ -213,6 +301,7 @@ func (h *PartnerCredentialHandler) UpdateCredential(c *fiber.Ctx) error {
 // @Success 200 {object} models.PartnerCredentialResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /partner-credentials/{id}/public-key [put]
 func (h *PartnerCredentialHandler) UpdatePublicKey(c *fiber.Ctx) error {
@@ -242,6 +331,9 @@ func (h *PartnerCredentialHandler) UpdatePublicKey(c *fiber.Ctx) error {
 		})
 	}
 
+	// Captured for the audit log
+	before, _ := h.service.GetCredential(id, userID)
+
 	response, err := h.service.UpdatePublicKey(id, userID, input)
 	if err != nil {
 		if errors.Is(err, services.ErrCredentialNotFound) {
@@ -250,10 +342,16 @@ func (h *PartnerCredentialHandler) UpdatePublicKey(c *fiber.Ctx) error {
 				Message: "Partner credential not found",
 			})
 		}
+		if errors.Is(err, services.ErrOrgPermissionDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Your organization role does not allow this",
+			})
+		}
 		if errors.Is(err, services.ErrInvalidPublicKey) {
 			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
 				Error:   "Bad Request",
-				Message: "Invalid public key format. Please provide a valid PEM-encoded RSA public key",
+				Message: "Invalid public key format. Please provide a valid PEM-encoded RSA, ECDSA P-256 or Ed25519 public key",
 			})
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
@@ -262,18 +360,22 @@ func (h *PartnerCredentialHandler) UpdatePublicKey(c *fiber.Ctx) error {
 		})
 	}
 
+	middleware.Audit(c, "partner_credential.update_public_key", "partner_credential", id.String(), credentialAuditValues(before), response)
 	return c.JSON(response)
 }
 
 // RegenerateSecret godoc
 // @Summary Regenerate client secret
-// @Description Generate a new client secret for a SNAP partner credential
+// @Description Generate a new client secret for a SNAP partner credential. Set retrievalLink to get a single-use link to the secret instead of the secret
 // @Tags Partner Credentials
 // @Security BearerAuth
+// @Accept json
 // @Produce json
 // @Param id path string true "Credential ID"
+// @Param input body services.RegenerateSecretInput false "Regeneration options"
 // @Success 200 {object} models.PartnerCredentialCreateResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /partner-credentials/{id}/regenerate-secret [post]
 func (h *PartnerCredentialHandler) RegenerateSecret(c *fiber.Ctx) error {
@@ -288,7 +390,17 @@ func (h *PartnerCredentialHandler) RegenerateSecret(c *fiber.Ctx) error {
 		})
 	}
 
-	response, err := h.service.RegenerateSecret(id, userID)
+	var input services.RegenerateSecretInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Invalid request body",
+			})
+		}
+	}
+
+	response, err := h.service.RegenerateSecret(id, userID, input)
 	if err != nil {
 		if errors.Is(err, services.ErrCredentialNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
@@ -296,12 +408,19 @@ func (h *PartnerCredentialHandler) RegenerateSecret(c *fiber.Ctx) error {
 				Message: "Partner credential not found",
 			})
 		}
+		if errors.Is(err, services.ErrOrgPermissionDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Your organization role does not allow this",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Error:   "Internal Server Error",
 			Message: "Failed to regenerate client secret",
 		})
 	}
 
+	middleware.Audit(c, "partner_credential.regenerate_secret", "partner_credential", id.String(), nil, response.PartnerCredentialResponse)
 	return c.JSON(response)
 }
 
@@ -314,6 +433,7 @@ func (h *PartnerCredentialHandler) RegenerateSecret(c *fiber.Ctx) error {
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /partner-credentials/{id} [delete]
 func (h *PartnerCredentialHandler) DeleteCredential(c *fiber.Ctx) error {
@@ -328,6 +448,9 @@ func (h *PartnerCredentialHandler) DeleteCredential(c *fiber.Ctx) error {
 		})
 	}
 
+	// Captured for the audit log
+	before, _ := h.service.GetCredential(id, userID)
+
 	if err := h.service.DeleteCredential(id, userID); err != nil {
 		if errors.Is(err, services.ErrCredentialNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
@@ -335,11 +458,104 @@ func (h *PartnerCredentialHandler) DeleteCredential(c *fiber.Ctx) error {
 				Message: "Partner credential not found",
 			})
 		}
+		if errors.Is(err, services.ErrOrgPermissionDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Your organization role does not allow this",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Error:   "Internal Server Error",
 			Message: "Failed to delete partner credential",
 		})
 	}
 
+	middleware.Audit(c, "partner_credential.delete", "partner_credential", id.String(), credentialAuditValues(before), nil)
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// ReactivateCredential godoc
+// @Summary Reactivate suspended credential
+// @Description Lift an inactivity suspension on a production credential after re-confirming the account password, or for accounts without one, within 10 minutes of signing in
+// @Tags Partner Credentials
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Credential ID"
+// @Param input body services.ReactivateCredentialInput true "Password confirmation (omit for accounts without a password)"
+// @Success 200 {object} models.PartnerCredentialResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /partner-credentials/{id}/reactivate [post]
+func (h *PartnerCredentialHandler) ReactivateCredential(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid credential ID",
+		})
+	}
+
+	var input services.ReactivateCredentialInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	response, err := h.service.ReactivateCredential(id, userID, middleware.GetSessionID(c), input)
+	if err != nil {
+		if errors.Is(err, services.ErrCredentialNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+				Error:   "Not Found",
+				Message: "Partner credential not found",
+			})
+		}
+		if errors.Is(err, services.ErrOrgPermissionDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Your organization role does not allow this",
+			})
+		}
+		if errors.Is(err, services.ErrCredentialNotSuspended) {
+			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+				Error:   "Conflict",
+				Message: "Partner credential is not suspended",
+			})
+		}
+		if errors.Is(err, services.ErrPasswordConfirmationFailed) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Password confirmation failed",
+			})
+		}
+		if errors.Is(err, services.ErrRecentLoginRequired) {
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Please sign in again before reactivating this credential",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to reactivate partner credential",
+		})
+	}
+
+	middleware.Audit(c, "partner_credential.reactivate", "partner_credential", id.String(), nil, response)
+	return c.JSON(response)
+}
+
+// credentialAuditValues returns the audit log form of a credential, without key material
+func credentialAuditValues(credential *models.PartnerCredentialDetailResponse) interface{} {
+	if credential == nil {
+		return nil
+	}
+	return credential.PartnerCredentialResponse
+}
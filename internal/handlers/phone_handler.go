@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PhoneHandler handles phone verification and SMS two-factor settings
+type PhoneHandler struct {
+	service *services.PhoneService
+}
+
+// NewPhoneHandler creates a new PhoneHandler
+func NewPhoneHandler(service *services.PhoneService) *PhoneHandler {
+	return &PhoneHandler{service: service}
+}
+
+// SetPhone godoc
+// @Summary Set phone number
+// @Description Save a mobile number (E.164, or Indonesian 08...) and text a 6-digit code to verify it. Sending the same unverified number again resends the code, at most once a minute
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.SetPhoneInput true "Phone number"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /users/me/phone [put]
+func (h *PhoneHandler) SetPhone(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var input services.SetPhoneInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	profile, err := h.service.SetPhone(userID, input)
+	if err != nil {
+		return h.phoneError(c, err, "Failed to update phone number")
+	}
+
+	middleware.Audit(c, "user.set_phone", "user", userID.String(), nil, fiber.Map{"phone": profile.Phone})
+	return c.JSON(profile)
+}
+
+// VerifyPhone godoc
+// @Summary Verify phone number
+// @Description Confirm the phone number with the code texted to it. Codes expire after 5 minutes or 5 wrong guesses
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.VerifyPhoneInput true "Code"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /users/me/phone/verify [post]
+func (h *PhoneHandler) VerifyPhone(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var input services.VerifyPhoneInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	profile, err := h.service.VerifyPhone(userID, input)
+	if err != nil {
+		return h.phoneError(c, err, "Failed to verify phone number")
+	}
+
+	middleware.Audit(c, "user.verify_phone", "user", userID.String(), nil, fiber.Map{"phone": profile.Phone})
+	return c.JSON(profile)
+}
+
+// EnableSMSMFA godoc
+// @Summary Turn on SMS two-factor sign-in
+// @Description Require a code texted to the verified phone number at every sign-in. Needs the current password, or a recent sign-in for accounts without one
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.SMSMFAInput true "Password confirmation"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users/me/mfa/sms [post]
+func (h *PhoneHandler) EnableSMSMFA(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var input services.SMSMFAInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Invalid request body",
+			})
+		}
+	}
+
+	profile, err := h.service.EnableSMSMFA(userID, middleware.GetSessionID(c), input)
+	if err != nil {
+		return h.phoneError(c, err, "Failed to turn on two-factor authentication")
+	}
+
+	middleware.Audit(c, "user.enable_mfa", "user", userID.String(), nil, fiber.Map{"mfaMethod": profile.MFAMethod})
+	return c.JSON(profile)
+}
+
+// DisableSMSMFA godoc
+// @Summary Turn off SMS two-factor sign-in
+// @Description Stop asking for a texted code at sign-in. Needs the current password, or a recent sign-in for accounts without one
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.SMSMFAInput true "Password confirmation"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /users/me/mfa/sms [delete]
+func (h *PhoneHandler) DisableSMSMFA(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var input services.SMSMFAInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Invalid request body",
+			})
+		}
+	}
+
+	profile, err := h.service.DisableSMSMFA(userID, middleware.GetSessionID(c), input)
+	if err != nil {
+		return h.phoneError(c, err, "Failed to turn off two-factor authentication")
+	}
+
+	middleware.Audit(c, "user.disable_mfa", "user", userID.String(), fiber.Map{"mfaMethod": "sms"}, nil)
+	return c.JSON(profile)
+}
+
+// phoneError maps phone and two-factor errors to responses
+func (h *PhoneHandler) phoneError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidPhone):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Enter a mobile number in international format, such as +6281234567890",
+		})
+	case errors.Is(err, services.ErrInvalidSMSCode):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid or expired code",
+		})
+	case errors.Is(err, services.ErrPhoneInUseForMFA):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "Turn off SMS two-factor authentication before changing your phone number",
+		})
+	case errors.Is(err, services.ErrPhoneRequiredForMFA):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "Verify a phone number before turning on SMS two-factor authentication",
+		})
+	case errors.Is(err, services.ErrSMSCodeTooSoon):
+		c.Set(fiber.HeaderRetryAfter, "60")
+		return c.Status(fiber.StatusTooManyRequests).JSON(ErrorResponse{
+			Error:   "Too Many Requests",
+			Message: "A code was sent recently, please wait a minute before requesting another",
+		})
+	case errors.Is(err, services.ErrSMSDeliveryFailed):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(ErrorResponse{
+			Error:   "Service Unavailable",
+			Message: "The code could not be texted, please try again later",
+		})
+	case errors.Is(err, services.ErrPasswordConfirmationFailed):
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error:   "Forbidden",
+			Message: "Password is incorrect",
+		})
+	case errors.Is(err, services.ErrRecentLoginRequired):
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error:   "Forbidden",
+			Message: "Please sign in again before changing two-factor authentication",
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "User not found",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// QuotaHandler handles admin management of key and credential quotas
+type QuotaHandler struct {
+	service *services.QuotaService
+}
+
+// NewQuotaHandler creates a new QuotaHandler
+func NewQuotaHandler(service *services.QuotaService) *QuotaHandler {
+	return &QuotaHandler{service: service}
+}
+
+// GetUserQuota godoc
+// @Summary Get a user's quota
+// @Description How many personal API keys and partner credentials the user may hold
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.Quota
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/users/{id}/quota [get]
+func (h *QuotaHandler) GetUserQuota(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid user ID",
+		})
+	}
+
+	quota, err := h.service.GetUserQuota(userID)
+	if err != nil {
+		return h.quotaError(c, err, "Failed to retrieve quota")
+	}
+
+	return c.JSON(quota)
+}
+
+// UpdateUserQuota godoc
+// @Summary Set a user's quota
+// @Description Replace the limits on the user's personal API keys and partner credentials. Existing keys and credentials above a lowered limit are kept
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param input body services.UpdateQuotaInput true "Limits"
+// @Success 200 {object} models.Quota
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/users/{id}/quota [put]
+func (h *QuotaHandler) UpdateUserQuota(c *fiber.Ctx) error {
+	staffID := middleware.GetUserID(c)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid user ID",
+		})
+	}
+
+	var input services.UpdateQuotaInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	old, err := h.service.GetUserQuota(userID)
+	if err != nil {
+		return h.quotaError(c, err, "Failed to update quota")
+	}
+
+	quota, err := h.service.SetUserQuota(staffID, userID, input)
+	if err != nil {
+		return h.quotaError(c, err, "Failed to update quota")
+	}
+
+	middleware.Audit(c, "quota.update", "user", userID.String(), old, quota)
+	return c.JSON(quota)
+}
+
+// GetOrganizationQuota godoc
+// @Summary Get an organization's quota
+// @Description How many API keys and partner credentials the organization may hold
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {object} models.Quota
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/organizations/{id}/quota [get]
+func (h *QuotaHandler) GetOrganizationQuota(c *fiber.Ctx) error {
+	orgID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	quota, err := h.service.GetOrganizationQuota(orgID)
+	if err != nil {
+		return h.quotaError(c, err, "Failed to retrieve quota")
+	}
+
+	return c.JSON(quota)
+}
+
+// UpdateOrganizationQuota godoc
+// @Summary Set an organization's quota
+// @Description Replace the limits on the organization's API keys and partner credentials. Existing keys and credentials above a lowered limit are kept
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param input body services.UpdateQuotaInput true "Limits"
+// @Success 200 {object} models.Quota
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/organizations/{id}/quota [put]
+func (h *QuotaHandler) UpdateOrganizationQuota(c *fiber.Ctx) error {
+	staffID := middleware.GetUserID(c)
+
+	orgID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	var input services.UpdateQuotaInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	old, err := h.service.GetOrganizationQuota(orgID)
+	if err != nil {
+		return h.quotaError(c, err, "Failed to update quota")
+	}
+
+	quota, err := h.service.SetOrganizationQuota(staffID, orgID, input)
+	if err != nil {
+		return h.quotaError(c, err, "Failed to update quota")
+	}
+
+	middleware.Audit(c, "quota.update", "organization", orgID.String(), old, quota)
+	return c.JSON(quota)
+}
+
+// quotaError maps quota errors to responses
+func (h *QuotaHandler) quotaError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidQuota):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Quota limits must be between 0 and 1000",
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "User not found",
+		})
+	case errors.Is(err, services.ErrOrganizationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Organization not found",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
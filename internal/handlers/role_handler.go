@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RoleHandler handles admin management of user roles
+type RoleHandler struct {
+	service *services.RoleService
+}
+
+// NewRoleHandler creates a new RoleHandler
+func NewRoleHandler(service *services.RoleService) *RoleHandler {
+	return &RoleHandler{service: service}
+}
+
+// ListStaff godoc
+// @Summary List staff accounts
+// @Description List users with the operator or admin role
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.UserResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/staff [get]
+func (h *RoleHandler) ListStaff(c *fiber.Ctx) error {
+	staff, err := h.service.ListStaff()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to retrieve staff accounts",
+		})
+	}
+
+	return c.JSON(staff)
+}
+
+// AssignRole godoc
+// @Summary Assign a role
+// @Description Set a user's role to developer, operator or admin. Staff roles are limited to ADMIN_EMAIL_DOMAINS, and admins cannot change their own role
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param input body services.AssignRoleInput true "New role"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/users/{id}/role [put]
+func (h *RoleHandler) AssignRole(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid user ID",
+		})
+	}
+
+	var input services.AssignRoleInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	user, err := h.service.AssignRole(adminID, userID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRole):
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Role must be developer, operator or admin",
+			})
+		case errors.Is(err, services.ErrCannotChangeOwnRole):
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "You cannot change your own role",
+			})
+		case errors.Is(err, services.ErrRoleDomainNotAllowed):
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Operator and admin roles are limited to bank email domains",
+			})
+		case errors.Is(err, services.ErrUserNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+				Error:   "Not Found",
+				Message: "User not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to assign role",
+		})
+	}
+
+	return c.JSON(user)
+}
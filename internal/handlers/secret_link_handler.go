@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SecretLinkHandler handles opening single-use links to new API keys and client secrets
+type SecretLinkHandler struct {
+	service *services.SecretLinkService
+}
+
+// NewSecretLinkHandler creates a new SecretLinkHandler
+func NewSecretLinkHandler(service *services.SecretLinkService) *SecretLinkHandler {
+	return &SecretLinkHandler{service: service}
+}
+
+// RetrieveSecret godoc
+// @Summary Open a secret retrieval link
+// @Description Reveal the API key or client secret behind a single-use retrieval link, using the token from the link's fragment. The link stops working once opened or after 24 hours, and its creator is emailed when it is opened
+// @Tags Public
+// @Accept json
+// @Produce json
+// @Param input body services.RetrieveSecretInput true "Retrieval token"
+// @Success 200 {object} services.SecretRetrieval
+// @Failure 400 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Router /public/secrets/retrieve [post]
+func (h *SecretLinkHandler) RetrieveSecret(c *fiber.Ctx) error {
+	var input services.RetrieveSecretInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	secret, err := h.service.Retrieve(input, c.IP())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSecretLink) {
+			return c.Status(fiber.StatusGone).JSON(ErrorResponse{
+				Error:   "Gone",
+				Message: "This link is invalid, has expired or was already opened. Ask for a new secret",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to retrieve secret",
+		})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(secret)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SecurityWebhookHandler handles admin configuration of the security event webhook
+type SecurityWebhookHandler struct {
+	service *services.SecurityWebhookService
+}
+
+// NewSecurityWebhookHandler creates a new SecurityWebhookHandler
+func NewSecurityWebhookHandler(service *services.SecurityWebhookService) *SecurityWebhookHandler {
+	return &SecurityWebhookHandler{service: service}
+}
+
+// GetWebhook godoc
+// @Summary Get security webhook
+// @Description Get the endpoint that receives signed auth events (logins, failed logins, password changes)
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} services.SecurityWebhookResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/settings/security-webhook [get]
+func (h *SecurityWebhookHandler) GetWebhook(c *fiber.Ctx) error {
+	webhook, err := h.service.GetWebhook()
+	if err != nil {
+		return h.handleError(c, err, "Failed to retrieve security webhook")
+	}
+
+	return c.JSON(webhook)
+}
+
+// ConfigureWebhook godoc
+// @Summary Configure security webhook
+// @Description Set the endpoint for signed auth events. The signing secret is generated on first setup or with rotateSecret and is only returned in that response. Deliveries carry X-BAS-Signature: t=<unix>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.ConfigureSecurityWebhookInput true "Webhook endpoint"
+// @Success 200 {object} services.SecurityWebhookResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/settings/security-webhook [put]
+func (h *SecurityWebhookHandler) ConfigureWebhook(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	var input services.ConfigureSecurityWebhookInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	webhook, err := h.service.ConfigureWebhook(adminID, input)
+	if err != nil {
+		return h.handleError(c, err, "Failed to configure security webhook")
+	}
+
+	return c.JSON(webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Remove security webhook
+// @Description Stop delivering auth events
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/settings/security-webhook [delete]
+func (h *SecurityWebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	if err := h.service.DeleteWebhook(middleware.GetUserID(c)); err != nil {
+		return h.handleError(c, err, "Failed to remove security webhook")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Security webhook removed",
+	})
+}
+
+// TestWebhook godoc
+// @Summary Test security webhook
+// @Description Send a signed webhook.test event to the configured endpoint and report whether it was accepted
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Router /admin/settings/security-webhook/test [post]
+func (h *SecurityWebhookHandler) TestWebhook(c *fiber.Ctx) error {
+	if err := h.service.SendTest(); err != nil {
+		if errors.Is(err, services.ErrSecurityWebhookNotFound) {
+			return h.handleError(c, err, "")
+		}
+		return c.Status(fiber.StatusBadGateway).JSON(ErrorResponse{
+			Error:   "Bad Gateway",
+			Message: "Webhook endpoint did not accept the test event: " + err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Test event delivered",
+	})
+}
+
+// handleError maps security webhook service errors to HTTP responses
+func (h *SecurityWebhookHandler) handleError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrSecurityWebhookNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Security webhook is not configured",
+		})
+	case errors.Is(err, services.ErrInvalidWebhookURL):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Webhook URL must be an absolute https URL",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
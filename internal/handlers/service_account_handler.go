@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ServiceAccountHandler handles organizations' service accounts and their tokens
+type ServiceAccountHandler struct {
+	service *services.ServiceAccountService
+}
+
+// NewServiceAccountHandler creates a new ServiceAccountHandler
+func NewServiceAccountHandler(service *services.ServiceAccountService) *ServiceAccountHandler {
+	return &ServiceAccountHandler{service: service}
+}
+
+// ListServiceAccounts godoc
+// @Summary List service accounts
+// @Description List the organization's service accounts with their roles (any member)
+// @Tags Organizations
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {array} models.ServiceAccountResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /organizations/{id}/service-accounts [get]
+func (h *ServiceAccountHandler) ListServiceAccounts(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	accounts, err := h.service.ListServiceAccounts(userID, orgID)
+	if err != nil {
+		return h.serviceAccountError(c, err, "Failed to retrieve service accounts")
+	}
+
+	return c.JSON(accounts)
+}
+
+// CreateServiceAccount godoc
+// @Summary Create a service account
+// @Description Add a machine user to the organization with the editor or viewer role (owners only). It cannot sign in; issue it tokens instead
+// @Tags Organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param input body services.CreateServiceAccountInput true "Service account"
+// @Success 201 {object} models.ServiceAccountResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /organizations/{id}/service-accounts [post]
+func (h *ServiceAccountHandler) CreateServiceAccount(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization ID",
+		})
+	}
+
+	var input services.CreateServiceAccountInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	account, err := h.service.CreateServiceAccount(userID, orgID, input)
+	if err != nil {
+		return h.serviceAccountError(c, err, "Failed to create service account")
+	}
+
+	middleware.Audit(c, "service_account.create", "service_account", account.ID.String(), nil, account)
+	return c.Status(fiber.StatusCreated).JSON(account)
+}
+
+// DeleteServiceAccount godoc
+// @Summary Delete a service account
+// @Description Remove a service account from the organization and revoke its tokens (owners only). Keys and credentials it created stay with the organization
+// @Tags Organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param accountId path string true "Service account ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /organizations/{id}/service-accounts/{accountId} [delete]
+func (h *ServiceAccountHandler) DeleteServiceAccount(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, accountID, err := serviceAccountParams(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization or service account ID",
+		})
+	}
+
+	if err := h.service.DeleteServiceAccount(userID, orgID, accountID); err != nil {
+		return h.serviceAccountError(c, err, "Failed to delete service account")
+	}
+
+	middleware.Audit(c, "service_account.delete", "service_account", accountID.String(), nil, nil)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListTokens godoc
+// @Summary List service account tokens
+// @Description List a service account's tokens, including revoked and expired ones (owners only). Token values are never shown again
+// @Tags Organizations
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param accountId path string true "Service account ID"
+// @Success 200 {array} models.ServiceAccountToken
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /organizations/{id}/service-accounts/{accountId}/tokens [get]
+func (h *ServiceAccountHandler) ListTokens(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, accountID, err := serviceAccountParams(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization or service account ID",
+		})
+	}
+
+	tokens, err := h.service.ListTokens(userID, orgID, accountID)
+	if err != nil {
+		return h.serviceAccountError(c, err, "Failed to retrieve tokens")
+	}
+
+	return c.JSON(tokens)
+}
+
+// CreateToken godoc
+// @Summary Create a service account token
+// @Description Issue a long-lived token with scopes api_keys:manage and/or credentials:manage, valid for 1-365 days (default 90) (owners only). The token is only shown in this response
+// @Tags Organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param accountId path string true "Service account ID"
+// @Param input body services.CreateServiceAccountTokenInput true "Token"
+// @Success 201 {object} models.ServiceAccountTokenCreateResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /organizations/{id}/service-accounts/{accountId}/tokens [post]
+func (h *ServiceAccountHandler) CreateToken(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, accountID, err := serviceAccountParams(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization or service account ID",
+		})
+	}
+
+	var input services.CreateServiceAccountTokenInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	token, err := h.service.CreateToken(userID, orgID, accountID, input)
+	if err != nil {
+		return h.serviceAccountError(c, err, "Failed to create token")
+	}
+
+	middleware.Audit(c, "service_account.create_token", "service_account", accountID.String(), nil, token.ServiceAccountToken)
+	return c.Status(fiber.StatusCreated).JSON(token)
+}
+
+// RevokeToken godoc
+// @Summary Revoke a service account token
+// @Description Revoke a token so it stops working at once (owners only)
+// @Tags Organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param accountId path string true "Service account ID"
+// @Param tokenId path string true "Token ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /organizations/{id}/service-accounts/{accountId}/tokens/{tokenId} [delete]
+func (h *ServiceAccountHandler) RevokeToken(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	orgID, accountID, err := serviceAccountParams(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid organization or service account ID",
+		})
+	}
+	tokenID, err := uuid.Parse(c.Params("tokenId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid token ID",
+		})
+	}
+
+	if err := h.service.RevokeToken(userID, orgID, accountID, tokenID); err != nil {
+		return h.serviceAccountError(c, err, "Failed to revoke token")
+	}
+
+	middleware.Audit(c, "service_account.revoke_token", "service_account", accountID.String(), nil, nil)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// serviceAccountError maps service account errors to HTTP responses
+func (h *ServiceAccountHandler) serviceAccountError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidServiceAccount):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Name must be 1-100 characters and role editor or viewer",
+		})
+	case errors.Is(err, services.ErrInvalidServiceAccountToken):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Name must be 1-100 characters, scopes api_keys:manage or credentials:manage, and expiresInDays 1-365",
+		})
+	case errors.Is(err, services.ErrOrgPermissionDenied):
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error:   "Forbidden",
+			Message: "Your organization role does not allow this",
+		})
+	case errors.Is(err, services.ErrOrganizationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Organization not found",
+		})
+	case errors.Is(err, services.ErrServiceAccountNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Service account not found",
+		})
+	case errors.Is(err, services.ErrServiceAccountTokenNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "Token not found or already revoked",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
+
+// serviceAccountParams parses the organization and service account IDs from the route
+func serviceAccountParams(c *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	orgID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return orgID, accountID, nil
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// SuspensionHandler handles admin suspension of user accounts
+type SuspensionHandler struct {
+	service *services.SuspensionService
+}
+
+// NewSuspensionHandler creates a new SuspensionHandler
+func NewSuspensionHandler(service *services.SuspensionService) *SuspensionHandler {
+	return &SuspensionHandler{service: service}
+}
+
+// SuspendUser godoc
+// @Summary Suspend a user
+// @Description Block sign-in to an account and sign out all of its sessions. The reason is recorded and emailed to the user
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param input body services.SuspensionInput true "Reason"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/users/{id}/suspend [post]
+func (h *SuspensionHandler) SuspendUser(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid user ID",
+		})
+	}
+
+	var input services.SuspensionInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	user, err := h.service.Suspend(adminID, userID, input)
+	if err != nil {
+		return h.suspensionError(c, err, "Failed to suspend user")
+	}
+
+	return c.JSON(user)
+}
+
+// ReactivateUser godoc
+// @Summary Reactivate a user
+// @Description Lift a suspension so the user can sign in again. The reason is recorded
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param input body services.SuspensionInput true "Reason"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/users/{id}/reactivate [post]
+func (h *SuspensionHandler) ReactivateUser(c *fiber.Ctx) error {
+	adminID := middleware.GetUserID(c)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid user ID",
+		})
+	}
+
+	var input services.SuspensionInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid request body",
+		})
+	}
+
+	user, err := h.service.Reactivate(adminID, userID, input)
+	if err != nil {
+		return h.suspensionError(c, err, "Failed to reactivate user")
+	}
+
+	return c.JSON(user)
+}
+
+// ListSuspensions godoc
+// @Summary Suspension history
+// @Description List an account's suspensions and reactivations with their reasons, newest first
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {array} models.UserSuspension
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/users/{id}/suspensions [get]
+func (h *SuspensionHandler) ListSuspensions(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Invalid user ID",
+		})
+	}
+
+	history, err := h.service.History(userID)
+	if err != nil {
+		return h.suspensionError(c, err, "Failed to retrieve suspension history")
+	}
+
+	return c.JSON(history)
+}
+
+// suspensionError maps suspension service errors to HTTP responses
+func (h *SuspensionHandler) suspensionError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrSuspensionReasonLength):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Reason must be 3-500 characters",
+		})
+	case errors.Is(err, services.ErrCannotSuspendSelf):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "You cannot suspend your own account",
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error:   "Not Found",
+			Message: "User not found",
+		})
+	case errors.Is(err, services.ErrAlreadySuspended):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "Account is already suspended",
+		})
+	case errors.Is(err, services.ErrNotSuspended):
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error:   "Conflict",
+			Message: "Account is not suspended",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: fallback,
+	})
+}
@@ -1,19 +1,35 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
 	"github.com/bankaceh/bas-portal-api/internal/middleware"
+	"github.com/bankaceh/bas-portal-api/internal/models"
 	"github.com/bankaceh/bas-portal-api/internal/services"
 	"github.com/gofiber/fiber/v2"
 )
 
 // UserHandler handles user-related endpoints
 type UserHandler struct {
-	userService *services.UserService
+	userService      *services.UserService
+	dashboardService *services.DashboardService
+	checkupService   *services.SecurityCheckupService
+	accountService   *services.AccountService
+	pictureService   *services.ProfilePictureService
 }
 
 // NewUserHandler creates a new UserHandler
-func NewUserHandler(userService *services.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService *services.UserService, dashboardService *services.DashboardService, checkupService *services.SecurityCheckupService, accountService *services.AccountService, pictureService *services.ProfilePictureService) *UserHandler {
+	return &UserHandler{
+		userService:      userService,
+		dashboardService: dashboardService,
+		checkupService:   checkupService,
+		accountService:   accountService,
+		pictureService:   pictureService,
+	}
 }
 
 // GetProfile godoc
@@ -69,13 +85,267 @@ func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
 		})
 	}
 
+	before, err := h.userService.GetProfile(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to update profile",
+		})
+	}
+
 	profile, err := h.userService.UpdateProfile(userID, input)
 	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrProfileFieldTooLong):
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "First and last name must be at most 100 characters",
+			})
+		case errors.Is(err, services.ErrInvalidProfilePicture):
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Profile picture must be an http(s) URL, such as one returned by the upload endpoint",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Error:   "Internal Server Error",
 			Message: "Failed to update profile",
 		})
 	}
 
+	middleware.Audit(c, "user.update_profile", "user", userID.String(), before, profile)
 	return c.JSON(profile)
 }
+
+// UpdateMetadata godoc
+// @Summary Update user metadata
+// @Description Merge free-form attributes into the user's metadata object. Keys set to null are removed and other keys are replaced whole. At most 50 keys of up to 64 characters, and 16 KB in total
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body object true "Metadata patch"
+// @Success 200 {object} object
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Router /users/me/metadata [patch]
+func (h *UserHandler) UpdateMetadata(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var patch models.JSONMap
+	if err := json.Unmarshal(c.Body(), &patch); err != nil || patch == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Request body must be a JSON object",
+		})
+	}
+
+	before, err := h.userService.GetProfile(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to update metadata",
+		})
+	}
+
+	metadata, err := h.userService.UpdateMetadata(userID, patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMetadataKey):
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Metadata keys must be 1-64 characters",
+			})
+		case errors.Is(err, services.ErrMetadataTooLarge):
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(ErrorResponse{
+				Error:   "Payload Too Large",
+				Message: "Metadata is limited to 50 keys and 16 KB",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to update metadata",
+		})
+	}
+
+	middleware.Audit(c, "user.update_metadata", "user", userID.String(), before.Metadata, metadata)
+	return c.JSON(metadata)
+}
+
+// UploadProfilePicture godoc
+// @Summary Upload profile picture
+// @Description Upload a JPEG or PNG image (multipart field "file"). The picture is scaled to at most 512px with a 128px square thumbnail, and metadata is stripped. Send the returned URL as profilePicture to PUT /users/me to use it
+// @Tags Users
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Image"
+// @Success 201 {object} services.ProfilePictureResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Router /users/me/profile-picture [post]
+func (h *UserHandler) UploadProfilePicture(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "An image is required in the file field",
+		})
+	}
+	if file.Size > h.pictureService.MaxBytes() {
+		return h.profilePictureError(c, services.ErrProfilePictureTooLarge)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return h.profilePictureError(c, err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, h.pictureService.MaxBytes()+1))
+	if err != nil {
+		return h.profilePictureError(c, err)
+	}
+
+	picture, err := h.pictureService.Upload(userID, data)
+	if err != nil {
+		return h.profilePictureError(c, err)
+	}
+
+	middleware.Audit(c, "user.upload_profile_picture", "user", userID.String(), nil, picture)
+	return c.Status(fiber.StatusCreated).JSON(picture)
+}
+
+// profilePictureError maps profile picture upload errors to HTTP responses
+func (h *UserHandler) profilePictureError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrProfilePictureTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(ErrorResponse{
+			Error:   "Payload Too Large",
+			Message: fmt.Sprintf("Images must be at most %d MB and %dx%d pixels", h.pictureService.MaxBytes()/(1024*1024), services.ProfilePictureMaxPixels, services.ProfilePictureMaxPixels),
+		})
+	case errors.Is(err, services.ErrUnsupportedImageType):
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(ErrorResponse{
+			Error:   "Unsupported Media Type",
+			Message: "Profile pictures must be JPEG or PNG images",
+		})
+	case errors.Is(err, services.ErrInvalidImage):
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Bad Request",
+			Message: "The file could not be read as an image",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Error:   "Internal Server Error",
+		Message: "Failed to upload profile picture",
+	})
+}
+
+// GetDashboard godoc
+// @Summary Get current user dashboard
+// @Description Profile, credential and API key counts, quota usage, recent activity and alerts for the portal home page. Cached for 30 seconds
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} services.DashboardResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /users/me/dashboard [get]
+func (h *UserHandler) GetDashboard(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	dashboard, err := h.dashboardService.GetDashboard(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to load dashboard",
+		})
+	}
+
+	return c.JSON(dashboard)
+}
+
+// GetSecurityCheckup godoc
+// @Summary Get security checkup
+// @Description Scored report of account security: email verification, password age, stale API keys, credentials without an IP whitelist and unverified callback URLs, with remediation links
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} services.SecurityCheckupResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /users/me/security-checkup [get]
+func (h *UserHandler) GetSecurityCheckup(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	checkup, err := h.checkupService.GetCheckup(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to run security checkup",
+		})
+	}
+
+	return c.JSON(checkup)
+}
+
+// DeleteAccount godoc
+// @Summary Delete account
+// @Description Close the authenticated user's account. Requires the current password, or for Google sign-in accounts a sign-in within the last 10 minutes. API keys and partner credentials are revoked and all sessions signed out immediately; personal data is anonymized after the grace period
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body services.DeleteAccountInput true "Password confirmation"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /users/me [delete]
+func (h *UserHandler) DeleteAccount(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var input services.DeleteAccountInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Invalid request body",
+			})
+		}
+	}
+
+	if err := h.accountService.DeleteAccount(userID, middleware.GetSessionID(c), input); err != nil {
+		switch {
+		case errors.Is(err, services.ErrPasswordConfirmationFailed):
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Password is incorrect",
+			})
+		case errors.Is(err, services.ErrRecentLoginRequired):
+			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Please sign in again before deleting your account",
+			})
+		case errors.Is(err, services.ErrUserNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+				Error:   "Not Found",
+				Message: "User not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to delete account",
+		})
+	}
+
+	middleware.Audit(c, "user.delete", "user", userID.String(), nil, nil)
+	return c.JSON(fiber.Map{
+		"message": "Your account has been deleted",
+	})
+}
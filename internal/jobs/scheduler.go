@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"log"
+	"time"
+)
+
+// Job is a unit of background work run on a fixed interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+}
+
+// Scheduler runs registered jobs in the background
+type Scheduler struct {
+	jobs []Job
+	stop chan struct{}
+}
+
+// NewScheduler creates a new Scheduler
+func NewScheduler() *Scheduler {
+	return &Scheduler{stop: make(chan struct{})}
+}
+
+// Register adds a job that runs every interval once the scheduler is started
+func (s *Scheduler) Register(name string, interval time.Duration, run func() error) {
+	s.jobs = append(s.jobs, Job{Name: name, Interval: interval, Run: run})
+}
+
+// Start launches every registered job in its own goroutine
+func (s *Scheduler) Start() {
+	for _, job := range s.jobs {
+		go s.loop(job)
+	}
+	log.Printf("✅ Background scheduler started with %d job(s)", len(s.jobs))
+}
+
+// Stop signals all jobs to stop after their current run
+func (s *Scheduler) Stop() {
+	close(s.stop)
+}
+
+func (s *Scheduler) loop(job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.run(job)
+		}
+	}
+}
+
+// run executes a job once, logging errors and recovering from panics
+func (s *Scheduler) run(job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v", job.Name, r)
+		}
+	}()
+
+	if err := job.Run(); err != nil {
+		log.Printf("Job %s failed: %v", job.Name, err)
+	}
+}
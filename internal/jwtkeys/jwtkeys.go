@@ -0,0 +1,296 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits is the smallest RSA signing key we accept
+const minRSAKeyBits = 2048
+
+var (
+	ErrUnknownKey         = errors.New("token signed with an unknown key")
+	ErrUnsupportedKey     = errors.New("unsupported signing key, use RSA (2048+ bits) or ECDSA P-256")
+	ErrActiveKeyNotSigner = errors.New("the first signing key must include its private key")
+)
+
+// signingKey is one entry of the key set
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer // nil for verify-only keys kept around during rotation
+	public  crypto.PublicKey
+}
+
+// KeySet signs portal tokens with the active key and verifies them with any key in the set, selected by kid.
+// Without asymmetric keys it falls back to HS256 with the shared secret.
+type KeySet struct {
+	active   *signingKey
+	keys     map[string]*signingKey
+	secret   []byte
+	issuer   string
+	audience string
+}
+
+// Load builds a key set from "kid=path" entries. The first entry signs new tokens; the
+// rest only verify tokens issued before a rotation. When kid is omitted it is derived
+// from the public key. An empty list selects HS256 with the shared secret. Every token
+// is stamped with issuer and audience, and tokens carrying other values are rejected.
+func Load(entries []string, secret, issuer, audience string) (*KeySet, error) {
+	ks := &KeySet{
+		keys:     make(map[string]*signingKey),
+		secret:   []byte(secret),
+		issuer:   issuer,
+		audience: audience,
+	}
+	if len(entries) == 0 {
+		log.Println("⚠️  No JWT signing keys configured, falling back to HS256 with JWT_SECRET")
+		return ks, nil
+	}
+
+	for i, entry := range entries {
+		kid, path, found := strings.Cut(entry, "=")
+		if !found {
+			kid, path = "", entry
+		}
+
+		data, err := os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT signing key %q: %w", path, err)
+		}
+
+		key, err := parseKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT signing key %q: %w", path, err)
+		}
+
+		key.kid = strings.TrimSpace(kid)
+		if key.kid == "" {
+			key.kid, err = thumbprint(key.public)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if _, exists := ks.keys[key.kid]; exists {
+			return nil, fmt.Errorf("duplicate JWT signing key id %q", key.kid)
+		}
+
+		if i == 0 {
+			if key.private == nil {
+				return nil, ErrActiveKeyNotSigner
+			}
+			ks.active = key
+		}
+		ks.keys[key.kid] = key
+	}
+
+	log.Printf("✅ Signing JWTs with %s key %q (%d key(s) loaded)", ks.active.method.Alg(), ks.active.kid, len(ks.keys))
+	return ks, nil
+}
+
+// Sign issues a token for the claims with the active key, adding the set's iss and aud
+// to map claims that do not carry their own
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if mapClaims, ok := claims.(jwt.MapClaims); ok {
+		if _, exists := mapClaims["iss"]; !exists && ks.issuer != "" {
+			mapClaims["iss"] = ks.issuer
+		}
+		if _, exists := mapClaims["aud"]; !exists && ks.audience != "" {
+			mapClaims["aud"] = ks.audience
+		}
+	}
+
+	if ks.active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.secret)
+	}
+
+	token := jwt.NewWithClaims(ks.active.method, claims)
+	token.Header["kid"] = ks.active.kid
+	return token.SignedString(ks.active.private)
+}
+
+// Parse verifies a token's signature, issuer, audience and standard time claims and
+// returns its claims. Tokens minted by another environment fail the iss/aud check.
+func (ks *KeySet) Parse(tokenString string) (jwt.MapClaims, error) {
+	options := []jwt.ParserOption{jwt.WithValidMethods(ks.methods())}
+	if ks.issuer != "" {
+		options = append(options, jwt.WithIssuer(ks.issuer))
+	}
+	if ks.audience != "" {
+		options = append(options, jwt.WithAudience(ks.audience))
+	}
+
+	token, err := jwt.Parse(tokenString, ks.keyfunc, options...)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+// keyfunc returns the verification key named by the token's kid header
+func (ks *KeySet) keyfunc(token *jwt.Token) (interface{}, error) {
+	if ks.active == nil {
+		return ks.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.public, nil
+}
+
+// methods lists the algorithms the key set accepts, so tokens cannot pick their own
+func (ks *KeySet) methods() []string {
+	if ks.active == nil {
+		return []string{jwt.SigningMethodHS256.Alg()}
+	}
+
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range ks.keys {
+		if alg := key.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public half of every key in the set, active key first
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	if ks.active == nil {
+		return set
+	}
+
+	set.Keys = append(set.Keys, toJWK(ks.active))
+	for kid, key := range ks.keys {
+		if kid != ks.active.kid {
+			set.Keys = append(set.Keys, toJWK(key))
+		}
+	}
+	return set
+}
+
+// toJWK encodes a key's public half
+func toJWK(key *signingKey) JWK {
+	jwk := JWK{Use: "sig", Alg: key.method.Alg(), Kid: key.kid}
+
+	switch pub := key.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32)))
+	}
+	return jwk
+}
+
+// parseKey reads a PEM private key (PKCS#1, SEC 1 or PKCS#8) or a PKIX public key for verify-only use
+func parseKey(data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.private = signer
+		parsed = signer.Public()
+	}
+
+	switch pub := parsed.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, ErrUnsupportedKey
+		}
+		key.method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return nil, ErrUnsupportedKey
+		}
+		key.method = jwt.SigningMethodES256
+	default:
+		return nil, ErrUnsupportedKey
+	}
+	key.public = parsed
+	return key, nil
+}
+
+// thumbprint derives a stable key id from the public key
+func thumbprint(public crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:8]), nil
+}
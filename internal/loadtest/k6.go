@@ -0,0 +1,105 @@
+package loadtest
+
+import (
+	"bytes"
+	"text/template"
+)
+
+// ScenarioOptions configures a generated k6 scenario
+type ScenarioOptions struct {
+	BaseURL  string // e.g. http://localhost:3000/api/v1
+	VUs      int    // Concurrent virtual users
+	Duration string // k6 duration, e.g. "5m"
+	Users    int    // Number of seeded accounts setup() logs in as; VUs share their tokens
+}
+
+// DefaultLoginPool is the number of accounts a scenario logs in as by default. Every login
+// in setup() comes from the k6 host, so it stays within the default LOGIN_RATE_LIMIT of 5
+// attempts per IP; asking for more users needs a higher LOGIN_RATE_LIMIT on the server.
+const DefaultLoginPool = 5
+
+var k6Template = template.Must(template.New("k6").Parse(`// Generated by the BAS Portal API. Run with: k6 run script.js
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+
+export const options = {
+  vus: {{.VUs}},
+  duration: '{{.Duration}}',
+  thresholds: {
+    'http_req_failed': ['rate<0.01'],
+    'http_req_duration{endpoint:login}': ['p(95)<800'],
+    'http_req_duration{endpoint:list_credentials}': ['p(95)<300'],
+    'http_req_duration{endpoint:get_credential}': ['p(95)<200'],
+    'http_req_duration{endpoint:list_api_keys}': ['p(95)<300'],
+  },
+};
+
+const BASE_URL = __ENV.BASE_URL || '{{.BaseURL}}';
+const SEEDED_USERS = {{.Users}};
+const jsonHeaders = { 'Content-Type': 'application/json' };
+
+export function setup() {
+  const tokens = [];
+  for (let i = 0; i < SEEDED_USERS; i++) {
+    const res = http.post(BASE_URL + '/auth/login', JSON.stringify({
+      email: '{{.EmailPrefix}}' + i + '{{.EmailSuffix}}',
+      password: '{{.Password}}',
+    }), { headers: jsonHeaders, tags: { endpoint: 'login' } });
+    if (check(res, { 'login succeeded': (r) => r.status === 200 })) {
+      tokens.push(res.json('accessToken'));
+    }
+  }
+  if (tokens.length === 0) {
+    throw new Error('no seeded account could log in; check cmd/seed and LOGIN_RATE_LIMIT');
+  }
+  return { tokens };
+}
+
+export default function (data) {
+  const token = data.tokens[(__VU - 1) % data.tokens.length];
+  const params = (endpoint) => ({
+    headers: { Authorization: 'Bearer ' + token },
+    tags: { endpoint },
+  });
+
+  const list = http.get(BASE_URL + '/partner-credentials', params('list_credentials'));
+  check(list, { 'list credentials 200': (r) => r.status === 200 });
+
+  const credentials = list.json();
+  if (Array.isArray(credentials) && credentials.length > 0) {
+    const id = credentials[Math.floor(Math.random() * credentials.length)].id;
+    const get = http.get(BASE_URL + '/partner-credentials/' + id, params('get_credential'));
+    check(get, { 'get credential 200': (r) => r.status === 200 });
+  }
+
+  const keys = http.get(BASE_URL + '/api-keys', params('list_api_keys'));
+  check(keys, { 'list api keys 200': (r) => r.status === 200 });
+
+  sleep(1);
+}
+`))
+
+// K6Script renders a k6 scenario that logs in as seeded accounts and exercises the list and lookup endpoints
+func K6Script(opts ScenarioOptions) (string, error) {
+	if opts.VUs <= 0 {
+		opts.VUs = 50
+	}
+	if opts.Duration == "" {
+		opts.Duration = "5m"
+	}
+	if opts.Users <= 0 {
+		opts.Users = min(opts.VUs, DefaultLoginPool)
+	}
+
+	var buf bytes.Buffer
+	err := k6Template.Execute(&buf, struct {
+		ScenarioOptions
+		EmailPrefix string
+		EmailSuffix string
+		Password    string
+	}{opts, "loadtest+", "@example.invalid", Password})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
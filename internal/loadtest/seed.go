@@ -0,0 +1,232 @@
+package loadtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Synthetic accounts use this email pattern so they can be found and purged
+const (
+	EmailPattern = "loadtest+%d@example.invalid"
+	emailLike    = "loadtest+%@example.invalid"
+
+	// Password shared by every synthetic account, used by generated k6 scenarios
+	Password = "loadtest-password"
+
+	// CredentialsPerUser matches the per-user partner credential limit
+	CredentialsPerUser = 5
+
+	// maxUsageHoursPerKey is the 90 days of hourly usage kept per API key
+	maxUsageHoursPerKey = 90 * 24
+)
+
+// SeedOptions controls the size and shape of the synthetic dataset
+type SeedOptions struct {
+	Credentials int   // Total partner credentials to create
+	UsageEvents int   // API key usage rows (one key, one hour) to spread over one key per user
+	BatchSize   int   // Rows per INSERT statement
+	Seed        int64 // Random seed, the same seed produces the same dataset
+}
+
+// SeedResult summarises what was inserted
+type SeedResult struct {
+	Users       int
+	Credentials int
+	APIKeys     int
+	UsageEvents int
+	Duration    time.Duration
+}
+
+// Seed inserts synthetic developers, partner credentials, and an API key per developer
+// with hourly usage history for load testing. Client secrets are hashed and encrypted
+// with the keys in cfg, like real ones, so credentials validate with SyntheticSecret.
+// API keys are for listing and usage series only; their secrets are not recoverable.
+func Seed(db *gorm.DB, cfg *config.Config, opts SeedOptions) (*SeedResult, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	start := time.Now()
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	// One bcrypt hash for every account keeps seeding fast
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(Password), bcrypt.MinCost)
+	if err != nil {
+		return nil, err
+	}
+
+	userCount := (opts.Credentials + CredentialsPerUser - 1) / CredentialsPerUser
+	usagePerKey := 0
+	if userCount > 0 {
+		usagePerKey = min((opts.UsageEvents+userCount-1)/userCount, maxUsageHoursPerKey)
+	}
+	lastHour := time.Now().Truncate(time.Hour)
+	result := &SeedResult{}
+
+	for offset := 0; offset < userCount; offset += opts.BatchSize {
+		end := min(offset+opts.BatchSize, userCount)
+
+		users := make([]models.User, 0, end-offset)
+		for i := offset; i < end; i++ {
+			users = append(users, models.User{
+				ID:           models.NewLegacyID(),
+				Email:        fmt.Sprintf(EmailPattern, i),
+				PasswordHash: string(passwordHash),
+				FullName:     fmt.Sprintf("Load Test %d", i),
+				Company:      fmt.Sprintf("Synthetic Partner %d", i%500),
+				Provider:     "local",
+				IsVerified:   true,
+			})
+		}
+		if err := db.CreateInBatches(users, opts.BatchSize).Error; err != nil {
+			return nil, fmt.Errorf("failed to seed users: %w", err)
+		}
+		result.Users += len(users)
+
+		credentials := make([]models.PartnerCredential, 0, len(users)*CredentialsPerUser)
+		for i, user := range users {
+			for n := 0; n < CredentialsPerUser && result.Credentials+len(credentials) < opts.Credentials; n++ {
+				credential, err := syntheticCredential(rng, cfg, opts.Seed, user.ID, offset+i, n)
+				if err != nil {
+					return nil, fmt.Errorf("failed to protect synthetic client secret: %w", err)
+				}
+				credentials = append(credentials, credential)
+			}
+		}
+		if err := db.CreateInBatches(credentials, opts.BatchSize).Error; err != nil {
+			return nil, fmt.Errorf("failed to seed partner credentials: %w", err)
+		}
+		result.Credentials += len(credentials)
+
+		keys := make([]models.APIKey, 0, len(users))
+		for i, user := range users {
+			keys = append(keys, syntheticAPIKey(rng, user.ID, offset+i))
+		}
+		var usage []models.APIKeyUsage
+		for i := range keys {
+			n := min(usagePerKey, opts.UsageEvents-result.UsageEvents-len(usage))
+			for hour := 1; hour <= n; hour++ {
+				requests := int64(rng.Intn(500) + 1)
+				usage = append(usage, models.APIKeyUsage{
+					KeyID:       keys[i].ID,
+					BucketStart: lastHour.Add(-time.Duration(hour) * time.Hour),
+					Requests:    requests,
+					Errors:      rng.Int63n(requests/20 + 1),
+				})
+				keys[i].RequestCount += requests
+			}
+		}
+		if err := db.CreateInBatches(keys, opts.BatchSize).Error; err != nil {
+			return nil, fmt.Errorf("failed to seed API keys: %w", err)
+		}
+		result.APIKeys += len(keys)
+		if len(usage) > 0 {
+			if err := db.CreateInBatches(usage, opts.BatchSize).Error; err != nil {
+				return nil, fmt.Errorf("failed to seed API key usage: %w", err)
+			}
+			result.UsageEvents += len(usage)
+		}
+
+		log.Printf("Seeded %d/%d users, %d/%d credentials, %d/%d usage events",
+			result.Users, userCount, result.Credentials, opts.Credentials, result.UsageEvents, opts.UsageEvents)
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// SyntheticSecret returns the client secret of the credential seeded with clientID by
+// the given seed
+func SyntheticSecret(seed int64, clientID string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("loadtest:%d:%s", seed, clientID)))
+	return hex.EncodeToString(sum[:])
+}
+
+// syntheticCredential builds a partner credential with a spread of realistic states
+func syntheticCredential(rng *rand.Rand, cfg *config.Config, seed int64, userID uuid.UUID, userIndex, n int) (models.PartnerCredential, error) {
+	environment := "sandbox"
+	if rng.Intn(4) == 0 {
+		environment = "production"
+	}
+
+	var lastUsedAt *time.Time
+	if rng.Intn(3) > 0 {
+		t := time.Now().Add(-time.Duration(rng.Intn(120*24)) * time.Hour)
+		lastUsedAt = &t
+	}
+
+	credential := models.PartnerCredential{
+		ID:          models.NewLegacyID(),
+		UserID:      userID,
+		ClientID:    fmt.Sprintf("LTS%08d%d%s", userIndex, n, randomHex(rng, 20)),
+		PartnerName: fmt.Sprintf("Load Test Partner %d-%d", userIndex, n),
+		ChannelID:   "CH" + randomHex(rng, 16),
+		Environment: environment,
+		IPWhitelist: models.StringArray{fmt.Sprintf("10.%d.%d.0/24", rng.Intn(256), rng.Intn(256))},
+		IsActive:    rng.Intn(10) > 0,
+		LastUsedAt:  lastUsedAt,
+	}
+	secret := SyntheticSecret(seed, credential.ClientID)
+	if err := services.SetClientSecret(cfg, &credential, secret, secret[:8]+"..."); err != nil {
+		return models.PartnerCredential{}, err
+	}
+	return credential, nil
+}
+
+// syntheticAPIKey builds a sandbox API key whose secret is random and never revealed
+func syntheticAPIKey(rng *rand.Rand, userID uuid.UUID, userIndex int) models.APIKey {
+	return models.APIKey{
+		ID:          models.NewLegacyID(),
+		UserID:      userID,
+		Name:        fmt.Sprintf("Load Test Key %d", userIndex),
+		KeyPrefix:   "bas_test_" + randomHex(rng, 8),
+		KeyHash:     randomHex(rng, 64),
+		KeyDigest:   randomHex(rng, 64),
+		Environment: "sandbox",
+		IsActive:    true,
+	}
+}
+
+// randomHex returns n hex characters drawn from rng
+func randomHex(rng *rand.Rand, n int) string {
+	const digits = "0123456789abcdef"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = digits[rng.Intn(len(digits))]
+	}
+	return string(b)
+}
+
+// Purge permanently deletes every synthetic account with its partner credentials, API
+// keys and key usage
+func Purge(db *gorm.DB) (int64, error) {
+	userIDs := db.Unscoped().Model(&models.User{}).Select("id").Where("email LIKE ?", emailLike)
+	keyIDs := db.Unscoped().Model(&models.APIKey{}).Select("id").Where("user_id IN (?)", userIDs)
+
+	if err := db.Where("key_id IN (?)", keyIDs).Delete(&models.APIKeyUsage{}).Error; err != nil {
+		return 0, err
+	}
+	if err := db.Unscoped().Where("user_id IN (?)", userIDs).Delete(&models.APIKey{}).Error; err != nil {
+		return 0, err
+	}
+
+	if err := db.Unscoped().Where("user_id IN (?)", userIDs).Delete(&models.PartnerCredential{}).Error; err != nil {
+		return 0, err
+	}
+	if err := db.Unscoped().Where("user_id IN (?)", userIDs).Delete(&models.Session{}).Error; err != nil {
+		return 0, err
+	}
+
+	result := db.Unscoped().Where("email LIKE ?", emailLike).Delete(&models.User{})
+	return result.RowsAffected, result.Error
+}
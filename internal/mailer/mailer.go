@@ -0,0 +1,78 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"net/mail"
+	"net/smtp"
+	"strings"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+)
+
+// Mailer sends transactional emails
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// New returns an SMTP mailer when SMTP is configured, otherwise a mailer that only logs
+func New(cfg *config.Config) Mailer {
+	if cfg.SMTPHost == "" {
+		log.Println("SMTP_HOST not set, emails will be logged instead of sent")
+		return &LogMailer{}
+	}
+
+	return &SMTPMailer{
+		host:     cfg.SMTPHost,
+		port:     cfg.SMTPPort,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+		from:     cfg.SMTPFrom,
+	}
+}
+
+// SMTPMailer delivers emails through an SMTP server
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// Send delivers a plain-text email
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	sender, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	headers := []string{
+		"From: " + m.from,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	msg := strings.Join(headers, "\r\n") + "\r\n\r\n" + body
+
+	addr := m.host + ":" + m.port
+	if err := smtp.SendMail(addr, auth, sender.Address, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// LogMailer writes emails to the application log (development only)
+type LogMailer struct{}
+
+// Send logs the email instead of delivering it
+func (m *LogMailer) Send(to, subject, body string) error {
+	log.Printf("📧 Email to %s: %s\n%s", to, subject, body)
+	return nil
+}
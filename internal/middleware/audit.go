@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"log"
+	"strings"
+
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// auditLocal is where handlers leave the details of the mutation they made
+const auditLocal = "audit"
+
+// Audit describes the mutation a handler made so AuditTrail can record it with the
+// values before and after. Values are stored as JSON and must never contain secrets.
+func Audit(c *fiber.Ctx, action, resourceType, resourceID string, oldValues, newValues interface{}) {
+	c.Locals(auditLocal, &services.AuditEntry{
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		OldValues:    oldValues,
+		NewValues:    newValues,
+	})
+}
+
+// AuditTrail middleware records every successful mutation (POST, PUT, PATCH, DELETE)
+// in the audit log with the actor, client IP and request ID. Handlers that call Audit
+// name the action and provide values; others are recorded by route. It must run after
+// JWTAuth, and a failed write is logged without failing the request.
+func AuditTrail(audit *services.AuditService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		switch c.Method() {
+		case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		default:
+			return err
+		}
+		if err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest {
+			return err
+		}
+
+		entry, ok := c.Locals(auditLocal).(*services.AuditEntry)
+		if !ok {
+			entry = &services.AuditEntry{
+				Action:     strings.ToLower(c.Method()) + " " + c.Route().Path,
+				ResourceID: c.Params("id"),
+			}
+		}
+		entry.ActorID = GetUserID(c)
+		entry.IP = c.IP()
+		entry.RequestID = c.GetRespHeader(fiber.HeaderXRequestID)
+
+		if recordErr := audit.Record(*entry); recordErr != nil {
+			log.Printf("Failed to record audit log for %s: %v", entry.Action, recordErr)
+		}
+		return err
+	}
+}
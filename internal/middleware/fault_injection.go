@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/chaos"
+	"github.com/gofiber/fiber/v2"
+)
+
+// FaultInjection middleware fails requests whose path matches a fault injected for a resilience drill
+func FaultInjection(injector *chaos.Injector) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		statusCode, ok := injector.FaultFor(c.Path())
+		if !ok {
+			return c.Next()
+		}
+
+		c.Set("X-Chaos-Fault", "injected")
+		return c.Status(statusCode).JSON(fiber.Map{
+			"error":   "Injected Fault",
+			"message": "This failure was injected by a resilience drill",
+		})
+	}
+}
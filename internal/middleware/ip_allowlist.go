@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// IPAllowlist middleware only lets requests through from the given CIDR ranges.
+// Plain IP addresses are accepted as single-host ranges. It fails closed: an invalid
+// entry is an error, and an empty list refuses every request. Refused requests are
+// recorded in the audit log unless audit is nil.
+func IPAllowlist(cidrs []string, audit *services.AuditService) (fiber.Handler, error) {
+	networks, err := parseCIDRs(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	if len(networks) == 0 {
+		log.Println("⚠️  IP allowlist is empty, every request to the routes it guards will be refused")
+	}
+
+	return func(c *fiber.Ctx) error {
+		ip := net.ParseIP(c.IP())
+		if ip != nil {
+			for _, network := range networks {
+				if network.Contains(ip) {
+					return c.Next()
+				}
+			}
+		}
+
+		if audit != nil {
+			err := audit.Record(services.AuditEntry{
+				Action:       services.AuditActionIPBlocked,
+				ResourceType: "request",
+				NewValues: map[string]string{
+					"method":    c.Method(),
+					"path":      c.OriginalURL(),
+					"userAgent": c.Get(fiber.HeaderUserAgent),
+				},
+				IP:        c.IP(),
+				RequestID: c.GetRespHeader(fiber.HeaderXRequestID),
+			})
+			if err != nil {
+				log.Printf("Failed to record audit log for %s: %v", services.AuditActionIPBlocked, err)
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   "Forbidden",
+			"message": "Access from this network is not allowed",
+		})
+	}, nil
+}
+
+// parseCIDRs converts CIDR strings to networks, failing on the first invalid entry
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q in IP allowlist: %w", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func allowlistStatus(t *testing.T, cidrs []string) int {
+	t.Helper()
+
+	allowlist, err := IPAllowlist(cidrs, nil)
+	if err != nil {
+		t.Fatalf("IPAllowlist(%q): %v", cidrs, err)
+	}
+
+	app := fiber.New()
+	app.Get("/admin", allowlist, func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	// app.Test requests come from 0.0.0.0
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/admin", nil))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	return resp.StatusCode
+}
+
+func TestIPAllowlistAllowsListedRange(t *testing.T) {
+	if status := allowlistStatus(t, []string{"10.0.0.0/8", "0.0.0.0"}); status != fiber.StatusOK {
+		t.Errorf("status = %d, want %d", status, fiber.StatusOK)
+	}
+}
+
+func TestIPAllowlistRefusesOtherAddresses(t *testing.T) {
+	if status := allowlistStatus(t, []string{"10.0.0.0/8"}); status != fiber.StatusForbidden {
+		t.Errorf("status = %d, want %d", status, fiber.StatusForbidden)
+	}
+}
+
+func TestIPAllowlistEmptyRefusesEverything(t *testing.T) {
+	for _, cidrs := range [][]string{nil, {}, {"", "  "}} {
+		if status := allowlistStatus(t, cidrs); status != fiber.StatusForbidden {
+			t.Errorf("IPAllowlist(%q): status = %d, want %d", cidrs, status, fiber.StatusForbidden)
+		}
+	}
+}
+
+func TestIPAllowlistRejectsInvalidEntries(t *testing.T) {
+	tests := [][]string{
+		{"10.0.0.0/33"},
+		{"not-an-ip", "10.0.0/8"},
+		{"10.0.0.0/8", "192.168.1.300"},
+	}
+
+	for _, cidrs := range tests {
+		if _, err := IPAllowlist(cidrs, nil); err == nil {
+			t.Errorf("IPAllowlist(%q) succeeded, want an error", cidrs)
+		}
+	}
+}
@@ -3,17 +3,24 @@ package middleware
 import (
 	"strings"
 
+	"github.com/bankaceh/bas-portal-api/internal/jwtkeys"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/services"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-// JWTAuth middleware validates JWT tokens
-func JWTAuth(secret string) fiber.Handler {
+// JWTAuth middleware validates JWT tokens, the session they belong to and that the
+// account is not suspended. Service account tokens, and personal API keys in X-API-Key when
+// there is no Authorization header, are accepted too; see RestrictServiceAccounts.
+func JWTAuth(keys *jwtkeys.KeySet, sessionService *services.SessionService, suspensions *services.SuspensionService, serviceAccounts *services.ServiceAccountService, apiKeys *services.APIKeyService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get Authorization header
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
+			if key := c.Get(APIKeyHeader); key != "" {
+				return apiKeyAuth(c, key, apiKeys, suspensions)
+			}
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error":   "Unauthorized",
 				"message": "Missing authorization header",
@@ -30,32 +37,19 @@ func JWTAuth(secret string) fiber.Handler {
 		}
 
 		tokenString := parts[1]
+		if strings.HasPrefix(tokenString, models.ServiceAccountTokenPrefix) {
+			return serviceAccountAuth(c, tokenString, serviceAccounts, suspensions)
+		}
 
 		// Parse and validate token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid signing method")
-			}
-			return []byte(secret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := keys.Parse(tokenString)
+		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error":   "Unauthorized",
 				"message": "Invalid or expired token",
 			})
 		}
 
-		// Extract claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error":   "Unauthorized",
-				"message": "Invalid token claims",
-			})
-		}
-
 		// Check token type
 		tokenType, ok := claims["type"].(string)
 		if !ok || tokenType != "access" {
@@ -82,14 +76,90 @@ func JWTAuth(secret string) fiber.Handler {
 			})
 		}
 
+		// Check the session is still active and record activity
+		sessionIDStr, _ := claims["sid"].(string)
+		sessionID, err := uuid.Parse(sessionIDStr)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "Unauthorized",
+				"message": "Invalid session in token",
+			})
+		}
+
+		session, err := sessionService.Validate(sessionID)
+		if err != nil || session.UserID != userID {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "Unauthorized",
+				"message": "Session expired, please log in again",
+			})
+		}
+
+		// Suspending revokes sessions too; this also stops requests racing the suspension
+		suspended, err := suspensions.IsSuspended(userID)
+		if err != nil || suspended {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "Unauthorized",
+				"message": "Account suspended",
+			})
+		}
+		_ = sessionService.Touch(sessionID)
+
 		// Store user ID in context
 		c.Locals("userID", userID)
+		c.Locals("sessionID", sessionID)
 		c.Locals("email", claims["email"])
+		c.Locals("passwordExpired", claims["pwd_exp"] == true)
+
+		// Role and permissions as of when the token was issued
+		tokenRole, _ := claims["role"].(string)
+		c.Locals("tokenRole", tokenRole)
+		var permissions []string
+		if perms, ok := claims["perms"].([]interface{}); ok {
+			for _, perm := range perms {
+				if p, ok := perm.(string); ok {
+					permissions = append(permissions, p)
+				}
+			}
+		}
+		c.Locals("permissions", permissions)
 
 		return c.Next()
 	}
 }
 
+// serviceAccountAuth authenticates a service account token. The request acts as the
+// service account, with the token's scopes as its permissions and no session.
+func serviceAccountAuth(c *fiber.Ctx, tokenString string, serviceAccounts *services.ServiceAccountService, suspensions *services.SuspensionService) error {
+	token, err := serviceAccounts.Authenticate(tokenString)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Invalid or expired token",
+		})
+	}
+
+	suspended, err := suspensions.IsSuspended(token.ServiceAccountID)
+	if err != nil || suspended {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Account suspended",
+		})
+	}
+
+	c.Locals("userID", token.ServiceAccountID)
+	c.Locals("serviceAccount", true)
+	c.Locals("tokenRole", models.RoleDeveloper)
+	c.Locals("permissions", []string(token.Scopes))
+
+	return c.Next()
+}
+
+// IsServiceAccount reports whether the request was made with a service account token
+func IsServiceAccount(c *fiber.Ctx) bool {
+	serviceAccount, _ := c.Locals("serviceAccount").(bool)
+	return serviceAccount
+}
+
 // GetUserID retrieves the user ID from context
 func GetUserID(c *fiber.Ctx) uuid.UUID {
 	userID, ok := c.Locals("userID").(uuid.UUID)
@@ -98,3 +168,38 @@ func GetUserID(c *fiber.Ctx) uuid.UUID {
 	}
 	return userID
 }
+
+// GetRole retrieves the user's role. It is the current role once RequireRole has run,
+// otherwise the role in the access token.
+func GetRole(c *fiber.Ctx) string {
+	if role, ok := c.Locals("role").(string); ok {
+		return role
+	}
+	role, _ := c.Locals("tokenRole").(string)
+	return role
+}
+
+// GetPermissions retrieves the permissions in the access token from context
+func GetPermissions(c *fiber.Ctx) []string {
+	permissions, _ := c.Locals("permissions").([]string)
+	return permissions
+}
+
+// HasPermission reports whether the access token grants permission
+func HasPermission(c *fiber.Ctx, permission string) bool {
+	for _, p := range GetPermissions(c) {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSessionID retrieves the session ID of the current token from context
+func GetSessionID(c *fiber.Ctx) uuid.UUID {
+	sessionID, ok := c.Locals("sessionID").(uuid.UUID)
+	if !ok {
+		return uuid.Nil
+	}
+	return sessionID
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// RateLimit middleware allows at most max requests per client IP within the window
+func RateLimit(max int, window time.Duration) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: window,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":   "Too Many Requests",
+				"message": "Rate limit exceeded, please try again later",
+			})
+		},
+	})
+}
+
+// CredentialRateLimit limits attempts on login and registration with two counters, one
+// per client IP and one per email address, and refuses a request once either is over
+// max. A client rotating email addresses is cut off by the IP count, and one account
+// attacked from many addresses by the email count. Windows are aligned to multiples of
+// window. A nil counter keeps counts in memory.
+func CredentialRateLimit(max int, window time.Duration, counter services.RateCounter) fiber.Handler {
+	if counter == nil {
+		counter = services.NewMemoryRateCounter()
+	}
+
+	return func(c *fiber.Ctx) error {
+		var body struct {
+			Email string `json:"email" form:"email"`
+		}
+		_ = c.BodyParser(&body)
+
+		start := time.Now().Truncate(window)
+		suffix := ":" + strconv.FormatInt(start.Unix(), 10)
+		keys := []string{"auth:" + c.Path() + ":ip:" + c.IP() + suffix}
+		if email := strings.ToLower(strings.TrimSpace(body.Email)); email != "" {
+			keys = append(keys, "auth:"+c.Path()+":email:"+email+suffix)
+		}
+
+		for _, key := range keys {
+			count, err := counter.Incr(key, 2*window)
+			if err != nil {
+				return err
+			}
+			if count > int64(max) {
+				retryAfter := int(time.Until(start.Add(window)).Seconds()) + 1
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+					"error":   "Too Many Requests",
+					"message": "Too many attempts, please try again later",
+				})
+			}
+		}
+		return c.Next()
+	}
+}
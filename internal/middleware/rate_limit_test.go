@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func credentialLimitApp(max int) *fiber.App {
+	app := fiber.New()
+	app.Post("/login", CredentialRateLimit(max, time.Minute, nil), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func postLogin(t *testing.T, app *fiber.App, email string) int {
+	t.Helper()
+
+	req := httptest.NewRequest(fiber.MethodPost, "/login", strings.NewReader(`{"email":"`+email+`"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	return resp.StatusCode
+}
+
+func TestCredentialRateLimitRotatingEmailsFromOneIP(t *testing.T) {
+	app := credentialLimitApp(3)
+
+	for i := 0; i < 3; i++ {
+		email := "user" + strconv.Itoa(i) + "@example.com"
+		if status := postLogin(t, app, email); status != fiber.StatusOK {
+			t.Fatalf("attempt %d: status = %d, want %d", i+1, status, fiber.StatusOK)
+		}
+	}
+
+	if status := postLogin(t, app, "another@example.com"); status != fiber.StatusTooManyRequests {
+		t.Errorf("fresh email from the same IP: status = %d, want %d", status, fiber.StatusTooManyRequests)
+	}
+}
+
+func TestCredentialRateLimitSameEmail(t *testing.T) {
+	app := credentialLimitApp(2)
+
+	for i := 0; i < 2; i++ {
+		if status := postLogin(t, app, "user@example.com"); status != fiber.StatusOK {
+			t.Fatalf("attempt %d: status = %d, want %d", i+1, status, fiber.StatusOK)
+		}
+	}
+
+	if status := postLogin(t, app, " USER@example.com"); status != fiber.StatusTooManyRequests {
+		t.Errorf("same email: status = %d, want %d", status, fiber.StatusTooManyRequests)
+	}
+}
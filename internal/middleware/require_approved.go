@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequireApproved middleware rejects users whose account staff have not approved.
+// It must run after JWTAuth.
+func RequireApproved(approvalService *services.ApprovalService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("userID").(uuid.UUID)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "Unauthorized",
+				"message": "Invalid user session",
+			})
+		}
+
+		err := approvalService.CheckApproved(userID)
+		switch {
+		case err == nil:
+			return c.Next()
+		case errors.Is(err, services.ErrAccountNotApproved):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   "Forbidden",
+				"message": "Your account is waiting for approval by the BAS team",
+			})
+		case errors.Is(err, services.ErrAccountRejected):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   "Forbidden",
+				"message": "Your account was not approved, please contact support",
+			})
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Invalid user session",
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireCurrentPassword middleware blocks tokens issued for an expired password (see
+// PASSWORD_MAX_AGE_DAYS) except on the given routes, written as "METHOD /path", which
+// must include the change-password endpoint. It must run after JWTAuth.
+func RequireCurrentPassword(allowed ...string) fiber.Handler {
+	allow := make(map[string]bool, len(allowed))
+	for _, route := range allowed {
+		allow[route] = true
+	}
+
+	return func(c *fiber.Ctx) error {
+		expired, _ := c.Locals("passwordExpired").(bool)
+		if !expired || allow[c.Method()+" "+c.Path()] {
+			return c.Next()
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   "Forbidden",
+			"message": "Your password has expired, please change it to continue",
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequireRole middleware only allows users whose effective role is one of roles. The
+// role is looked up once per request and kept in the context for nested groups. It must
+// run after JWTAuth.
+func RequireRole(roleService *services.RoleService, roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, ok := c.Locals("role").(string)
+		if !ok {
+			userID, _ := c.Locals("userID").(uuid.UUID)
+			effective, err := roleService.EffectiveRole(userID)
+			if err != nil {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error":   "Unauthorized",
+					"message": "Invalid user session",
+				})
+			}
+			role = effective
+			c.Locals("role", role)
+		}
+
+		for _, allowed := range roles {
+			if role == allowed {
+				return c.Next()
+			}
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   "Forbidden",
+			"message": "You do not have permission to access this resource",
+		})
+	}
+}
+
+// RequirePermission middleware only allows access tokens that carry permission. Unlike
+// RequireRole it does not load the user, so a role change only applies once the token is
+// refreshed. It must run after JWTAuth.
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !HasPermission(c, permission) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   "Forbidden",
+				"message": "You do not have permission to access this resource",
+			})
+		}
+		return c.Next()
+	}
+}
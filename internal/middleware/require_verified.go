@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequireVerifiedEmail middleware rejects users who have not verified their email address.
+// It must run after JWTAuth.
+func RequireVerifiedEmail(userService *services.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("userID").(uuid.UUID)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "Unauthorized",
+				"message": "Invalid user session",
+			})
+		}
+
+		verified, err := userService.IsVerified(userID)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "Unauthorized",
+				"message": "Invalid user session",
+			})
+		}
+		if !verified {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   "Forbidden",
+				"message": "Please verify your email address first",
+			})
+		}
+		return c.Next()
+	}
+}
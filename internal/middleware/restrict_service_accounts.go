@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RestrictServiceAccounts middleware limits service account tokens and API keys to the
+// given path prefixes, each mapped to the scope it needs. Any other route answers 403 for
+// them. Interactive users pass through. It must run after JWTAuth.
+func RestrictServiceAccounts(scopes map[string]string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !IsServiceAccount(c) && GetAPIKeyID(c) == uuid.Nil {
+			return c.Next()
+		}
+
+		path := c.Path()
+		for prefix, scope := range scopes {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				if HasPermission(c, scope) {
+					return c.Next()
+				}
+				break
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   "Forbidden",
+			"message": "This token or API key does not have the scope for this resource",
+		})
+	}
+}
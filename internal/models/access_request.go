@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Access request statuses
+const (
+	AccessRequestStatusNew = "new"
+)
+
+// AccessRequest is a sales lead submitted through the public "request access" form
+type AccessRequest struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Name      string    `gorm:"not null;size:255" json:"name"`
+	Company   string    `gorm:"not null;size:255" json:"company"`
+	Email     string    `gorm:"not null;size:255;index" json:"email"`
+	UseCase   string    `gorm:"type:text;not null" json:"useCase"`
+	Status    string    `gorm:"default:'new';size:20;index" json:"status"` // new
+	IPAddress string    `gorm:"size:45" json:"ipAddress"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// BeforeCreate generates a UUID before creating a new access request
+func (a *AccessRequest) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = NewID()
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package models
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"net"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,13 +15,25 @@ import (
 type APIKey struct {
 	ID          uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
 	UserID      uuid.UUID      `gorm:"type:uuid;not null;index" json:"userId"`
+	OrganizationID *uuid.UUID  `gorm:"type:uuid;index" json:"organizationId,omitempty"` // Set when owned by an organization
 	Name        string         `gorm:"not null" json:"name"`
-	KeyPrefix   string         `gorm:"not null" json:"keyPrefix"`       // First 8 chars for display
+	Description string         `gorm:"size:500" json:"description"`
+	Tags        StringArray    `gorm:"type:jsonb" json:"tags"`         // Labels to find the key by, lowercase
+	KeyPrefix   string         `gorm:"not null" json:"keyPrefix"`       // Environment prefix and first 8 hex chars, for display
 	KeyHash     string         `gorm:"not null" json:"-"`               // Hashed full key
+	KeyDigest   string         `gorm:"index" json:"-"`                  // SHA-256 of the full key, to look keys up; empty on keys older than it
 	Environment string         `gorm:"default:'sandbox'" json:"environment"` // sandbox, production
+	Scopes      StringArray    `gorm:"type:jsonb" json:"scopes"`       // Portal routes the key may call with X-API-Key, see ServiceAccountScopes
+	RateLimit   int            `gorm:"not null;default:0" json:"rateLimit"` // Requests per minute, 0 for the default
+	IPAllowlist StringArray    `gorm:"type:jsonb" json:"ipAllowlist"`  // CIDR ranges the key may be used from, any when empty
 	IsActive    bool           `gorm:"default:true" json:"isActive"`
 	LastUsedAt  *time.Time     `json:"lastUsedAt"`
+	LastUsedIP  string         `gorm:"size:64" json:"lastUsedIp"`
+	LastUserAgent string       `gorm:"size:512" json:"lastUserAgent"`
+	RequestCount int64         `gorm:"not null;default:0" json:"requestCount"`
 	ExpiresAt   *time.Time     `json:"expiresAt"`
+	ReplacedByID *uuid.UUID    `gorm:"type:uuid" json:"replacedById,omitempty"` // Set when rotated; the key works until ExpiresAt
+	RevokedAt   *time.Time     `json:"revokedAt,omitempty"`
 	CreatedAt   time.Time      `json:"createdAt"`
 	UpdatedAt   time.Time      `json:"updatedAt"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
@@ -31,34 +45,87 @@ type APIKey struct {
 // BeforeCreate generates a UUID before creating a new API key
 func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
 	if k.ID == uuid.Nil {
-		k.ID = uuid.New()
+		k.ID = NewLegacyID()
 	}
 	return nil
 }
 
-// GenerateAPIKey creates a new random API key
-func GenerateAPIKey() (string, string, error) {
+// IsExpired reports whether the key's expiry has passed at now
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// AllowsIP reports whether the key may be used from ip. Keys without an allowlist may be
+// used from anywhere.
+func (k *APIKey) AllowsIP(ip string) bool {
+	if len(k.IPAllowlist) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, cidr := range k.IPAllowlist {
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Key prefixes tell production and sandbox keys apart at a glance. Keys created before
+// them start with a plain "bas_".
+const (
+	APIKeyLivePrefix = "bas_live_"
+	APIKeyTestPrefix = "bas_test_"
+)
+
+// GenerateAPIKey creates a new random API key for an environment, bas_live_ for
+// production and bas_test_ for sandbox
+func GenerateAPIKey(environment string) (string, string, error) {
 	// Generate 32 random bytes (256 bits)
 	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
 		return "", "", err
 	}
 
-	fullKey := "bas_" + hex.EncodeToString(bytes)
-	prefix := fullKey[:12] // "bas_" + first 8 hex chars
+	envPrefix := APIKeyTestPrefix
+	if environment == "production" {
+		envPrefix = APIKeyLivePrefix
+	}
+	fullKey := envPrefix + hex.EncodeToString(bytes)
+	prefix := fullKey[:len(envPrefix)+8] // environment prefix + first 8 hex chars
 
 	return fullKey, prefix, nil
 }
 
+// DigestAPIKey returns the SHA-256 digest a key is looked up by. Keys are 256 random
+// bits, so an unsalted fast hash is enough for lookups; KeyHash stays bcrypt.
+func DigestAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
 // APIKeyResponse is the response struct for listing keys
 type APIKeyResponse struct {
 	ID          uuid.UUID  `json:"id"`
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
 	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags"`
 	KeyPrefix   string     `json:"keyPrefix"`
 	Environment string     `json:"environment"`
+	Scopes      []string   `json:"scopes"`
+	RateLimit   int        `json:"rateLimit"` // 0 means the default limit
+	IPAllowlist []string   `json:"ipAllowlist,omitempty"`
 	IsActive    bool       `json:"isActive"`
 	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	LastUsedIP  string     `json:"lastUsedIp,omitempty"`
+	LastUserAgent string   `json:"lastUserAgent,omitempty"`
+	RequestCount int64     `json:"requestCount"`
 	ExpiresAt   *time.Time `json:"expiresAt"`
+	ReplacedByID *uuid.UUID `json:"replacedById,omitempty"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
 	CreatedAt   time.Time  `json:"createdAt"`
 }
 
@@ -66,18 +133,31 @@ type APIKeyResponse struct {
 func (k *APIKey) ToResponse() APIKeyResponse {
 	return APIKeyResponse{
 		ID:          k.ID,
+		OrganizationID: k.OrganizationID,
 		Name:        k.Name,
+		Description: k.Description,
+		Tags:        k.Tags,
 		KeyPrefix:   k.KeyPrefix,
 		Environment: k.Environment,
+		Scopes:      k.Scopes,
+		RateLimit:   k.RateLimit,
+		IPAllowlist: k.IPAllowlist,
 		IsActive:    k.IsActive,
 		LastUsedAt:  k.LastUsedAt,
+		LastUsedIP:  k.LastUsedIP,
+		LastUserAgent: k.LastUserAgent,
+		RequestCount: k.RequestCount,
 		ExpiresAt:   k.ExpiresAt,
+		ReplacedByID: k.ReplacedByID,
+		RevokedAt:   k.RevokedAt,
 		CreatedAt:   k.CreatedAt,
 	}
 }
 
-// APIKeyCreateResponse includes the full key (only shown once)
+// APIKeyCreateResponse includes the full key (only shown once), or a link to retrieve it
+// when one was requested
 type APIKeyCreateResponse struct {
 	APIKeyResponse
-	Key string `json:"key"` // Full key, only returned on creation
+	Key           string               `json:"key,omitempty"` // Full key, only returned on creation
+	RetrievalLink *SecretRetrievalLink `json:"retrievalLink,omitempty"`
 }
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Usage intervals a key's usage series can be bucketed by
+const (
+	UsageIntervalHour = "hour"
+	UsageIntervalDay  = "day"
+)
+
+// APIKeyUsage counts the requests made with a key in one hour, starting at BucketStart
+// (UTC). Requests that ended in a 4xx or 5xx response also count as errors.
+type APIKeyUsage struct {
+	KeyID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"keyId"`
+	BucketStart time.Time `gorm:"primaryKey;index" json:"bucketStart"`
+	Requests    int64     `gorm:"not null;default:0" json:"requests"`
+	Errors      int64     `gorm:"not null;default:0" json:"errors"`
+}
+
+// APIKeyUsageBucket is one interval of a usage series
+type APIKeyUsageBucket struct {
+	Start     time.Time `json:"start"`
+	Requests  int64     `json:"requests"`
+	Errors    int64     `json:"errors"`
+	ErrorRate float64   `json:"errorRate"` // Errors / Requests, 0 without requests
+}
+
+// APIKeyUsageSeries is a key's request counts over a time range, one bucket per interval
+// without gaps
+type APIKeyUsageSeries struct {
+	KeyID         uuid.UUID           `json:"keyId"`
+	From          time.Time           `json:"from"`
+	To            time.Time           `json:"to"`
+	Interval      string              `json:"interval"`
+	TotalRequests int64               `json:"totalRequests"`
+	TotalErrors   int64               `json:"totalErrors"`
+	Buckets       []APIKeyUsageBucket `json:"buckets"`
+}
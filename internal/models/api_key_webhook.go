@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKeyWebhook is the endpoint where a user receives signed lifecycle events for their
+// API keys. Each user has at most one; the secret is never returned after it is generated.
+type APIKeyWebhook struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"userId"`
+	URL       string    `gorm:"not null;size:2048" json:"url"`
+	Secret    string    `gorm:"not null;size:128" json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// BeforeCreate generates a UUID before creating the webhook
+func (w *APIKeyWebhook) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = NewID()
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLog records one mutation made through the API: who did what to which resource,
+// the values before and after, and where the request came from
+type AuditLog struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	ActorID      *uuid.UUID `gorm:"type:uuid;index" json:"actorId"`
+	Action       string     `gorm:"not null;size:100;index" json:"action"` // e.g. api_key.create
+	ResourceType string     `gorm:"size:50;index:idx_audit_logs_resource" json:"resourceType"`
+	ResourceID   string     `gorm:"size:100;index:idx_audit_logs_resource" json:"resourceId"`
+	OldValues    string     `gorm:"type:jsonb;default:null" json:"-"`
+	NewValues    string     `gorm:"type:jsonb;default:null" json:"-"`
+	IP           string     `gorm:"size:64" json:"ip"`
+	RequestID    string     `gorm:"size:64;index" json:"requestId"`
+	CreatedAt    time.Time  `gorm:"index" json:"createdAt"`
+}
+
+// BeforeCreate generates a UUID before creating a new audit log entry
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = NewID()
+	}
+	return nil
+}
+
+// AuditLogResponse is an audit log entry with its values as JSON
+type AuditLogResponse struct {
+	ID           uuid.UUID       `json:"id"`
+	ActorID      *uuid.UUID      `json:"actorId"`
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resourceType"`
+	ResourceID   string          `json:"resourceId"`
+	OldValues    json.RawMessage `json:"oldValues,omitempty"`
+	NewValues    json.RawMessage `json:"newValues,omitempty"`
+	IP           string          `json:"ip"`
+	RequestID    string          `json:"requestId"`
+	CreatedAt    time.Time       `json:"createdAt"`
+}
+
+// ToResponse converts AuditLog to AuditLogResponse
+func (a *AuditLog) ToResponse() AuditLogResponse {
+	response := AuditLogResponse{
+		ID:           a.ID,
+		ActorID:      a.ActorID,
+		Action:       a.Action,
+		ResourceType: a.ResourceType,
+		ResourceID:   a.ResourceID,
+		IP:           a.IP,
+		RequestID:    a.RequestID,
+		CreatedAt:    a.CreatedAt,
+	}
+	if a.OldValues != "" {
+		response.OldValues = json.RawMessage(a.OldValues)
+	}
+	if a.NewValues != "" {
+		response.NewValues = json.RawMessage(a.NewValues)
+	}
+	return response
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Company verification statuses. A profile is edited as a draft, submitted for review and
+// then verified or rejected; editing a verified or rejected profile makes it a draft again.
+const (
+	CompanyStatusDraft    = "draft"
+	CompanyStatusPending  = "pending"
+	CompanyStatusVerified = "verified"
+	CompanyStatusRejected = "rejected"
+)
+
+// Company document types
+const (
+	CompanyDocumentBusinessLicense = "business_license" // NIB or SIUP
+	CompanyDocumentNPWP            = "npwp"             // Tax registration card
+)
+
+// RequiredCompanyDocuments must all be uploaded before a profile can be submitted
+var RequiredCompanyDocuments = []string{CompanyDocumentBusinessLicense, CompanyDocumentNPWP}
+
+// IsValidCompanyDocumentType reports whether t is a known document type
+func IsValidCompanyDocumentType(t string) bool {
+	return t == CompanyDocumentBusinessLicense || t == CompanyDocumentNPWP
+}
+
+// CompanyProfile is the legal entity behind a user's or organization's credentials. It
+// must be verified by bank staff before production credentials are issued.
+type CompanyProfile struct {
+	ID                    uuid.UUID         `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID                uuid.UUID         `gorm:"type:uuid;not null;uniqueIndex:idx_company_profiles_personal,where:organization_id IS NULL" json:"userId"` // Owner, or who created an organization's profile
+	OrganizationID        *uuid.UUID        `gorm:"type:uuid;uniqueIndex" json:"organizationId,omitempty"`
+	LegalName             string            `gorm:"not null;size:255" json:"legalName"`
+	BusinessLicenseNumber string            `gorm:"not null;size:20" json:"businessLicenseNumber"` // NIB
+	NPWP                  string            `gorm:"not null;size:20" json:"npwp"`
+	Address               string            `gorm:"not null;size:500" json:"address"`
+	Status                string            `gorm:"not null;default:'draft';size:20;index" json:"status"`
+	SubmittedAt           *time.Time        `json:"submittedAt,omitempty"`
+	ReviewedAt            *time.Time        `json:"reviewedAt,omitempty"`
+	ReviewedBy            *uuid.UUID        `gorm:"type:uuid" json:"reviewedBy,omitempty"`
+	RejectionReason       string            `gorm:"size:500" json:"rejectionReason,omitempty"`
+	CreatedAt             time.Time         `json:"createdAt"`
+	UpdatedAt             time.Time         `json:"updatedAt"`
+	Documents             []CompanyDocument `gorm:"foreignKey:CompanyID" json:"documents,omitempty"`
+}
+
+// BeforeCreate generates a UUID before creating a new company profile
+func (p *CompanyProfile) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = NewID()
+	}
+	return nil
+}
+
+// HasRequiredDocuments reports whether every required document type has been uploaded
+func (p *CompanyProfile) HasRequiredDocuments() bool {
+	for _, required := range RequiredCompanyDocuments {
+		found := false
+		for _, document := range p.Documents {
+			if document.Type == required {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// CompanyDocument is a KYC document kept in private storage. Files are only readable
+// by bank staff through the API.
+type CompanyDocument struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CompanyID   uuid.UUID `gorm:"type:uuid;not null;index" json:"companyId"`
+	Type        string    `gorm:"not null;size:30" json:"type"`
+	FileName    string    `gorm:"not null;size:255" json:"fileName"`
+	ContentType string    `gorm:"not null;size:100" json:"contentType"`
+	Size        int64     `gorm:"not null" json:"size"`
+	StorageKey  string    `gorm:"not null;size:512" json:"-"`
+	UploadedBy  uuid.UUID `gorm:"type:uuid;not null" json:"uploadedBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// BeforeCreate generates a UUID before creating a new document
+func (d *CompanyDocument) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = NewID()
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Email campaign statuses
+const (
+	CampaignStatusDraft     = "draft"
+	CampaignStatusScheduled = "scheduled"
+	CampaignStatusSending   = "sending"
+	CampaignStatusSent      = "sent"
+	CampaignStatusFailed    = "failed"
+)
+
+// Email delivery statuses
+const (
+	DeliveryStatusSent   = "sent"
+	DeliveryStatusFailed = "failed"
+)
+
+// EmailCampaign is a bulk email sent by admins to all subscribed developers
+type EmailCampaign struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedBy       uuid.UUID  `gorm:"type:uuid;not null" json:"createdBy"`
+	Subject         string     `gorm:"not null;size:255" json:"subject"`
+	Body            string     `gorm:"type:text;not null" json:"body"` // text/template with {{.Name}} and {{.Email}}
+	Status          string     `gorm:"default:'draft';size:20;index" json:"status"`
+	ScheduledAt     *time.Time `gorm:"index" json:"scheduledAt"`
+	StartedAt       *time.Time `json:"startedAt"`
+	CompletedAt     *time.Time `json:"completedAt"`
+	TotalRecipients int64      `json:"totalRecipients"`
+	SentCount       int64      `json:"sentCount"`
+	FailedCount     int64      `json:"failedCount"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// BeforeCreate generates a UUID before creating a new campaign
+func (e *EmailCampaign) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = NewID()
+	}
+	return nil
+}
+
+// EmailCampaignDelivery records the outcome of sending a campaign to one user
+type EmailCampaignDelivery struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CampaignID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_campaign_delivery_user" json:"campaignId"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_campaign_delivery_user" json:"userId"`
+	Status     string    `gorm:"not null;size:20" json:"status"` // sent, failed
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// BeforeCreate generates a UUID before creating a new delivery record
+func (d *EmailCampaignDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = NewID()
+	}
+	return nil
+}
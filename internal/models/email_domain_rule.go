@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Email domain rule types
+const (
+	DomainRuleAllow = "allow"
+	DomainRuleBlock = "block"
+)
+
+// EmailDomainRule allows or blocks sign-ups from an email domain. Deleted rules are kept,
+// with who removed them, as the change history.
+type EmailDomainRule struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
+	Domain    string         `gorm:"not null;size:255;index" json:"domain"`
+	Type      string         `gorm:"not null;size:10" json:"type"` // allow, block
+	Note      string         `gorm:"size:255" json:"note"`
+	CreatedBy uuid.UUID      `gorm:"type:uuid;not null" json:"createdBy"`
+	DeletedBy *uuid.UUID     `gorm:"type:uuid" json:"deletedBy,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
+}
+
+// BeforeCreate generates a UUID before creating a new rule
+func (r *EmailDomainRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = NewID()
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Resources that get expiry reminders
+const (
+	ExpiryReminderAPIKey            = "api_key"
+	ExpiryReminderPartnerCredential = "partner_credential"
+)
+
+// ExpiryReminder records that the owner of an API key or partner credential was reminded
+// DaysBefore days ahead of its expiry. The expiry is part of the key, so moving it lets the
+// reminders be sent again for the new date.
+type ExpiryReminder struct {
+	ResourceType string    `gorm:"primaryKey;size:30" json:"resourceType"`
+	ResourceID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"resourceId"`
+	ExpiresAt    time.Time `gorm:"primaryKey" json:"expiresAt"`
+	DaysBefore   int       `gorm:"primaryKey;autoIncrement:false" json:"daysBefore"`
+	SentAt       time.Time `gorm:"not null" json:"sentAt"`
+}
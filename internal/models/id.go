@@ -0,0 +1,29 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// UseTimeOrderedIDsEverywhere switches the original tables (users, API keys, partner
+// credentials) to UUIDv7 as well. Existing rows keep their IDs either way; both versions
+// share the same column type and format, so external references stay valid.
+var UseTimeOrderedIDsEverywhere = false
+
+// NewID returns a time-ordered UUIDv7. Consecutive IDs sort together, so inserts append to
+// the end of the primary key index instead of landing on random pages. New tables use this.
+func NewID() uuid.UUID {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.New()
+	}
+	return id
+}
+
+// NewLegacyID returns a random UUIDv4 for the original tables, unless they have opted
+// into time-ordered IDs with UseTimeOrderedIDsEverywhere
+func NewLegacyID() uuid.UUID {
+	if UseTimeOrderedIDsEverywhere {
+		return NewID()
+	}
+	return uuid.New()
+}
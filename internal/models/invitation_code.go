@@ -0,0 +1,58 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InvitationCode lets someone register while the portal is in invitation-only mode.
+// Only a hash of the code is stored; the code itself is shown once, on creation.
+type InvitationCode struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	CodePrefix string     `gorm:"not null;size:16" json:"codePrefix"` // For display
+	CodeHash   string     `gorm:"not null;uniqueIndex;size:64" json:"-"`
+	Note       string     `gorm:"size:255" json:"note"`
+	MaxUses    int        `gorm:"not null;default:1" json:"maxUses"`
+	UseCount   int        `gorm:"not null;default:0" json:"useCount"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expiresAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedBy  uuid.UUID  `gorm:"type:uuid;not null" json:"createdBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// BeforeCreate generates a UUID before creating a new invitation code
+func (i *InvitationCode) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = NewID()
+	}
+	return nil
+}
+
+// IsUsable reports whether the code can still be redeemed
+func (i *InvitationCode) IsUsable(now time.Time) bool {
+	return i.RevokedAt == nil && now.Before(i.ExpiresAt) && i.UseCount < i.MaxUses
+}
+
+// GenerateInvitationCode creates a new random invitation code and its display prefix
+func GenerateInvitationCode() (string, string, error) {
+	bytes := make([]byte, 12)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", err
+	}
+
+	code := "inv_" + hex.EncodeToString(bytes)
+	return code, code[:10], nil
+}
+
+// HashInvitationCode returns the stored form of an invitation code
+func HashInvitationCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(code)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// KnownDevice is a browser or client a user has signed in from before, identified by a
+// hash of its user agent. Sign-ins from unknown devices trigger a security email.
+type KnownDevice struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_known_devices_user_fingerprint" json:"userId"`
+	Fingerprint string    `gorm:"not null;size:64;uniqueIndex:idx_known_devices_user_fingerprint" json:"-"`
+	UserAgent   string    `gorm:"size:512" json:"userAgent"`
+	LastIP      string    `gorm:"size:45" json:"lastIp"`
+	CreatedAt   time.Time `json:"createdAt"`
+	LastSeenAt  time.Time `gorm:"not null" json:"lastSeenAt"`
+}
+
+// BeforeCreate generates a UUID before creating a new device
+func (d *KnownDevice) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = NewID()
+	}
+	return nil
+}
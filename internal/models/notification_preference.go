@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification categories a user can opt out of. Transactional emails (verification,
+// password reset, invitations, suspension notices) are always sent.
+const (
+	NotificationKeyExpiry            = "key_expiry"            // Expiry and inactivity of keys and credentials
+	NotificationSecurityAlerts       = "security_alerts"       // New devices, new keys, changed secrets and sign-in methods
+	NotificationProductAnnouncements = "product_announcements" // Email campaigns
+)
+
+// NotificationPreference holds a user's email choices. Users without a row receive every
+// category. Product announcements are tracked by User.UnsubscribedAt so the unsubscribe
+// link in campaign emails and this setting stay the same thing.
+type NotificationPreference struct {
+	UserID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	KeyExpiry      bool      `gorm:"not null" json:"keyExpiry"` // No column default: GORM would skip false on insert
+	SecurityAlerts bool      `gorm:"not null" json:"securityAlerts"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// DefaultNotificationPreference returns the preferences of a user who never changed them
+func DefaultNotificationPreference(userID uuid.UUID) *NotificationPreference {
+	return &NotificationPreference{UserID: userID, KeyExpiry: true, SecurityAlerts: true}
+}
+
+// NotificationPreferencesResponse is the full set of a user's notification choices
+type NotificationPreferencesResponse struct {
+	KeyExpiry            bool `json:"keyExpiry"`
+	SecurityAlerts       bool `json:"securityAlerts"`
+	ProductAnnouncements bool `json:"productAnnouncements"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Purposes for one-time tokens
+const (
+	TokenPurposeMagicLink         = "magic_link"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeAccountLock       = "account_lock"
+)
+
+// OneTimeToken tracks a single-use token sent to a user by email
+type OneTimeToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	Purpose   string     `gorm:"not null;size:32" json:"purpose"` // magic_link, email_verification, password_reset, account_lock
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// BeforeCreate generates a UUID before creating a new token
+func (t *OneTimeToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = NewID()
+	}
+	return nil
+}
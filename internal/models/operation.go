@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Operation statuses
+const (
+	OperationStatusPending   = "pending"
+	OperationStatusRunning   = "running"
+	OperationStatusSucceeded = "succeeded"
+	OperationStatusFailed    = "failed"
+)
+
+// Operation tracks a long-running task started by a request and finished in the background
+type Operation struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	Type        string     `gorm:"not null;size:64" json:"type"`
+	Status      string     `gorm:"default:'pending';size:20;index" json:"status"`
+	Progress    int        `gorm:"default:0" json:"progress"`        // 0-100
+	Params      string     `gorm:"type:jsonb;default:null" json:"-"` // Input captured when the operation was started
+	Result      string     `gorm:"type:jsonb;default:null" json:"-"` // Output once the operation succeeds
+	Error       string     `gorm:"size:500" json:"error"`
+	StartedAt   *time.Time `json:"startedAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// BeforeCreate generates a UUID before creating a new operation
+func (o *Operation) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = NewID()
+	}
+	return nil
+}
+
+// IsDone reports whether the operation has finished, successfully or not
+func (o *Operation) IsDone() bool {
+	return o.Status == OperationStatusSucceeded || o.Status == OperationStatusFailed
+}
+
+// OperationResponse is the status polling response for an operation
+type OperationResponse struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        string          `json:"type"`
+	Status      string          `json:"status"`
+	Progress    int             `json:"progress"`
+	Done        bool            `json:"done"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	StartedAt   *time.Time      `json:"startedAt"`
+	CompletedAt *time.Time      `json:"completedAt"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// ToResponse converts Operation to OperationResponse
+func (o *Operation) ToResponse() OperationResponse {
+	response := OperationResponse{
+		ID:          o.ID,
+		Type:        o.Type,
+		Status:      o.Status,
+		Progress:    o.Progress,
+		Done:        o.IsDone(),
+		Error:       o.Error,
+		StartedAt:   o.StartedAt,
+		CompletedAt: o.CompletedAt,
+		CreatedAt:   o.CreatedAt,
+	}
+	if o.Result != "" {
+		response.Result = json.RawMessage(o.Result)
+	}
+	return response
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Organization member roles. Owners manage the team, editors manage its keys and
+// credentials, viewers can only see them.
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleEditor = "editor"
+	OrgRoleViewer = "viewer"
+)
+
+// orgRoleRanks orders organization roles from least to most privileged
+var orgRoleRanks = map[string]int{
+	OrgRoleViewer: 1,
+	OrgRoleEditor: 2,
+	OrgRoleOwner:  3,
+}
+
+// IsValidOrgRole reports whether role is a known organization role
+func IsValidOrgRole(role string) bool {
+	return orgRoleRanks[role] > 0
+}
+
+// OrgRoleAtLeast reports whether role grants at least the permissions of minRole
+func OrgRoleAtLeast(role, minRole string) bool {
+	return orgRoleRanks[role] >= orgRoleRanks[minRole] && orgRoleRanks[role] > 0
+}
+
+// Organization is a partner team that can own API keys and partner credentials
+type Organization struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
+	Name      string         `gorm:"not null;size:100" json:"name"`
+	CreatedBy uuid.UUID      `gorm:"type:uuid;not null" json:"createdBy"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate generates a UUID before creating a new organization
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = NewID()
+	}
+	return nil
+}
+
+// OrganizationMember gives a user a role in an organization
+type OrganizationMember struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_org_members_org_user" json:"organizationId"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_org_members_org_user;index" json:"userId"`
+	Role           string    `gorm:"not null;size:20" json:"role"`
+	CreatedAt      time.Time `json:"joinedAt"`
+
+	// Relations
+	Organization Organization `gorm:"foreignKey:OrganizationID" json:"-"`
+	User         User         `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// BeforeCreate generates a UUID before creating a new membership
+func (m *OrganizationMember) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = NewID()
+	}
+	return nil
+}
+
+// OrganizationResponse describes an organization from the caller's point of view
+type OrganizationResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"` // The caller's role
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// OrganizationMemberResponse describes a member of an organization
+type OrganizationMemberResponse struct {
+	UserID   uuid.UUID `json:"userId"`
+	Email    string    `json:"email"`
+	FullName string    `json:"fullName"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+// ToResponse converts a membership (with its Organization loaded) to OrganizationResponse
+func (m *OrganizationMember) ToResponse() OrganizationResponse {
+	return OrganizationResponse{
+		ID:        m.Organization.ID,
+		Name:      m.Organization.Name,
+		Role:      m.Role,
+		CreatedAt: m.Organization.CreatedAt,
+	}
+}
+
+// ToMemberResponse converts a membership (with its User loaded) to OrganizationMemberResponse
+func (m *OrganizationMember) ToMemberResponse() OrganizationMemberResponse {
+	return OrganizationMemberResponse{
+		UserID:   m.UserID,
+		Email:    m.User.Email,
+		FullName: m.User.FullName,
+		Role:     m.Role,
+		JoinedAt: m.CreatedAt,
+	}
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Organization invitation states
+const (
+	OrgInvitationPending  = "pending"
+	OrgInvitationAccepted = "accepted"
+	OrgInvitationDeclined = "declined"
+	OrgInvitationRevoked  = "revoked"
+	OrgInvitationExpired  = "expired"
+)
+
+// OrganizationInvitation asks someone, by email, to join an organization with a role.
+// Only a hash of the token is stored; the token itself is emailed to the invitee.
+type OrganizationInvitation struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	OrganizationID uuid.UUID  `gorm:"type:uuid;not null;index" json:"organizationId"`
+	Email          string     `gorm:"not null;index" json:"email"`
+	Role           string     `gorm:"not null;size:20" json:"role"`
+	TokenHash      string     `gorm:"not null;uniqueIndex;size:64" json:"-"`
+	InvitedBy      uuid.UUID  `gorm:"type:uuid;not null" json:"invitedBy"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expiresAt"`
+	AcceptedAt     *time.Time `json:"acceptedAt,omitempty"`
+	DeclinedAt     *time.Time `json:"declinedAt,omitempty"`
+	RevokedAt      *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+
+	// Relations
+	Organization Organization `gorm:"foreignKey:OrganizationID" json:"-"`
+}
+
+// BeforeCreate generates a UUID before creating a new invitation
+func (i *OrganizationInvitation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = NewID()
+	}
+	return nil
+}
+
+// Status reports where the invitation stands
+func (i *OrganizationInvitation) Status(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return OrgInvitationAccepted
+	case i.DeclinedAt != nil:
+		return OrgInvitationDeclined
+	case i.RevokedAt != nil:
+		return OrgInvitationRevoked
+	case !now.Before(i.ExpiresAt):
+		return OrgInvitationExpired
+	}
+	return OrgInvitationPending
+}
+
+// OrganizationInvitationResponse describes an invitation to the organization's owners
+type OrganizationInvitationResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Status    string    `json:"status"`
+	InvitedBy uuid.UUID `json:"invitedBy"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ToResponse converts an OrganizationInvitation to OrganizationInvitationResponse
+func (i *OrganizationInvitation) ToResponse() OrganizationInvitationResponse {
+	return OrganizationInvitationResponse{
+		ID:        i.ID,
+		Email:     i.Email,
+		Role:      i.Role,
+		Status:    i.Status(time.Now()),
+		InvitedBy: i.InvitedBy,
+		ExpiresAt: i.ExpiresAt,
+		CreatedAt: i.CreatedAt,
+	}
+}
+
+// GenerateOrgInvitationToken creates a new random organization invitation token
+func GenerateOrgInvitationToken() (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "orginv_" + hex.EncodeToString(bytes), nil
+}
+
+// HashOrgInvitationToken returns the stored form of an organization invitation token
+func HashOrgInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
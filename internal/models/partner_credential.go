@@ -1,7 +1,12 @@
 package models
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
 	"database/sql/driver"
@@ -10,6 +15,8 @@ import (
 	"encoding/json"
 	"encoding/pem"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -44,15 +51,19 @@ func (s *StringArray) Scan(value interface{}) error {
 type PartnerCredential struct {
 	ID                   uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
 	UserID               uuid.UUID      `gorm:"type:uuid;not null;index" json:"userId"`
+	OrganizationID       *uuid.UUID     `gorm:"type:uuid;index" json:"organizationId,omitempty"` // Set when owned by an organization
 
 	// SNAP Authentication
 	ClientID             string         `gorm:"uniqueIndex;not null;size:64" json:"clientId"`
-	ClientSecret         string         `gorm:"not null" json:"-"` // Encrypted, never exposed
+	ClientSecret         string         `gorm:"not null" json:"-"` // Plaintext of credentials older than ClientSecretHash, cleared at startup
+	ClientSecretHash     string         `gorm:"size:64;index" json:"-"` // HMAC-SHA256 of the secret, never exposed
+	ClientSecretSealed   []byte         `json:"-"` // AES-GCM encrypted secret, to verify SNAP symmetric signatures
 	ClientSecretPrefix   string         `gorm:"size:12" json:"clientSecretPrefix"` // First 8 chars for display
 
-	// RSA Public Key Configuration
+	// Public Key Configuration
 	PublicKey            string         `gorm:"type:text" json:"-"` // PEM format, not exposed in list
 	PublicKeyFingerprint string         `gorm:"size:64;index" json:"publicKeyFingerprint"` // SHA256 fingerprint
+	PublicKeyAlgorithm   string         `gorm:"size:16" json:"publicKeyAlgorithm"` // RSA, ECDSA-P256, Ed25519
 	PublicKeyAddedAt     *time.Time     `json:"publicKeyAddedAt"`
 
 	// Partner Configuration
@@ -62,6 +73,9 @@ type PartnerCredential struct {
 
 	// Security Settings
 	CallbackURL          string         `gorm:"size:500" json:"callbackUrl"`
+	CallbackTLSVersion   string         `gorm:"size:10" json:"callbackTlsVersion"` // Negotiated on last probe, e.g. "TLS 1.3"
+	CallbackCertExpiresAt *time.Time    `json:"callbackCertExpiresAt"`
+	CallbackCheckedAt    *time.Time     `json:"callbackCheckedAt"`
 	IPWhitelist          StringArray    `gorm:"type:jsonb" json:"ipWhitelist"`
 
 	// Status
@@ -69,6 +83,11 @@ type PartnerCredential struct {
 	ExpiresAt            *time.Time     `json:"expiresAt"`
 	LastUsedAt           *time.Time     `json:"lastUsedAt"`
 
+	// Inactivity policy (production only)
+	InactivityWarnedAt   *time.Time     `json:"inactivityWarnedAt"`
+	SuspendedAt          *time.Time     `gorm:"index" json:"suspendedAt"`
+	ReactivatedAt        *time.Time     `json:"reactivatedAt"`
+
 	// Timestamps
 	CreatedAt            time.Time      `json:"createdAt"`
 	UpdatedAt            time.Time      `json:"updatedAt"`
@@ -81,7 +100,7 @@ type PartnerCredential struct {
 // BeforeCreate generates UUID and credentials before creating
 func (p *PartnerCredential) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == uuid.Nil {
-		p.ID = uuid.New()
+		p.ID = NewLegacyID()
 	}
 	return nil
 }
@@ -115,41 +134,139 @@ func GenerateChannelID() (string, error) {
 	return "CH" + hex.EncodeToString(bytes), nil
 }
 
-// ValidatePublicKey validates a PEM-encoded RSA public key and returns its fingerprint
-func ValidatePublicKey(pemKey string) (fingerprint string, err error) {
+// Supported partner public key algorithms
+const (
+	KeyAlgorithmRSA       = "RSA"
+	KeyAlgorithmECDSAP256 = "ECDSA-P256"
+	KeyAlgorithmEd25519   = "Ed25519"
+)
+
+// ValidatePublicKey validates a PEM-encoded RSA, ECDSA P-256 or Ed25519 public key
+// and returns its fingerprint and algorithm
+func ValidatePublicKey(pemKey string) (fingerprint, algorithm string, err error) {
 	if pemKey == "" {
-		return "", nil // Empty is allowed
+		return "", "", nil // Empty is allowed
 	}
 
 	block, _ := pem.Decode([]byte(pemKey))
 	if block == nil {
-		return "", errors.New("invalid PEM format: no valid PEM block found")
+		return "", "", errors.New("invalid PEM format: no valid PEM block found")
+	}
+
+	pubKey, err := parsePublicKeyBlock(block)
+	if err != nil {
+		return "", "", err
 	}
 
-	if block.Type != "PUBLIC KEY" && block.Type != "RSA PUBLIC KEY" {
-		return "", errors.New("invalid PEM format: expected PUBLIC KEY or RSA PUBLIC KEY")
+	algorithm, err = publicKeyAlgorithm(pubKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Calculate SHA256 fingerprint
+	hash := sha256.Sum256(block.Bytes)
+	fingerprint = hex.EncodeToString(hash[:])
+
+	return fingerprint, algorithm, nil
+}
+
+// VerifySignature verifies a signature over message with a PEM-encoded partner public key.
+// RSA keys use PKCS#1 v1.5 with SHA-256, ECDSA keys an ASN.1 signature over SHA-256,
+// and Ed25519 keys sign the message directly.
+func VerifySignature(pemKey string, message, signature []byte) error {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return errors.New("invalid PEM format: no valid PEM block found")
 	}
 
-	// Try to parse as PKIX public key
-	pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	pubKey, err := parsePublicKeyBlock(block)
 	if err != nil {
-		// Try parsing as PKCS1 public key
-		_, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		return err
+	}
+
+	digest := sha256.Sum256(message)
+	switch key := pubKey.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return errors.New("invalid ECDSA signature")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, message, signature) {
+			return errors.New("invalid Ed25519 signature")
+		}
+		return nil
+	}
+	return errors.New("unsupported public key algorithm")
+}
+
+// parsePublicKeyBlock parses a PKIX or PKCS1 encoded public key
+func parsePublicKeyBlock(block *pem.Block) (crypto.PublicKey, error) {
+	switch block.Type {
+	case "PUBLIC KEY":
+		pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.New("invalid public key: unable to parse")
+		}
+		return pubKey, nil
+	case "RSA PUBLIC KEY":
+		pubKey, err := x509.ParsePKCS1PublicKey(block.Bytes)
 		if err != nil {
-			return "", errors.New("invalid public key: unable to parse")
+			return nil, errors.New("invalid public key: unable to parse")
 		}
+		return pubKey, nil
 	}
+	return nil, errors.New("invalid PEM format: expected PUBLIC KEY or RSA PUBLIC KEY")
+}
 
-	// If we got here with PKIX, verify it's valid
-	if pubKey != nil {
-		// Key is valid
+// publicKeyAlgorithm returns the algorithm name for a supported public key
+func publicKeyAlgorithm(pubKey crypto.PublicKey) (string, error) {
+	switch key := pubKey.(type) {
+	case *rsa.PublicKey:
+		return KeyAlgorithmRSA, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return "", errors.New("invalid public key: only the P-256 curve is supported for ECDSA")
+		}
+		return KeyAlgorithmECDSAP256, nil
+	case ed25519.PublicKey:
+		return KeyAlgorithmEd25519, nil
 	}
+	return "", errors.New("invalid public key: unsupported algorithm")
+}
 
-	// Calculate SHA256 fingerprint
-	hash := sha256.Sum256(block.Bytes)
-	fingerprint = hex.EncodeToString(hash[:])
+// CertExpiryWarningDays is how long before expiry a callback certificate is flagged
+const CertExpiryWarningDays = 30
+
+// CallbackWarnings returns human-readable warnings about the callback URL's TLS setup
+func (p *PartnerCredential) CallbackWarnings() []string {
+	var warnings []string
+	if p.CallbackURL == "" {
+		return warnings
+	}
+
+	if !strings.HasPrefix(strings.ToLower(p.CallbackURL), "https://") {
+		warnings = append(warnings, "Callback URL does not use HTTPS")
+		return warnings
+	}
+
+	if p.CallbackCheckedAt != nil && p.CallbackTLSVersion == "" {
+		warnings = append(warnings, "Callback URL TLS check failed")
+	}
+
+	if p.CallbackCertExpiresAt != nil {
+		remaining := time.Until(*p.CallbackCertExpiresAt)
+		if remaining <= 0 {
+			warnings = append(warnings, "Callback URL certificate has expired")
+		} else if remaining < CertExpiryWarningDays*24*time.Hour {
+			warnings = append(warnings, fmt.Sprintf("Callback URL certificate expires on %s",
+				p.CallbackCertExpiresAt.Format("2006-01-02")))
+		}
+	}
 
-	return fingerprint, nil
+	return warnings
 }
 
 // FormatFingerprint formats a fingerprint for display (e.g., "94:32:f2:a1:...")
@@ -170,45 +287,59 @@ func FormatFingerprint(fingerprint string) string {
 // PartnerCredentialResponse is the response struct for listing credentials
 type PartnerCredentialResponse struct {
 	ID                   uuid.UUID  `json:"id"`
+	OrganizationID       *uuid.UUID `json:"organizationId,omitempty"`
 	ClientID             string     `json:"clientId"`
 	ClientSecretPrefix   string     `json:"clientSecretPrefix"`
 	PublicKeyFingerprint string     `json:"publicKeyFingerprint,omitempty"`
+	PublicKeyAlgorithm   string     `json:"publicKeyAlgorithm,omitempty"`
 	PublicKeyAddedAt     *time.Time `json:"publicKeyAddedAt,omitempty"`
 	PartnerName          string     `json:"partnerName"`
 	ChannelID            string     `json:"channelId"`
 	Environment          string     `json:"environment"`
 	CallbackURL          string     `json:"callbackUrl,omitempty"`
+	CallbackTLSVersion   string     `json:"callbackTlsVersion,omitempty"`
+	CallbackCertExpiresAt *time.Time `json:"callbackCertExpiresAt,omitempty"`
 	IPWhitelist          []string   `json:"ipWhitelist,omitempty"`
 	IsActive             bool       `json:"isActive"`
 	ExpiresAt            *time.Time `json:"expiresAt,omitempty"`
 	LastUsedAt           *time.Time `json:"lastUsedAt,omitempty"`
+	SuspendedAt          *time.Time `json:"suspendedAt,omitempty"`
 	CreatedAt            time.Time  `json:"createdAt"`
+	Warnings             []string   `json:"warnings,omitempty"`
 }
 
 // ToResponse converts PartnerCredential to PartnerCredentialResponse
 func (p *PartnerCredential) ToResponse() PartnerCredentialResponse {
 	return PartnerCredentialResponse{
 		ID:                   p.ID,
+		OrganizationID:       p.OrganizationID,
 		ClientID:             p.ClientID,
 		ClientSecretPrefix:   p.ClientSecretPrefix,
 		PublicKeyFingerprint: FormatFingerprint(p.PublicKeyFingerprint),
+		PublicKeyAlgorithm:   p.PublicKeyAlgorithm,
 		PublicKeyAddedAt:     p.PublicKeyAddedAt,
 		PartnerName:          p.PartnerName,
 		ChannelID:            p.ChannelID,
 		Environment:          p.Environment,
 		CallbackURL:          p.CallbackURL,
+		CallbackTLSVersion:   p.CallbackTLSVersion,
+		CallbackCertExpiresAt: p.CallbackCertExpiresAt,
 		IPWhitelist:          p.IPWhitelist,
 		IsActive:             p.IsActive,
 		ExpiresAt:            p.ExpiresAt,
 		LastUsedAt:           p.LastUsedAt,
+		SuspendedAt:          p.SuspendedAt,
 		CreatedAt:            p.CreatedAt,
+		Warnings:             p.CallbackWarnings(),
 	}
 }
 
-// PartnerCredentialCreateResponse includes the full secret (only shown once)
+// PartnerCredentialCreateResponse includes the full secret (only shown once), or a link to
+// retrieve it when one was requested
 type PartnerCredentialCreateResponse struct {
 	PartnerCredentialResponse
-	ClientSecret string `json:"clientSecret"` // Full secret, only returned on creation
+	ClientSecret  string               `json:"clientSecret,omitempty"` // Full secret, only returned on creation
+	RetrievalLink *SecretRetrievalLink `json:"retrievalLink,omitempty"`
 }
 
 // PartnerCredentialDetailResponse includes public key for detail view
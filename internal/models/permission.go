@@ -0,0 +1,54 @@
+package models
+
+// Permissions granted by roles. They are embedded in access tokens so handlers and
+// gateways can check them without loading the user.
+const (
+	PermissionManageAPIKeys     = "api_keys:manage"
+	PermissionManageCredentials = "credentials:manage"
+	PermissionManageCampaigns   = "campaigns:manage"
+	PermissionManageInvitations = "invitations:manage"
+	PermissionReviewAccounts    = "accounts:review"
+	PermissionReviewCompanies   = "companies:review"
+	PermissionManageSettings    = "settings:manage"
+	PermissionManageRoles       = "roles:manage"
+	PermissionSuspendUsers      = "users:suspend"
+	PermissionManageQuotas      = "quotas:manage"
+	PermissionReadAuditLogs     = "audit_logs:read"
+	PermissionReadStats         = "stats:read"
+)
+
+// rolePermissions lists what each role may do. Each role includes the permissions of the
+// roles below it.
+var rolePermissions = map[string][]string{
+	RoleDeveloper: {
+		PermissionManageAPIKeys,
+		PermissionManageCredentials,
+	},
+	RoleOperator: {
+		PermissionManageAPIKeys,
+		PermissionManageCredentials,
+		PermissionManageCampaigns,
+		PermissionManageInvitations,
+		PermissionReviewAccounts,
+		PermissionReviewCompanies,
+	},
+	RoleAdmin: {
+		PermissionManageAPIKeys,
+		PermissionManageCredentials,
+		PermissionManageCampaigns,
+		PermissionManageInvitations,
+		PermissionReviewAccounts,
+		PermissionReviewCompanies,
+		PermissionManageSettings,
+		PermissionManageRoles,
+		PermissionSuspendUsers,
+		PermissionManageQuotas,
+		PermissionReadAuditLogs,
+		PermissionReadStats,
+	},
+}
+
+// RolePermissions returns the permissions granted by role, none for an unknown role
+func RolePermissions(role string) []string {
+	return append([]string(nil), rolePermissions[role]...)
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Quota owner types. A personal key or credential counts against the user's quota, one
+// created for an organization against the organization's.
+const (
+	QuotaOwnerUser         = "user"
+	QuotaOwnerOrganization = "organization"
+)
+
+// Quota caps how many API keys and partner credentials a user or an organization may hold.
+// Owners without a row get the defaults from DEFAULT_MAX_API_KEYS and DEFAULT_MAX_CREDENTIALS.
+type Quota struct {
+	OwnerType      string     `gorm:"primaryKey;size:20" json:"ownerType"`
+	OwnerID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"ownerId"`
+	MaxAPIKeys     int        `gorm:"not null" json:"maxApiKeys"`
+	MaxCredentials int        `gorm:"not null" json:"maxCredentials"`
+	UpdatedBy      *uuid.UUID `gorm:"type:uuid" json:"updatedBy,omitempty"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+
+	// Whether the owner has no quota record and the defaults apply
+	IsDefault bool `gorm:"-" json:"isDefault"`
+}
+
+// DefaultQuota returns the quota of an owner that was never given one
+func DefaultQuota(ownerType string, ownerID uuid.UUID, maxAPIKeys, maxCredentials int) *Quota {
+	return &Quota{
+		OwnerType:      ownerType,
+		OwnerID:        ownerID,
+		MaxAPIKeys:     maxAPIKeys,
+		MaxCredentials: maxCredentials,
+		IsDefault:      true,
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Secrets that can be handed over with a retrieval link
+const (
+	SecretLinkAPIKey            = "api_key"
+	SecretLinkPartnerCredential = "partner_credential"
+)
+
+// SecretLink is a single-use, time-limited link to a new API key or client secret. The
+// secret is encrypted with a key that is only part of the link, so the database alone
+// cannot reveal it, and the ciphertext is wiped once the link is opened.
+type SecretLink struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"` // Who created the secret
+	ResourceType string     `gorm:"not null;size:30" json:"resourceType"`   // api_key, partner_credential
+	ResourceID   uuid.UUID  `gorm:"type:uuid;not null" json:"resourceId"`
+	Ciphertext   []byte     `json:"-"`
+	ExpiresAt    time.Time  `gorm:"not null;index" json:"expiresAt"`
+	RetrievedAt  *time.Time `json:"retrievedAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// SecretRetrievalLink is returned instead of a new secret when a retrieval link is requested
+type SecretRetrievalLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
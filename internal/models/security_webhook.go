@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SecurityWebhook is the endpoint that receives signed auth events for the SOC. There is
+// at most one; the secret is kept so deliveries can be signed and is never returned after
+// it is generated.
+type SecurityWebhook struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	URL       string    `gorm:"not null;size:2048" json:"url"`
+	Secret    string    `gorm:"not null;size:128" json:"-"`
+	UpdatedBy uuid.UUID `gorm:"type:uuid;not null" json:"updatedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// BeforeCreate generates a UUID before creating the webhook
+func (w *SecurityWebhook) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = NewID()
+	}
+	return nil
+}
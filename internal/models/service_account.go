@@ -0,0 +1,113 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ServiceAccountTokenPrefix starts every service account token, which tells them apart
+// from access tokens
+const ServiceAccountTokenPrefix = "bas_sat_"
+
+// ServiceAccountScopes are the permissions a service account token can be given
+var ServiceAccountScopes = []string{
+	PermissionManageAPIKeys,
+	PermissionManageCredentials,
+}
+
+// IsValidServiceAccountScope reports whether scope can be given to a service account token
+func IsValidServiceAccountScope(scope string) bool {
+	for _, s := range ServiceAccountScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceAccount links a service account user to the organization that owns it. The
+// account acts in the organization with the role of its membership.
+type ServiceAccount struct {
+	UserID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null;index" json:"organizationId"`
+	CreatedBy      uuid.UUID `gorm:"type:uuid;not null" json:"createdBy"`
+	CreatedAt      time.Time `json:"createdAt"`
+
+	// Relations
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// ServiceAccountResponse describes a service account
+type ServiceAccountResponse struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organizationId"`
+	Name           string    `json:"name"`
+	Role           string    `json:"role"`
+	CreatedBy      uuid.UUID `json:"createdBy"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// ToResponse converts a ServiceAccount (with its User loaded) to ServiceAccountResponse
+func (a *ServiceAccount) ToResponse(role string) ServiceAccountResponse {
+	return ServiceAccountResponse{
+		ID:             a.UserID,
+		OrganizationID: a.OrganizationID,
+		Name:           a.User.FullName,
+		Role:           role,
+		CreatedBy:      a.CreatedBy,
+		CreatedAt:      a.CreatedAt,
+	}
+}
+
+// ServiceAccountToken is a long-lived bearer token for a service account. Only its
+// SHA-256 hash is stored.
+type ServiceAccountToken struct {
+	ID               uuid.UUID   `gorm:"type:uuid;primaryKey" json:"id"`
+	ServiceAccountID uuid.UUID   `gorm:"type:uuid;not null;index" json:"serviceAccountId"`
+	Name             string      `gorm:"not null;size:100" json:"name"`
+	TokenPrefix      string      `gorm:"not null;size:20" json:"tokenPrefix"`
+	TokenHash        string      `gorm:"not null;size:64;uniqueIndex" json:"-"`
+	Scopes           StringArray `gorm:"type:jsonb" json:"scopes"`
+	ExpiresAt        time.Time   `gorm:"not null" json:"expiresAt"`
+	LastUsedAt       *time.Time  `json:"lastUsedAt"`
+	RevokedAt        *time.Time  `json:"revokedAt,omitempty"`
+	CreatedBy        uuid.UUID   `gorm:"type:uuid;not null" json:"createdBy"`
+	CreatedAt        time.Time   `json:"createdAt"`
+}
+
+// BeforeCreate generates a UUID before creating a new token
+func (t *ServiceAccountToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = NewID()
+	}
+	return nil
+}
+
+// ServiceAccountTokenCreateResponse includes the token, shown only once
+type ServiceAccountTokenCreateResponse struct {
+	ServiceAccountToken
+	Token string `json:"token"`
+}
+
+// GenerateServiceAccountToken creates a new random token and returns it with its display
+// prefix and hash
+func GenerateServiceAccountToken() (string, string, string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", "", err
+	}
+
+	token := ServiceAccountTokenPrefix + hex.EncodeToString(bytes)
+	return token, token[:len(ServiceAccountTokenPrefix)+8], HashServiceAccountToken(token), nil
+}
+
+// HashServiceAccountToken returns the stored hash of a service account token
+func HashServiceAccountToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session represents a login session shared by an access/refresh token pair
+type Session struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	LastActivityAt time.Time  `gorm:"not null" json:"lastActivityAt"`
+	RevokedAt      *time.Time `json:"revokedAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// BeforeCreate generates a UUID before creating a new session
+func (s *Session) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = NewID()
+	}
+	return nil
+}
+
+// IsIdle reports whether the session has been inactive for longer than the given timeout
+func (s *Session) IsIdle(timeout time.Duration) bool {
+	if timeout <= 0 {
+		return false
+	}
+	return time.Since(s.LastActivityAt) > timeout
+}
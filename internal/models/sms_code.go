@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Purposes for SMS codes
+const (
+	SMSPurposePhoneVerification = "phone_verification"
+	SMSPurposeMFALogin          = "mfa_login"
+)
+
+// MFAMethodSMS is the only two-factor method so far: a code texted to the verified phone
+const MFAMethodSMS = "sms"
+
+// SMSCode is a one-time code texted to a user. Only a hash of the code is stored.
+type SMSCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	Purpose   string     `gorm:"not null;size:32" json:"purpose"` // phone_verification, mfa_login
+	Phone     string     `gorm:"not null;size:20" json:"phone"`
+	CodeHash  string     `gorm:"not null;size:64" json:"-"`
+	Attempts  int        `gorm:"not null;default:0" json:"attempts"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// BeforeCreate generates a UUID before creating a new code
+func (c *SMSCode) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = NewID()
+	}
+	return nil
+}
@@ -1,61 +1,187 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// User roles. Developers are partner accounts; operators and admins are bank staff.
+const (
+	RoleDeveloper = "developer"
+	RoleOperator  = "operator" // Runs partner onboarding: campaigns and invitations
+	RoleAdmin     = "admin"    // Everything operators can do, plus settings and role assignment
+)
+
+// Account approval statuses. Accounts only start pending when REGISTRATION_REQUIRE_APPROVAL
+// is on; every other account is approved.
+const (
+	ApprovalStatusPending  = "pending"
+	ApprovalStatusApproved = "approved"
+	ApprovalStatusRejected = "rejected"
+)
+
+// Account types. Service accounts are machine users that belong to an organization and
+// authenticate only with service account tokens.
+const (
+	AccountTypeUser    = "user"
+	AccountTypeService = "service"
+)
+
+// IsValidRole reports whether role is a known user role
+func IsValidRole(role string) bool {
+	return role == RoleDeveloper || role == RoleOperator || role == RoleAdmin
+}
+
 // User represents a developer account
 type User struct {
-	ID           uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
-	Email        string         `gorm:"uniqueIndex;not null" json:"email"`
-	PasswordHash string         `gorm:"" json:"-"`
-	FullName     string         `gorm:"not null" json:"fullName"`
-	JobTitle     string         `gorm:"" json:"jobTitle"`
-	Company      string         `gorm:"" json:"company"`
-	Provider     string         `gorm:"default:'local'" json:"provider"` // local, google
-	ProviderID   string         `gorm:"" json:"-"`
-	IsVerified   bool           `gorm:"default:false" json:"isVerified"`
-	CreatedAt    time.Time      `json:"createdAt"`
-	UpdatedAt    time.Time      `json:"updatedAt"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                    uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
+	Email                 string         `gorm:"uniqueIndex;not null" json:"email"`
+	PasswordHash          string         `gorm:"" json:"-"`
+	PasswordChangedAt     *time.Time     `json:"-"`
+	FullName              string         `gorm:"not null" json:"fullName"`
+	FirstName             string         `gorm:"size:100" json:"firstName"`
+	LastName              string         `gorm:"size:100" json:"lastName"`
+	JobTitle              string         `gorm:"" json:"jobTitle"`
+	Company               string         `gorm:"" json:"company"`
+	ProfilePicture        string         `gorm:"size:1024" json:"profilePicture"`
+	Phone                 string         `gorm:"size:20" json:"phone"` // E.164
+	PhoneVerifiedAt       *time.Time     `json:"phoneVerifiedAt"`
+	MFAMethod             string         `gorm:"size:10" json:"mfaMethod"`        // Empty when two-factor sign-in is off, or sms
+	Metadata              JSONMap        `gorm:"type:jsonb" json:"metadata"`      // Free-form attributes set by the portal frontend and integrations
+	Provider              string         `gorm:"default:'local'" json:"provider"` // local, google
+	ProviderID            string         `gorm:"" json:"-"`
+	IsVerified            bool           `gorm:"default:false" json:"isVerified"`
+	Role                  string         `gorm:"not null;default:'developer';size:20;index" json:"role"`
+	AccountType           string         `gorm:"not null;default:'user';size:20" json:"accountType"`
+	ApprovalStatus        string         `gorm:"not null;default:'approved';size:20;index" json:"approvalStatus"`
+	ApprovalReviewedAt    *time.Time     `json:"approvalReviewedAt,omitempty"`
+	ApprovalReviewedBy    *uuid.UUID     `gorm:"type:uuid" json:"approvalReviewedBy,omitempty"`
+	RejectionReason       string         `gorm:"size:500" json:"rejectionReason,omitempty"`
+	UnsubscribedAt        *time.Time     `json:"-"`                                                   // Opted out of bulk emails
+	AnonymizedAt          *time.Time     `json:"-"`                                                   // PII scrubbed after the deletion grace period
+	LockedAt              *time.Time     `json:"-"`                                                   // Locked by the owner from a security email
+	PasswordResetRequired bool           `gorm:"not null;default:false" json:"passwordResetRequired"` // Set by staff; sign-in is refused until the password is reset
+	IsSuspended           bool           `gorm:"not null;default:false" json:"isSuspended"`
+	SuspendedAt           *time.Time     `json:"suspendedAt,omitempty"`
+	SuspensionReason      string         `gorm:"size:500" json:"suspensionReason,omitempty"`
+	CreatedAt             time.Time      `json:"createdAt"`
+	UpdatedAt             time.Time      `json:"updatedAt"`
+	DeletedAt             gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
 	APIKeys []APIKey `gorm:"foreignKey:UserID" json:"-"`
 }
 
+// IsServiceAccount reports whether the user is a service account, which cannot sign in
+// interactively
+func (u *User) IsServiceAccount() bool {
+	return u.AccountType == AccountTypeService
+}
+
+// JSONMap is a JSON object stored in a PostgreSQL jsonb column. Values are kept as raw
+// JSON so numbers and nested objects round-trip unchanged.
+type JSONMap map[string]json.RawMessage
+
+// Value implements the driver.Valuer interface for database storage
+func (m JSONMap) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (m *JSONMap) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	}
+	return errors.New("type assertion to []byte failed")
+}
+
 // BeforeCreate generates a UUID before creating a new user
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
-		u.ID = uuid.New()
+		u.ID = NewLegacyID()
 	}
 	return nil
 }
 
 // UserResponse is the safe response struct without sensitive data
 type UserResponse struct {
-	ID         uuid.UUID `json:"id"`
-	Email      string    `json:"email"`
-	FullName   string    `json:"fullName"`
-	JobTitle   string    `json:"jobTitle"`
-	Company    string    `json:"company"`
-	Provider   string    `json:"provider"`
-	IsVerified bool      `json:"isVerified"`
-	CreatedAt  time.Time `json:"createdAt"`
+	ID             uuid.UUID `json:"id"`
+	Email          string    `json:"email"`
+	FullName       string    `json:"fullName"`
+	FirstName      string    `json:"firstName"`
+	LastName       string    `json:"lastName"`
+	JobTitle       string    `json:"jobTitle"`
+	Company        string    `json:"company"`
+	ProfilePicture string    `json:"profilePicture"`
+	Phone          string    `json:"phone"`
+	PhoneVerified  bool      `json:"phoneVerified"`
+	MFAMethod      string    `json:"mfaMethod"`
+	Metadata       JSONMap   `json:"metadata"`
+	Provider       string    `json:"provider"`
+	IsVerified     bool      `json:"isVerified"`
+	Role           string    `json:"role"`
+	AccountType    string    `json:"accountType"`
+	ApprovalStatus string    `json:"approvalStatus"`
+	CreatedAt      time.Time `json:"createdAt"`
+
+	// Only set on rejected accounts
+	RejectionReason string `json:"rejectionReason,omitempty"`
+
+	// Only set on suspended accounts, which only staff can see
+	IsSuspended      bool       `json:"isSuspended,omitempty"`
+	SuspendedAt      *time.Time `json:"suspendedAt,omitempty"`
+	SuspensionReason string     `json:"suspensionReason,omitempty"`
+
+	// Only set while staff require a password reset
+	PasswordResetRequired bool `json:"passwordResetRequired,omitempty"`
 }
 
 // ToResponse converts User to UserResponse
 func (u *User) ToResponse() UserResponse {
-	return UserResponse{
-		ID:         u.ID,
-		Email:      u.Email,
-		FullName:   u.FullName,
-		JobTitle:   u.JobTitle,
-		Company:    u.Company,
-		Provider:   u.Provider,
-		IsVerified: u.IsVerified,
-		CreatedAt:  u.CreatedAt,
+	response := UserResponse{
+		ID:             u.ID,
+		Email:          u.Email,
+		FullName:       u.FullName,
+		FirstName:      u.FirstName,
+		LastName:       u.LastName,
+		JobTitle:       u.JobTitle,
+		Company:        u.Company,
+		ProfilePicture: u.ProfilePicture,
+		Phone:          u.Phone,
+		PhoneVerified:  u.PhoneVerifiedAt != nil,
+		MFAMethod:      u.MFAMethod,
+		Metadata:       u.Metadata,
+		Provider:       u.Provider,
+		IsVerified:     u.IsVerified,
+		Role:           u.Role,
+		AccountType:    u.AccountType,
+		ApprovalStatus: u.ApprovalStatus,
+		CreatedAt:      u.CreatedAt,
+
+		RejectionReason: u.RejectionReason,
+
+		IsSuspended:      u.IsSuspended,
+		SuspendedAt:      u.SuspendedAt,
+		SuspensionReason: u.SuspensionReason,
+
+		PasswordResetRequired: u.PasswordResetRequired,
+	}
+	if response.Metadata == nil {
+		response.Metadata = JSONMap{}
 	}
+	return response
 }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Identity providers that can be linked to an account. Password sign-in ("local") is
+// not stored as an identity; it is the user's password hash.
+const (
+	IdentityProviderLocal  = "local"
+	IdentityProviderGoogle = "google"
+)
+
+// UserIdentity links an external login (e.g. a Google account) to a portal user. A user
+// has at most one identity per provider and an external account belongs to one user.
+type UserIdentity struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"-"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_user_identities_user_provider" json:"-"`
+	Provider   string     `gorm:"not null;size:20;uniqueIndex:idx_user_identities_user_provider;uniqueIndex:idx_user_identities_subject" json:"provider"`
+	ProviderID string     `gorm:"not null;size:255;uniqueIndex:idx_user_identities_subject" json:"-"`
+	Email      string     `gorm:"size:255" json:"email"`
+	CreatedAt  time.Time  `json:"linkedAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// BeforeCreate generates a UUID before creating a new identity
+func (i *UserIdentity) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = NewID()
+	}
+	return nil
+}
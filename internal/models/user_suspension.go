@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Suspension history actions
+const (
+	SuspensionActionSuspended   = "suspended"
+	SuspensionActionReactivated = "reactivated"
+)
+
+// UserSuspension records an admin suspending or reactivating an account, and why
+type UserSuspension struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"userId"`
+	Action    string    `gorm:"not null;size:20" json:"action"` // suspended, reactivated
+	Reason    string    `gorm:"not null;size:500" json:"reason"`
+	ActorID   uuid.UUID `gorm:"type:uuid;not null" json:"actorId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BeforeCreate generates a UUID before creating a new suspension record
+func (s *UserSuspension) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = NewID()
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// AccessRequestRepository handles database operations for access requests
+type AccessRequestRepository struct {
+	db *gorm.DB
+}
+
+// NewAccessRequestRepository creates a new AccessRequestRepository
+func NewAccessRequestRepository(db *gorm.DB) *AccessRequestRepository {
+	return &AccessRequestRepository{db: db}
+}
+
+// Create inserts a new access request into the database
+func (r *AccessRequestRepository) Create(request *models.AccessRequest) error {
+	return r.db.Create(request).Error
+}
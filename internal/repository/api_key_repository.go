@@ -1,11 +1,44 @@
 package repository
 
 import (
+	"strings"
+	"time"
+
 	"github.com/bankaceh/bas-portal-api/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// APIKeyFilter narrows a list of keys. Sort is a trusted column name to order by, newest
+// first when empty; keys missing the value sort last.
+type APIKeyFilter struct {
+	Environment string
+	Tag         string
+	Active      bool
+	Sort        string
+	Descending  bool
+}
+
+// APIKeySearch finds keys across all users for staff. Prefix matches the start of the
+// display prefix.
+type APIKeySearch struct {
+	UserID         *uuid.UUID
+	OrganizationID *uuid.UUID
+	Prefix         string
+	Environment    string
+	Active         *bool
+	Limit          int
+	Offset         int
+}
+
+// EnvironmentCount counts keys or credentials in one environment
+type EnvironmentCount struct {
+	Environment string
+	Total       int64
+	Active      int64
+}
+
 // APIKeyRepository handles database operations for API keys
 type APIKeyRepository struct {
 	db *gorm.DB
@@ -31,10 +64,10 @@ func (r *APIKeyRepository) FindByID(id uuid.UUID) (*models.APIKey, error) {
 	return &key, nil
 }
 
-// FindByUserID finds all API keys for a user
+// FindByUserID finds all personal API keys for a user
 func (r *APIKeyRepository) FindByUserID(userID uuid.UUID) ([]models.APIKey, error) {
 	var keys []models.APIKey
-	err := r.db.Where("user_id = ? AND is_active = ?", userID, true).
+	err := r.db.Where("user_id = ? AND organization_id IS NULL AND is_active = ?", userID, true).
 		Order("created_at DESC").
 		Find(&keys).Error
 	if err != nil {
@@ -43,10 +76,87 @@ func (r *APIKeyRepository) FindByUserID(userID uuid.UUID) ([]models.APIKey, erro
 	return keys, nil
 }
 
-// FindByKeyHash finds an API key by its hash (for validation)
-func (r *APIKeyRepository) FindByKeyHash(keyHash string) (*models.APIKey, error) {
+// FindForOwner finds the personal keys of a user, or the keys of an organization when
+// orgID is set, that match the filter
+func (r *APIKeyRepository) FindForOwner(userID uuid.UUID, orgID *uuid.UUID, filter APIKeyFilter) ([]models.APIKey, error) {
+	query := r.db.Where("is_active = ?", filter.Active)
+	if orgID != nil {
+		query = query.Where("organization_id = ?", *orgID)
+	} else {
+		query = query.Where("user_id = ? AND organization_id IS NULL", userID)
+	}
+	if filter.Environment != "" {
+		query = query.Where("environment = ?", filter.Environment)
+	}
+	if filter.Tag != "" {
+		query = query.Where("tags @> ?::jsonb", models.StringArray{filter.Tag})
+	}
+
+	order := "created_at DESC"
+	if filter.Sort != "" {
+		order = filter.Sort + " ASC NULLS LAST"
+		if filter.Descending {
+			order = filter.Sort + " DESC NULLS LAST"
+		}
+	}
+
+	var keys []models.APIKey
+	err := query.Order(order).
+		Order("id").
+		Find(&keys).Error
+	return keys, err
+}
+
+// Search finds keys matching the search with their owners, newest first, and counts all
+// matches
+func (r *APIKeyRepository) Search(search APIKeySearch) ([]models.APIKey, int64, error) {
+	query := r.db.Model(&models.APIKey{})
+	if search.UserID != nil {
+		query = query.Where("user_id = ?", *search.UserID)
+	}
+	if search.OrganizationID != nil {
+		query = query.Where("organization_id = ?", *search.OrganizationID)
+	}
+	if search.Prefix != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(search.Prefix)
+		query = query.Where("key_prefix LIKE ?", escaped+"%")
+	}
+	if search.Environment != "" {
+		query = query.Where("environment = ?", search.Environment)
+	}
+	if search.Active != nil {
+		query = query.Where("is_active = ?", *search.Active)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var keys []models.APIKey
+	err := query.Preload("User").
+		Order("created_at DESC").
+		Limit(search.Limit).
+		Offset(search.Offset).
+		Find(&keys).Error
+	return keys, total, err
+}
+
+// FindAllCreatedBy finds every API key the user created, including revoked keys and keys
+// of organizations, for data exports
+func (r *APIKeyRepository) FindAllCreatedBy(userID uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Unscoped().Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// FindByKeyDigest finds an active, unexpired API key by its SHA-256 digest (for validation)
+func (r *APIKeyRepository) FindByKeyDigest(digest string) (*models.APIKey, error) {
 	var key models.APIKey
-	err := r.db.Where("key_hash = ? AND is_active = ?", keyHash, true).
+	err := r.db.Where("key_digest = ? AND is_active = ?", digest, true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
 		Preload("User").
 		First(&key).Error
 	if err != nil {
@@ -55,23 +165,182 @@ func (r *APIKeyRepository) FindByKeyHash(keyHash string) (*models.APIKey, error)
 	return &key, nil
 }
 
+// FindAnyByKeyDigest finds a key by its digest whatever its status
+func (r *APIKeyRepository) FindAnyByKeyDigest(digest string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.Where("key_digest = ?", digest).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// FindAnyLegacyByPrefix finds keys with the display prefix that have no digest, whatever
+// their status
+func (r *APIKeyRepository) FindAnyLegacyByPrefix(prefix string) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("key_prefix = ? AND key_digest = ''", prefix).Find(&keys).Error
+	return keys, err
+}
+
+// FindLegacyByPrefix finds active, unexpired keys with the display prefix that were
+// created before digests were stored
+func (r *APIKeyRepository) FindLegacyByPrefix(prefix string) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("key_prefix = ? AND key_digest = '' AND is_active = ?", prefix, true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Preload("User").
+		Find(&keys).Error
+	return keys, err
+}
+
+// AddUsage adds requests to a key's count and records when and from where it was last used
+func (r *APIKeyRepository) AddUsage(id uuid.UUID, requests int64, lastUsedAt time.Time, ip, userAgent string) error {
+	return r.db.Model(&models.APIKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"request_count":   gorm.Expr("request_count + ?", requests),
+			"last_used_at":    lastUsedAt,
+			"last_used_ip":    ip,
+			"last_user_agent": userAgent,
+		}).Error
+}
+
+// SetDigest stores the digest of a legacy key once it has been matched
+func (r *APIKeyRepository) SetDigest(id uuid.UUID, digest string) error {
+	return r.db.Model(&models.APIKey{}).
+		Where("id = ?", id).
+		Update("key_digest", digest).Error
+}
+
+// UpdateDetails saves a key's editable settings, leaving usage counters to AddUsage
+func (r *APIKeyRepository) UpdateDetails(apiKey *models.APIKey) error {
+	return r.db.Model(apiKey).
+		Select("name", "description", "tags", "expires_at", "rate_limit", "ip_allowlist").
+		Updates(apiKey).Error
+}
+
 // Update updates an existing API key
 func (r *APIKeyRepository) Update(apiKey *models.APIKey) error {
 	return r.db.Save(apiKey).Error
 }
 
-// Revoke deactivates an API key
-func (r *APIKeyRepository) Revoke(id, userID uuid.UUID) error {
-	return r.db.Model(&models.APIKey{}).
-		Where("id = ? AND user_id = ?", id, userID).
-		Update("is_active", false).Error
+// Revoke deactivates an API key that is not revoked yet, reporting whether it was. A
+// revoked key keeps its first revocation time, so the reactivation window cannot be
+// extended by revoking it again.
+func (r *APIKeyRepository) Revoke(id, userID uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Updates(map[string]interface{}{"is_active": false, "revoked_at": gorm.Expr("NOW()")})
+	return result.RowsAffected == 1, result.Error
 }
 
-// CountByUserID counts active API keys for a user
+// Reactivate turns a revoked key back on if it is still revoked and was not rotated,
+// reporting whether it was
+func (r *APIKeyRepository) Reactivate(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND is_active = ? AND revoked_at IS NOT NULL AND replaced_by_id IS NULL", id, false).
+		Updates(map[string]interface{}{"is_active": true, "revoked_at": nil})
+	return result.RowsAffected == 1, result.Error
+}
+
+// FindExpiring finds active keys that expire after now and by until, with their owners.
+// Rotated keys are left out; their replacement is already in use.
+func (r *APIKeyRepository) FindExpiring(now, until time.Time) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("is_active = ? AND replaced_by_id IS NULL", true).
+		Where("expires_at > ? AND expires_at <= ?", now, until).
+		Preload("User").
+		Order("expires_at ASC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// FindExpired finds active keys whose expiry has passed at now
+func (r *APIKeyRepository) FindExpired(now time.Time) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("is_active = ? AND expires_at <= ?", true, now).
+		Order("expires_at ASC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// Expire deactivates a key if it is still active, reporting whether it was
+func (r *APIKeyRepository) Expire(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND is_active = ?", id, true).
+		Update("is_active", false)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Rotate makes a key expire at graceEndsAt, or keeps its earlier expiry, and stores its
+// replacement in one transaction. A grace that has already ended deactivates the key at
+// once. replacement.ID must be set. It reports false if the key was inactive or already
+// rotated.
+func (r *APIKeyRepository) Rotate(id uuid.UUID, replacement *models.APIKey, graceEndsAt time.Time) (bool, error) {
+	rotated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"replaced_by_id": replacement.ID,
+			"expires_at":     gorm.Expr("LEAST(COALESCE(expires_at, ?), ?)", graceEndsAt, graceEndsAt),
+		}
+		if !graceEndsAt.After(time.Now()) {
+			updates["is_active"] = false
+		}
+		result := tx.Model(&models.APIKey{}).
+			Where("id = ? AND is_active = ? AND replaced_by_id IS NULL", id, true).
+			Updates(updates)
+		if result.Error != nil || result.RowsAffected != 1 {
+			return result.Error
+		}
+		if err := tx.Create(replacement).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
+// RevokeAllForUser deactivates every active personal API key a user owns in a single
+// statement and returns the keys it revoked. Keys they created for an organization stay
+// with the organization.
+func (r *APIKeyRepository) RevokeAllForUser(userID uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Model(&keys).
+		Clauses(clause.Returning{}).
+		Where("user_id = ? AND organization_id IS NULL AND is_active = ?", userID, true).
+		Updates(map[string]interface{}{"is_active": false, "revoked_at": gorm.Expr("NOW()")}).Error
+	return keys, err
+}
+
+// CountByUserID counts active personal API keys for a user
 func (r *APIKeyRepository) CountByUserID(userID uuid.UUID) (int64, error) {
 	var count int64
 	err := r.db.Model(&models.APIKey{}).
-		Where("user_id = ? AND is_active = ?", userID, true).
+		Where("user_id = ? AND organization_id IS NULL AND is_active = ?", userID, true).
+		Count(&count).Error
+	return count, err
+}
+
+// CountByOrganizationID counts active API keys owned by an organization
+func (r *APIKeyRepository) CountByOrganizationID(orgID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.APIKey{}).
+		Where("organization_id = ? AND is_active = ?", orgID, true).
 		Count(&count).Error
 	return count, err
 }
+
+// CountByEnvironment counts API keys per environment. Active keys are neither revoked
+// nor expired.
+func (r *APIKeyRepository) CountByEnvironment() ([]EnvironmentCount, error) {
+	var counts []EnvironmentCount
+	err := r.db.Model(&models.APIKey{}).
+		Select("environment, COUNT(*) AS total, " +
+			"COUNT(*) FILTER (WHERE is_active AND (expires_at IS NULL OR expires_at > NOW())) AS active").
+		Group("environment").
+		Order("environment ASC").
+		Scan(&counts).Error
+	return counts, err
+}
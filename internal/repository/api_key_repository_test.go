@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bankaceh/bas-portal-api/internal/database"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDB connects to the database in TEST_DATABASE_URL, skipping the test when it is unset
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestAPIKeyRepositoryUpdateDetailsSavesTags(t *testing.T) {
+	db := testDB(t)
+	repo := NewAPIKeyRepository(db)
+
+	user := &models.User{Email: uuid.NewString() + "@example.com", FullName: "Tag Test"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	t.Cleanup(func() { db.Unscoped().Delete(user) })
+
+	key := &models.APIKey{
+		UserID:    user.ID,
+		Name:      "tagged",
+		Tags:      models.StringArray{"billing"},
+		KeyPrefix: "bas_test_00000000",
+		KeyHash:   "hash",
+	}
+	if err := repo.Create(key); err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	t.Cleanup(func() { db.Unscoped().Delete(key) })
+
+	key.Name = "renamed"
+	key.Tags = models.StringArray{"ci", "payments"}
+	if err := repo.UpdateDetails(key); err != nil {
+		t.Fatalf("update details: %v", err)
+	}
+
+	reloaded, err := repo.FindByID(key.ID)
+	if err != nil {
+		t.Fatalf("reload key: %v", err)
+	}
+	if reloaded.Name != "renamed" {
+		t.Errorf("name = %q, want %q", reloaded.Name, "renamed")
+	}
+	if len(reloaded.Tags) != 2 || reloaded.Tags[0] != "ci" || reloaded.Tags[1] != "payments" {
+		t.Errorf("tags = %v, want [ci payments]", reloaded.Tags)
+	}
+}
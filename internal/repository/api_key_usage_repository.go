@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UsageCount is the requests and errors in one bucket of a usage series
+type UsageCount struct {
+	Bucket   time.Time
+	Requests int64
+	Errors   int64
+}
+
+// APIKeyUsageRepository handles database operations for hourly API key usage
+type APIKeyUsageRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyUsageRepository creates a new APIKeyUsageRepository
+func NewAPIKeyUsageRepository(db *gorm.DB) *APIKeyUsageRepository {
+	return &APIKeyUsageRepository{db: db}
+}
+
+// Add adds requests and errors to a key's hourly bucket, creating it if needed
+func (r *APIKeyUsageRepository) Add(usage *models.APIKeyUsage) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key_id"}, {Name: "bucket_start"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests": gorm.Expr("api_key_usages.requests + EXCLUDED.requests"),
+			"errors":   gorm.Expr("api_key_usages.errors + EXCLUDED.errors"),
+		}),
+	}).Create(usage).Error
+}
+
+// Series sums a key's usage per interval ("hour" or "day") in [from, to). Intervals
+// without requests are left out.
+func (r *APIKeyUsageRepository) Series(keyID uuid.UUID, from, to time.Time, interval string) ([]UsageCount, error) {
+	var counts []UsageCount
+	err := r.db.Model(&models.APIKeyUsage{}).
+		Select("date_trunc(?, bucket_start AT TIME ZONE 'UTC') AS bucket, SUM(requests) AS requests, SUM(errors) AS errors", interval).
+		Where("key_id = ? AND bucket_start >= ? AND bucket_start < ?", keyID, from, to).
+		Group("bucket").
+		Order("bucket ASC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// DeleteBefore removes buckets older than a time
+func (r *APIKeyUsageRepository) DeleteBefore(cutoff time.Time) error {
+	return r.db.Where("bucket_start < ?", cutoff).Delete(&models.APIKeyUsage{}).Error
+}
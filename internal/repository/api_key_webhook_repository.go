@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKeyWebhookRepository handles database operations for users' API key webhooks
+type APIKeyWebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyWebhookRepository creates a new APIKeyWebhookRepository
+func NewAPIKeyWebhookRepository(db *gorm.DB) *APIKeyWebhookRepository {
+	return &APIKeyWebhookRepository{db: db}
+}
+
+// FindByUserID returns the user's webhook
+func (r *APIKeyWebhookRepository) FindByUserID(userID uuid.UUID) (*models.APIKeyWebhook, error) {
+	var webhook models.APIKeyWebhook
+	err := r.db.Where("user_id = ?", userID).First(&webhook).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// Save creates or updates a webhook
+func (r *APIKeyWebhookRepository) Save(webhook *models.APIKeyWebhook) error {
+	return r.db.Save(webhook).Error
+}
+
+// DeleteByUserID removes the user's webhook
+func (r *APIKeyWebhookRepository) DeleteByUserID(userID uuid.UUID) (int64, error) {
+	result := r.db.Where("user_id = ?", userID).Delete(&models.APIKeyWebhook{})
+	return result.RowsAffected, result.Error
+}
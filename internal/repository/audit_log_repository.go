@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLogFilter narrows an audit log search. Zero values match everything.
+type AuditLogFilter struct {
+	ActorID      *uuid.UUID
+	Action       string
+	ResourceType string
+	ResourceID   string
+	RequestID    string
+	From         *time.Time
+	To           *time.Time
+	Limit        int
+	Offset       int
+}
+
+// AuditLogRepository handles database operations for audit logs
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository creates a new AuditLogRepository
+func NewAuditLogRepository(db *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// Create inserts a new audit log entry
+func (r *AuditLogRepository) Create(entry *models.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+// Find lists audit log entries matching the filter, newest first, with the total match count
+func (r *AuditLogRepository) Find(filter AuditLogFilter) ([]models.AuditLog, int64, error) {
+	query := r.db.Model(&models.AuditLog{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ResourceType != "" {
+		query = query.Where("resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != "" {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.AuditLog
+	err := query.Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&entries).Error
+	return entries, total, err
+}
+
+// CountSince counts entries with an action recorded since a time
+func (r *AuditLogRepository) CountSince(action string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.AuditLog{}).
+		Where("action = ? AND created_at >= ?", action, since).
+		Count(&count).Error
+	return count, err
+}
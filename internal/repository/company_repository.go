@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CompanyRepository handles database operations for company profiles and their documents
+type CompanyRepository struct {
+	db *gorm.DB
+}
+
+// NewCompanyRepository creates a new CompanyRepository
+func NewCompanyRepository(db *gorm.DB) *CompanyRepository {
+	return &CompanyRepository{db: db}
+}
+
+// ownedBy scopes a query to the personal profile of a user, or an organization's profile
+func ownedBy(userID uuid.UUID, orgID *uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if orgID != nil {
+			return db.Where("organization_id = ?", *orgID)
+		}
+		return db.Where("user_id = ? AND organization_id IS NULL", userID)
+	}
+}
+
+// Create inserts a new company profile into the database
+func (r *CompanyRepository) Create(profile *models.CompanyProfile) error {
+	return r.db.Create(profile).Error
+}
+
+// Update saves changes to a company profile
+func (r *CompanyRepository) Update(profile *models.CompanyProfile) error {
+	return r.db.Omit("Documents").Save(profile).Error
+}
+
+// FindByOwner finds the profile of a user or organization, with its documents
+func (r *CompanyRepository) FindByOwner(userID uuid.UUID, orgID *uuid.UUID) (*models.CompanyProfile, error) {
+	var profile models.CompanyProfile
+	err := r.db.Preload("Documents", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Scopes(ownedBy(userID, orgID)).First(&profile).Error
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// FindByID finds a profile by ID, with its documents
+func (r *CompanyRepository) FindByID(id uuid.UUID) (*models.CompanyProfile, error) {
+	var profile models.CompanyProfile
+	err := r.db.Preload("Documents", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).First(&profile, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// FindByStatus lists profiles in a status, the longest waiting first
+func (r *CompanyRepository) FindByStatus(status string) ([]models.CompanyProfile, error) {
+	var profiles []models.CompanyProfile
+	err := r.db.Where("status = ?", status).
+		Order("submitted_at ASC NULLS LAST, created_at ASC").
+		Find(&profiles).Error
+	return profiles, err
+}
+
+// IsVerified reports whether the user's or organization's company is verified
+func (r *CompanyRepository) IsVerified(userID uuid.UUID, orgID *uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.CompanyProfile{}).
+		Scopes(ownedBy(userID, orgID)).
+		Where("status = ?", models.CompanyStatusVerified).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Submit moves a draft or rejected profile into the review queue, reporting whether it
+// was still in one of those states
+func (r *CompanyRepository) Submit(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.CompanyProfile{}).
+		Where("id = ? AND status IN ?", id, []string{models.CompanyStatusDraft, models.CompanyStatusRejected}).
+		Updates(map[string]interface{}{
+			"status":           models.CompanyStatusPending,
+			"submitted_at":     gorm.Expr("NOW()"),
+			"reviewed_at":      nil,
+			"reviewed_by":      nil,
+			"rejection_reason": "",
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// Review records a decision on a pending profile, reporting whether it was still pending
+func (r *CompanyRepository) Review(id, reviewerID uuid.UUID, status, reason string) (bool, error) {
+	result := r.db.Model(&models.CompanyProfile{}).
+		Where("id = ? AND status = ?", id, models.CompanyStatusPending).
+		Updates(map[string]interface{}{
+			"status":           status,
+			"reviewed_at":      gorm.Expr("NOW()"),
+			"reviewed_by":      reviewerID,
+			"rejection_reason": reason,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// CreateDocument records an uploaded document
+func (r *CompanyRepository) CreateDocument(document *models.CompanyDocument) error {
+	return r.db.Create(document).Error
+}
+
+// FindDocument finds a document of a profile
+func (r *CompanyRepository) FindDocument(companyID, documentID uuid.UUID) (*models.CompanyDocument, error) {
+	var document models.CompanyDocument
+	err := r.db.Where("id = ? AND company_id = ?", documentID, companyID).First(&document).Error
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
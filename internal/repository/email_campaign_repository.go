@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailCampaignRepository handles database operations for email campaigns
+type EmailCampaignRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailCampaignRepository creates a new EmailCampaignRepository
+func NewEmailCampaignRepository(db *gorm.DB) *EmailCampaignRepository {
+	return &EmailCampaignRepository{db: db}
+}
+
+// Create inserts a new campaign into the database
+func (r *EmailCampaignRepository) Create(campaign *models.EmailCampaign) error {
+	return r.db.Create(campaign).Error
+}
+
+// FindByID finds a campaign by its UUID
+func (r *EmailCampaignRepository) FindByID(id uuid.UUID) (*models.EmailCampaign, error) {
+	var campaign models.EmailCampaign
+	err := r.db.Where("id = ?", id).First(&campaign).Error
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// FindAll lists all campaigns, newest first
+func (r *EmailCampaignRepository) FindAll() ([]models.EmailCampaign, error) {
+	var campaigns []models.EmailCampaign
+	err := r.db.Order("created_at DESC").Find(&campaigns).Error
+	if err != nil {
+		return nil, err
+	}
+	return campaigns, nil
+}
+
+// FindDue finds scheduled campaigns whose send time has passed
+func (r *EmailCampaignRepository) FindDue() ([]models.EmailCampaign, error) {
+	var campaigns []models.EmailCampaign
+	err := r.db.Where("status = ? AND scheduled_at <= NOW()", models.CampaignStatusScheduled).
+		Order("scheduled_at ASC").
+		Find(&campaigns).Error
+	if err != nil {
+		return nil, err
+	}
+	return campaigns, nil
+}
+
+// Update updates an existing campaign
+func (r *EmailCampaignRepository) Update(campaign *models.EmailCampaign) error {
+	return r.db.Save(campaign).Error
+}
+
+// ClaimForSending moves a scheduled campaign to sending and reports whether this caller won it
+func (r *EmailCampaignRepository) ClaimForSending(id uuid.UUID, totalRecipients int64) (bool, error) {
+	result := r.db.Model(&models.EmailCampaign{}).
+		Where("id = ? AND status = ?", id, models.CampaignStatusScheduled).
+		Updates(map[string]interface{}{
+			"status":           models.CampaignStatusSending,
+			"started_at":       gorm.Expr("NOW()"),
+			"total_recipients": totalRecipients,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// IncrementCounts adds to the sent and failed counters of a campaign
+func (r *EmailCampaignRepository) IncrementCounts(id uuid.UUID, sent, failed int64) error {
+	return r.db.Model(&models.EmailCampaign{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"sent_count":   gorm.Expr("sent_count + ?", sent),
+			"failed_count": gorm.Expr("failed_count + ?", failed),
+		}).Error
+}
+
+// Finish sets the final status of a campaign
+func (r *EmailCampaignRepository) Finish(id uuid.UUID, status string) error {
+	return r.db.Model(&models.EmailCampaign{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"completed_at": gorm.Expr("NOW()"),
+		}).Error
+}
+
+// CreateDelivery records the outcome of sending a campaign to one user
+func (r *EmailCampaignRepository) CreateDelivery(delivery *models.EmailCampaignDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+// CountRecipients counts users who have not opted out of bulk emails
+func (r *EmailCampaignRepository) CountRecipients() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).
+		Where("unsubscribed_at IS NULL").
+		Count(&count).Error
+	return count, err
+}
+
+// FindPendingRecipients finds subscribed users who have no delivery record for the campaign yet
+func (r *EmailCampaignRepository) FindPendingRecipients(campaignID uuid.UUID, limit int) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("unsubscribed_at IS NULL").
+		Where("NOT EXISTS (SELECT 1 FROM email_campaign_deliveries d WHERE d.campaign_id = ? AND d.user_id = users.id)", campaignID).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailDomainRuleRepository handles database operations for sign-up domain rules
+type EmailDomainRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailDomainRuleRepository creates a new EmailDomainRuleRepository
+func NewEmailDomainRuleRepository(db *gorm.DB) *EmailDomainRuleRepository {
+	return &EmailDomainRuleRepository{db: db}
+}
+
+// Create inserts a new rule into the database
+func (r *EmailDomainRuleRepository) Create(rule *models.EmailDomainRule) error {
+	return r.db.Create(rule).Error
+}
+
+// FindActive lists the rules currently in force
+func (r *EmailDomainRuleRepository) FindActive() ([]models.EmailDomainRule, error) {
+	var rules []models.EmailDomainRule
+	err := r.db.Order("domain ASC").Find(&rules).Error
+	return rules, err
+}
+
+// FindAllWithDeleted lists every rule ever created, newest first, as an audit trail
+func (r *EmailDomainRuleRepository) FindAllWithDeleted() ([]models.EmailDomainRule, error) {
+	var rules []models.EmailDomainRule
+	err := r.db.Unscoped().Order("created_at DESC").Find(&rules).Error
+	return rules, err
+}
+
+// ExistsActive checks whether an active rule of the type already covers the domain
+func (r *EmailDomainRuleRepository) ExistsActive(domain, ruleType string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.EmailDomainRule{}).
+		Where("domain = ? AND type = ?", domain, ruleType).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Delete soft deletes a rule, recording which admin removed it
+func (r *EmailDomainRuleRepository) Delete(id, deletedBy uuid.UUID) (*models.EmailDomainRule, error) {
+	var rule models.EmailDomainRule
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&rule).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&rule).Update("deleted_by", deletedBy).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&rule).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExpiryReminderRepository handles database operations for sent expiry reminders
+type ExpiryReminderRepository struct {
+	db *gorm.DB
+}
+
+// NewExpiryReminderRepository creates a new ExpiryReminderRepository
+func NewExpiryReminderRepository(db *gorm.DB) *ExpiryReminderRepository {
+	return &ExpiryReminderRepository{db: db}
+}
+
+// Record stores a reminder, reporting false if it was already recorded
+func (r *ExpiryReminderRepository) Record(reminder *models.ExpiryReminder) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+	return result.RowsAffected == 1, result.Error
+}
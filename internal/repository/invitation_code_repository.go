@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InvitationCodeRepository handles database operations for invitation codes
+type InvitationCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewInvitationCodeRepository creates a new InvitationCodeRepository
+func NewInvitationCodeRepository(db *gorm.DB) *InvitationCodeRepository {
+	return &InvitationCodeRepository{db: db}
+}
+
+// Create inserts a new invitation code into the database
+func (r *InvitationCodeRepository) Create(code *models.InvitationCode) error {
+	return r.db.Create(code).Error
+}
+
+// FindAll lists invitation codes, newest first
+func (r *InvitationCodeRepository) FindAll() ([]models.InvitationCode, error) {
+	var codes []models.InvitationCode
+	err := r.db.Order("created_at DESC").Find(&codes).Error
+	return codes, err
+}
+
+// FindByID finds an invitation code by ID
+func (r *InvitationCodeRepository) FindByID(id uuid.UUID) (*models.InvitationCode, error) {
+	var code models.InvitationCode
+	err := r.db.Where("id = ?", id).First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// Redeem atomically uses one redemption of a usable code, reporting whether it succeeded
+func (r *InvitationCodeRepository) Redeem(codeHash string, now time.Time) (bool, error) {
+	result := r.db.Model(&models.InvitationCode{}).
+		Where("code_hash = ? AND revoked_at IS NULL AND expires_at > ? AND use_count < max_uses", codeHash, now).
+		Update("use_count", gorm.Expr("use_count + 1"))
+	return result.RowsAffected == 1, result.Error
+}
+
+// Release gives back a redemption when the registration it was used for failed
+func (r *InvitationCodeRepository) Release(codeHash string) error {
+	return r.db.Model(&models.InvitationCode{}).
+		Where("code_hash = ? AND use_count > 0", codeHash).
+		Update("use_count", gorm.Expr("use_count - 1")).Error
+}
+
+// Revoke marks an invitation code as revoked
+func (r *InvitationCodeRepository) Revoke(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.InvitationCode{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", gorm.Expr("NOW()"))
+	return result.RowsAffected == 1, result.Error
+}
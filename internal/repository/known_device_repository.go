@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KnownDeviceRepository handles database operations for users' known devices
+type KnownDeviceRepository struct {
+	db *gorm.DB
+}
+
+// NewKnownDeviceRepository creates a new KnownDeviceRepository
+func NewKnownDeviceRepository(db *gorm.DB) *KnownDeviceRepository {
+	return &KnownDeviceRepository{db: db}
+}
+
+// CountByUserID counts the devices a user has signed in from
+func (r *KnownDeviceRepository) CountByUserID(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.KnownDevice{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// Record inserts the device or refreshes its last sighting, reporting whether it was new
+func (r *KnownDeviceRepository) Record(device *models.KnownDevice) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(device)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	err := r.db.Model(&models.KnownDevice{}).
+		Where("user_id = ? AND fingerprint = ?", device.UserID, device.Fingerprint).
+		Updates(map[string]interface{}{
+			"last_ip":      device.LastIP,
+			"last_seen_at": device.LastSeenAt,
+		}).Error
+	return false, err
+}
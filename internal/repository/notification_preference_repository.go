@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationPreferenceRepository handles database operations for notification preferences
+type NotificationPreferenceRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationPreferenceRepository creates a new NotificationPreferenceRepository
+func NewNotificationPreferenceRepository(db *gorm.DB) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{db: db}
+}
+
+// FindByUserID returns a user's preferences, or the defaults if they never saved any
+func (r *NotificationPreferenceRepository) FindByUserID(userID uuid.UUID) (*models.NotificationPreference, error) {
+	var preference models.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).First(&preference).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.DefaultNotificationPreference(userID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &preference, nil
+}
+
+// Save inserts or replaces a user's preferences
+func (r *NotificationPreferenceRepository) Save(preference *models.NotificationPreference) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"key_expiry", "security_alerts", "updated_at"}),
+	}).Create(preference).Error
+}
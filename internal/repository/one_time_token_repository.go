@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OneTimeTokenRepository handles database operations for one-time tokens
+type OneTimeTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewOneTimeTokenRepository creates a new OneTimeTokenRepository
+func NewOneTimeTokenRepository(db *gorm.DB) *OneTimeTokenRepository {
+	return &OneTimeTokenRepository{db: db}
+}
+
+// Create inserts a new one-time token into the database
+func (r *OneTimeTokenRepository) Create(token *models.OneTimeToken) error {
+	return r.db.Create(token).Error
+}
+
+// Consume marks an unused, unexpired token as used and reports whether it was consumed
+func (r *OneTimeTokenRepository) Consume(id uuid.UUID, purpose string) (bool, error) {
+	result := r.db.Model(&models.OneTimeToken{}).
+		Where("id = ? AND purpose = ? AND used_at IS NULL AND expires_at > NOW()", id, purpose).
+		Update("used_at", gorm.Expr("NOW()"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
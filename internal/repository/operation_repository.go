@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OperationRepository handles database operations for long-running operations
+type OperationRepository struct {
+	db *gorm.DB
+}
+
+// NewOperationRepository creates a new OperationRepository
+func NewOperationRepository(db *gorm.DB) *OperationRepository {
+	return &OperationRepository{db: db}
+}
+
+// Create inserts a new operation into the database
+func (r *OperationRepository) Create(operation *models.Operation) error {
+	return r.db.Create(operation).Error
+}
+
+// FindByIDAndUserID finds an operation that belongs to a user
+func (r *OperationRepository) FindByIDAndUserID(id, userID uuid.UUID) (*models.Operation, error) {
+	var operation models.Operation
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&operation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &operation, nil
+}
+
+// FindLatest finds the user's most recent operation of a type
+func (r *OperationRepository) FindLatest(userID uuid.UUID, opType string) (*models.Operation, error) {
+	var operation models.Operation
+	err := r.db.Where("user_id = ? AND type = ?", userID, opType).
+		Order("created_at DESC").
+		First(&operation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &operation, nil
+}
+
+// ClaimPending marks up to limit pending operations as running and returns them.
+// Rows locked by another worker are skipped, so each operation runs once.
+func (r *OperationRepository) ClaimPending(limit int) ([]models.Operation, error) {
+	var operations []models.Operation
+	err := r.db.Raw(`
+		UPDATE operations SET status = ?, started_at = NOW(), updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM operations WHERE status = ?
+			ORDER BY created_at LIMIT ? FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		models.OperationStatusRunning, models.OperationStatusPending, limit,
+	).Scan(&operations).Error
+	return operations, err
+}
+
+// UpdateProgress records how far a running operation has got
+func (r *OperationRepository) UpdateProgress(id uuid.UUID, progress int) error {
+	return r.db.Model(&models.Operation{}).
+		Where("id = ? AND status = ?", id, models.OperationStatusRunning).
+		Update("progress", progress).Error
+}
+
+// Succeed marks an operation as finished and stores its result
+func (r *OperationRepository) Succeed(id uuid.UUID, result string) error {
+	return r.db.Model(&models.Operation{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       models.OperationStatusSucceeded,
+		"progress":     100,
+		"result":       gorm.Expr("NULLIF(?, '')::jsonb", result),
+		"completed_at": gorm.Expr("NOW()"),
+	}).Error
+}
+
+// Fail marks an operation as failed with a user-facing error message
+func (r *OperationRepository) Fail(id uuid.UUID, message string) error {
+	return r.db.Model(&models.Operation{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       models.OperationStatusFailed,
+		"error":        message,
+		"completed_at": gorm.Expr("NOW()"),
+	}).Error
+}
+
+// FailStale fails operations that have been running since before cutoff, e.g. after a crash
+func (r *OperationRepository) FailStale(cutoff time.Time, message string) (int64, error) {
+	result := r.db.Model(&models.Operation{}).
+		Where("status = ? AND started_at < ?", models.OperationStatusRunning, cutoff).
+		Updates(map[string]interface{}{
+			"status":       models.OperationStatusFailed,
+			"error":        message,
+			"completed_at": gorm.Expr("NOW()"),
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// pendingInvitation scopes a query to invitations that can still be accepted
+func pendingInvitation(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("organization_invitations.accepted_at IS NULL AND organization_invitations.declined_at IS NULL AND organization_invitations.revoked_at IS NULL AND organization_invitations.expires_at > ?", now)
+	}
+}
+
+// OrganizationInvitationRepository handles database operations for organization invitations
+type OrganizationInvitationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationInvitationRepository creates a new OrganizationInvitationRepository
+func NewOrganizationInvitationRepository(db *gorm.DB) *OrganizationInvitationRepository {
+	return &OrganizationInvitationRepository{db: db}
+}
+
+// Create inserts a new invitation into the database
+func (r *OrganizationInvitationRepository) Create(invitation *models.OrganizationInvitation) error {
+	return r.db.Create(invitation).Error
+}
+
+// FindPendingByTokenHash finds a pending invitation by its token hash, with its organization loaded
+func (r *OrganizationInvitationRepository) FindPendingByTokenHash(tokenHash string, now time.Time) (*models.OrganizationInvitation, error) {
+	var invitation models.OrganizationInvitation
+	err := r.db.Joins("Organization").
+		Scopes(pendingInvitation(now)).
+		Where("organization_invitations.token_hash = ?", tokenHash).
+		First(&invitation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// FindPendingByOrganizationID lists an organization's pending invitations, newest first
+func (r *OrganizationInvitationRepository) FindPendingByOrganizationID(orgID uuid.UUID, now time.Time) ([]models.OrganizationInvitation, error) {
+	var invitations []models.OrganizationInvitation
+	err := r.db.Scopes(pendingInvitation(now)).
+		Where("organization_id = ?", orgID).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+// Revoke revokes a pending invitation of an organization, reporting whether one was found
+func (r *OrganizationInvitationRepository) Revoke(id, orgID uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.OrganizationInvitation{}).
+		Scopes(pendingInvitation(time.Now())).
+		Where("id = ? AND organization_id = ?", id, orgID).
+		Update("revoked_at", gorm.Expr("NOW()"))
+	return result.RowsAffected == 1, result.Error
+}
+
+// RevokePendingForEmail revokes earlier pending invitations to the same address, so only
+// the newest link works
+func (r *OrganizationInvitationRepository) RevokePendingForEmail(orgID uuid.UUID, email string) error {
+	return r.db.Model(&models.OrganizationInvitation{}).
+		Scopes(pendingInvitation(time.Now())).
+		Where("organization_id = ? AND LOWER(email) = LOWER(?)", orgID, email).
+		Update("revoked_at", gorm.Expr("NOW()")).Error
+}
+
+// Decline marks a pending invitation as declined, reporting whether it was still pending
+func (r *OrganizationInvitationRepository) Decline(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.OrganizationInvitation{}).
+		Scopes(pendingInvitation(time.Now())).
+		Where("id = ?", id).
+		Update("declined_at", gorm.Expr("NOW()"))
+	return result.RowsAffected == 1, result.Error
+}
+
+// Accept marks a pending invitation as accepted and adds the membership in one
+// transaction. It reports false if the invitation was no longer pending.
+func (r *OrganizationInvitationRepository) Accept(id uuid.UUID, member *models.OrganizationMember) (bool, error) {
+	accepted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.OrganizationInvitation{}).
+			Scopes(pendingInvitation(time.Now())).
+			Where("id = ?", id).
+			Update("accepted_at", gorm.Expr("NOW()"))
+		if result.Error != nil || result.RowsAffected != 1 {
+			return result.Error
+		}
+		if err := tx.Create(member).Error; err != nil {
+			return err
+		}
+		accepted = true
+		return nil
+	})
+	return accepted, err
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrganizationRepository handles database operations for organizations and their members
+type OrganizationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository creates a new OrganizationRepository
+func NewOrganizationRepository(db *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// Create inserts a new organization together with its first owner
+func (r *OrganizationRepository) Create(org *models.Organization, owner *models.OrganizationMember) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		owner.OrganizationID = org.ID
+		return tx.Create(owner).Error
+	})
+}
+
+// Update saves changes to an organization
+func (r *OrganizationRepository) Update(org *models.Organization) error {
+	return r.db.Save(org).Error
+}
+
+// Delete soft deletes an organization, removes its memberships and revokes its pending invitations
+func (r *OrganizationRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ?", id).Delete(&models.OrganizationMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.OrganizationInvitation{}).
+			Where("organization_id = ? AND accepted_at IS NULL AND declined_at IS NULL AND revoked_at IS NULL", id).
+			Update("revoked_at", gorm.Expr("NOW()")).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Organization{}).Error
+	})
+}
+
+// FindByID finds an organization by ID
+func (r *OrganizationRepository) FindByID(id uuid.UUID) (*models.Organization, error) {
+	var org models.Organization
+	if err := r.db.Where("id = ?", id).First(&org).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// FindMembership finds a user's membership in an organization, with the organization loaded
+func (r *OrganizationRepository) FindMembership(orgID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := r.db.Joins("Organization").
+		Where("organization_members.organization_id = ? AND organization_members.user_id = ?", orgID, userID).
+		First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// FindMembershipsByUserID lists a user's memberships, with their organizations loaded
+func (r *OrganizationRepository) FindMembershipsByUserID(userID uuid.UUID) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := r.db.Joins("Organization").
+		Where("organization_members.user_id = ?", userID).
+		Order("\"Organization\".name ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// FindMembers lists an organization's members, with their users loaded
+func (r *OrganizationRepository) FindMembers(orgID uuid.UUID) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := r.db.Joins("User").
+		Where("organization_members.organization_id = ?", orgID).
+		Order("organization_members.created_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// UpdateMemberRole changes a member's role
+func (r *OrganizationRepository) UpdateMemberRole(orgID, userID uuid.UUID, role string) error {
+	return r.db.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Update("role", role).Error
+}
+
+// RemoveMember deletes a membership
+func (r *OrganizationRepository) RemoveMember(orgID, userID uuid.UUID) error {
+	return r.db.Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Delete(&models.OrganizationMember{}).Error
+}
+
+// CountOwners counts an organization's owners
+func (r *OrganizationRepository) CountOwners(orgID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND role = ?", orgID, models.OrgRoleOwner).
+		Count(&count).Error
+	return count, err
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/bankaceh/bas-portal-api/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -31,20 +33,32 @@ func (r *PartnerCredentialRepository) FindByID(id uuid.UUID) (*models.PartnerCre
 	return &credential, nil
 }
 
-// FindByIDAndUserID finds a partner credential by ID and user ID
-func (r *PartnerCredentialRepository) FindByIDAndUserID(id, userID uuid.UUID) (*models.PartnerCredential, error) {
-	var credential models.PartnerCredential
-	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&credential).Error
+// FindByUserID finds all personal partner credentials for a user
+func (r *PartnerCredentialRepository) FindByUserID(userID uuid.UUID) ([]models.PartnerCredential, error) {
+	var credentials []models.PartnerCredential
+	err := r.db.Where("user_id = ? AND organization_id IS NULL AND is_active = ?", userID, true).
+		Order("created_at DESC").
+		Find(&credentials).Error
 	if err != nil {
 		return nil, err
 	}
-	return &credential, nil
+	return credentials, nil
 }
 
-// FindByUserID finds all partner credentials for a user
-func (r *PartnerCredentialRepository) FindByUserID(userID uuid.UUID) ([]models.PartnerCredential, error) {
+// FindAllCreatedBy finds every partner credential the user created, including deleted and
+// organization credentials, for data exports
+func (r *PartnerCredentialRepository) FindAllCreatedBy(userID uuid.UUID) ([]models.PartnerCredential, error) {
 	var credentials []models.PartnerCredential
-	err := r.db.Where("user_id = ? AND is_active = ?", userID, true).
+	err := r.db.Unscoped().Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+// FindByOrganizationID finds all partner credentials owned by an organization
+func (r *PartnerCredentialRepository) FindByOrganizationID(orgID uuid.UUID) ([]models.PartnerCredential, error) {
+	var credentials []models.PartnerCredential
+	err := r.db.Where("organization_id = ? AND is_active = ?", orgID, true).
 		Order("created_at DESC").
 		Find(&credentials).Error
 	if err != nil {
@@ -71,12 +85,13 @@ func (r *PartnerCredentialRepository) Update(credential *models.PartnerCredentia
 }
 
 // UpdatePublicKey updates only the public key fields
-func (r *PartnerCredentialRepository) UpdatePublicKey(id, userID uuid.UUID, publicKey, fingerprint string) error {
+func (r *PartnerCredentialRepository) UpdatePublicKey(id, userID uuid.UUID, publicKey, fingerprint, algorithm string) error {
 	return r.db.Model(&models.PartnerCredential{}).
 		Where("id = ? AND user_id = ?", id, userID).
 		Updates(map[string]interface{}{
 			"public_key":             publicKey,
 			"public_key_fingerprint": fingerprint,
+			"public_key_algorithm":   algorithm,
 			"public_key_added_at":    gorm.Expr("NOW()"),
 		}).Error
 }
@@ -94,18 +109,137 @@ func (r *PartnerCredentialRepository) Deactivate(id, userID uuid.UUID) error {
 		Update("is_active", false).Error
 }
 
-// UpdateLastUsed updates the last used timestamp
+// DeactivateAllForUser deactivates every personal partner credential a user owns.
+// Credentials they created for an organization stay with the organization.
+func (r *PartnerCredentialRepository) DeactivateAllForUser(userID uuid.UUID) error {
+	return r.db.Model(&models.PartnerCredential{}).
+		Where("user_id = ? AND organization_id IS NULL AND is_active = ?", userID, true).
+		Update("is_active", false).Error
+}
+
+// UpdateLastUsed updates the last used timestamp and clears any inactivity warning
 func (r *PartnerCredentialRepository) UpdateLastUsed(id uuid.UUID) error {
 	return r.db.Model(&models.PartnerCredential{}).
 		Where("id = ?", id).
-		Update("last_used_at", gorm.Expr("NOW()")).Error
+		Updates(map[string]interface{}{
+			"last_used_at":         gorm.Expr("NOW()"),
+			"inactivity_warned_at": nil,
+		}).Error
+}
+
+// FindWithPlaintextSecret finds credentials, deleted ones included, whose secret was
+// stored before secrets were hashed
+func (r *PartnerCredentialRepository) FindWithPlaintextSecret() ([]models.PartnerCredential, error) {
+	var credentials []models.PartnerCredential
+	err := r.db.Unscoped().
+		Where("client_secret <> '' AND (client_secret_hash IS NULL OR client_secret_hash = '')").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+// SetSecretHash stores the digest and encrypted copy of a credential's secret and clears
+// the plaintext
+func (r *PartnerCredentialRepository) SetSecretHash(id uuid.UUID, hash string, sealed []byte) error {
+	return r.db.Unscoped().Model(&models.PartnerCredential{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"client_secret_hash":   hash,
+			"client_secret_sealed": sealed,
+			"client_secret":        "",
+		}).Error
+}
+
+// inactiveProduction scopes a query to active, unsuspended production credentials that
+// have been used and whose last activity (use, reactivation or creation) is before the
+// cutoff. Credentials never used are left alone, since not every way of using a
+// credential records its use yet.
+func inactiveProduction(cutoff time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("environment = ? AND is_active = ? AND suspended_at IS NULL", "production", true).
+			Where("last_used_at IS NOT NULL").
+			Where("GREATEST(created_at, last_used_at, reactivated_at) < ?", cutoff)
+	}
+}
+
+// FindExpiring finds active credentials that expire after now and by until, with their
+// owners
+func (r *PartnerCredentialRepository) FindExpiring(now, until time.Time) ([]models.PartnerCredential, error) {
+	var credentials []models.PartnerCredential
+	err := r.db.Where("is_active = ?", true).
+		Where("expires_at > ? AND expires_at <= ?", now, until).
+		Preload("User").
+		Order("expires_at ASC").
+		Find(&credentials).Error
+	if err != nil {
+		return nil, err
+	}
+	return credentials, nil
 }
 
-// CountByUserID counts active partner credentials for a user
+// FindInactiveUnwarned finds inactive production credentials whose owners have not been warned yet
+func (r *PartnerCredentialRepository) FindInactiveUnwarned(cutoff time.Time) ([]models.PartnerCredential, error) {
+	var credentials []models.PartnerCredential
+	err := r.db.Scopes(inactiveProduction(cutoff)).
+		Where("inactivity_warned_at IS NULL").
+		Preload("User").
+		Find(&credentials).Error
+	if err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// FindInactive finds inactive production credentials
+func (r *PartnerCredentialRepository) FindInactive(cutoff time.Time) ([]models.PartnerCredential, error) {
+	var credentials []models.PartnerCredential
+	err := r.db.Scopes(inactiveProduction(cutoff)).
+		Preload("User").
+		Find(&credentials).Error
+	if err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// MarkInactivityWarned records that the owner was warned about inactivity
+func (r *PartnerCredentialRepository) MarkInactivityWarned(id uuid.UUID) error {
+	return r.db.Model(&models.PartnerCredential{}).
+		Where("id = ?", id).
+		Update("inactivity_warned_at", gorm.Expr("NOW()")).Error
+}
+
+// Suspend suspends a partner credential
+func (r *PartnerCredentialRepository) Suspend(id uuid.UUID) error {
+	return r.db.Model(&models.PartnerCredential{}).
+		Where("id = ? AND suspended_at IS NULL", id).
+		Update("suspended_at", gorm.Expr("NOW()")).Error
+}
+
+// Reactivate lifts a suspension and restarts the inactivity clock
+func (r *PartnerCredentialRepository) Reactivate(id, userID uuid.UUID) error {
+	return r.db.Model(&models.PartnerCredential{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Updates(map[string]interface{}{
+			"suspended_at":         nil,
+			"inactivity_warned_at": nil,
+			"reactivated_at":       gorm.Expr("NOW()"),
+		}).Error
+}
+
+// CountByUserID counts active personal partner credentials for a user
 func (r *PartnerCredentialRepository) CountByUserID(userID uuid.UUID) (int64, error) {
 	var count int64
 	err := r.db.Model(&models.PartnerCredential{}).
-		Where("user_id = ? AND is_active = ?", userID, true).
+		Where("user_id = ? AND organization_id IS NULL AND is_active = ?", userID, true).
+		Count(&count).Error
+	return count, err
+}
+
+// CountByOrganizationID counts active partner credentials owned by an organization
+func (r *PartnerCredentialRepository) CountByOrganizationID(orgID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.PartnerCredential{}).
+		Where("organization_id = ? AND is_active = ?", orgID, true).
 		Count(&count).Error
 	return count, err
 }
@@ -118,3 +252,14 @@ func (r *PartnerCredentialRepository) ExistsByClientID(clientID string) (bool, e
 		Count(&count).Error
 	return count > 0, err
 }
+
+// CountByEnvironment counts partner credentials per environment
+func (r *PartnerCredentialRepository) CountByEnvironment() ([]EnvironmentCount, error) {
+	var counts []EnvironmentCount
+	err := r.db.Model(&models.PartnerCredential{}).
+		Select("environment, COUNT(*) AS total, COUNT(*) FILTER (WHERE is_active) AS active").
+		Group("environment").
+		Order("environment ASC").
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestPartnerCredentialRepositoryFindInactive(t *testing.T) {
+	db := testDB(t)
+	repo := NewPartnerCredentialRepository(db)
+
+	user := &models.User{Email: uuid.NewString() + "@example.com", FullName: "Inactivity Test"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	t.Cleanup(func() { db.Unscoped().Delete(user) })
+
+	now := time.Now()
+	created := now.AddDate(0, 0, -120)
+	recent := now.AddDate(0, 0, -1)
+	stale := now.AddDate(0, 0, -100)
+
+	credential := func(name string, lastUsedAt *time.Time) *models.PartnerCredential {
+		c := &models.PartnerCredential{
+			UserID:      user.ID,
+			ClientID:    "BAS" + uuid.NewString()[:29],
+			PartnerName: name,
+			Environment: "production",
+			IsActive:    true,
+			LastUsedAt:  lastUsedAt,
+			CreatedAt:   created,
+		}
+		if err := db.Create(c).Error; err != nil {
+			t.Fatalf("create %s credential: %v", name, err)
+		}
+		t.Cleanup(func() { db.Unscoped().Delete(c) })
+		return c
+	}
+	used := credential("used", &recent)
+	unused := credential("unused", &stale)
+	neverUsed := credential("never used", nil)
+
+	inactive, err := repo.FindInactive(now.AddDate(0, 0, -90))
+	if err != nil {
+		t.Fatalf("find inactive: %v", err)
+	}
+
+	found := make(map[uuid.UUID]bool)
+	for _, c := range inactive {
+		found[c.ID] = true
+	}
+	if found[used.ID] {
+		t.Error("a credential used yesterday is inactive")
+	}
+	if !found[unused.ID] {
+		t.Error("a credential last used 100 days ago is not inactive")
+	}
+	if found[neverUsed.ID] {
+		t.Error("a credential that was never used is inactive")
+	}
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QuotaRepository handles database operations for key and credential quotas
+type QuotaRepository struct {
+	db *gorm.DB
+}
+
+// NewQuotaRepository creates a new QuotaRepository
+func NewQuotaRepository(db *gorm.DB) *QuotaRepository {
+	return &QuotaRepository{db: db}
+}
+
+// Find returns an owner's quota record
+func (r *QuotaRepository) Find(ownerType string, ownerID uuid.UUID) (*models.Quota, error) {
+	var quota models.Quota
+	err := r.db.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).First(&quota).Error
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// Save inserts or replaces an owner's quota
+func (r *QuotaRepository) Save(quota *models.Quota) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner_type"}, {Name: "owner_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_api_keys", "max_credentials", "updated_by", "updated_at"}),
+	}).Create(quota).Error
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SecretLinkRepository handles database operations for secret retrieval links
+type SecretLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewSecretLinkRepository creates a new SecretLinkRepository
+func NewSecretLinkRepository(db *gorm.DB) *SecretLinkRepository {
+	return &SecretLinkRepository{db: db}
+}
+
+// Create inserts a new secret link into the database
+func (r *SecretLinkRepository) Create(link *models.SecretLink) error {
+	return r.db.Create(link).Error
+}
+
+// Claim marks an unopened, unexpired link as retrieved and wipes its ciphertext in one
+// transaction, returning the link as it was before. It returns gorm.ErrRecordNotFound if
+// the link was already opened or has expired.
+func (r *SecretLinkRepository) Claim(id uuid.UUID) (*models.SecretLink, error) {
+	var link models.SecretLink
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND retrieved_at IS NULL AND expires_at > NOW()", id).
+			First(&link).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.SecretLink{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"retrieved_at": gorm.Expr("NOW()"), "ciphertext": nil}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// DeleteExpired removes links that expired without being opened, with their ciphertext
+func (r *SecretLinkRepository) DeleteExpired() error {
+	return r.db.Where("retrieved_at IS NULL AND expires_at <= NOW()").Delete(&models.SecretLink{}).Error
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// SecurityWebhookRepository handles database operations for the security webhook setting
+type SecurityWebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewSecurityWebhookRepository creates a new SecurityWebhookRepository
+func NewSecurityWebhookRepository(db *gorm.DB) *SecurityWebhookRepository {
+	return &SecurityWebhookRepository{db: db}
+}
+
+// Get returns the configured webhook
+func (r *SecurityWebhookRepository) Get() (*models.SecurityWebhook, error) {
+	var webhook models.SecurityWebhook
+	err := r.db.Order("created_at ASC").First(&webhook).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// Save creates or updates the webhook
+func (r *SecurityWebhookRepository) Save(webhook *models.SecurityWebhook) error {
+	return r.db.Save(webhook).Error
+}
+
+// DeleteAll removes the webhook configuration
+func (r *SecurityWebhookRepository) DeleteAll() (int64, error) {
+	result := r.db.Where("1 = 1").Delete(&models.SecurityWebhook{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ServiceAccountRepository handles database operations for service accounts and their tokens
+type ServiceAccountRepository struct {
+	db *gorm.DB
+}
+
+// NewServiceAccountRepository creates a new ServiceAccountRepository
+func NewServiceAccountRepository(db *gorm.DB) *ServiceAccountRepository {
+	return &ServiceAccountRepository{db: db}
+}
+
+// Create inserts a service account user, links it to its organization and adds it as a member
+func (r *ServiceAccountRepository) Create(user *models.User, account *models.ServiceAccount, member *models.OrganizationMember) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		account.UserID = user.ID
+		member.UserID = user.ID
+		if err := tx.Create(account).Error; err != nil {
+			return err
+		}
+		return tx.Create(member).Error
+	})
+}
+
+// FindByID finds an organization's service account, with its user loaded
+func (r *ServiceAccountRepository) FindByID(orgID, id uuid.UUID) (*models.ServiceAccount, error) {
+	var account models.ServiceAccount
+	err := r.db.Joins("User").
+		Where("service_accounts.organization_id = ? AND service_accounts.user_id = ?", orgID, id).
+		First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// FindByOrganizationID lists an organization's service accounts, with their users loaded
+func (r *ServiceAccountRepository) FindByOrganizationID(orgID uuid.UUID) ([]models.ServiceAccount, error) {
+	var accounts []models.ServiceAccount
+	err := r.db.Joins("User").
+		Where("service_accounts.organization_id = ?", orgID).
+		Order("service_accounts.created_at ASC").
+		Find(&accounts).Error
+	return accounts, err
+}
+
+// Delete revokes a service account's tokens, removes its membership and link, and soft
+// deletes its user
+func (r *ServiceAccountRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ServiceAccountToken{}).
+			Where("service_account_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.OrganizationMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.ServiceAccount{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.User{}).Error
+	})
+}
+
+// CreateToken inserts a new service account token
+func (r *ServiceAccountRepository) CreateToken(token *models.ServiceAccountToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindTokens lists a service account's tokens, newest first
+func (r *ServiceAccountRepository) FindTokens(accountID uuid.UUID) ([]models.ServiceAccountToken, error) {
+	var tokens []models.ServiceAccountToken
+	err := r.db.Where("service_account_id = ?", accountID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// FindActiveTokenByHash finds an unrevoked, unexpired token by its hash
+func (r *ServiceAccountRepository) FindActiveTokenByHash(hash string) (*models.ServiceAccountToken, error) {
+	var token models.ServiceAccountToken
+	err := r.db.Where("token_hash = ? AND revoked_at IS NULL AND expires_at > ?", hash, time.Now()).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeToken revokes one of a service account's tokens. It reports false if the token
+// does not exist or was already revoked.
+func (r *ServiceAccountRepository) RevokeToken(accountID, tokenID uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.ServiceAccountToken{}).
+		Where("id = ? AND service_account_id = ? AND revoked_at IS NULL", tokenID, accountID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// TouchToken records that a token was used
+func (r *ServiceAccountRepository) TouchToken(id uuid.UUID) error {
+	return r.db.Model(&models.ServiceAccountToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", time.Now()).Error
+}
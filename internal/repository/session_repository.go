@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SessionRepository handles database operations for login sessions
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new SessionRepository
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create inserts a new session into the database
+func (r *SessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+// FindByID finds a session by its UUID
+func (r *SessionRepository) FindByID(id uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Touch records activity on a session, writing at most once per minute
+func (r *SessionRepository) Touch(id uuid.UUID) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL AND last_activity_at < NOW() - INTERVAL '1 minute'", id).
+		Update("last_activity_at", gorm.Expr("NOW()")).Error
+}
+
+// Revoke marks a session as revoked
+func (r *SessionRepository) Revoke(id uuid.UUID) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", gorm.Expr("NOW()")).Error
+}
+
+// RevokeAllForUser revokes every active session belonging to a user
+func (r *SessionRepository) RevokeAllForUser(userID uuid.UUID) error {
+	return r.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", gorm.Expr("NOW()")).Error
+}
+
+// RevokeAllButNewest revokes a user's live sessions beyond the newest keep, returning how
+// many were revoked. Sessions idle since before idleCutoff are already dead and don't
+// count towards the kept ones.
+func (r *SessionRepository) RevokeAllButNewest(userID uuid.UUID, keep int, idleCutoff time.Time) (int64, error) {
+	newest := r.db.Model(&models.Session{}).
+		Select("id").
+		Where("user_id = ? AND revoked_at IS NULL AND last_activity_at >= ?", userID, idleCutoff).
+		Order("created_at DESC").
+		Limit(keep)
+
+	result := r.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND id NOT IN (?)", userID, newest).
+		Update("revoked_at", gorm.Expr("NOW()"))
+	return result.RowsAffected, result.Error
+}
+
+// FindRecentByUserID returns a user's most recently started sessions
+func (r *SessionRepository) FindRecentByUserID(userID uuid.UUID, limit int) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&sessions).Error
+	return sessions, err
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SMSCodeRepository handles database operations for SMS codes
+type SMSCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewSMSCodeRepository creates a new SMSCodeRepository
+func NewSMSCodeRepository(db *gorm.DB) *SMSCodeRepository {
+	return &SMSCodeRepository{db: db}
+}
+
+// Create inserts a new SMS code into the database
+func (r *SMSCodeRepository) Create(code *models.SMSCode) error {
+	return r.db.Create(code).Error
+}
+
+// FindActive finds an unused, unexpired code of a user
+func (r *SMSCodeRepository) FindActive(id, userID uuid.UUID, purpose string) (*models.SMSCode, error) {
+	var code models.SMSCode
+	err := r.db.Where("id = ? AND user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > NOW()", id, userID, purpose).
+		First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// FindLatest finds the code most recently sent to a user for a purpose
+func (r *SMSCodeRepository) FindLatest(userID uuid.UUID, purpose string) (*models.SMSCode, error) {
+	var code models.SMSCode
+	err := r.db.Where("user_id = ? AND purpose = ?", userID, purpose).
+		Order("created_at DESC").
+		First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// RecordAttempt counts a wrong guess at a code
+func (r *SMSCodeRepository) RecordAttempt(id uuid.UUID) error {
+	return r.db.Model(&models.SMSCode{}).
+		Where("id = ?", id).
+		Update("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+// Consume marks an unused code as used and reports whether it was consumed
+func (r *SMSCodeRepository) Consume(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.SMSCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", gorm.Expr("NOW()"))
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserIdentityRepository handles database operations for linked login identities
+type UserIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewUserIdentityRepository creates a new UserIdentityRepository
+func NewUserIdentityRepository(db *gorm.DB) *UserIdentityRepository {
+	return &UserIdentityRepository{db: db}
+}
+
+// Create inserts a new identity into the database
+func (r *UserIdentityRepository) Create(identity *models.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// CreateWithUser inserts a new user together with their first identity
+func (r *UserIdentityRepository) CreateWithUser(user *models.User, identity *models.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+// FindByProviderID finds the identity for an external account
+func (r *UserIdentityRepository) FindByProviderID(provider, providerID string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.Where("provider = ? AND provider_id = ?", provider, providerID).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// FindByUserID lists the identities linked to a user
+func (r *UserIdentityRepository) FindByUserID(userID uuid.UUID) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error
+	return identities, err
+}
+
+// ExistsForUser checks whether the user has an identity with the provider
+func (r *UserIdentityRepository) ExistsForUser(userID uuid.UUID, provider string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.UserIdentity{}).
+		Where("user_id = ? AND provider = ?", userID, provider).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Touch records a sign-in with the identity
+func (r *UserIdentityRepository) Touch(id uuid.UUID) error {
+	return r.db.Model(&models.UserIdentity{}).Where("id = ?", id).
+		Update("last_used_at", gorm.Expr("NOW()")).Error
+}
+
+// Delete unlinks the user's identity with the provider, reporting whether one existed
+func (r *UserIdentityRepository) Delete(userID uuid.UUID, provider string) (bool, error) {
+	result := r.db.Where("user_id = ? AND provider = ?", userID, provider).Delete(&models.UserIdentity{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -1,11 +1,26 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/bankaceh/bas-portal-api/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// UserCounts are account totals for the admin dashboard
+type UserCounts struct {
+	Total     int64
+	Verified  int64
+	Suspended int64
+}
+
+// WeeklyCount is a count for the week starting at Week (Monday, UTC)
+type WeeklyCount struct {
+	Week  time.Time
+	Count int64
+}
+
 // UserRepository handles database operations for users
 type UserRepository struct {
 	db *gorm.DB
@@ -61,9 +76,224 @@ func (r *UserRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&models.User{}, id).Error
 }
 
-// EmailExists checks if an email is already registered
+// EmailExists checks if an email is already registered, including accounts still in
+// their deletion grace period
 func (r *UserRepository) EmailExists(email string) bool {
 	var count int64
-	r.db.Model(&models.User{}).Where("email = ?", email).Count(&count)
+	r.db.Unscoped().Model(&models.User{}).Where("email = ?", email).Count(&count)
 	return count > 0
 }
+
+// Unsubscribe opts a user out of bulk emails
+func (r *UserRepository) Unsubscribe(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ? AND unsubscribed_at IS NULL", id).
+		Update("unsubscribed_at", gorm.Expr("NOW()")).Error
+}
+
+// Resubscribe opts a user back in to bulk emails
+func (r *UserRepository) Resubscribe(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("unsubscribed_at", nil).Error
+}
+
+// MarkVerified flags a user's email address as verified
+func (r *UserRepository) MarkVerified(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("is_verified", true).Error
+}
+
+// UpdatePassword replaces a user's password hash, records when it changed and clears a
+// required reset
+func (r *UserRepository) UpdatePassword(id uuid.UUID, passwordHash string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password_hash":           passwordHash,
+		"password_changed_at":     gorm.Expr("NOW()"),
+		"password_reset_required": false,
+	}).Error
+}
+
+// RequirePasswordReset blocks sign-in until the user resets their password
+func (r *UserRepository) RequirePasswordReset(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("password_reset_required", true).Error
+}
+
+// FindDeletedBefore returns deleted accounts whose grace period ended and that still hold PII
+func (r *UserRepository) FindDeletedBefore(cutoff time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND anonymized_at IS NULL", cutoff).
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// Anonymize scrubs the personal data of a deleted account. The email is replaced with a
+// unique placeholder so the address can register again.
+func (r *UserRepository) Anonymize(id uuid.UUID) error {
+	return r.db.Unscoped().Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"email":             "deleted-" + id.String() + "@deleted.invalid",
+		"password_hash":     "",
+		"full_name":         "Deleted user",
+		"first_name":        "",
+		"last_name":         "",
+		"job_title":         "",
+		"company":           "",
+		"profile_picture":   "",
+		"metadata":          nil,
+		"phone":             "",
+		"phone_verified_at": nil,
+		"mfa_method":        "",
+		"provider_id":       "",
+		"anonymized_at":     gorm.Expr("NOW()"),
+	}).Error
+}
+
+// UpdateMetadata replaces a user's metadata
+func (r *UserRepository) UpdateMetadata(id uuid.UUID, metadata models.JSONMap) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("metadata", metadata).Error
+}
+
+// UpdatePhone sets a user's phone number, which is unverified until a code is confirmed
+func (r *UserRepository) UpdatePhone(id uuid.UUID, phone string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"phone":             phone,
+		"phone_verified_at": nil,
+	}).Error
+}
+
+// MarkPhoneVerified confirms a user's phone number if it is still the one the code was
+// sent to
+func (r *UserRepository) MarkPhoneVerified(id uuid.UUID, phone string) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND phone = ?", id, phone).
+		Update("phone_verified_at", gorm.Expr("NOW()"))
+	return result.RowsAffected == 1, result.Error
+}
+
+// UpdateMFAMethod turns two-factor sign-in on with a method, or off with ""
+func (r *UserRepository) UpdateMFAMethod(id uuid.UUID, method string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("mfa_method", method).Error
+}
+
+// FindByApprovalStatus lists accounts in an approval status, the oldest first
+func (r *UserRepository) FindByApprovalStatus(status string) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("approval_status = ?", status).Order("created_at ASC").Find(&users).Error
+	return users, err
+}
+
+// ReviewApproval records a decision on a pending account, reporting whether it was
+// still pending
+func (r *UserRepository) ReviewApproval(id, reviewerID uuid.UUID, status, reason string) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND approval_status = ?", id, models.ApprovalStatusPending).
+		Updates(map[string]interface{}{
+			"approval_status":      status,
+			"approval_reviewed_at": gorm.Expr("NOW()"),
+			"approval_reviewed_by": reviewerID,
+			"rejection_reason":     reason,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// Lock blocks sign-in to an account
+func (r *UserRepository) Lock(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ? AND locked_at IS NULL", id).
+		Update("locked_at", gorm.Expr("NOW()")).Error
+}
+
+// Unlock allows sign-in to a locked account again
+func (r *UserRepository) Unlock(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("locked_at", nil).Error
+}
+
+// Suspend blocks an account and records why, reporting false if it was already suspended
+func (r *UserRepository) Suspend(record *models.UserSuspension) (bool, error) {
+	suspended := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND is_suspended = ?", record.UserID, false).
+			Updates(map[string]interface{}{
+				"is_suspended":      true,
+				"suspended_at":      gorm.Expr("NOW()"),
+				"suspension_reason": record.Reason,
+			})
+		if result.Error != nil || result.RowsAffected != 1 {
+			return result.Error
+		}
+		suspended = true
+		return tx.Create(record).Error
+	})
+	return suspended, err
+}
+
+// Reactivate lifts a suspension and records why, reporting false if the account was not suspended
+func (r *UserRepository) Reactivate(record *models.UserSuspension) (bool, error) {
+	reactivated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND is_suspended = ?", record.UserID, true).
+			Updates(map[string]interface{}{
+				"is_suspended":      false,
+				"suspended_at":      nil,
+				"suspension_reason": "",
+			})
+		if result.Error != nil || result.RowsAffected != 1 {
+			return result.Error
+		}
+		reactivated = true
+		return tx.Create(record).Error
+	})
+	return reactivated, err
+}
+
+// FindSuspensions lists an account's suspension history, newest first
+func (r *UserRepository) FindSuspensions(userID uuid.UUID) ([]models.UserSuspension, error) {
+	var records []models.UserSuspension
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&records).Error
+	return records, err
+}
+
+// ClearPassword removes password sign-in from an account
+func (r *UserRepository) ClearPassword(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password_hash":       "",
+		"password_changed_at": gorm.Expr("NOW()"),
+	}).Error
+}
+
+// UpdateRole sets a user's role
+func (r *UserRepository) UpdateRole(id uuid.UUID, role string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("role", role).Error
+}
+
+// FindByRoles lists users holding any of the roles
+func (r *UserRepository) FindByRoles(roles []string) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("role IN ?", roles).Order("email ASC").Find(&users).Error
+	return users, err
+}
+
+// Counts totals the accounts that have not been deleted
+func (r *UserRepository) Counts() (UserCounts, error) {
+	var counts UserCounts
+	err := r.db.Model(&models.User{}).
+		Select("COUNT(*) AS total, " +
+			"COUNT(*) FILTER (WHERE is_verified) AS verified, " +
+			"COUNT(*) FILTER (WHERE is_suspended) AS suspended").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// CountSignupsByWeek counts accounts created since a time, per week. Deleted accounts
+// still count as signups. Weeks without signups are left out.
+func (r *UserRepository) CountSignupsByWeek(since time.Time) ([]WeeklyCount, error) {
+	var counts []WeeklyCount
+	err := r.db.Unscoped().Model(&models.User{}).
+		Select("date_trunc('week', created_at AT TIME ZONE 'UTC') AS week, COUNT(*) AS count").
+		Where("created_at >= ?", since).
+		Group("week").
+		Order("week ASC").
+		Scan(&counts).Error
+	return counts, err
+}
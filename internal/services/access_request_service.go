@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/bankaceh/bas-portal-api/internal/captcha"
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/mailer"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+)
+
+var (
+	ErrCaptchaFailed = errors.New("captcha verification failed")
+)
+
+// AccessRequestService handles public access requests from prospective partners
+type AccessRequestService struct {
+	repo    *repository.AccessRequestRepository
+	captcha captcha.Verifier
+	mailer  mailer.Mailer
+	cfg     *config.Config
+}
+
+// NewAccessRequestService creates a new AccessRequestService
+func NewAccessRequestService(repo *repository.AccessRequestRepository, captcha captcha.Verifier, mailer mailer.Mailer, cfg *config.Config) *AccessRequestService {
+	return &AccessRequestService{
+		repo:    repo,
+		captcha: captcha,
+		mailer:  mailer,
+		cfg:     cfg,
+	}
+}
+
+// AccessRequestInput represents a "contact sales / request access" submission
+type AccessRequestInput struct {
+	Name         string `json:"name" validate:"required"`
+	Company      string `json:"company" validate:"required"`
+	Email        string `json:"email" validate:"required,email"`
+	UseCase      string `json:"useCase" validate:"required"`
+	CaptchaToken string `json:"captchaToken"`
+}
+
+// Submit verifies the captcha, stores the lead and notifies the partnership team
+func (s *AccessRequestService) Submit(input AccessRequestInput, remoteIP string) (*models.AccessRequest, error) {
+	ok, err := s.captcha.Verify(input.CaptchaToken, remoteIP)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrCaptchaFailed
+	}
+
+	request := &models.AccessRequest{
+		Name:      input.Name,
+		Company:   input.Company,
+		Email:     input.Email,
+		UseCase:   input.UseCase,
+		Status:    models.AccessRequestStatusNew,
+		IPAddress: remoteIP,
+	}
+
+	if err := s.repo.Create(request); err != nil {
+		return nil, err
+	}
+
+	// The lead is stored either way, so a failed notification is only logged
+	subject := fmt.Sprintf("New API access request from %s", request.Company)
+	body := fmt.Sprintf(
+		"A new access request was submitted on the BAS Developer Portal.\n\n"+
+			"Name: %s\nCompany: %s\nEmail: %s\n\nUse case:\n%s\n",
+		request.Name, request.Company, request.Email, request.UseCase,
+	)
+	if err := s.mailer.Send(s.cfg.PartnershipEmail, subject, body); err != nil {
+		log.Printf("Failed to notify partnership team about access request %s: %v", request.ID, err)
+	}
+
+	return request, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// RecentLoginWindow is how fresh a session must be to delete an account without a
+// password (Google sign-in accounts)
+const RecentLoginWindow = 10 * time.Minute
+
+var (
+	ErrRecentLoginRequired = errors.New("sign in again to confirm this action")
+)
+
+// AccountService handles closing developer accounts
+type AccountService struct {
+	userRepo        *repository.UserRepository
+	apiKeyRepo      *repository.APIKeyRepository
+	partnerCredRepo *repository.PartnerCredentialRepository
+	sessionService  *SessionService
+	bus             *events.Bus
+	cfg             *config.Config
+}
+
+// NewAccountService creates a new AccountService
+func NewAccountService(userRepo *repository.UserRepository, apiKeyRepo *repository.APIKeyRepository, partnerCredRepo *repository.PartnerCredentialRepository, sessionService *SessionService, bus *events.Bus, cfg *config.Config) *AccountService {
+	return &AccountService{
+		userRepo:        userRepo,
+		apiKeyRepo:      apiKeyRepo,
+		partnerCredRepo: partnerCredRepo,
+		sessionService:  sessionService,
+		bus:             bus,
+		cfg:             cfg,
+	}
+}
+
+// DeleteAccountInput represents an account deletion request
+type DeleteAccountInput struct {
+	Password string `json:"password"`
+}
+
+// DeleteAccount closes the user's account after re-confirming their identity. Keys and
+// credentials are revoked and sessions signed out straight away; personal data is
+// anonymized by AnonymizeDeletedAccounts once the grace period ends.
+func (s *AccountService) DeleteAccount(userID, sessionID uuid.UUID, input DeleteAccountInput) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if err := confirmStepUp(user, input.Password, sessionID, s.sessionService); err != nil {
+		return err
+	}
+
+	if _, err := s.apiKeyRepo.RevokeAllForUser(user.ID); err != nil {
+		return err
+	}
+	if err := s.partnerCredRepo.DeactivateAllForUser(user.ID); err != nil {
+		return err
+	}
+	if err := s.sessionService.RevokeAll(user.ID); err != nil {
+		return err
+	}
+	if err := s.userRepo.Delete(user.ID); err != nil {
+		return err
+	}
+
+	s.bus.Publish(events.UserDeleted{UserID: user.ID, GraceDays: s.cfg.AccountDeletionGraceDays})
+	return nil
+}
+
+// AnonymizeDeletedAccounts scrubs personal data from accounts deleted longer ago than
+// the grace period. Run periodically by the scheduler.
+func (s *AccountService) AnonymizeDeletedAccounts() error {
+	cutoff := time.Now().AddDate(0, 0, -s.cfg.AccountDeletionGraceDays)
+
+	users, err := s.userRepo.FindDeletedBefore(cutoff, 100)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if err := s.userRepo.Anonymize(user.ID); err != nil {
+			log.Printf("Failed to anonymize deleted user %s: %v", user.ID, err)
+			continue
+		}
+		s.bus.Publish(events.UserAnonymized{UserID: user.ID})
+	}
+	return nil
+}
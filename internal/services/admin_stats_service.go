@@ -0,0 +1,212 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// adminStatsCacheTTL is how long computed statistics are served from memory
+	adminStatsCacheTTL = time.Minute
+
+	// AdminStatsSignupWeeks is how many weeks of signups the trend covers, this week included
+	AdminStatsSignupWeeks = 12
+
+	// adminStatsRecentAuthFailures caps the list of recent failed logins
+	adminStatsRecentAuthFailures = 10
+)
+
+// AdminStatsService computes portal-wide statistics for the staff dashboard
+type AdminStatsService struct {
+	userRepo        *repository.UserRepository
+	apiKeyRepo      *repository.APIKeyRepository
+	partnerCredRepo *repository.PartnerCredentialRepository
+	auditRepo       *repository.AuditLogRepository
+
+	mu        sync.Mutex
+	cached    *AdminStatsResponse
+	expiresAt time.Time
+}
+
+// NewAdminStatsService creates a new AdminStatsService
+func NewAdminStatsService(userRepo *repository.UserRepository, apiKeyRepo *repository.APIKeyRepository, partnerCredRepo *repository.PartnerCredentialRepository, auditRepo *repository.AuditLogRepository) *AdminStatsService {
+	return &AdminStatsService{
+		userRepo:        userRepo,
+		apiKeyRepo:      apiKeyRepo,
+		partnerCredRepo: partnerCredRepo,
+		auditRepo:       auditRepo,
+	}
+}
+
+// AdminStatsResponse is the read model behind the admin dashboard
+type AdminStatsResponse struct {
+	Users        AdminUserStats        `json:"users"`
+	APIKeys      AdminEnvironmentStats `json:"apiKeys"`
+	Credentials  AdminEnvironmentStats `json:"credentials"`
+	AuthFailures AdminAuthFailureStats `json:"authFailures"`
+	GeneratedAt  time.Time             `json:"generatedAt"`
+}
+
+// AdminUserStats counts accounts and recent signups
+type AdminUserStats struct {
+	Total         int64               `json:"total"`
+	Verified      int64               `json:"verified"`
+	Suspended     int64               `json:"suspended"`
+	SignupsByWeek []AdminWeeklySignup `json:"signupsByWeek"` // Oldest first, one entry per week
+}
+
+// AdminWeeklySignup is the number of accounts created in the week starting on WeekStart
+type AdminWeeklySignup struct {
+	WeekStart time.Time `json:"weekStart"` // Monday 00:00 UTC
+	Count     int64     `json:"count"`
+}
+
+// AdminEnvironmentStats counts API keys or partner credentials per environment
+type AdminEnvironmentStats struct {
+	Total         int64                       `json:"total"`
+	Active        int64                       `json:"active"`
+	ByEnvironment map[string]AdminStatusCount `json:"byEnvironment"`
+}
+
+// AdminStatusCount is a total with the part of it that is active
+type AdminStatusCount struct {
+	Total  int64 `json:"total"`
+	Active int64 `json:"active"`
+}
+
+// AdminAuthFailureStats counts rejected password logins
+type AdminAuthFailureStats struct {
+	Last24Hours int64                     `json:"last24Hours"`
+	Last7Days   int64                     `json:"last7Days"`
+	Recent      []models.AuditLogResponse `json:"recent"`
+}
+
+// GetStats returns the statistics, serving them from a short-lived cache
+func (s *AdminStatsService) GetStats() (*AdminStatsResponse, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	if s.cached != nil && now.Before(s.expiresAt) {
+		stats := s.cached
+		s.mu.Unlock()
+		return stats, nil
+	}
+	s.mu.Unlock()
+
+	stats, err := s.buildStats(now)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cached = stats
+	s.expiresAt = now.Add(adminStatsCacheTTL)
+	s.mu.Unlock()
+
+	return stats, nil
+}
+
+// buildStats runs the aggregate queries concurrently and combines them
+func (s *AdminStatsService) buildStats(now time.Time) (*AdminStatsResponse, error) {
+	firstWeek := weekStart(now).AddDate(0, 0, -7*(AdminStatsSignupWeeks-1))
+
+	var (
+		userCounts      repository.UserCounts
+		signups         []repository.WeeklyCount
+		keyCounts       []repository.EnvironmentCount
+		credentialCount []repository.EnvironmentCount
+		failuresDay     int64
+		failuresWeek    int64
+		recentFailures  []models.AuditLog
+	)
+
+	var g errgroup.Group
+	g.Go(func() (err error) {
+		userCounts, err = s.userRepo.Counts()
+		return err
+	})
+	g.Go(func() (err error) {
+		signups, err = s.userRepo.CountSignupsByWeek(firstWeek)
+		return err
+	})
+	g.Go(func() (err error) {
+		keyCounts, err = s.apiKeyRepo.CountByEnvironment()
+		return err
+	})
+	g.Go(func() (err error) {
+		credentialCount, err = s.partnerCredRepo.CountByEnvironment()
+		return err
+	})
+	g.Go(func() (err error) {
+		failuresDay, err = s.auditRepo.CountSince(AuditActionLoginFailed, now.Add(-24*time.Hour))
+		return err
+	})
+	g.Go(func() (err error) {
+		since := now.Add(-7 * 24 * time.Hour)
+		recentFailures, failuresWeek, err = s.auditRepo.Find(repository.AuditLogFilter{
+			Action: AuditActionLoginFailed,
+			From:   &since,
+			Limit:  adminStatsRecentAuthFailures,
+		})
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	stats := &AdminStatsResponse{
+		Users: AdminUserStats{
+			Total:         userCounts.Total,
+			Verified:      userCounts.Verified,
+			Suspended:     userCounts.Suspended,
+			SignupsByWeek: make([]AdminWeeklySignup, AdminStatsSignupWeeks),
+		},
+		APIKeys:     environmentStats(keyCounts),
+		Credentials: environmentStats(credentialCount),
+		AuthFailures: AdminAuthFailureStats{
+			Last24Hours: failuresDay,
+			Last7Days:   failuresWeek,
+			Recent:      make([]models.AuditLogResponse, len(recentFailures)),
+		},
+		GeneratedAt: now,
+	}
+
+	// Fill every week so the chart has no gaps
+	for i := range stats.Users.SignupsByWeek {
+		stats.Users.SignupsByWeek[i].WeekStart = firstWeek.AddDate(0, 0, 7*i)
+	}
+	for _, signup := range signups {
+		i := int(signup.Week.Sub(firstWeek).Hours() / (7 * 24))
+		if i >= 0 && i < len(stats.Users.SignupsByWeek) {
+			stats.Users.SignupsByWeek[i].Count = signup.Count
+		}
+	}
+
+	for i := range recentFailures {
+		stats.AuthFailures.Recent[i] = recentFailures[i].ToResponse()
+	}
+
+	return stats, nil
+}
+
+// environmentStats totals per-environment counts
+func environmentStats(counts []repository.EnvironmentCount) AdminEnvironmentStats {
+	stats := AdminEnvironmentStats{ByEnvironment: make(map[string]AdminStatusCount)}
+	for _, count := range counts {
+		stats.Total += count.Total
+		stats.Active += count.Active
+		stats.ByEnvironment[count.Environment] = AdminStatusCount{Total: count.Total, Active: count.Active}
+	}
+	return stats
+}
+
+// weekStart returns midnight UTC on the Monday of t's week, matching Postgres date_trunc
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"net"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// KeyCaller is a service account token or API key managing API keys through the portal
+// API. The keys it creates, changes or rotates may not have more access than it has, so a
+// narrowly scoped token cannot mint itself a broader one.
+type KeyCaller struct {
+	Permissions []string  // Scopes of the token or key
+	KeyID       uuid.UUID // The calling key, for X-API-Key callers
+}
+
+// checkCaller returns ErrKeyExceedsCaller if key would give a non-interactive caller more
+// access than it has. A nil caller is a signed-in user and may do anything their role
+// allows.
+func (s *APIKeyService) checkCaller(caller *KeyCaller, key *models.APIKey) error {
+	if caller == nil {
+		return nil
+	}
+
+	var callerKey *models.APIKey
+	if caller.KeyID != uuid.Nil {
+		found, err := s.keyRepo.FindByID(caller.KeyID)
+		if err != nil {
+			return ErrKeyExceedsCaller
+		}
+		callerKey = found
+	}
+
+	if !withinCaller(caller.Permissions, callerKey, key, s.cfg.APIKeyDefaultRateLimit) {
+		return ErrKeyExceedsCaller
+	}
+	return nil
+}
+
+// withinCaller reports whether key has no scope outside permissions and, for an API key
+// caller, is at least as restricted as callerKey: its IP ranges lie within the caller's,
+// its rate limit is no higher and it expires no later. defaultRateLimit applies to keys
+// without their own limit.
+func withinCaller(permissions []string, callerKey, key *models.APIKey, defaultRateLimit int) bool {
+	held := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		held[permission] = true
+	}
+	for _, scope := range key.Scopes {
+		if !held[scope] {
+			return false
+		}
+	}
+
+	if callerKey == nil {
+		return true
+	}
+
+	if len(callerKey.IPAllowlist) > 0 {
+		if len(key.IPAllowlist) == 0 {
+			return false
+		}
+		for _, entry := range key.IPAllowlist {
+			if !networkWithin(entry, callerKey.IPAllowlist) {
+				return false
+			}
+		}
+	}
+
+	if effectiveRateLimit(key, defaultRateLimit) > effectiveRateLimit(callerKey, defaultRateLimit) {
+		return false
+	}
+
+	if callerKey.ExpiresAt != nil && (key.ExpiresAt == nil || key.ExpiresAt.After(*callerKey.ExpiresAt)) {
+		return false
+	}
+	return true
+}
+
+// networkWithin reports whether the CIDR range lies inside one of the allowlist's ranges
+func networkWithin(cidr string, allowlist []string) bool {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+	size, bits := network.Mask.Size()
+	for _, entry := range allowlist {
+		_, outer, err := net.ParseCIDR(entry)
+		if err != nil {
+			continue
+		}
+		outerSize, outerBits := outer.Mask.Size()
+		if outerBits == bits && outerSize <= size && outer.Contains(network.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// effectiveRateLimit is a key's requests per minute, falling back to the default
+func effectiveRateLimit(key *models.APIKey, defaultRateLimit int) int {
+	if key.RateLimit > 0 {
+		return key.RateLimit
+	}
+	return defaultRateLimit
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+)
+
+func TestWithinCallerServiceAccount(t *testing.T) {
+	permissions := []string{"api_keys:manage", "applications:read"}
+
+	tests := []struct {
+		name   string
+		scopes []string
+		want   bool
+	}{
+		{"no scopes", nil, true},
+		{"held scope", []string{"applications:read"}, true},
+		{"all held scopes", []string{"api_keys:manage", "applications:read"}, true},
+		{"scope not held", []string{"credentials:manage"}, false},
+		{"held and not held", []string{"applications:read", "credentials:manage"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := &models.APIKey{Scopes: tt.scopes}
+			if got := withinCaller(permissions, nil, key, 60); got != tt.want {
+				t.Errorf("withinCaller() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithinCallerAPIKey(t *testing.T) {
+	expires := time.Now().Add(24 * time.Hour)
+	sooner := expires.Add(-time.Hour)
+	later := expires.Add(time.Hour)
+
+	callerKey := &models.APIKey{
+		Scopes:      models.StringArray{"api_keys:manage", "applications:read"},
+		RateLimit:   30,
+		IPAllowlist: models.StringArray{"10.0.0.0/16"},
+		ExpiresAt:   &expires,
+	}
+
+	tests := []struct {
+		name string
+		key  models.APIKey
+		want bool
+	}{
+		{"narrower key", models.APIKey{Scopes: models.StringArray{"applications:read"}, RateLimit: 10, IPAllowlist: models.StringArray{"10.0.1.0/24"}, ExpiresAt: &sooner}, true},
+		{"same restrictions", models.APIKey{Scopes: models.StringArray{"api_keys:manage"}, RateLimit: 30, IPAllowlist: models.StringArray{"10.0.0.0/16"}, ExpiresAt: &expires}, true},
+		{"scope not held", models.APIKey{Scopes: models.StringArray{"credentials:manage"}, RateLimit: 10, IPAllowlist: models.StringArray{"10.0.1.0/24"}, ExpiresAt: &sooner}, false},
+		{"no IP allowlist", models.APIKey{RateLimit: 10, ExpiresAt: &sooner}, false},
+		{"broader IP range", models.APIKey{RateLimit: 10, IPAllowlist: models.StringArray{"10.0.0.0/8"}, ExpiresAt: &sooner}, false},
+		{"other IP range", models.APIKey{RateLimit: 10, IPAllowlist: models.StringArray{"10.0.1.0/24", "192.168.0.0/24"}, ExpiresAt: &sooner}, false},
+		{"higher rate limit", models.APIKey{RateLimit: 31, IPAllowlist: models.StringArray{"10.0.1.0/24"}, ExpiresAt: &sooner}, false},
+		{"default rate limit above caller", models.APIKey{IPAllowlist: models.StringArray{"10.0.1.0/24"}, ExpiresAt: &sooner}, false},
+		{"no expiry", models.APIKey{RateLimit: 10, IPAllowlist: models.StringArray{"10.0.1.0/24"}}, false},
+		{"expires later", models.APIKey{RateLimit: 10, IPAllowlist: models.StringArray{"10.0.1.0/24"}, ExpiresAt: &later}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := tt.key
+			if got := withinCaller(callerKey.Scopes, callerKey, &key, 60); got != tt.want {
+				t.Errorf("withinCaller() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithinCallerUnrestrictedAPIKey(t *testing.T) {
+	callerKey := &models.APIKey{Scopes: models.StringArray{"api_keys:manage"}}
+	key := &models.APIKey{Scopes: models.StringArray{"api_keys:manage"}}
+
+	if !withinCaller(callerKey.Scopes, callerKey, key, 60) {
+		t.Error("withinCaller() = false for a key as unrestricted as its caller")
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+)
+
+// MaxAPIKeyRateLimit is the highest per-minute rate limit a key can be given
+const MaxAPIKeyRateLimit = 10000
+
+// RateCounter counts hits on a key. Backed by Redis, counts are shared between API
+// instances.
+type RateCounter interface {
+	Incr(key string, exp time.Duration) (int64, error)
+}
+
+// KeyRateLimit is the state of a key's rate limit after a request
+type KeyRateLimit struct {
+	Limit     int
+	Remaining int
+	ResetAt   time.Time
+	Exceeded  bool
+}
+
+// CheckRateLimit counts a request against the key's per-minute limit. Windows are
+// aligned to the clock minute.
+func (s *APIKeyService) CheckRateLimit(apiKey *models.APIKey) (*KeyRateLimit, error) {
+	limit := apiKey.RateLimit
+	if limit == 0 {
+		limit = s.cfg.APIKeyDefaultRateLimit
+	}
+
+	window := time.Now().Truncate(time.Minute)
+	count, err := s.counter.Incr("apikey:"+apiKey.ID.String()+":"+strconv.FormatInt(window.Unix(), 10), 2*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := limit - int(count)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &KeyRateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		ResetAt:   window.Add(time.Minute),
+		Exceeded:  count > int64(limit),
+	}, nil
+}
+
+// memoryRateCounter counts hits in memory, for a single instance without Redis
+type memoryRateCounter struct {
+	mu      sync.Mutex
+	counts  map[string]int64
+	expires map[string]time.Time
+	sweptAt time.Time
+}
+
+// NewMemoryRateCounter creates a RateCounter that only counts within this instance
+func NewMemoryRateCounter() RateCounter {
+	return &memoryRateCounter{
+		counts:  make(map[string]int64),
+		expires: make(map[string]time.Time),
+	}
+}
+
+// Incr implements RateCounter, dropping expired counters once a minute
+func (m *memoryRateCounter) Incr(key string, exp time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(m.sweptAt) > time.Minute {
+		for k, expiresAt := range m.expires {
+			if now.After(expiresAt) {
+				delete(m.counts, k)
+				delete(m.expires, k)
+			}
+		}
+		m.sweptAt = now
+	}
+
+	m.counts[key]++
+	m.expires[key] = now.Add(exp)
+	return m.counts[key], nil
+}
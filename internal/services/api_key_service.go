@@ -2,39 +2,230 @@ package services
 
 import (
 	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/events"
 	"github.com/bankaceh/bas-portal-api/internal/models"
 	"github.com/bankaceh/bas-portal-api/internal/repository"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
-const MaxAPIKeysPerUser = 10
+const (
+	// MaxAPIKeyLifetime is the furthest in the future a key's expiry can be set
+	MaxAPIKeyLifetime = 2 * 365 * 24 * time.Hour
+
+	// MaxAPIKeyRotationGraceHours caps how long a rotated key keeps working
+	MaxAPIKeyRotationGraceHours = 7 * 24
+
+	// APIKeyReactivationWindow is how long after revocation an admin can reactivate a key
+	APIKeyReactivationWindow = 30 * 24 * time.Hour
+
+	// MaxAPIKeyIPAllowlist caps the number of entries in a key's IP allowlist
+	MaxAPIKeyIPAllowlist = 50
+
+	// MaxAPIKeyTags caps the number of tags on a key
+	MaxAPIKeyTags = 10
+
+	// DefaultAdminKeyLimit and MaxAdminKeyLimit bound a page of the staff key search
+	DefaultAdminKeyLimit = 50
+	MaxAdminKeyLimit     = 200
+)
+
+// apiKeySortColumns maps the sort fields ListKeys accepts to columns
+var apiKeySortColumns = map[string]string{
+	"createdAt":  "created_at",
+	"name":       "name",
+	"lastUsedAt": "last_used_at",
+	"expiresAt":  "expires_at",
+}
 
 var (
-	ErrMaxKeysReached = errors.New("maximum number of API keys reached")
-	ErrKeyNotFound    = errors.New("API key not found")
+	ErrMaxKeysReached      = errors.New("maximum number of API keys reached")
+	ErrKeyNotFound         = errors.New("API key not found")
+	ErrInvalidKeyExpiry    = errors.New("expiry must be in the future and within two years")
+	ErrInvalidKeyGrace     = errors.New("grace period must be 0-168 hours")
+	ErrKeyAlreadyRotated   = errors.New("API key has already been rotated")
+	ErrInvalidAPIKey       = errors.New("invalid or expired API key")
+	ErrInvalidKeyScope     = errors.New("unknown API key scope")
+	ErrOrgKeyNotAllowed    = errors.New("organization API keys cannot call the portal API")
+	ErrInvalidRateLimit    = errors.New("rate limit must be 1-10000 requests per minute")
+	ErrInvalidIPAllowlist  = errors.New("IP allowlist entries must be IP addresses or CIDR ranges, at most 50")
+	ErrInvalidKeyName      = errors.New("name must be 1-100 characters and description at most 500")
+	ErrKeyNotRevoked       = errors.New("API key is not revoked")
+	ErrKeyNotReactivatable = errors.New("API key expired, was rotated or was revoked too long ago to reactivate")
+	ErrReactivationReason  = errors.New("reason must be 3-500 characters")
+	ErrTestKeyInProduction = errors.New("sandbox API keys cannot be used in production")
+	ErrInvalidKeyTags      = errors.New("tags must be 1-32 lowercase letters, digits, '-', '_', '.' or ':', at most 10")
+	ErrInvalidKeyListQuery = errors.New("invalid API key list query")
+	ErrInvalidKeySearch    = errors.New("invalid API key search")
+	ErrRevocationReason    = errors.New("reason must be 3-500 characters")
+	ErrKeyNotActive        = errors.New("API key is already revoked or expired")
+	ErrKeyExceedsCaller    = errors.New("a token or API key cannot give a key more access than it has")
 )
 
 // APIKeyService handles API key business logic
 type APIKeyService struct {
-	keyRepo *repository.APIKeyRepository
+	keyRepo   *repository.APIKeyRepository
+	usageRepo *repository.APIKeyUsageRepository
+	orgs      *OrganizationService
+	quotas    *QuotaService
+	links     *SecretLinkService
+	counter   RateCounter
+	bus       *events.Bus
+	cfg       *config.Config
+
+	// Uses recorded since the last FlushUsage, so requests never wait on the write
+	usageMu sync.Mutex
+	usage   map[uuid.UUID]*keyUsage
 }
 
-// NewAPIKeyService creates a new APIKeyService
-func NewAPIKeyService(keyRepo *repository.APIKeyRepository) *APIKeyService {
-	return &APIKeyService{keyRepo: keyRepo}
+// NewAPIKeyService creates a new APIKeyService. A nil counter keeps rate limit counts in
+// memory.
+func NewAPIKeyService(keyRepo *repository.APIKeyRepository, usageRepo *repository.APIKeyUsageRepository, orgs *OrganizationService, quotas *QuotaService, links *SecretLinkService, counter RateCounter, bus *events.Bus, cfg *config.Config) *APIKeyService {
+	if counter == nil {
+		counter = NewMemoryRateCounter()
+	}
+	return &APIKeyService{
+		keyRepo:   keyRepo,
+		usageRepo: usageRepo,
+		orgs:      orgs,
+		quotas:    quotas,
+		links:     links,
+		counter:   counter,
+		bus:       bus,
+		cfg:       cfg,
+		usage:     make(map[uuid.UUID]*keyUsage),
+	}
 }
 
 // CreateKeyInput represents new API key request data
 type CreateKeyInput struct {
 	Name        string `json:"name" validate:"required,min=1,max=100"`
 	Environment string `json:"environment" validate:"required,oneof=sandbox production"`
+	Description string `json:"description,omitempty" validate:"max=500"`
+
+	// Optional labels to find the key by, e.g. "ci"
+	Tags []string `json:"tags,omitempty" validate:"omitempty,max=10"`
+
+	// Optional; the key stops working at this time and is then deactivated
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	// Optional portal scopes for calling the API with X-API-Key, see models.ServiceAccountScopes
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Optional requests per minute; defaults to API_KEY_RATE_LIMIT
+	RateLimit int `json:"rateLimit,omitempty" validate:"omitempty,min=1,max=10000"`
+
+	// Optional IP addresses or CIDR ranges the key may be used from
+	IPAllowlist []string `json:"ipAllowlist,omitempty" validate:"omitempty,max=50"`
+
+	// Creates the key for an organization (editor role required) instead of the user
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+
+	// Returns a single-use link to the key instead of the key itself
+	RetrievalLink bool `json:"retrievalLink,omitempty"`
+}
+
+// UpdateKeyInput replaces a key's editable settings. Omitting expiresAt, rateLimit or
+// ipAllowlist clears them.
+type UpdateKeyInput struct {
+	Name        string     `json:"name" validate:"required,min=1,max=100"`
+	Description string     `json:"description" validate:"max=500"`
+	Tags        []string   `json:"tags" validate:"omitempty,max=10"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	RateLimit   int        `json:"rateLimit" validate:"omitempty,min=1,max=10000"`
+	IPAllowlist []string   `json:"ipAllowlist" validate:"omitempty,max=50"`
+}
+
+// ListKeysQuery filters and sorts a list of keys
+type ListKeysQuery struct {
+	Environment string `query:"environment"` // sandbox or production
+	Tag         string `query:"tag"`
+	Active      string `query:"active"` // true (default) or false for revoked and expired keys
+	Sort        string `query:"sort"`   // createdAt, name, lastUsedAt or expiresAt; prefix with - for descending
+}
+
+// VerifyKeyInput carries a key value to look up
+type VerifyKeyInput struct {
+	Key string `json:"key" validate:"required"`
+}
+
+// KeyVerification describes a key found by its value. Valid is whether it would be
+// accepted by this server right now.
+type KeyVerification struct {
+	models.APIKeyResponse
+	Valid  bool   `json:"valid"`
+	Status string `json:"status"` // active, expired, revoked or sandbox_in_production
+}
+
+// AdminKeyQuery searches every user's keys
+type AdminKeyQuery struct {
+	UserID         string `query:"userId"`
+	OrganizationID string `query:"organizationId"`
+	Prefix         string `query:"prefix"` // Start of the key, e.g. bas_live_1a2b
+	Environment    string `query:"environment"`
+	Active         string `query:"active"` // true or false, any when empty
+	Limit          int    `query:"limit"`
+	Offset         int    `query:"offset"`
+}
+
+// AdminAPIKeyResponse is a key with its owner, for staff
+type AdminAPIKeyResponse struct {
+	models.APIKeyResponse
+	UserID    uuid.UUID `json:"userId"`
+	UserEmail string    `json:"userEmail"`
+}
+
+// AdminKeyPage is one page of staff key search results
+type AdminKeyPage struct {
+	Keys   []AdminAPIKeyResponse `json:"keys"`
+	Total  int64                 `json:"total"`
+	Limit  int                   `json:"limit"`
+	Offset int                   `json:"offset"`
+}
+
+// AdminRevokeKeyInput carries the reason staff revoke a key
+type AdminRevokeKeyInput struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"`
+}
+
+// ReactivateKeyInput carries the reason an admin reactivates a key
+type ReactivateKeyInput struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"`
+}
+
+// RotateKeyInput controls how a key is rotated
+type RotateKeyInput struct {
+	// Hours the old key keeps working, 0 to stop it at once. Defaults to API_KEY_ROTATION_GRACE_HOURS.
+	GraceHours *int `json:"graceHours,omitempty" validate:"omitempty,min=0,max=168"`
+
+	// Optional expiry of the new key
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	// Returns a single-use link to the new key instead of the key itself
+	RetrievalLink bool `json:"retrievalLink,omitempty"`
 }
 
-// ListKeys retrieves all API keys for a user
-func (s *APIKeyService) ListKeys(userID uuid.UUID) ([]models.APIKeyResponse, error) {
-	keys, err := s.keyRepo.FindByUserID(userID)
+// ListKeys retrieves the user's personal API keys, or an organization's keys when orgID
+// is set and the user is a member, filtered and sorted by the query
+func (s *APIKeyService) ListKeys(userID uuid.UUID, orgID *uuid.UUID, query ListKeysQuery) ([]models.APIKeyResponse, error) {
+	filter, err := keyListFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if orgID != nil {
+		if _, err := s.orgs.Authorize(userID, *orgID, models.OrgRoleViewer); err != nil {
+			return nil, err
+		}
+	}
+	keys, err := s.keyRepo.FindForOwner(userID, orgID, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -47,71 +238,703 @@ func (s *APIKeyService) ListKeys(userID uuid.UUID) ([]models.APIKeyResponse, err
 	return response, nil
 }
 
-// CreateKey generates a new API key for a user
-func (s *APIKeyService) CreateKey(userID uuid.UUID, input CreateKeyInput) (*models.APIKeyCreateResponse, error) {
-	// Check key limit
-	count, err := s.keyRepo.CountByUserID(userID)
+// CreateKey generates a new API key for a user. caller is nil for signed-in users.
+func (s *APIKeyService) CreateKey(userID uuid.UUID, input CreateKeyInput, caller *KeyCaller) (*models.APIKeyCreateResponse, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Description = strings.TrimSpace(input.Description)
+	if err := validateKeyName(input.Name, input.Description); err != nil {
+		return nil, err
+	}
+	if err := validateKeyExpiry(input.ExpiresAt); err != nil {
+		return nil, err
+	}
+	if input.RateLimit < 0 || input.RateLimit > MaxAPIKeyRateLimit {
+		return nil, ErrInvalidRateLimit
+	}
+	ipAllowlist, err := normalizeIPAllowlist(input.IPAllowlist)
 	if err != nil {
 		return nil, err
 	}
-	if count >= MaxAPIKeysPerUser {
-		return nil, ErrMaxKeysReached
+	tags, err := normalizeKeyTags(input.Tags)
+	if err != nil {
+		return nil, err
+	}
+	scopes := models.StringArray{}
+	for _, scope := range input.Scopes {
+		if !models.IsValidServiceAccountScope(scope) {
+			return nil, ErrInvalidKeyScope
+		}
+		scopes = append(scopes, scope)
 	}
 
-	// Generate key
-	fullKey, prefix, err := models.GenerateAPIKey()
+	// Check the owner's quota
+	var count int64
+	if input.OrganizationID != nil {
+		if _, err := s.orgs.Authorize(userID, *input.OrganizationID, models.OrgRoleEditor); err != nil {
+			return nil, err
+		}
+		count, err = s.keyRepo.CountByOrganizationID(*input.OrganizationID)
+	} else {
+		count, err = s.keyRepo.CountByUserID(userID)
+	}
 	if err != nil {
 		return nil, err
 	}
-
-	// Hash the key for storage
-	keyHash, err := bcrypt.GenerateFromPassword([]byte(fullKey), bcrypt.DefaultCost)
+	quota, err := s.quotas.ForOwner(userID, input.OrganizationID)
 	if err != nil {
 		return nil, err
 	}
+	if count >= int64(quota.MaxAPIKeys) {
+		return nil, ErrMaxKeysReached
+	}
 
 	// Create API key record
 	apiKey := &models.APIKey{
-		UserID:      userID,
-		Name:        input.Name,
-		KeyPrefix:   prefix,
-		KeyHash:     string(keyHash),
-		Environment: input.Environment,
-		IsActive:    true,
+		UserID:         userID,
+		OrganizationID: input.OrganizationID,
+		Name:           input.Name,
+		Description:    input.Description,
+		Tags:           tags,
+		Environment:    input.Environment,
+		Scopes:         scopes,
+		RateLimit:      input.RateLimit,
+		IPAllowlist:    ipAllowlist,
+		IsActive:       true,
+		ExpiresAt:      input.ExpiresAt,
+	}
+	if err := s.checkCaller(caller, apiKey); err != nil {
+		return nil, err
+	}
+	fullKey, err := generateKeySecret(apiKey)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := s.keyRepo.Create(apiKey); err != nil {
 		return nil, err
 	}
 
-	return &models.APIKeyCreateResponse{
-		APIKeyResponse: apiKey.ToResponse(),
-		Key:            fullKey,
-	}, nil
+	s.bus.Publish(events.APIKeyCreated{UserID: userID, KeyID: apiKey.ID, Environment: apiKey.Environment})
+
+	return s.createResponse(userID, apiKey, fullKey, input.RetrievalLink)
+}
+
+// UpdateKey changes the name, description, expiry, rate limit and IP allowlist of a key
+// owned by the user, or by an organization where the user is at least an editor. Rotated
+// keys cannot be changed, so their grace period cannot be extended. caller is nil for
+// signed-in users.
+func (s *APIKeyService) UpdateKey(keyID, userID uuid.UUID, input UpdateKeyInput, caller *KeyCaller) (*models.APIKeyResponse, error) {
+	name := strings.TrimSpace(input.Name)
+	description := strings.TrimSpace(input.Description)
+	if err := validateKeyName(name, description); err != nil {
+		return nil, err
+	}
+	if err := validateKeyExpiry(input.ExpiresAt); err != nil {
+		return nil, err
+	}
+	if input.RateLimit < 0 || input.RateLimit > MaxAPIKeyRateLimit {
+		return nil, ErrInvalidRateLimit
+	}
+	ipAllowlist, err := normalizeIPAllowlist(input.IPAllowlist)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := normalizeKeyTags(input.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.keyRepo.FindByID(keyID)
+	if err != nil || !key.IsActive || key.IsExpired(time.Now()) {
+		return nil, ErrKeyNotFound
+	}
+	if err := s.orgs.authorizeResource(userID, key.UserID, key.OrganizationID, models.OrgRoleEditor); err != nil {
+		if errors.Is(err, errResourceNotAccessible) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+	if key.ReplacedByID != nil {
+		return nil, ErrKeyAlreadyRotated
+	}
+
+	key.Name = name
+	key.Description = description
+	key.Tags = tags
+	key.ExpiresAt = input.ExpiresAt
+	key.RateLimit = input.RateLimit
+	key.IPAllowlist = ipAllowlist
+	if err := s.checkCaller(caller, key); err != nil {
+		return nil, err
+	}
+	if err := s.keyRepo.UpdateDetails(key); err != nil {
+		return nil, err
+	}
+
+	response := key.ToResponse()
+	return &response, nil
 }
 
-// RevokeKey deactivates an API key
+// RevokeKey deactivates an API key owned by the user, or by an organization where the
+// user is at least an editor
 func (s *APIKeyService) RevokeKey(keyID, userID uuid.UUID) error {
-	// Verify key exists and belongs to user
 	key, err := s.keyRepo.FindByID(keyID)
 	if err != nil {
 		return ErrKeyNotFound
 	}
 
-	if key.UserID != userID {
+	if err := s.orgs.authorizeResource(userID, key.UserID, key.OrganizationID, models.OrgRoleEditor); err != nil {
+		if errors.Is(err, errResourceNotAccessible) {
+			return ErrKeyNotFound
+		}
+		return err
+	}
+	if key.RevokedAt != nil {
+		return ErrKeyNotActive
+	}
+
+	revoked, err := s.keyRepo.Revoke(keyID, key.UserID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrKeyNotActive
+	}
+
+	s.bus.Publish(events.APIKeyRevoked{UserID: userID, KeyID: keyID})
+	return nil
+}
+
+// RevokeAllKeys deactivates every active personal key the user owns at once, for when
+// keys may have leaked, and returns how many were revoked
+func (s *APIKeyService) RevokeAllKeys(userID uuid.UUID) (int, error) {
+	return s.revokeAll(userID, userID)
+}
+
+// AdminRevokeAllKeys deactivates every active personal key a user owns on behalf of staff
+// responding to an incident
+func (s *APIKeyService) AdminRevokeAllKeys(adminID, userID uuid.UUID) (int, error) {
+	return s.revokeAll(adminID, userID)
+}
+
+// revokeAll revokes the owner's keys, publishing a revocation by actorID for each
+func (s *APIKeyService) revokeAll(actorID, ownerID uuid.UUID) (int, error) {
+	keys, err := s.keyRepo.RevokeAllForUser(ownerID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		s.bus.Publish(events.APIKeyRevoked{UserID: actorID, KeyID: key.ID})
+	}
+	return len(keys), nil
+}
+
+// RotateKey issues a new key with the same name, environment and owner. The old key
+// keeps working for the grace period so integrations can switch without downtime, then
+// expires. Rotation does not count against the quota. caller is nil for signed-in users.
+func (s *APIKeyService) RotateKey(keyID, userID uuid.UUID, input RotateKeyInput, caller *KeyCaller) (*models.APIKeyCreateResponse, error) {
+	graceHours := s.cfg.APIKeyRotationGraceHours
+	if input.GraceHours != nil {
+		graceHours = *input.GraceHours
+	}
+	if graceHours < 0 || graceHours > MaxAPIKeyRotationGraceHours {
+		return nil, ErrInvalidKeyGrace
+	}
+	if err := validateKeyExpiry(input.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	key, err := s.keyRepo.FindByID(keyID)
+	if err != nil || !key.IsActive || key.IsExpired(time.Now()) {
+		return nil, ErrKeyNotFound
+	}
+	if err := s.orgs.authorizeResource(userID, key.UserID, key.OrganizationID, models.OrgRoleEditor); err != nil {
+		if errors.Is(err, errResourceNotAccessible) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+	if key.ReplacedByID != nil {
+		return nil, ErrKeyAlreadyRotated
+	}
+
+	replacement := &models.APIKey{
+		ID:             models.NewLegacyID(),
+		UserID:         userID,
+		OrganizationID: key.OrganizationID,
+		Name:           key.Name,
+		Description:    key.Description,
+		Tags:           key.Tags,
+		Environment:    key.Environment,
+		Scopes:         key.Scopes,
+		RateLimit:      key.RateLimit,
+		IPAllowlist:    key.IPAllowlist,
+		IsActive:       true,
+		ExpiresAt:      input.ExpiresAt,
+	}
+	if err := s.checkCaller(caller, replacement); err != nil {
+		return nil, err
+	}
+	fullKey, err := generateKeySecret(replacement)
+	if err != nil {
+		return nil, err
+	}
+	graceEndsAt := time.Now().Add(time.Duration(graceHours) * time.Hour)
+
+	rotated, err := s.keyRepo.Rotate(key.ID, replacement, graceEndsAt)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, ErrKeyAlreadyRotated
+	}
+
+	s.bus.Publish(events.APIKeyRotated{UserID: userID, KeyID: key.ID, NewKeyID: replacement.ID, GraceEndsAt: graceEndsAt})
+
+	return s.createResponse(userID, replacement, fullKey, input.RetrievalLink)
+}
+
+// createResponse returns a new key once, or a single-use link to it when asked for one
+func (s *APIKeyService) createResponse(userID uuid.UUID, key *models.APIKey, fullKey string, retrievalLink bool) (*models.APIKeyCreateResponse, error) {
+	response := &models.APIKeyCreateResponse{APIKeyResponse: key.ToResponse()}
+	if !retrievalLink {
+		response.Key = fullKey
+		return response, nil
+	}
+
+	link, err := s.links.Issue(userID, SecretRetrieval{
+		ResourceType: models.SecretLinkAPIKey,
+		ResourceID:   key.ID,
+		Name:         key.Name,
+		Secret:       fullKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	response.RetrievalLink = link
+	return response, nil
+}
+
+// SearchKeys finds keys across all users and organizations for staff
+func (s *APIKeyService) SearchKeys(query AdminKeyQuery) (*AdminKeyPage, error) {
+	search := repository.APIKeySearch{
+		Prefix:      strings.TrimSpace(query.Prefix),
+		Environment: query.Environment,
+		Limit:       query.Limit,
+		Offset:      query.Offset,
+	}
+	if search.Limit == 0 {
+		search.Limit = DefaultAdminKeyLimit
+	}
+	if search.Limit < 0 || search.Limit > MaxAdminKeyLimit || search.Offset < 0 {
+		return nil, ErrInvalidKeySearch
+	}
+	if search.Environment != "" && search.Environment != "sandbox" && search.Environment != "production" {
+		return nil, ErrInvalidKeySearch
+	}
+
+	for _, id := range []struct {
+		raw string
+		dst **uuid.UUID
+	}{{query.UserID, &search.UserID}, {query.OrganizationID, &search.OrganizationID}} {
+		if id.raw == "" {
+			continue
+		}
+		parsed, err := uuid.Parse(id.raw)
+		if err != nil {
+			return nil, ErrInvalidKeySearch
+		}
+		*id.dst = &parsed
+	}
+	switch query.Active {
+	case "":
+	case "true", "false":
+		active := query.Active == "true"
+		search.Active = &active
+	default:
+		return nil, ErrInvalidKeySearch
+	}
+
+	keys, total, err := s.keyRepo.Search(search)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AdminKeyPage{
+		Keys:   make([]AdminAPIKeyResponse, len(keys)),
+		Total:  total,
+		Limit:  search.Limit,
+		Offset: search.Offset,
+	}
+	for i := range keys {
+		page.Keys[i] = AdminAPIKeyResponse{
+			APIKeyResponse: keys[i].ToResponse(),
+			UserID:         keys[i].UserID,
+			UserEmail:      keys[i].User.Email,
+		}
+	}
+	return page, nil
+}
+
+// AdminRevokeKey lets staff revoke any active key, e.g. one found in a leak. The owner is
+// notified with the reason.
+func (s *APIKeyService) AdminRevokeKey(adminID, keyID uuid.UUID, input AdminRevokeKeyInput) error {
+	reason := strings.TrimSpace(input.Reason)
+	if len(reason) < 3 || len(reason) > 500 {
+		return ErrRevocationReason
+	}
+
+	key, err := s.keyRepo.FindByID(keyID)
+	if err != nil {
 		return ErrKeyNotFound
 	}
+	if !key.IsActive {
+		return ErrKeyNotActive
+	}
+
+	revoked, err := s.keyRepo.Revoke(key.ID, key.UserID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrKeyNotActive
+	}
+
+	s.bus.Publish(events.APIKeyForceRevoked{UserID: key.UserID, KeyID: key.ID, Reason: reason, RevokedBy: adminID})
+	return nil
+}
+
+// ReactivateKey lets an admin undo an accidental revocation. Keys that expired, were
+// rotated, were revoked over APIKeyReactivationWindow ago, or whose owner is gone cannot
+// be reactivated, and the owner's quota still applies.
+func (s *APIKeyService) ReactivateKey(adminID, keyID uuid.UUID, input ReactivateKeyInput) (*models.APIKeyResponse, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if len(reason) < 3 || len(reason) > 500 {
+		return nil, ErrReactivationReason
+	}
+
+	key, err := s.keyRepo.FindByID(keyID)
+	if err != nil {
+		return nil, ErrKeyNotFound
+	}
+	if key.IsActive {
+		return nil, ErrKeyNotRevoked
+	}
+	if key.RevokedAt == nil || key.ReplacedByID != nil || key.IsExpired(time.Now()) ||
+		time.Since(*key.RevokedAt) > APIKeyReactivationWindow {
+		return nil, ErrKeyNotReactivatable
+	}
+
+	// The owner must still exist and have room for the key
+	var quota *models.Quota
+	var count int64
+	if key.OrganizationID != nil {
+		quota, err = s.quotas.GetOrganizationQuota(*key.OrganizationID)
+		if err == nil {
+			count, err = s.keyRepo.CountByOrganizationID(*key.OrganizationID)
+		}
+	} else {
+		quota, err = s.quotas.GetUserQuota(key.UserID)
+		if err == nil {
+			count, err = s.keyRepo.CountByUserID(key.UserID)
+		}
+	}
+	if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrOrganizationNotFound) {
+		return nil, ErrKeyNotReactivatable
+	}
+	if err != nil {
+		return nil, err
+	}
+	if count >= int64(quota.MaxAPIKeys) {
+		return nil, ErrMaxKeysReached
+	}
+
+	reactivated, err := s.keyRepo.Reactivate(key.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !reactivated {
+		return nil, ErrKeyNotRevoked
+	}
+
+	s.bus.Publish(events.APIKeyReactivated{UserID: key.UserID, KeyID: key.ID, Reason: reason, ReactivatedBy: adminID})
+
+	key.IsActive = true
+	key.RevokedAt = nil
+	response := key.ToResponse()
+	return &response, nil
+}
+
+// DeactivateExpiredKeys deactivates keys whose expiry has passed (run by the scheduler).
+// Expired keys are already refused by validation; this makes the list and counts match.
+func (s *APIKeyService) DeactivateExpiredKeys() error {
+	keys, err := s.keyRepo.FindExpired(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		expired, err := s.keyRepo.Expire(key.ID)
+		if err != nil {
+			return err
+		}
+		if expired {
+			s.bus.Publish(events.APIKeyExpired{UserID: key.UserID, KeyID: key.ID})
+		}
+	}
+	return nil
+}
+
+// VerifyKey looks up a key by its value so developers can check what it is while debugging
+// an integration. Keys the user cannot see are reported as not found, like unknown keys.
+func (s *APIKeyService) VerifyKey(userID uuid.UUID, input VerifyKeyInput) (*KeyVerification, error) {
+	key, err := s.findAnyKey(strings.TrimSpace(input.Key))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.orgs.authorizeResource(userID, key.UserID, key.OrganizationID, models.OrgRoleViewer); err != nil {
+		if errors.Is(err, errResourceNotAccessible) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+
+	verification := &KeyVerification{APIKeyResponse: key.ToResponse(), Status: "active"}
+	switch {
+	case key.IsExpired(time.Now()):
+		verification.Status = "expired"
+	case !key.IsActive:
+		verification.Status = "revoked"
+	case s.cfg.Env == "production" && key.Environment != "production":
+		verification.Status = "sandbox_in_production"
+	default:
+		verification.Valid = true
+	}
+	return verification, nil
+}
+
+// findAnyKey finds the key matching a presented key whatever its status, or returns
+// ErrKeyNotFound
+func (s *APIKeyService) findAnyKey(key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, "bas_") || len(key) < 12 {
+		return nil, ErrKeyNotFound
+	}
 
-	return s.keyRepo.Revoke(keyID, userID)
+	apiKey, err := s.keyRepo.FindAnyByKeyDigest(models.DigestAPIKey(key))
+	if err == nil {
+		return apiKey, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if strings.HasPrefix(key, models.APIKeyLivePrefix) || strings.HasPrefix(key, models.APIKeyTestPrefix) {
+		return nil, ErrKeyNotFound
+	}
+
+	legacy, err := s.keyRepo.FindAnyLegacyByPrefix(key[:12])
+	if err != nil {
+		return nil, err
+	}
+	for i := range legacy {
+		if bcrypt.CompareHashAndPassword([]byte(legacy[i].KeyHash), []byte(key)) == nil {
+			return &legacy[i], nil
+		}
+	}
+	return nil, ErrKeyNotFound
 }
 
 // ValidateKey checks if an API key is valid and returns the associated user
 func (s *APIKeyService) ValidateKey(key string) (*models.User, error) {
-	// Find all active keys and check against hash
-	// Note: In production, you'd want a more efficient lookup
-	// This is simplified for demonstration
+	apiKey, err := s.findValidKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey.User, nil
+}
+
+// Authenticate resolves a key presented in X-API-Key. Only personal keys can call the
+// portal API; organizations use service accounts.
+func (s *APIKeyService) Authenticate(key string) (*models.APIKey, error) {
+	apiKey, err := s.findValidKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey.OrganizationID != nil {
+		return nil, ErrOrgKeyNotAllowed
+	}
+	return apiKey, nil
+}
+
+// findValidKey looks up an active, unexpired key by its digest. Keys created before
+// digests were stored are matched by prefix and bcrypt, and get their digest on the way.
+// A production server only accepts production keys.
+func (s *APIKeyService) findValidKey(key string) (*models.APIKey, error) {
+	apiKey, err := s.lookupKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.Env == "production" && apiKey.Environment != "production" {
+		return nil, ErrTestKeyInProduction
+	}
+	return apiKey, nil
+}
+
+// lookupKey finds the active, unexpired key matching a presented key
+func (s *APIKeyService) lookupKey(key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, "bas_") || len(key) < 12 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	digest := models.DigestAPIKey(key)
+	apiKey, err := s.keyRepo.FindByKeyDigest(digest)
+	if err == nil {
+		return apiKey, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	// Keys with an environment prefix always have a digest
+	if strings.HasPrefix(key, models.APIKeyLivePrefix) || strings.HasPrefix(key, models.APIKeyTestPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	legacy, err := s.keyRepo.FindLegacyByPrefix(key[:12])
+	if err != nil {
+		return nil, err
+	}
+	for i := range legacy {
+		if bcrypt.CompareHashAndPassword([]byte(legacy[i].KeyHash), []byte(key)) != nil {
+			continue
+		}
+		if err := s.keyRepo.SetDigest(legacy[i].ID, digest); err != nil {
+			return nil, err
+		}
+		return &legacy[i], nil
+	}
+	return nil, ErrInvalidAPIKey
+}
+
+// validateKeyName checks a key's name and description lengths
+func validateKeyName(name, description string) error {
+	if name == "" || len(name) > 100 || len(description) > 500 {
+		return ErrInvalidKeyName
+	}
+	return nil
+}
+
+// validateKeyExpiry checks an optional expiry is in the future and within MaxAPIKeyLifetime
+func validateKeyExpiry(expiresAt *time.Time) error {
+	if expiresAt == nil {
+		return nil
+	}
+	now := time.Now()
+	if !expiresAt.After(now) || expiresAt.After(now.Add(MaxAPIKeyLifetime)) {
+		return ErrInvalidKeyExpiry
+	}
+	return nil
+}
+
+// normalizeIPAllowlist checks IP allowlist entries and stores them as CIDR ranges, plain
+// addresses becoming single-host ranges
+func normalizeIPAllowlist(entries []string) (models.StringArray, error) {
+	if len(entries) > MaxAPIKeyIPAllowlist {
+		return nil, ErrInvalidIPAllowlist
+	}
+	allowlist := models.StringArray{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, ErrInvalidIPAllowlist
+			}
+			if ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, ErrInvalidIPAllowlist
+		}
+		allowlist = append(allowlist, network.String())
+	}
+	return allowlist, nil
+}
+
+// normalizeKeyTags lowercases and de-duplicates tags, keeping their order
+func normalizeKeyTags(entries []string) (models.StringArray, error) {
+	if len(entries) > MaxAPIKeyTags {
+		return nil, ErrInvalidKeyTags
+	}
+	tags := models.StringArray{}
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		tag := strings.ToLower(strings.TrimSpace(entry))
+		if tag == "" || len(tag) > 32 {
+			return nil, ErrInvalidKeyTags
+		}
+		for _, r := range tag {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && !strings.ContainsRune("-_.:", r) {
+				return nil, ErrInvalidKeyTags
+			}
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// keyListFilter checks a list query and turns it into a repository filter
+func keyListFilter(query ListKeysQuery) (repository.APIKeyFilter, error) {
+	filter := repository.APIKeyFilter{
+		Environment: query.Environment,
+		Tag:         strings.ToLower(strings.TrimSpace(query.Tag)),
+		Active:      true,
+	}
+	if filter.Environment != "" && filter.Environment != "sandbox" && filter.Environment != "production" {
+		return filter, ErrInvalidKeyListQuery
+	}
+
+	switch query.Active {
+	case "", "true":
+	case "false":
+		filter.Active = false
+	default:
+		return filter, ErrInvalidKeyListQuery
+	}
+
+	if query.Sort != "" {
+		field := strings.TrimPrefix(query.Sort, "-")
+		column, ok := apiKeySortColumns[field]
+		if !ok {
+			return filter, ErrInvalidKeyListQuery
+		}
+		filter.Sort = column
+		filter.Descending = strings.HasPrefix(query.Sort, "-")
+	}
+	return filter, nil
+}
+
+// generateKeySecret generates a key for apiKey's environment, sets its display prefix,
+// hash and digest, and returns the full key
+func generateKeySecret(apiKey *models.APIKey) (string, error) {
+	fullKey, prefix, err := models.GenerateAPIKey(apiKey.Environment)
+	if err != nil {
+		return "", err
+	}
+
+	// Hash the key for storage
+	keyHash, err := bcrypt.GenerateFromPassword([]byte(fullKey), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
 
-	// For now, we'll just return an error
-	// Real implementation would hash the key and look it up
-	return nil, errors.New("key validation not implemented")
+	apiKey.KeyPrefix = prefix
+	apiKey.KeyHash = string(keyHash)
+	apiKey.KeyDigest = models.DigestAPIKey(fullKey)
+	return fullKey, nil
 }
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// APIKeyUsageRetention is how long hourly usage is kept
+	APIKeyUsageRetention = 90 * 24 * time.Hour
+
+	// Longest range a usage series can cover per interval
+	maxHourlyUsageRange = 7 * 24 * time.Hour
+	maxDailyUsageRange  = APIKeyUsageRetention
+)
+
+var ErrInvalidUsageQuery = errors.New("invalid usage query")
+
+// UsageQuery selects a range of a key's usage. From defaults to 7 days before To, To to
+// now, and Interval to "hour".
+type UsageQuery struct {
+	From     string `query:"from"` // RFC 3339
+	To       string `query:"to"`   // RFC 3339
+	Interval string `query:"interval"`
+}
+
+// keyUsage accumulates one key's requests between flushes
+type keyUsage struct {
+	requests   int64
+	lastUsedAt time.Time
+	ip         string
+	userAgent  string
+	hours      map[time.Time]*models.APIKeyUsage
+}
+
+// RecordUse counts a request made with a key, as an error when it failed. It is written
+// by FlushUsage.
+func (s *APIKeyService) RecordUse(keyID uuid.UUID, ip, userAgent string, failed bool) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	usage, ok := s.usage[keyID]
+	if !ok {
+		usage = &keyUsage{hours: make(map[time.Time]*models.APIKeyUsage)}
+		s.usage[keyID] = usage
+	}
+	now := time.Now()
+	usage.requests++
+	usage.lastUsedAt = now
+	usage.ip = truncate(ip, 64)
+	usage.userAgent = truncate(userAgent, 512)
+
+	hour := now.UTC().Truncate(time.Hour)
+	bucket, ok := usage.hours[hour]
+	if !ok {
+		bucket = &models.APIKeyUsage{KeyID: keyID, BucketStart: hour}
+		usage.hours[hour] = bucket
+	}
+	bucket.Requests++
+	if failed {
+		bucket.Errors++
+	}
+}
+
+// FlushUsage writes the uses recorded since the last flush (run by the scheduler). Uses
+// that could not be written are kept for the next run.
+func (s *APIKeyService) FlushUsage() error {
+	s.usageMu.Lock()
+	pending := s.usage
+	s.usage = make(map[uuid.UUID]*keyUsage)
+	s.usageMu.Unlock()
+
+	for keyID, usage := range pending {
+		// Hourly buckets first, each dropped once written, so a retry never counts twice
+		for hour, bucket := range usage.hours {
+			if err := s.usageRepo.Add(bucket); err != nil {
+				s.requeueUsage(pending)
+				return err
+			}
+			delete(usage.hours, hour)
+		}
+		if err := s.keyRepo.AddUsage(keyID, usage.requests, usage.lastUsedAt, usage.ip, usage.userAgent); err != nil {
+			s.requeueUsage(pending)
+			return err
+		}
+		delete(pending, keyID)
+	}
+	return nil
+}
+
+// requeueUsage merges unwritten uses back into those recorded since
+func (s *APIKeyService) requeueUsage(pending map[uuid.UUID]*keyUsage) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	for keyID, usage := range pending {
+		newer, ok := s.usage[keyID]
+		if !ok {
+			s.usage[keyID] = usage
+			continue
+		}
+		newer.requests += usage.requests
+		for hour, bucket := range usage.hours {
+			if newerBucket, ok := newer.hours[hour]; ok {
+				newerBucket.Requests += bucket.Requests
+				newerBucket.Errors += bucket.Errors
+			} else {
+				newer.hours[hour] = bucket
+			}
+		}
+	}
+}
+
+// PruneUsage deletes hourly usage older than APIKeyUsageRetention (run by the scheduler)
+func (s *APIKeyService) PruneUsage() error {
+	return s.usageRepo.DeleteBefore(time.Now().Add(-APIKeyUsageRetention))
+}
+
+// UsageSeries returns a key's request and error counts per hour or day. Revoked keys keep
+// their history. Members of the owning organization can see it.
+func (s *APIKeyService) UsageSeries(keyID, userID uuid.UUID, query UsageQuery) (*models.APIKeyUsageSeries, error) {
+	interval := query.Interval
+	if interval == "" {
+		interval = models.UsageIntervalHour
+	}
+	step, maxRange := time.Hour, maxHourlyUsageRange
+	switch interval {
+	case models.UsageIntervalHour:
+	case models.UsageIntervalDay:
+		step, maxRange = 24*time.Hour, maxDailyUsageRange
+	default:
+		return nil, ErrInvalidUsageQuery
+	}
+
+	to := time.Now().UTC()
+	if query.To != "" {
+		t, err := time.Parse(time.RFC3339, query.To)
+		if err != nil {
+			return nil, ErrInvalidUsageQuery
+		}
+		to = t.UTC()
+	}
+	from := to.Add(-7 * 24 * time.Hour)
+	if query.From != "" {
+		t, err := time.Parse(time.RFC3339, query.From)
+		if err != nil {
+			return nil, ErrInvalidUsageQuery
+		}
+		from = t.UTC()
+	}
+	// Whole buckets only, the last one including to
+	from = from.Truncate(step)
+	to = to.Truncate(step).Add(step)
+	if !from.Before(to) || to.Sub(from) > maxRange {
+		return nil, ErrInvalidUsageQuery
+	}
+
+	key, err := s.keyRepo.FindByID(keyID)
+	if err != nil {
+		return nil, ErrKeyNotFound
+	}
+	if err := s.orgs.authorizeResource(userID, key.UserID, key.OrganizationID, models.OrgRoleViewer); err != nil {
+		if errors.Is(err, errResourceNotAccessible) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+
+	counts, err := s.usageRepo.Series(keyID, from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	series := &models.APIKeyUsageSeries{
+		KeyID:    keyID,
+		From:     from,
+		To:       to,
+		Interval: interval,
+		Buckets:  make([]models.APIKeyUsageBucket, int(to.Sub(from)/step)),
+	}
+	// Fill every interval so charts have no gaps
+	for i := range series.Buckets {
+		series.Buckets[i].Start = from.Add(time.Duration(i) * step)
+	}
+	for _, count := range counts {
+		i := int(count.Bucket.Sub(from) / step)
+		if i < 0 || i >= len(series.Buckets) {
+			continue
+		}
+		bucket := &series.Buckets[i]
+		bucket.Requests = count.Requests
+		bucket.Errors = count.Errors
+		if count.Requests > 0 {
+			bucket.ErrorRate = float64(count.Errors) / float64(count.Requests)
+		}
+		series.TotalRequests += count.Requests
+		series.TotalErrors += count.Errors
+	}
+	return series, nil
+}
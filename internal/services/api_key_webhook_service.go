@@ -0,0 +1,199 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKeyWebhookEvents are the key lifecycle events delivered to the key owner's webhook
+var APIKeyWebhookEvents = []string{
+	events.APIKeyCreated{}.EventName(),
+	events.APIKeyRotated{}.EventName(),
+	events.APIKeyRevoked{}.EventName(),
+	events.APIKeyForceRevoked{}.EventName(),
+	events.APIKeyExpired{}.EventName(),
+}
+
+var ErrAPIKeyWebhookNotFound = errors.New("API key webhook is not configured")
+
+// APIKeyWebhookService delivers signed API key lifecycle events to the endpoints users
+// configure, so partner tooling can react to keys changing
+type APIKeyWebhookService struct {
+	repo    *repository.APIKeyWebhookRepository
+	keyRepo *repository.APIKeyRepository
+	client  *http.Client
+	cfg     *config.Config
+}
+
+// NewAPIKeyWebhookService creates a new APIKeyWebhookService. In production deliveries
+// may not connect to private or loopback addresses, since the URLs come from users.
+func NewAPIKeyWebhookService(repo *repository.APIKeyWebhookRepository, keyRepo *repository.APIKeyRepository, cfg *config.Config) *APIKeyWebhookService {
+	client := &http.Client{Timeout: 10 * time.Second}
+	if cfg.Env == "production" {
+		dialer := &net.Dialer{Timeout: 5 * time.Second, Control: rejectInternalAddress}
+		client.Transport = &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 5 * time.Second}
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return &APIKeyWebhookService{
+		repo:    repo,
+		keyRepo: keyRepo,
+		client:  client,
+		cfg:     cfg,
+	}
+}
+
+// ConfigureAPIKeyWebhookInput sets the user's webhook endpoint
+type ConfigureAPIKeyWebhookInput struct {
+	URL          string `json:"url" validate:"required,url"`
+	RotateSecret bool   `json:"rotateSecret"`
+}
+
+// APIKeyWebhookResponse describes the webhook; Secret is only set when newly generated
+type APIKeyWebhookResponse struct {
+	models.APIKeyWebhook
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+// Subscribe forwards key lifecycle events to the owners' webhooks
+func (s *APIKeyWebhookService) Subscribe(bus *events.Bus) {
+	for _, name := range APIKeyWebhookEvents {
+		bus.Subscribe(name, s.Deliver)
+	}
+}
+
+// GetWebhook returns the user's webhook
+func (s *APIKeyWebhookService) GetWebhook(userID uuid.UUID) (*APIKeyWebhookResponse, error) {
+	webhook, err := s.find(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &APIKeyWebhookResponse{APIKeyWebhook: *webhook, Events: APIKeyWebhookEvents}, nil
+}
+
+// ConfigureWebhook sets the user's endpoint. A signing secret is generated the first
+// time, or when rotation is requested, and returned only in that response.
+func (s *APIKeyWebhookService) ConfigureWebhook(userID uuid.UUID, input ConfigureAPIKeyWebhookInput) (*APIKeyWebhookResponse, error) {
+	if err := validateWebhookURL(input.URL, s.cfg); err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		webhook = &models.APIKeyWebhook{UserID: userID}
+	}
+
+	var secret string
+	if webhook.Secret == "" || input.RotateSecret {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+		webhook.Secret = secret
+	}
+
+	webhook.URL = input.URL
+	if err := s.repo.Save(webhook); err != nil {
+		return nil, err
+	}
+	return &APIKeyWebhookResponse{APIKeyWebhook: *webhook, Events: APIKeyWebhookEvents, Secret: secret}, nil
+}
+
+// DeleteWebhook stops delivering the user's key events
+func (s *APIKeyWebhookService) DeleteWebhook(userID uuid.UUID) error {
+	deleted, err := s.repo.DeleteByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrAPIKeyWebhookNotFound
+	}
+	return nil
+}
+
+// SendTest delivers a test event synchronously so users can check their endpoint
+func (s *APIKeyWebhookService) SendTest(userID uuid.UUID) error {
+	webhook, err := s.find(userID)
+	if err != nil {
+		return err
+	}
+	return postSignedEvent(s.client, webhook.URL, webhook.Secret, testWebhookEnvelope())
+}
+
+// Deliver is an event bus handler that forwards a key event to the webhook of the key's
+// owner, who may not be the user that acted on it, retrying in the background
+func (s *APIKeyWebhookService) Deliver(envelope events.Envelope) error {
+	var keyID uuid.UUID
+	switch event := envelope.Event.(type) {
+	case events.APIKeyCreated:
+		keyID = event.KeyID
+	case events.APIKeyRotated:
+		keyID = event.KeyID
+	case events.APIKeyRevoked:
+		keyID = event.KeyID
+	case events.APIKeyForceRevoked:
+		keyID = event.KeyID
+	case events.APIKeyExpired:
+		keyID = event.KeyID
+	default:
+		return nil
+	}
+
+	key, err := s.keyRepo.FindByID(keyID)
+	if err != nil {
+		return err
+	}
+	webhook, err := s.repo.FindByUserID(key.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	go deliverWithRetry("API key webhook", envelope, func() error {
+		return postSignedEvent(s.client, webhook.URL, webhook.Secret, envelope)
+	})
+	return nil
+}
+
+// find returns the user's webhook or ErrAPIKeyWebhookNotFound
+func (s *APIKeyWebhookService) find(userID uuid.UUID) (*models.APIKeyWebhook, error) {
+	webhook, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyWebhookNotFound
+		}
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// rejectInternalAddress stops a webhook connection to a loopback, private, link-local or
+// unspecified address. It runs after DNS resolution, so hostnames can't get around it.
+func rejectInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("webhook address %s is not allowed", host)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/mailer"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAccountNotApproved     = errors.New("account is waiting for approval")
+	ErrAccountNotPending      = errors.New("account is not waiting for approval")
+	ErrApprovalReasonLength   = errors.New("reason must be 3-500 characters")
+	ErrAccountRejected        = errors.New("account was not approved")
+	ErrCannotReviewOwnAccount = errors.New("you cannot review your own account")
+)
+
+// ApprovalService lets staff approve new accounts before they can create API keys and
+// partner credentials, when REGISTRATION_REQUIRE_APPROVAL is on
+type ApprovalService struct {
+	userRepo *repository.UserRepository
+	mailer   mailer.Mailer
+	bus      *events.Bus
+	cfg      *config.Config
+}
+
+// NewApprovalService creates a new ApprovalService
+func NewApprovalService(userRepo *repository.UserRepository, mailer mailer.Mailer, bus *events.Bus, cfg *config.Config) *ApprovalService {
+	return &ApprovalService{
+		userRepo: userRepo,
+		mailer:   mailer,
+		bus:      bus,
+		cfg:      cfg,
+	}
+}
+
+// RejectAccountInput carries the reason a new account was not approved
+type RejectAccountInput struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"`
+}
+
+// Subscribe tells staff about accounts that need review
+func (s *ApprovalService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.UserRegistered{}.EventName(), s.onUserRegistered)
+}
+
+// onUserRegistered emails the partnership team when a new account is waiting for approval
+func (s *ApprovalService) onUserRegistered(envelope events.Envelope) error {
+	event := envelope.Event.(events.UserRegistered)
+
+	user, err := s.userRepo.FindByID(event.UserID)
+	if err != nil {
+		return err
+	}
+	if user.ApprovalStatus != models.ApprovalStatusPending {
+		return nil
+	}
+
+	body := fmt.Sprintf(
+		"A new developer account is waiting for approval.\n\nName: %s\nEmail: %s\nCompany: %s\n\n"+
+			"Review it in the admin console:\n\n%s/admin/approvals",
+		user.FullName, user.Email, user.Company, s.cfg.FrontendURL,
+	)
+	if err := s.mailer.Send(s.cfg.PartnershipEmail, "New account awaiting approval: "+user.Email, body); err != nil {
+		log.Printf("Failed to send approval request email for %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+// ListPending lists accounts waiting for approval, the longest waiting first
+func (s *ApprovalService) ListPending() ([]models.UserResponse, error) {
+	users, err := s.userRepo.FindByApprovalStatus(models.ApprovalStatusPending)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.UserResponse, len(users))
+	for i := range users {
+		responses[i] = users[i].ToResponse()
+	}
+	return responses, nil
+}
+
+// Approve lets a pending account create API keys and partner credentials
+func (s *ApprovalService) Approve(staffID, userID uuid.UUID) (*models.UserResponse, error) {
+	user, err := s.review(staffID, userID, models.ApprovalStatusApproved, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.UserApproved{UserID: userID, ApprovedBy: staffID})
+
+	s.notify(user, "Your BAS Developer Portal account is approved", fmt.Sprintf(
+		"Hi %s,\n\nYour BAS Developer Portal account has been approved. You can now create API keys "+
+			"and partner credentials:\n\n%s",
+		user.FullName, s.cfg.FrontendURL,
+	))
+
+	return s.reload(userID)
+}
+
+// Reject declines a pending account with a reason the user is emailed
+func (s *ApprovalService) Reject(staffID, userID uuid.UUID, input RejectAccountInput) (*models.UserResponse, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if len(reason) < 3 || len(reason) > 500 {
+		return nil, ErrApprovalReasonLength
+	}
+
+	user, err := s.review(staffID, userID, models.ApprovalStatusRejected, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.UserRejected{UserID: userID, Reason: reason, RejectedBy: staffID})
+
+	s.notify(user, "Your BAS Developer Portal account was not approved", fmt.Sprintf(
+		"Hi %s,\n\nYour BAS Developer Portal account was not approved.\n\nReason: %s\n\n"+
+			"Please contact %s if you have questions.",
+		user.FullName, reason, s.cfg.PartnershipEmail,
+	))
+
+	return s.reload(userID)
+}
+
+// CheckApproved returns ErrAccountNotApproved or ErrAccountRejected unless the user's
+// account is approved
+func (s *ApprovalService) CheckApproved(userID uuid.UUID) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	switch user.ApprovalStatus {
+	case models.ApprovalStatusPending:
+		return ErrAccountNotApproved
+	case models.ApprovalStatusRejected:
+		return ErrAccountRejected
+	}
+	return nil
+}
+
+// review records a decision on a pending account
+func (s *ApprovalService) review(staffID, userID uuid.UUID, status, reason string) (*models.User, error) {
+	if staffID == userID {
+		return nil, ErrCannotReviewOwnAccount
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	reviewed, err := s.userRepo.ReviewApproval(userID, staffID, status, reason)
+	if err != nil {
+		return nil, err
+	}
+	if !reviewed {
+		return nil, ErrAccountNotPending
+	}
+	return user, nil
+}
+
+// reload returns the user's current profile
+func (s *ApprovalService) reload(userID uuid.UUID) (*models.UserResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// notify emails the account holder, logging failures
+func (s *ApprovalService) notify(user *models.User, subject, body string) {
+	if err := s.mailer.Send(user.Email, subject, body); err != nil {
+		log.Printf("Failed to send approval email to %s: %v", user.Email, err)
+	}
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Audit log and activity feed page sizes
+const (
+	DefaultAuditLogLimit = 50
+	MaxAuditLogLimit     = 200
+	DefaultActivityLimit = 20
+	MaxActivityLimit     = 100
+)
+
+// AuditActionLoginFailed is recorded for every rejected password login. There is no
+// actor; the attempted email and reason are kept as new values.
+const AuditActionLoginFailed = "auth.login_failed"
+
+// AuditActionLogin is recorded for every successful sign-in, with the user as actor
+const AuditActionLogin = "auth.login"
+
+// AuditActionIPBlocked is recorded when a request is refused by an IP allowlist. There is
+// no actor; the method, path and user agent are kept as new values.
+const AuditActionIPBlocked = "security.ip_blocked"
+
+// AuditActionSecretLinkRetrieved is recorded when a secret retrieval link is opened. There
+// is no actor; the link's creator is kept as a new value.
+const AuditActionSecretLinkRetrieved = "secret_link.retrieve"
+
+var (
+	ErrInvalidAuditQuery    = errors.New("invalid audit log query")
+	ErrInvalidActivityQuery = errors.New("invalid activity query")
+)
+
+// activityDescriptions describe audit actions in the user's activity feed. Other actions
+// get a generic description.
+var activityDescriptions = map[string]string{
+	AuditActionLogin:                       "Signed in",
+	"api_key.create":                       "Created an API key",
+	"api_key.revoke":                       "Revoked an API key",
+	"partner_credential.create":            "Created a partner credential",
+	"partner_credential.update":            "Updated a partner credential",
+	"partner_credential.update_public_key": "Changed a partner credential's public key",
+	"partner_credential.regenerate_secret": "Regenerated a client secret",
+	"partner_credential.reactivate":        "Reactivated a partner credential",
+	"partner_credential.delete":            "Deleted a partner credential",
+	"user.update_profile":                  "Updated your profile",
+	"user.upload_profile_picture":          "Changed your profile picture",
+	"user.change_password":                 "Changed your password",
+	"user.set_phone":                       "Changed your phone number",
+	"user.verify_phone":                    "Verified your phone number",
+	"user.enable_mfa":                      "Turned on two-factor sign-in",
+	"user.disable_mfa":                     "Turned off two-factor sign-in",
+	"user.update_notification_preferences": "Changed your notification preferences",
+	"user.request_data_export":             "Requested a data export",
+	"user.download_data_export":            "Downloaded your data export",
+	"company.save":                         "Updated your company profile",
+	"company.upload_document":              "Uploaded a company document",
+	"company.submit":                       "Submitted your company for verification",
+	"service_account.create":               "Created a service account",
+	"service_account.delete":               "Deleted a service account",
+	"service_account.create_token":         "Created a service account token",
+	"service_account.revoke_token":         "Revoked a service account token",
+}
+
+// AuditService records mutations to the audit log and searches it
+type AuditService struct {
+	repo *repository.AuditLogRepository
+}
+
+// NewAuditService creates a new AuditService
+func NewAuditService(repo *repository.AuditLogRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Subscribe records auth and secret link events that happen outside an authenticated request
+func (s *AuditService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.LoginSucceeded{}.EventName(), s.onLoginSucceeded)
+	bus.Subscribe(events.LoginFailed{}.EventName(), s.onLoginFailed)
+	bus.Subscribe(events.SecretLinkRetrieved{}.EventName(), s.onSecretLinkRetrieved)
+}
+
+// onLoginSucceeded records a sign-in
+func (s *AuditService) onLoginSucceeded(envelope events.Envelope) error {
+	event := envelope.Event.(events.LoginSucceeded)
+	return s.Record(AuditEntry{
+		ActorID:      event.UserID,
+		Action:       AuditActionLogin,
+		ResourceType: "user",
+		ResourceID:   event.UserID.String(),
+		NewValues: map[string]string{
+			"method":    event.Method,
+			"userAgent": event.UserAgent,
+		},
+		IP: event.IP,
+	})
+}
+
+// onLoginFailed records a rejected password login
+func (s *AuditService) onLoginFailed(envelope events.Envelope) error {
+	event := envelope.Event.(events.LoginFailed)
+	return s.Record(AuditEntry{
+		Action:       AuditActionLoginFailed,
+		ResourceType: "user",
+		NewValues: map[string]string{
+			"email":     event.Email,
+			"reason":    event.Reason,
+			"userAgent": event.UserAgent,
+		},
+		IP: event.IP,
+	})
+}
+
+// onSecretLinkRetrieved records that a secret retrieval link was opened
+func (s *AuditService) onSecretLinkRetrieved(envelope events.Envelope) error {
+	event := envelope.Event.(events.SecretLinkRetrieved)
+	return s.Record(AuditEntry{
+		Action:       AuditActionSecretLinkRetrieved,
+		ResourceType: event.ResourceType,
+		ResourceID:   event.ResourceID.String(),
+		NewValues:    map[string]string{"createdBy": event.UserID.String()},
+		IP:           event.IP,
+	})
+}
+
+// AuditEntry describes one mutation. OldValues and NewValues are stored as JSON and must
+// never contain secrets.
+type AuditEntry struct {
+	ActorID      uuid.UUID
+	Action       string
+	ResourceType string
+	ResourceID   string
+	OldValues    interface{}
+	NewValues    interface{}
+	IP           string
+	RequestID    string
+}
+
+// AuditLogQuery represents the filters of an audit log search
+type AuditLogQuery struct {
+	ActorID      string `query:"actorId"`
+	Action       string `query:"action"`
+	ResourceType string `query:"resourceType"`
+	ResourceID   string `query:"resourceId"`
+	RequestID    string `query:"requestId"`
+	From         string `query:"from"` // RFC 3339
+	To           string `query:"to"`   // RFC 3339
+	Limit        int    `query:"limit"`
+	Offset       int    `query:"offset"`
+}
+
+// AuditLogPage is one page of audit log search results
+type AuditLogPage struct {
+	Entries []models.AuditLogResponse `json:"entries"`
+	Total   int64                     `json:"total"`
+	Limit   int                       `json:"limit"`
+	Offset  int                       `json:"offset"`
+}
+
+// ActivityQuery represents a page of the user's activity feed
+type ActivityQuery struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+// ActivityEntry is one event in the user's activity feed
+type ActivityEntry struct {
+	ID           uuid.UUID `json:"id"`
+	Action       string    `json:"action"`
+	Description  string    `json:"description"`
+	ResourceType string    `json:"resourceType"`
+	ResourceID   string    `json:"resourceId"`
+	IP           string    `json:"ip"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// ActivityPage is one page of the user's activity feed
+type ActivityPage struct {
+	Entries []ActivityEntry `json:"entries"`
+	Total   int64           `json:"total"`
+	Limit   int             `json:"limit"`
+	Offset  int             `json:"offset"`
+}
+
+// Record writes an entry to the audit log
+func (s *AuditService) Record(entry AuditEntry) error {
+	record := &models.AuditLog{
+		Action:       entry.Action,
+		ResourceType: entry.ResourceType,
+		ResourceID:   entry.ResourceID,
+		IP:           entry.IP,
+		RequestID:    entry.RequestID,
+	}
+	if entry.ActorID != uuid.Nil {
+		actorID := entry.ActorID
+		record.ActorID = &actorID
+	}
+
+	var err error
+	if record.OldValues, err = auditJSON(entry.OldValues); err != nil {
+		return err
+	}
+	if record.NewValues, err = auditJSON(entry.NewValues); err != nil {
+		return err
+	}
+
+	return s.repo.Create(record)
+}
+
+// ListAuditLogs searches the audit log, newest first
+func (s *AuditService) ListAuditLogs(query AuditLogQuery) (*AuditLogPage, error) {
+	filter := repository.AuditLogFilter{
+		Action:       query.Action,
+		ResourceType: query.ResourceType,
+		ResourceID:   query.ResourceID,
+		RequestID:    query.RequestID,
+		Limit:        query.Limit,
+		Offset:       query.Offset,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultAuditLogLimit
+	}
+	if filter.Limit < 0 || filter.Limit > MaxAuditLogLimit || filter.Offset < 0 {
+		return nil, ErrInvalidAuditQuery
+	}
+
+	if query.ActorID != "" {
+		actorID, err := uuid.Parse(query.ActorID)
+		if err != nil {
+			return nil, ErrInvalidAuditQuery
+		}
+		filter.ActorID = &actorID
+	}
+	for _, bound := range []struct {
+		raw string
+		dst **time.Time
+	}{{query.From, &filter.From}, {query.To, &filter.To}} {
+		if bound.raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.raw)
+		if err != nil {
+			return nil, ErrInvalidAuditQuery
+		}
+		*bound.dst = &t
+	}
+
+	entries, total, err := s.repo.Find(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AuditLogPage{
+		Entries: make([]models.AuditLogResponse, len(entries)),
+		Total:   total,
+		Limit:   filter.Limit,
+		Offset:  filter.Offset,
+	}
+	for i := range entries {
+		page.Entries[i] = entries[i].ToResponse()
+	}
+	return page, nil
+}
+
+// ListActivity returns the audit entries the user is the actor of, newest first
+func (s *AuditService) ListActivity(userID uuid.UUID, query ActivityQuery) (*ActivityPage, error) {
+	if query.Limit == 0 {
+		query.Limit = DefaultActivityLimit
+	}
+	if query.Limit < 0 || query.Limit > MaxActivityLimit || query.Offset < 0 {
+		return nil, ErrInvalidActivityQuery
+	}
+
+	entries, total, err := s.repo.Find(repository.AuditLogFilter{
+		ActorID: &userID,
+		Limit:   query.Limit,
+		Offset:  query.Offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ActivityPage{
+		Entries: make([]ActivityEntry, len(entries)),
+		Total:   total,
+		Limit:   query.Limit,
+		Offset:  query.Offset,
+	}
+	for i, entry := range entries {
+		description, ok := activityDescriptions[entry.Action]
+		if !ok {
+			description = "Changed your account settings"
+		}
+		page.Entries[i] = ActivityEntry{
+			ID:           entry.ID,
+			Action:       entry.Action,
+			Description:  description,
+			ResourceType: entry.ResourceType,
+			ResourceID:   entry.ResourceID,
+			IP:           entry.IP,
+			CreatedAt:    entry.CreatedAt,
+		}
+	}
+	return page, nil
+}
+
+// auditJSON encodes audit values, leaving the column empty when there are none
+func auditJSON(values interface{}) (string, error) {
+	if values == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -1,35 +1,106 @@
 package services
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/bankaceh/bas-portal-api/internal/breach"
 	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/jwtkeys"
+	"github.com/bankaceh/bas-portal-api/internal/mailer"
 	"github.com/bankaceh/bas-portal-api/internal/models"
 	"github.com/bankaceh/bas-portal-api/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
 	"gorm.io/gorm"
 )
 
+const (
+	// googleUserInfoURL is the OpenID Connect userinfo endpoint for Google accounts
+	googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+
+	// OAuthStateTTL is how long a user has to complete the Google consent screen
+	OAuthStateTTL = 10 * time.Minute
+
+	// AccountLockLinkTTL is how long the "this wasn't me" link in security emails works
+	AccountLockLinkTTL = 7 * 24 * time.Hour
+)
+
 var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrEmailExists        = errors.New("email already registered")
 	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrAccountLocked      = errors.New("account is locked")
+
+	ErrPasswordResetRequired = errors.New("a password reset is required")
+
+	ErrGoogleNotConfigured    = errors.New("google login is not configured")
+	ErrGoogleAuthFailed       = errors.New("google authentication failed")
+	ErrGoogleEmailNotVerified = errors.New("google account email is not verified")
+	ErrInvalidOAuthState      = errors.New("oauth state mismatch or expired")
 )
 
 // AuthService handles authentication logic
 type AuthService struct {
-	userRepo *repository.UserRepository
-	cfg      *config.Config
+	userRepo       *repository.UserRepository
+	identityRepo   *repository.UserIdentityRepository
+	tokenRepo      *repository.OneTimeTokenRepository
+	sessionService *SessionService
+	domainPolicy   *DomainPolicyService
+	invitations    *InvitationService
+	orgInvitations *OrganizationInvitationService
+	phones         *PhoneService
+	breachChecker  breach.Checker
+	mailer         mailer.Mailer
+	googleOAuth    *oauth2.Config
+	keys           *jwtkeys.KeySet
+	bus            *events.Bus
+	cfg            *config.Config
 }
 
 // NewAuthService creates a new AuthService
-func NewAuthService(userRepo *repository.UserRepository, cfg *config.Config) *AuthService {
+func NewAuthService(userRepo *repository.UserRepository, identityRepo *repository.UserIdentityRepository, tokenRepo *repository.OneTimeTokenRepository, sessionService *SessionService, domainPolicy *DomainPolicyService, invitations *InvitationService, orgInvitations *OrganizationInvitationService, phones *PhoneService, breachChecker breach.Checker, mailer mailer.Mailer, keys *jwtkeys.KeySet, bus *events.Bus, cfg *config.Config) *AuthService {
+	googleOAuth := &oauth2.Config{
+		ClientID:     cfg.GoogleClientID,
+		ClientSecret: cfg.GoogleClientSecret,
+		RedirectURL:  cfg.GoogleRedirectURL,
+		Endpoint:     endpoints.Google,
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+
 	return &AuthService{
-		userRepo: userRepo,
-		cfg:      cfg,
+		userRepo:       userRepo,
+		identityRepo:   identityRepo,
+		tokenRepo:      tokenRepo,
+		sessionService: sessionService,
+		domainPolicy:   domainPolicy,
+		invitations:    invitations,
+		orgInvitations: orgInvitations,
+		phones:         phones,
+		breachChecker:  breachChecker,
+		mailer:         mailer,
+		googleOAuth:    googleOAuth,
+		keys:           keys,
+		bus:            bus,
+		cfg:            cfg,
 	}
 }
 
@@ -38,6 +109,13 @@ type RegisterInput struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
 	FullName string `json:"fullName" validate:"required,min=2"`
+
+	// Required when REGISTRATION_INVITE_ONLY is enabled
+	InvitationCode string `json:"invitationCode,omitempty"`
+
+	// Token from an organization invitation email. The account joins the organization,
+	// skips the invitation code and starts verified, since the email was proven.
+	OrganizationInvitation string `json:"organizationInvitation,omitempty"`
 }
 
 // LoginInput represents login request data
@@ -46,12 +124,80 @@ type LoginInput struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// MagicLinkInput represents a magic link request
+type MagicLinkInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// VerifyMFAInput completes a two-factor sign-in with the texted code
+type VerifyMFAInput struct {
+	MFAToken string `json:"mfaToken" validate:"required"`
+	Code     string `json:"code" validate:"required,len=6"`
+}
+
+// VerifyMagicLinkInput represents a magic link exchange request
+type VerifyMagicLinkInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// VerifyEmailInput represents an email verification request
+type VerifyEmailInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResendVerificationInput represents a request to resend the verification email
+type ResendVerificationInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ForgotPasswordInput represents a password reset request
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ChangePasswordInput represents a password change by a signed-in user
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,min=8,max=72"`
+}
+
+// LockAccountInput represents a "this wasn't me" request from a security email
+type LockAccountInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResetPasswordInput represents a password reset with the emailed token
+type ResetPasswordInput struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,min=8"`
+}
+
+// ClientInfo identifies where a sign-in came from, for security events
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
 // AuthResponse contains tokens and user data
 type AuthResponse struct {
-	AccessToken  string              `json:"accessToken"`
-	RefreshToken string              `json:"refreshToken"`
-	ExpiresIn    int                 `json:"expiresIn"`
-	User         models.UserResponse `json:"user"`
+	AccessToken  string               `json:"accessToken,omitempty"`
+	RefreshToken string               `json:"refreshToken,omitempty"`
+	ExpiresIn    int                  `json:"expiresIn,omitempty"`
+	User         *models.UserResponse `json:"user,omitempty"`
+
+	// Set instead of tokens when the account has two-factor sign-in. The code texted to
+	// MFAPhone is exchanged with MFAToken at POST /auth/mfa/verify.
+	MFARequired bool   `json:"mfaRequired,omitempty"`
+	MFAToken    string `json:"mfaToken,omitempty"`
+	MFAPhone    string `json:"mfaPhone,omitempty"` // Masked
+
+	// Set when the password is older than PASSWORD_MAX_AGE_DAYS. The access token is then
+	// limited to changing the password.
+	PasswordExpired bool `json:"passwordExpired,omitempty"`
+
+	// In cookie mode the refresh token is a cookie and the body carries the CSRF token
+	CSRFToken        string    `json:"csrfToken,omitempty"`
+	RefreshExpiresAt time.Time `json:"-"`
 }
 
 // Register creates a new user account
@@ -61,33 +207,76 @@ func (s *AuthService) Register(input RegisterInput) (*AuthResponse, error) {
 		return nil, ErrEmailExists
 	}
 
+	if err := s.domainPolicy.CheckEmail(input.Email); err != nil {
+		return nil, err
+	}
+
+	var orgInvitation *models.OrganizationInvitation
+	if input.OrganizationInvitation != "" {
+		invitation, err := s.orgInvitations.pending(input.OrganizationInvitation)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(invitation.Email, input.Email) {
+			return nil, ErrOrgInvitationEmailMismatch
+		}
+		orgInvitation = invitation
+	}
+
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(input.Password, s.breachChecker)
 	if err != nil {
 		return nil, err
 	}
 
+	redeemCode := s.cfg.RegistrationInviteOnly && orgInvitation == nil
+	if redeemCode {
+		if err := s.invitations.Redeem(input.InvitationCode); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create user
+	now := time.Now()
 	user := &models.User{
-		Email:        input.Email,
-		PasswordHash: string(hashedPassword),
-		FullName:     input.FullName,
-		Provider:     "local",
+		Email:             input.Email,
+		PasswordHash:      hashedPassword,
+		PasswordChangedAt: &now,
+		FullName:          input.FullName,
+		Provider:          "local",
+		IsVerified:        orgInvitation != nil,
+		ApprovalStatus:    s.initialApprovalStatus(redeemCode || orgInvitation != nil),
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
+		if redeemCode {
+			s.invitations.Release(input.InvitationCode)
+		}
 		return nil, err
 	}
 
+	s.bus.Publish(events.UserRegistered{UserID: user.ID, Provider: user.Provider})
+
+	if orgInvitation != nil {
+		// The account stands on its own; an owner can send a new invitation if this fails
+		if err := s.orgInvitations.accept(orgInvitation, user); err != nil {
+			log.Printf("Failed to accept organization invitation %s for %s: %v", orgInvitation.ID, user.Email, err)
+		}
+	} else if err := s.sendVerificationEmail(user); err != nil {
+		// A failed email must not fail the signup; the user can ask for a resend
+		log.Printf("Failed to send verification email to %s: %v", user.Email, err)
+	}
+
 	// Generate tokens
-	return s.generateAuthResponse(user)
+	return s.startSession(user)
 }
 
 // Login authenticates a user
-func (s *AuthService) Login(input LoginInput) (*AuthResponse, error) {
+func (s *AuthService) Login(input LoginInput, client ClientInfo) (*AuthResponse, error) {
 	user, err := s.userRepo.FindByEmail(input.Email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.publishLoginFailed(input.Email, "unknown_email", client)
 			return nil, ErrInvalidCredentials
 		}
 		return nil, err
@@ -95,66 +284,665 @@ func (s *AuthService) Login(input LoginInput) (*AuthResponse, error) {
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
+		s.publishLoginFailed(input.Email, "wrong_password", client)
 		return nil, ErrInvalidCredentials
 	}
 
-	return s.generateAuthResponse(user)
-}
-
-// GoogleAuth handles Google OAuth authentication
-func (s *AuthService) GoogleAuth(email, fullName, providerID string) (*AuthResponse, error) {
-	// Try to find existing user
-	user, err := s.userRepo.FindByProvider("google", providerID)
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, err
-	}
-
-	if user == nil {
-		// Check if email exists with different provider
-		existingUser, err := s.userRepo.FindByEmail(email)
-		if err == nil {
-			// Link Google to existing account
-			existingUser.Provider = "google"
-			existingUser.ProviderID = providerID
-			if err := s.userRepo.Update(existingUser); err != nil {
-				return nil, err
-			}
-			user = existingUser
-		} else if errors.Is(err, gorm.ErrRecordNotFound) {
-			// Create new user
-			user = &models.User{
-				Email:      email,
-				FullName:   fullName,
-				Provider:   "google",
-				ProviderID: providerID,
-				IsVerified: true, // Google accounts are pre-verified
-			}
-			if err := s.userRepo.Create(user); err != nil {
-				return nil, err
-			}
-		} else {
+	return s.signIn(user, "password", client)
+}
+
+// signIn starts a session for a successful login and announces it. Accounts with
+// two-factor sign-in get a code texted instead and finish with VerifyMFA.
+func (s *AuthService) signIn(user *models.User, method string, client ClientInfo) (*AuthResponse, error) {
+	if user.IsServiceAccount() {
+		return nil, ErrInvalidCredentials
+	}
+	if err := signInBlocked(user); err != nil {
+		return nil, err
+	}
+
+	if user.MFAMethod == models.MFAMethodSMS {
+		return s.startMFA(user, method)
+	}
+	return s.completeSignIn(user, method, client)
+}
+
+// startMFA texts a sign-in code and returns a token binding it to the first factor
+func (s *AuthService) startMFA(user *models.User, method string) (*AuthResponse, error) {
+	codeID, err := s.phones.SendMFACode(user)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.keys.Sign(jwt.MapClaims{
+		"sub":    user.ID.String(),
+		"jti":    codeID.String(),
+		"type":   "mfa",
+		"method": method,
+		"exp":    time.Now().Add(SMSCodeTTL).Unix(),
+		"iat":    time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		MFAPhone:    maskPhone(user.Phone),
+	}, nil
+}
+
+// VerifyMFA finishes a two-factor sign-in with the code texted by signIn
+func (s *AuthService) VerifyMFA(input VerifyMFAInput, client ClientInfo) (*AuthResponse, error) {
+	claims, err := s.keys.Parse(input.MFAToken)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if tokenType, _ := claims["type"].(string); tokenType != "mfa" {
+		return nil, ErrInvalidToken
+	}
+	codeIDStr, _ := claims["jti"].(string)
+	codeID, err := uuid.Parse(codeIDStr)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	userIDStr, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	method, _ := claims["method"].(string)
+
+	if err := s.phones.VerifyMFACode(codeID, userID, input.Code); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := signInBlocked(user); err != nil {
+		return nil, err
+	}
+	return s.completeSignIn(user, method+"+sms", client)
+}
+
+// signInBlocked returns why the account cannot sign in or refresh its tokens, if it cannot
+func signInBlocked(user *models.User) error {
+	switch {
+	case user.LockedAt != nil:
+		return ErrAccountLocked
+	case user.IsSuspended:
+		return ErrAccountSuspended
+	case user.PasswordResetRequired:
+		return ErrPasswordResetRequired
+	}
+	return nil
+}
+
+// completeSignIn starts the session once every factor has been checked
+func (s *AuthService) completeSignIn(user *models.User, method string, client ClientInfo) (*AuthResponse, error) {
+	response, err := s.startSession(user)
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.LoginSucceeded{
+		UserID:    user.ID,
+		Method:    method,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	})
+	return response, nil
+}
+
+// initialApprovalStatus is the approval status of a new account. Accounts invited by
+// staff or an organization skip approval.
+func (s *AuthService) initialApprovalStatus(invited bool) string {
+	if s.cfg.RegistrationRequireApproval && !invited {
+		return models.ApprovalStatusPending
+	}
+	return models.ApprovalStatusApproved
+}
+
+// publishLoginFailed announces a failed password login
+func (s *AuthService) publishLoginFailed(email, reason string, client ClientInfo) {
+	s.bus.Publish(events.LoginFailed{
+		Email:     email,
+		Reason:    reason,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	})
+}
+
+// googleUserInfo is the subset of the OpenID Connect userinfo response we use
+type googleUserInfo struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+// GoogleLoginStart is what the browser needs to begin a Google login
+type GoogleLoginStart struct {
+	AuthURL     string // Google consent screen URL
+	StateCookie string // Signed state and PKCE verifier, to be returned on the callback
+}
+
+// GoogleAuthURL starts a Google login with a fresh state and PKCE verifier. Both are kept
+// client-side in a signed cookie so any instance can verify the callback.
+func (s *AuthService) GoogleAuthURL() (*GoogleLoginStart, error) {
+	return s.startGoogleOAuth("")
+}
+
+// StartGoogleLink starts the Google OAuth flow to link a Google account to a signed-in
+// user. The user must confirm their password, or have signed in recently if they have
+// none, so a stolen session cannot attach an attacker's Google account.
+func (s *AuthService) StartGoogleLink(userID, sessionID uuid.UUID, password string) (*GoogleLoginStart, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	linked, err := s.identityRepo.ExistsForUser(user.ID, models.IdentityProviderGoogle)
+	if err != nil {
+		return nil, err
+	}
+	if linked {
+		return nil, ErrIdentityAlreadyLinked
+	}
+
+	if err := confirmStepUp(user, password, sessionID, s.sessionService); err != nil {
+		return nil, err
+	}
+
+	return s.startGoogleOAuth(user.ID.String())
+}
+
+// startGoogleOAuth builds the consent screen URL and state cookie. linkUserID is empty
+// for a sign-in and names the account to link to otherwise.
+func (s *AuthService) startGoogleOAuth(linkUserID string) (*GoogleLoginStart, error) {
+	if s.googleOAuth.ClientID == "" || s.googleOAuth.ClientSecret == "" {
+		return nil, ErrGoogleNotConfigured
+	}
+
+	stateBytes := make([]byte, 16)
+	if _, err := rand.Read(stateBytes); err != nil {
+		return nil, err
+	}
+	state := hex.EncodeToString(stateBytes)
+	verifier := oauth2.GenerateVerifier()
+
+	return &GoogleLoginStart{
+		AuthURL:     s.googleOAuth.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)),
+		StateCookie: s.signOAuthState(state, verifier, linkUserID, time.Now().Add(OAuthStateTTL)),
+	}, nil
+}
+
+// GoogleCallbackResult is the outcome of a Google OAuth callback
+type GoogleCallbackResult struct {
+	Auth   *AuthResponse // Tokens, when the flow was a sign-in
+	Linked bool          // Set instead when the flow linked Google to a signed-in account
+}
+
+// GoogleCallback checks the state against the flow's cookie, exchanges the authorization
+// code with its PKCE verifier, fetches the Google profile and then either signs the user
+// in or links the Google account, depending on how the flow was started
+func (s *AuthService) GoogleCallback(code, state, stateCookie string, client ClientInfo) (*GoogleCallbackResult, error) {
+	if s.googleOAuth.ClientID == "" || s.googleOAuth.ClientSecret == "" {
+		return nil, ErrGoogleNotConfigured
+	}
+
+	verifier, linkUserID, err := s.verifyOAuthState(state, stateCookie)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := s.googleOAuth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		log.Printf("Google code exchange failed: %v", err)
+		return nil, ErrGoogleAuthFailed
+	}
+
+	profile, err := s.fetchGoogleProfile(ctx, token)
+	if err != nil {
+		log.Printf("Failed to fetch Google profile: %v", err)
+		return nil, ErrGoogleAuthFailed
+	}
+
+	if !profile.EmailVerified {
+		return nil, ErrGoogleEmailNotVerified
+	}
+
+	if linkUserID != "" {
+		userID, err := uuid.Parse(linkUserID)
+		if err != nil {
+			return nil, ErrInvalidOAuthState
+		}
+		if err := s.linkGoogle(userID, profile); err != nil {
+			return nil, err
+		}
+		return &GoogleCallbackResult{Linked: true}, nil
+	}
+
+	fullName := profile.Name
+	if fullName == "" {
+		fullName = profile.Email
+	}
+
+	response, err := s.GoogleAuth(profile.Email, fullName, profile.Sub, client)
+	if err != nil {
+		return nil, err
+	}
+	return &GoogleCallbackResult{Auth: response}, nil
+}
+
+// linkGoogle attaches a Google account to an existing user
+func (s *AuthService) linkGoogle(userID uuid.UUID, profile *googleUserInfo) error {
+	existing, err := s.identityRepo.FindByProviderID(models.IdentityProviderGoogle, profile.Sub)
+	if err == nil {
+		if existing.UserID == userID {
+			return nil
+		}
+		return ErrIdentityInUse
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	identity := &models.UserIdentity{
+		UserID:     userID,
+		Provider:   models.IdentityProviderGoogle,
+		ProviderID: profile.Sub,
+		Email:      profile.Email,
+	}
+	if err := s.identityRepo.Create(identity); err != nil {
+		return err
+	}
+
+	s.bus.Publish(events.IdentityLinked{UserID: userID, Provider: identity.Provider, Email: identity.Email})
+	return nil
+}
+
+// signOAuthState encodes the state, PKCE verifier, linking user (empty for a sign-in) and
+// expiry as state.verifier.link.expiry.signature
+func (s *AuthService) signOAuthState(state, verifier, linkUserID string, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%s.%s.%s.%d", state, verifier, linkUserID, expiresAt.Unix())
+	return payload + "." + s.oauthStateSignature(payload)
+}
+
+// verifyOAuthState checks the cookie's signature and expiry, matches its state against the
+// callback's and returns the PKCE verifier and linking user
+func (s *AuthService) verifyOAuthState(state, stateCookie string) (string, string, error) {
+	parts := strings.Split(stateCookie, ".")
+	if state == "" || len(parts) != 5 {
+		return "", "", ErrInvalidOAuthState
+	}
+
+	payload := strings.Join(parts[:4], ".")
+	if !hmac.Equal([]byte(parts[4]), []byte(s.oauthStateSignature(payload))) {
+		return "", "", ErrInvalidOAuthState
+	}
+
+	expiresAt, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return "", "", ErrInvalidOAuthState
+	}
+
+	if subtle.ConstantTimeCompare([]byte(parts[0]), []byte(state)) != 1 {
+		return "", "", ErrInvalidOAuthState
+	}
+
+	return parts[1], parts[2], nil
+}
+
+// oauthStateSignature signs an OAuth state cookie payload
+func (s *AuthService) oauthStateSignature(payload string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.JWTSecret))
+	mac.Write([]byte("oauth-state:" + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// fetchGoogleProfile loads the signed-in user's profile from Google
+func (s *AuthService) fetchGoogleProfile(ctx context.Context, token *oauth2.Token) (*googleUserInfo, error) {
+	resp, err := s.googleOAuth.Client(ctx, token).Get(googleUserInfoURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo returned status %d", resp.StatusCode)
+	}
+
+	var profile googleUserInfo
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return nil, err
+	}
+
+	if profile.Sub == "" || profile.Email == "" {
+		return nil, errors.New("userinfo response is missing sub or email")
+	}
+
+	return &profile, nil
+}
+
+// GoogleAuth signs in the user linked to a Google account, creating an account on first
+// sign-in. A Google account is never attached to an existing account by email alone; its
+// owner has to link it from account settings.
+func (s *AuthService) GoogleAuth(email, fullName, providerID string, client ClientInfo) (*AuthResponse, error) {
+	identity, err := s.identityRepo.FindByProviderID(models.IdentityProviderGoogle, providerID)
+	if err == nil {
+		user, err := s.userRepo.FindByID(identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.identityRepo.Touch(identity.ID); err != nil {
+			log.Printf("Failed to record Google sign-in for user %s: %v", user.ID, err)
+		}
+		return s.signIn(user, "google", client)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if s.userRepo.EmailExists(email) {
+		return nil, ErrLinkRequired
+	}
+
+	// Only new sign-ups go through the domain policy
+	if err := s.domainPolicy.CheckEmail(email); err != nil {
+		return nil, err
+	}
+	// Invitations are redeemed through the registration form
+	if s.cfg.RegistrationInviteOnly {
+		return nil, ErrInvitationRequired
+	}
+
+	user := &models.User{
+		Email:          email,
+		FullName:       fullName,
+		Provider:       models.IdentityProviderGoogle,
+		ProviderID:     providerID,
+		IsVerified:     true, // Google accounts are pre-verified
+		ApprovalStatus: s.initialApprovalStatus(false),
+	}
+	if err := s.identityRepo.CreateWithUser(user, &models.UserIdentity{
+		Provider:   models.IdentityProviderGoogle,
+		ProviderID: providerID,
+		Email:      email,
+	}); err != nil {
+		return nil, err
+	}
+	s.bus.Publish(events.UserRegistered{UserID: user.ID, Provider: user.Provider})
+
+	return s.signIn(user, "google", client)
+}
+
+// RequestMagicLink emails a single-use login link if the email belongs to an account.
+// Unknown emails are silently ignored so the endpoint cannot be used to probe accounts, as
+// are service accounts, which cannot sign in interactively. Mail delivery failures are only
+// logged for the same reason.
+func (s *AuthService) RequestMagicLink(input MagicLinkInput) error {
+	user, err := s.userRepo.FindByEmail(input.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.IsServiceAccount() {
+		return nil
+	}
+
+	expiry := time.Duration(s.cfg.MagicLinkExpiryMinutes) * time.Minute
+	token, err := s.issueOneTimeToken(user, models.TokenPurposeMagicLink, expiry)
+	if err != nil {
+		return err
+	}
+
+	link := s.cfg.FrontendURL + "/auth/magic-link?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf(
+		"Hi %s,\n\nUse the link below to sign in to the BAS Developer Portal. "+
+			"The link expires in %d minutes and can only be used once.\n\n%s\n\n"+
+			"If you did not request this email, you can safely ignore it.",
+		user.FullName, s.cfg.MagicLinkExpiryMinutes, link,
+	)
+
+	if err := s.mailer.Send(user.Email, "Your BAS Developer Portal login link", body); err != nil {
+		log.Printf("Failed to send magic link to %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+// VerifyMagicLink exchanges a magic link token for portal tokens
+func (s *AuthService) VerifyMagicLink(input VerifyMagicLinkInput, client ClientInfo) (*AuthResponse, error) {
+	user, err := s.consumeOneTimeToken(input.Token, models.TokenPurposeMagicLink)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.signIn(user, "magic_link", client)
+}
+
+// VerifyEmail consumes a verification token and marks the user's email as verified
+func (s *AuthService) VerifyEmail(input VerifyEmailInput) (*models.UserResponse, error) {
+	user, err := s.consumeOneTimeToken(input.Token, models.TokenPurposeEmailVerification)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsVerified {
+		if err := s.userRepo.MarkVerified(user.ID); err != nil {
 			return nil, err
 		}
+		user.IsVerified = true
 	}
 
-	return s.generateAuthResponse(user)
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// ResendVerification emails a fresh verification link to an unverified account.
+// Unknown emails are silently ignored so the endpoint cannot be used to probe accounts.
+func (s *AuthService) ResendVerification(input ResendVerificationInput) error {
+	user, err := s.userRepo.FindByEmail(input.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if user.IsVerified {
+		return nil
+	}
+
+	if err := s.sendVerificationEmail(user); err != nil {
+		log.Printf("Failed to send verification email to %s: %v", user.Email, err)
+		return err
+	}
+	return nil
+}
+
+// sendVerificationEmail issues a verification token and emails the link to the user
+func (s *AuthService) sendVerificationEmail(user *models.User) error {
+	expiry := time.Duration(s.cfg.EmailVerificationExpiryHours) * time.Hour
+	token, err := s.issueOneTimeToken(user, models.TokenPurposeEmailVerification, expiry)
+	if err != nil {
+		return err
+	}
+
+	link := s.cfg.FrontendURL + "/auth/verify-email?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf(
+		"Hi %s,\n\nPlease confirm your email address for the BAS Developer Portal "+
+			"by opening the link below. The link expires in %d hours.\n\n%s\n\n"+
+			"If you did not create an account, you can safely ignore this email.",
+		user.FullName, s.cfg.EmailVerificationExpiryHours, link,
+	)
+
+	return s.mailer.Send(user.Email, "Verify your BAS Developer Portal email", body)
+}
+
+// PasswordResetLink returns a single-use password reset link, valid for
+// PASSWORD_RESET_EXPIRY_MINUTES
+func (s *AuthService) PasswordResetLink(user *models.User) (string, error) {
+	expiry := time.Duration(s.cfg.PasswordResetExpiryMinutes) * time.Minute
+	token, err := s.issueOneTimeToken(user, models.TokenPurposePasswordReset, expiry)
+	if err != nil {
+		return "", err
+	}
+	return s.cfg.FrontendURL + "/auth/reset-password?token=" + url.QueryEscape(token), nil
+}
+
+// AccountLockLink returns a single-use "this wasn't me" link for security emails
+func (s *AuthService) AccountLockLink(user *models.User) (string, error) {
+	token, err := s.issueOneTimeToken(user, models.TokenPurposeAccountLock, AccountLockLinkTTL)
+	if err != nil {
+		return "", err
+	}
+	return s.cfg.FrontendURL + "/auth/lock-account?token=" + url.QueryEscape(token), nil
+}
+
+// LockAccount locks the account named by a "this wasn't me" token and signs out every
+// session. The owner unlocks it by resetting their password.
+func (s *AuthService) LockAccount(input LockAccountInput) error {
+	user, err := s.consumeOneTimeToken(input.Token, models.TokenPurposeAccountLock)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Lock(user.ID); err != nil {
+		return err
+	}
+	if err := s.sessionService.RevokeAll(user.ID); err != nil {
+		return err
+	}
+
+	s.bus.Publish(events.AccountLocked{UserID: user.ID})
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nYour BAS Developer Portal account has been locked and all sessions "+
+			"were signed out. API keys and partner credentials keep working.\n\n"+
+			"To unlock the account, choose a new password here:\n\n%s\n\n"+
+			"If you did not lock your account, please contact support.",
+		user.FullName, s.cfg.FrontendURL+"/auth/forgot-password",
+	)
+	if err := s.mailer.Send(user.Email, "Your BAS Developer Portal account is locked", body); err != nil {
+		log.Printf("Failed to send account locked email to %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+// ForgotPassword emails a time-limited password reset link if the email belongs to an account.
+// Unknown emails are silently ignored so the endpoint cannot be used to probe accounts, as
+// are service accounts, which have no password.
+func (s *AuthService) ForgotPassword(input ForgotPasswordInput) error {
+	user, err := s.userRepo.FindByEmail(input.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.IsServiceAccount() {
+		return nil
+	}
+
+	link, err := s.PasswordResetLink(user)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nWe received a request to reset the password for your BAS Developer Portal account. "+
+			"Use the link below to choose a new password. The link expires in %d minutes and can only be used once.\n\n%s\n\n"+
+			"If you did not request a password reset, you can safely ignore this email.",
+		user.FullName, s.cfg.PasswordResetExpiryMinutes, link,
+	)
+
+	if err := s.mailer.Send(user.Email, "Reset your BAS Developer Portal password", body); err != nil {
+		log.Printf("Failed to send password reset email to %s: %v", user.Email, err)
+		return err
+	}
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token and signs the user out everywhere
+func (s *AuthService) ResetPassword(input ResetPasswordInput) error {
+	// Check the new password first so a rejected one doesn't burn the reset link
+	hashedPassword, err := hashPassword(input.NewPassword, s.breachChecker)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.consumeOneTimeToken(input.Token, models.TokenPurposePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return err
+	}
+
+	// Proving control of the mailbox with a fresh password unlocks a locked account
+	if user.LockedAt != nil {
+		if err := s.userRepo.Unlock(user.ID); err != nil {
+			return err
+		}
+	}
+
+	s.bus.Publish(events.PasswordChanged{UserID: user.ID, Reset: true})
+
+	// Existing refresh tokens are bound to sessions, so revoking them logs out every device
+	return s.sessionService.RevokeAll(user.ID)
+}
+
+// ChangePassword replaces the user's password after re-checking the current one. Every
+// existing session is revoked and a fresh one is started for the caller.
+func (s *AuthService) ChangePassword(userID uuid.UUID, input ChangePasswordInput) (*AuthResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if err := confirmPassword(user, input.CurrentPassword); err != nil {
+		return nil, err
+	}
+	if input.NewPassword == input.CurrentPassword {
+		return nil, ErrSamePassword
+	}
+
+	hashedPassword, err := hashPassword(input.NewPassword, s.breachChecker)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.PasswordChanged{UserID: user.ID})
+
+	if err := s.sessionService.RevokeAll(user.ID); err != nil {
+		return nil, err
+	}
+
+	return s.startSession(user)
 }
 
 // RefreshToken generates a new access token from a refresh token
 func (s *AuthService) RefreshToken(refreshToken string) (*AuthResponse, error) {
 	// Parse and validate refresh token
-	token, err := jwt.Parse(refreshToken, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.cfg.JWTSecret), nil
-	})
-	if err != nil || !token.Valid {
+	claims, err := s.keys.Parse(refreshToken)
+	if err != nil {
 		return nil, errors.New("invalid refresh token")
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, errors.New("invalid token claims")
-	}
-
 	// Check token type
 	tokenType, ok := claims["type"].(string)
 	if !ok || tokenType != "refresh" {
@@ -172,52 +960,182 @@ func (s *AuthService) RefreshToken(refreshToken string) (*AuthResponse, error) {
 		return nil, errors.New("invalid user ID format")
 	}
 
+	// Reject sessions that were revoked or left idle too long
+	sessionIDStr, _ := claims["sid"].(string)
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		return nil, ErrSessionExpired
+	}
+
+	session, err := s.sessionService.Validate(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, ErrSessionExpired
+	}
+
 	// Find user
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
 		return nil, ErrUserNotFound
 	}
+	if err := signInBlocked(user); err != nil {
+		return nil, err
+	}
+
+	_ = s.sessionService.Touch(session.ID)
+
+	return s.generateAuthResponse(user, session)
+}
+
+// Logout ends the session a refresh token belongs to
+func (s *AuthService) Logout(refreshToken string) error {
+	claims, err := s.keys.Parse(refreshToken)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if tokenType, _ := claims["type"].(string); tokenType != "refresh" {
+		return ErrInvalidToken
+	}
 
-	return s.generateAuthResponse(user)
+	sessionIDStr, _ := claims["sid"].(string)
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	return s.sessionService.Revoke(sessionID)
 }
 
-// generateAuthResponse creates access and refresh tokens
-func (s *AuthService) generateAuthResponse(user *models.User) (*AuthResponse, error) {
+// startSession opens a new session for the user and issues its tokens
+func (s *AuthService) startSession(user *models.User) (*AuthResponse, error) {
+	session, err := s.sessionService.Start(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.generateAuthResponse(user, session)
+}
+
+// generateAuthResponse creates access and refresh tokens bound to a session
+func (s *AuthService) generateAuthResponse(user *models.User, session *models.Session) (*AuthResponse, error) {
 	expiryHours := s.cfg.JWTExpiryHours
 	accessExpiry := time.Now().Add(time.Duration(expiryHours) * time.Hour)
 	refreshExpiry := time.Now().Add(time.Duration(expiryHours*7) * time.Hour) // 7x access token lifetime
 
-	// Access token
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	// Access token. The role and permissions are those at issue time; routes that need
+	// the current role still look it up.
+	role := effectiveRole(user, s.cfg)
+	accessClaims := jwt.MapClaims{
 		"sub":   user.ID.String(),
+		"sid":   session.ID.String(),
 		"email": user.Email,
+		"role":  role,
+		"perms": models.RolePermissions(role),
 		"type":  "access",
 		"exp":   accessExpiry.Unix(),
 		"iat":   time.Now().Unix(),
-	})
-
-	accessTokenString, err := accessToken.SignedString([]byte(s.cfg.JWTSecret))
+	}
+	passwordExpired := s.passwordExpired(user)
+	if passwordExpired {
+		accessClaims["pwd_exp"] = true
+	}
+	accessTokenString, err := s.keys.Sign(accessClaims)
 	if err != nil {
 		return nil, err
 	}
 
 	// Refresh token
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	refreshTokenString, err := s.keys.Sign(jwt.MapClaims{
 		"sub":  user.ID.String(),
+		"sid":  session.ID.String(),
 		"type": "refresh",
 		"exp":  refreshExpiry.Unix(),
 		"iat":  time.Now().Unix(),
 	})
-
-	refreshTokenString, err := refreshToken.SignedString([]byte(s.cfg.JWTSecret))
 	if err != nil {
 		return nil, err
 	}
 
+	profile := user.ToResponse()
 	return &AuthResponse{
-		AccessToken:  accessTokenString,
-		RefreshToken: refreshTokenString,
-		ExpiresIn:    expiryHours * 3600,
-		User:         user.ToResponse(),
+		AccessToken:      accessTokenString,
+		RefreshToken:     refreshTokenString,
+		ExpiresIn:        expiryHours * 3600,
+		User:             &profile,
+		PasswordExpired:  passwordExpired,
+		RefreshExpiresAt: refreshExpiry,
 	}, nil
 }
+
+// passwordExpired reports whether the user's password is older than the maximum age.
+// Accounts without a password never expire.
+func (s *AuthService) passwordExpired(user *models.User) bool {
+	if s.cfg.PasswordMaxAgeDays <= 0 || user.PasswordHash == "" {
+		return false
+	}
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	return time.Since(changedAt) > time.Duration(s.cfg.PasswordMaxAgeDays)*24*time.Hour
+}
+
+// issueOneTimeToken records a single-use token and returns it signed as a JWT
+func (s *AuthService) issueOneTimeToken(user *models.User, purpose string, ttl time.Duration) (string, error) {
+	record := &models.OneTimeToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.tokenRepo.Create(record); err != nil {
+		return "", err
+	}
+
+	return s.keys.Sign(jwt.MapClaims{
+		"sub":  user.ID.String(),
+		"jti":  record.ID.String(),
+		"type": purpose,
+		"exp":  record.ExpiresAt.Unix(),
+		"iat":  time.Now().Unix(),
+	})
+}
+
+// consumeOneTimeToken validates a signed one-time token, marks it used and returns its user
+func (s *AuthService) consumeOneTimeToken(tokenString, purpose string) (*models.User, error) {
+	claims, err := s.keys.Parse(tokenString)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if tokenType, _ := claims["type"].(string); tokenType != purpose {
+		return nil, ErrInvalidToken
+	}
+
+	tokenIDStr, _ := claims["jti"].(string)
+	tokenID, err := uuid.Parse(tokenIDStr)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	userIDStr, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	consumed, err := s.tokenRepo.Consume(tokenID, purpose)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	return user, nil
+}
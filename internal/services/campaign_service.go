@@ -0,0 +1,326 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/mailer"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// maxFailedBatches is how many fully failed batches in a row abort a campaign
+const maxFailedBatches = 3
+
+var (
+	ErrCampaignNotFound        = errors.New("email campaign not found")
+	ErrCampaignNotEditable     = errors.New("email campaign is already sending or sent")
+	ErrInvalidTemplate         = errors.New("invalid email template")
+	ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+)
+
+// CampaignService handles bulk email campaigns sent by admins
+type CampaignService struct {
+	repo     *repository.EmailCampaignRepository
+	userRepo *repository.UserRepository
+	mailer   mailer.Mailer
+	cfg      *config.Config
+}
+
+// NewCampaignService creates a new CampaignService
+func NewCampaignService(repo *repository.EmailCampaignRepository, userRepo *repository.UserRepository, mailer mailer.Mailer, cfg *config.Config) *CampaignService {
+	return &CampaignService{
+		repo:     repo,
+		userRepo: userRepo,
+		mailer:   mailer,
+		cfg:      cfg,
+	}
+}
+
+// CreateCampaignInput represents the input for creating a campaign
+type CreateCampaignInput struct {
+	Subject string `json:"subject" validate:"required"`
+	Body    string `json:"body" validate:"required"`
+}
+
+// ScheduleCampaignInput represents the input for scheduling a campaign
+type ScheduleCampaignInput struct {
+	ScheduledAt *time.Time `json:"scheduledAt"` // Defaults to now
+}
+
+// UnsubscribeInput represents an unsubscribe request from an email footer link
+type UnsubscribeInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// CampaignPreview is a campaign rendered for a sample recipient
+type CampaignPreview struct {
+	Subject        string `json:"subject"`
+	Body           string `json:"body"`
+	RecipientCount int64  `json:"recipientCount"`
+}
+
+// CreateCampaign creates a draft campaign
+func (s *CampaignService) CreateCampaign(adminID uuid.UUID, input CreateCampaignInput) (*models.EmailCampaign, error) {
+	if _, err := template.New("body").Parse(input.Body); err != nil {
+		return nil, ErrInvalidTemplate
+	}
+
+	campaign := &models.EmailCampaign{
+		CreatedBy: adminID,
+		Subject:   input.Subject,
+		Body:      input.Body,
+		Status:    models.CampaignStatusDraft,
+	}
+
+	if err := s.repo.Create(campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// ListCampaigns returns all campaigns
+func (s *CampaignService) ListCampaigns() ([]models.EmailCampaign, error) {
+	return s.repo.FindAll()
+}
+
+// GetCampaign returns a campaign with its delivery stats
+func (s *CampaignService) GetCampaign(id uuid.UUID) (*models.EmailCampaign, error) {
+	campaign, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, ErrCampaignNotFound
+	}
+	return campaign, nil
+}
+
+// PreviewCampaign renders a campaign as the given admin would receive it
+func (s *CampaignService) PreviewCampaign(id, adminID uuid.UUID) (*CampaignPreview, error) {
+	campaign, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, ErrCampaignNotFound
+	}
+
+	admin, err := s.userRepo.FindByID(adminID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	body, err := s.render(campaign, admin)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountRecipients()
+	if err != nil {
+		return nil, err
+	}
+
+	return &CampaignPreview{
+		Subject:        campaign.Subject,
+		Body:           body,
+		RecipientCount: count,
+	}, nil
+}
+
+// ScheduleCampaign queues a campaign to be sent at the given time
+func (s *CampaignService) ScheduleCampaign(id uuid.UUID, input ScheduleCampaignInput) (*models.EmailCampaign, error) {
+	campaign, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, ErrCampaignNotFound
+	}
+
+	if campaign.Status != models.CampaignStatusDraft && campaign.Status != models.CampaignStatusScheduled {
+		return nil, ErrCampaignNotEditable
+	}
+
+	scheduledAt := time.Now()
+	if input.ScheduledAt != nil && input.ScheduledAt.After(scheduledAt) {
+		scheduledAt = *input.ScheduledAt
+	}
+
+	campaign.Status = models.CampaignStatusScheduled
+	campaign.ScheduledAt = &scheduledAt
+
+	if err := s.repo.Update(campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// SendCampaign schedules a campaign for immediate delivery and starts sending in the background
+func (s *CampaignService) SendCampaign(id uuid.UUID) (*models.EmailCampaign, error) {
+	campaign, err := s.ScheduleCampaign(id, ScheduleCampaignInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if err := s.SendDueCampaigns(); err != nil {
+			log.Printf("Failed to send email campaigns: %v", err)
+		}
+	}()
+
+	return campaign, nil
+}
+
+// SendDueCampaigns sends every scheduled campaign whose time has come (run by the scheduler)
+func (s *CampaignService) SendDueCampaigns() error {
+	campaigns, err := s.repo.FindDue()
+	if err != nil {
+		return err
+	}
+
+	for i := range campaigns {
+		campaign := &campaigns[i]
+
+		total, err := s.repo.CountRecipients()
+		if err != nil {
+			return err
+		}
+
+		// Another worker may have picked the campaign up already
+		claimed, err := s.repo.ClaimForSending(campaign.ID, total)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		status := models.CampaignStatusSent
+		if err := s.deliver(campaign); err != nil {
+			log.Printf("Email campaign %s aborted: %v", campaign.ID, err)
+			status = models.CampaignStatusFailed
+		}
+
+		if err := s.repo.Finish(campaign.ID, status); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Unsubscribe opts the user identified by an email footer token out of bulk emails
+func (s *CampaignService) Unsubscribe(input UnsubscribeInput) error {
+	userIDStr, signature, ok := strings.Cut(input.Token, ".")
+	if !ok {
+		return ErrInvalidUnsubscribeToken
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return ErrInvalidUnsubscribeToken
+	}
+
+	expected := s.unsubscribeToken(userID)
+	if !hmac.Equal([]byte(userIDStr+"."+signature), []byte(expected)) {
+		return ErrInvalidUnsubscribeToken
+	}
+
+	return s.userRepo.Unsubscribe(userID)
+}
+
+// deliver sends a campaign in throttled batches, slowing down while the mail server rejects messages
+func (s *CampaignService) deliver(campaign *models.EmailCampaign) error {
+	interval := time.Duration(s.cfg.CampaignBatchIntervalSeconds) * time.Second
+	failedBatches := 0
+
+	for {
+		recipients, err := s.repo.FindPendingRecipients(campaign.ID, s.cfg.CampaignBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(recipients) == 0 {
+			return nil
+		}
+
+		var sent, failed int64
+		for i := range recipients {
+			user := &recipients[i]
+			delivery := &models.EmailCampaignDelivery{
+				CampaignID: campaign.ID,
+				UserID:     user.ID,
+				Status:     models.DeliveryStatusSent,
+			}
+
+			body, err := s.render(campaign, user)
+			if err == nil {
+				err = s.mailer.Send(user.Email, campaign.Subject, body)
+			}
+			if err != nil {
+				delivery.Status = models.DeliveryStatusFailed
+				delivery.Error = err.Error()
+				failed++
+			} else {
+				sent++
+			}
+
+			if err := s.repo.CreateDelivery(delivery); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.IncrementCounts(campaign.ID, sent, failed); err != nil {
+			return err
+		}
+
+		// Back off exponentially while whole batches fail
+		delay := interval
+		if sent == 0 {
+			failedBatches++
+			if failedBatches >= maxFailedBatches {
+				return fmt.Errorf("%d consecutive batches failed", failedBatches)
+			}
+			delay = interval * time.Duration(1<<failedBatches)
+		} else {
+			failedBatches = 0
+		}
+
+		time.Sleep(delay)
+	}
+}
+
+// render executes the campaign body template for a recipient and appends the unsubscribe footer
+func (s *CampaignService) render(campaign *models.EmailCampaign, user *models.User) (string, error) {
+	tmpl, err := template.New("body").Parse(campaign.Body)
+	if err != nil {
+		return "", ErrInvalidTemplate
+	}
+
+	var buf bytes.Buffer
+	data := struct {
+		Name  string
+		Email string
+	}{
+		Name:  user.FullName,
+		Email: user.Email,
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", ErrInvalidTemplate
+	}
+
+	unsubscribeURL := s.cfg.FrontendURL + "/unsubscribe?token=" + url.QueryEscape(s.unsubscribeToken(user.ID))
+	buf.WriteString("\n\n--\nYou are receiving this email because you have a BAS Developer Portal account.\n")
+	buf.WriteString("Unsubscribe: " + unsubscribeURL + "\n")
+
+	return buf.String(), nil
+}
+
+// unsubscribeToken returns a signed, non-expiring token identifying the user
+func (s *CampaignService) unsubscribeToken(userID uuid.UUID) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.JWTSecret))
+	mac.Write([]byte("unsubscribe:" + userID.String()))
+	return userID.String() + "." + hex.EncodeToString(mac.Sum(nil))
+}
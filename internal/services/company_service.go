@@ -0,0 +1,355 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/mailer"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/bankaceh/bas-portal-api/internal/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCompanyNotFound         = errors.New("company profile not found")
+	ErrCompanyDocumentNotFound = errors.New("company document not found")
+	ErrInvalidCompanyProfile   = errors.New("legal name and address are required, NIB must be 13 digits and NPWP 15 or 16 digits")
+	ErrInvalidCompanyDocument  = errors.New("document type must be business_license or npwp")
+	ErrCompanyDocumentTooLarge = errors.New("company document is too large")
+	ErrUnsupportedDocumentType = errors.New("company documents must be PDF, JPEG or PNG files")
+	ErrCompanyUnderReview      = errors.New("company profile is under review and cannot be changed")
+	ErrCompanyDocumentsMissing = errors.New("business license and NPWP documents are required")
+	ErrCompanyNotPending       = errors.New("company profile is not awaiting review")
+	ErrCompanyNotSubmittable   = errors.New("company profile is already submitted or verified")
+	ErrCompanyNotVerified      = errors.New("a verified company profile is required for production credentials")
+	ErrInvalidCompanyStatus    = errors.New("invalid company status")
+	ErrCompanyReasonLength     = errors.New("reason must be 3-500 characters")
+)
+
+// CompanyService handles company profiles, their KYC documents and staff review
+type CompanyService struct {
+	repo     *repository.CompanyRepository
+	orgs     *OrganizationService
+	userRepo *repository.UserRepository
+	files    storage.Storage
+	mailer   mailer.Mailer
+	bus      *events.Bus
+	cfg      *config.Config
+}
+
+// NewCompanyService creates a new CompanyService. files must be private storage.
+func NewCompanyService(repo *repository.CompanyRepository, orgs *OrganizationService, userRepo *repository.UserRepository, files storage.Storage, mailer mailer.Mailer, bus *events.Bus, cfg *config.Config) *CompanyService {
+	return &CompanyService{
+		repo:     repo,
+		orgs:     orgs,
+		userRepo: userRepo,
+		files:    files,
+		mailer:   mailer,
+		bus:      bus,
+		cfg:      cfg,
+	}
+}
+
+// CompanyProfileInput represents the legal details of a company
+type CompanyProfileInput struct {
+	LegalName             string `json:"legalName" validate:"required,max=255"`
+	BusinessLicenseNumber string `json:"businessLicenseNumber" validate:"required"` // NIB, 13 digits
+	NPWP                  string `json:"npwp" validate:"required"`                  // 15 or 16 digits, punctuation allowed
+	Address               string `json:"address" validate:"required,max=500"`
+}
+
+// CompanyReviewInput carries the reason for rejecting a company profile
+type CompanyReviewInput struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"`
+}
+
+// GetCompany returns the company profile of the user, or of an organization they belong to
+func (s *CompanyService) GetCompany(userID uuid.UUID, orgID *uuid.UUID) (*models.CompanyProfile, error) {
+	if err := s.authorize(userID, orgID, models.OrgRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.find(userID, orgID)
+}
+
+// SaveCompany creates or updates the company profile (organization owners only). Changing
+// a verified or rejected profile makes it a draft that must be submitted again.
+func (s *CompanyService) SaveCompany(userID uuid.UUID, orgID *uuid.UUID, input CompanyProfileInput) (*models.CompanyProfile, error) {
+	if err := s.authorize(userID, orgID, models.OrgRoleOwner); err != nil {
+		return nil, err
+	}
+
+	input.LegalName = strings.TrimSpace(input.LegalName)
+	input.Address = strings.TrimSpace(input.Address)
+	nib := digitsOnly(input.BusinessLicenseNumber)
+	npwp := digitsOnly(input.NPWP)
+	if input.LegalName == "" || len(input.LegalName) > 255 || input.Address == "" || len(input.Address) > 500 ||
+		len(nib) != 13 || (len(npwp) != 15 && len(npwp) != 16) {
+		return nil, ErrInvalidCompanyProfile
+	}
+
+	profile, err := s.find(userID, orgID)
+	if errors.Is(err, ErrCompanyNotFound) {
+		profile = &models.CompanyProfile{
+			UserID:         userID,
+			OrganizationID: orgID,
+			Status:         models.CompanyStatusDraft,
+		}
+	} else if err != nil {
+		return nil, err
+	} else if profile.Status == models.CompanyStatusPending {
+		return nil, ErrCompanyUnderReview
+	}
+
+	profile.LegalName = input.LegalName
+	profile.BusinessLicenseNumber = nib
+	profile.NPWP = npwp
+	profile.Address = input.Address
+	s.reopen(profile)
+
+	if profile.CreatedAt.IsZero() {
+		err = s.repo.Create(profile)
+	} else {
+		err = s.repo.Update(profile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// UploadDocument stores a KYC document for the company profile (organization owners only)
+func (s *CompanyService) UploadDocument(userID uuid.UUID, orgID *uuid.UUID, docType, fileName string, data []byte) (*models.CompanyDocument, error) {
+	if !models.IsValidCompanyDocumentType(docType) {
+		return nil, ErrInvalidCompanyDocument
+	}
+	if int64(len(data)) > s.MaxDocumentBytes() {
+		return nil, ErrCompanyDocumentTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	var ext string
+	switch contentType {
+	case "application/pdf":
+		ext = ".pdf"
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/png":
+		ext = ".png"
+	default:
+		return nil, ErrUnsupportedDocumentType
+	}
+
+	if err := s.authorize(userID, orgID, models.OrgRoleOwner); err != nil {
+		return nil, err
+	}
+	profile, err := s.find(userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Status == models.CompanyStatusPending {
+		return nil, ErrCompanyUnderReview
+	}
+
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return nil, err
+	}
+	key := "kyc/" + profile.ID.String() + "/" + hex.EncodeToString(name) + ext
+	if _, err := s.files.Put(key, contentType, data); err != nil {
+		return nil, err
+	}
+
+	document := &models.CompanyDocument{
+		CompanyID:   profile.ID,
+		Type:        docType,
+		FileName:    truncate(filepath.Base(fileName), 255),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		StorageKey:  key,
+		UploadedBy:  userID,
+	}
+	if err := s.repo.CreateDocument(document); err != nil {
+		return nil, err
+	}
+
+	if profile.Status != models.CompanyStatusDraft {
+		s.reopen(profile)
+		if err := s.repo.Update(profile); err != nil {
+			return nil, err
+		}
+	}
+	return document, nil
+}
+
+// SubmitCompany sends a draft or rejected profile to bank staff for review (organization
+// owners only). Both required documents must have been uploaded.
+func (s *CompanyService) SubmitCompany(userID uuid.UUID, orgID *uuid.UUID) (*models.CompanyProfile, error) {
+	if err := s.authorize(userID, orgID, models.OrgRoleOwner); err != nil {
+		return nil, err
+	}
+	profile, err := s.find(userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !profile.HasRequiredDocuments() {
+		return nil, ErrCompanyDocumentsMissing
+	}
+
+	submitted, err := s.repo.Submit(profile.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !submitted {
+		return nil, ErrCompanyNotSubmittable
+	}
+
+	s.bus.Publish(events.CompanySubmitted{CompanyID: profile.ID, OrganizationID: profile.OrganizationID, SubmittedBy: userID})
+
+	return s.repo.FindByID(profile.ID)
+}
+
+// MaxDocumentBytes is the largest document upload accepted
+func (s *CompanyService) MaxDocumentBytes() int64 {
+	return int64(s.cfg.CompanyDocumentMaxMB) * 1024 * 1024
+}
+
+// IsVerified reports whether the user's company, or the organization's when orgID is set,
+// has been verified
+func (s *CompanyService) IsVerified(userID uuid.UUID, orgID *uuid.UUID) (bool, error) {
+	return s.repo.IsVerified(userID, orgID)
+}
+
+// ListCompanies returns the profiles in a status for staff, pending by default
+func (s *CompanyService) ListCompanies(status string) ([]models.CompanyProfile, error) {
+	if status == "" {
+		status = models.CompanyStatusPending
+	}
+	switch status {
+	case models.CompanyStatusDraft, models.CompanyStatusPending, models.CompanyStatusVerified, models.CompanyStatusRejected:
+	default:
+		return nil, ErrInvalidCompanyStatus
+	}
+	return s.repo.FindByStatus(status)
+}
+
+// GetCompanyForReview returns any company profile with its documents, for staff
+func (s *CompanyService) GetCompanyForReview(id uuid.UUID) (*models.CompanyProfile, error) {
+	profile, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, ErrCompanyNotFound
+	}
+	return profile, nil
+}
+
+// DownloadDocument reads a company document from storage, for staff
+func (s *CompanyService) DownloadDocument(companyID, documentID uuid.UUID) (*models.CompanyDocument, []byte, error) {
+	document, err := s.repo.FindDocument(companyID, documentID)
+	if err != nil {
+		return nil, nil, ErrCompanyDocumentNotFound
+	}
+	data, err := s.files.Get(document.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return document, data, nil
+}
+
+// ApproveCompany verifies a pending company profile
+func (s *CompanyService) ApproveCompany(reviewerID, id uuid.UUID) (*models.CompanyProfile, error) {
+	return s.review(reviewerID, id, models.CompanyStatusVerified, "")
+}
+
+// RejectCompany rejects a pending company profile with a reason the owner can act on
+func (s *CompanyService) RejectCompany(reviewerID, id uuid.UUID, input CompanyReviewInput) (*models.CompanyProfile, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if len(reason) < 3 || len(reason) > 500 {
+		return nil, ErrCompanyReasonLength
+	}
+	return s.review(reviewerID, id, models.CompanyStatusRejected, reason)
+}
+
+// review records a decision and tells whoever set up the profile
+func (s *CompanyService) review(reviewerID, id uuid.UUID, status, reason string) (*models.CompanyProfile, error) {
+	profile, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, ErrCompanyNotFound
+	}
+
+	reviewed, err := s.repo.Review(id, reviewerID, status, reason)
+	if err != nil {
+		return nil, err
+	}
+	if !reviewed {
+		return nil, ErrCompanyNotPending
+	}
+
+	s.bus.Publish(events.CompanyReviewed{CompanyID: id, Status: status, Reason: reason, ReviewedBy: reviewerID})
+
+	if owner, err := s.userRepo.FindByID(profile.UserID); err == nil {
+		subject := "Your company verification is approved"
+		body := fmt.Sprintf("Hi %s,\n\n%s has been verified. You can now create production credentials.", owner.FullName, profile.LegalName)
+		if status == models.CompanyStatusRejected {
+			subject = "Your company verification needs changes"
+			body = fmt.Sprintf("Hi %s,\n\nWe could not verify %s.\n\nReason: %s\n\n"+
+				"Please update the company profile or documents and submit it again.", owner.FullName, profile.LegalName, reason)
+		}
+		if err := s.mailer.Send(owner.Email, subject, body); err != nil {
+			log.Printf("Failed to send company review email to %s: %v", owner.Email, err)
+		}
+	}
+
+	return s.repo.FindByID(id)
+}
+
+// authorize checks the user may act on the profile: their own, or an organization's where
+// their role is at least minRole
+func (s *CompanyService) authorize(userID uuid.UUID, orgID *uuid.UUID, minRole string) error {
+	if orgID == nil {
+		return nil
+	}
+	_, err := s.orgs.Authorize(userID, *orgID, minRole)
+	return err
+}
+
+// find loads the profile of a user or organization
+func (s *CompanyService) find(userID uuid.UUID, orgID *uuid.UUID) (*models.CompanyProfile, error) {
+	profile, err := s.repo.FindByOwner(userID, orgID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCompanyNotFound
+	}
+	return profile, err
+}
+
+// reopen turns a reviewed profile back into a draft after it changed
+func (s *CompanyService) reopen(profile *models.CompanyProfile) {
+	profile.Status = models.CompanyStatusDraft
+	profile.ReviewedAt = nil
+	profile.ReviewedBy = nil
+	profile.RejectionReason = ""
+}
+
+// digitsOnly strips the dots, dashes and spaces registration numbers are usually written
+// with. Any other character makes the number invalid and returns "".
+func digitsOnly(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '.' || r == '-' || r == ' ':
+		default:
+			return ""
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// dashboardCacheTTL is how long an assembled dashboard is served from memory
+	dashboardCacheTTL = 30 * time.Second
+
+	// dashboardActivityLimit caps the recent activity feed
+	dashboardActivityLimit = 10
+)
+
+// DashboardService assembles the developer home page from several aggregates
+type DashboardService struct {
+	userRepo        *repository.UserRepository
+	apiKeyRepo      *repository.APIKeyRepository
+	partnerCredRepo *repository.PartnerCredentialRepository
+	sessionRepo     *repository.SessionRepository
+	quotas          *QuotaService
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cachedDashboard
+}
+
+// cachedDashboard is a dashboard with its expiry time
+type cachedDashboard struct {
+	dashboard *DashboardResponse
+	expiresAt time.Time
+}
+
+// NewDashboardService creates a new DashboardService
+func NewDashboardService(userRepo *repository.UserRepository, apiKeyRepo *repository.APIKeyRepository, partnerCredRepo *repository.PartnerCredentialRepository, sessionRepo *repository.SessionRepository, quotas *QuotaService) *DashboardService {
+	return &DashboardService{
+		userRepo:        userRepo,
+		apiKeyRepo:      apiKeyRepo,
+		partnerCredRepo: partnerCredRepo,
+		sessionRepo:     sessionRepo,
+		quotas:          quotas,
+		cache:           make(map[uuid.UUID]cachedDashboard),
+	}
+}
+
+// DashboardResponse is the read model behind the SPA home page
+type DashboardResponse struct {
+	Profile        models.UserResponse       `json:"profile"`
+	Credentials    DashboardCredentialCounts `json:"credentials"`
+	APIKeys        DashboardAPIKeyCounts     `json:"apiKeys"`
+	Quota          DashboardQuota            `json:"quota"`
+	RecentActivity []DashboardActivity       `json:"recentActivity"`
+	Alerts         []DashboardAlert          `json:"alerts"`
+	GeneratedAt    time.Time                 `json:"generatedAt"`
+}
+
+// DashboardCredentialCounts summarises a user's partner credentials
+type DashboardCredentialCounts struct {
+	Total      int `json:"total"`
+	Active     int `json:"active"`
+	Suspended  int `json:"suspended"`
+	Sandbox    int `json:"sandbox"`
+	Production int `json:"production"`
+}
+
+// DashboardAPIKeyCounts summarises a user's API keys
+type DashboardAPIKeyCounts struct {
+	Active int `json:"active"`
+}
+
+// DashboardQuota shows how much of the user's personal quota is used
+type DashboardQuota struct {
+	APIKeysUsed      int `json:"apiKeysUsed"`
+	APIKeysLimit     int `json:"apiKeysLimit"`
+	CredentialsUsed  int `json:"credentialsUsed"`
+	CredentialsLimit int `json:"credentialsLimit"`
+}
+
+// DashboardActivity is one entry of the recent activity feed
+type DashboardActivity struct {
+	Type        string    `json:"type"` // login, credential_created, credential_used, api_key_created, api_key_used
+	Description string    `json:"description"`
+	At          time.Time `json:"at"`
+}
+
+// DashboardAlert is something on the account that needs the developer's attention
+type DashboardAlert struct {
+	Severity   string     `json:"severity"` // info, warning, critical
+	Message    string     `json:"message"`
+	ResourceID *uuid.UUID `json:"resourceId,omitempty"`
+}
+
+// GetDashboard returns the user's dashboard, serving it from a short-lived per-user cache
+func (s *DashboardService) GetDashboard(userID uuid.UUID) (*DashboardResponse, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	if cached, ok := s.cache[userID]; ok && now.Before(cached.expiresAt) {
+		s.mu.Unlock()
+		return cached.dashboard, nil
+	}
+	s.mu.Unlock()
+
+	dashboard, err := s.buildDashboard(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	for id, cached := range s.cache {
+		if now.After(cached.expiresAt) {
+			delete(s.cache, id)
+		}
+	}
+	s.cache[userID] = cachedDashboard{dashboard: dashboard, expiresAt: now.Add(dashboardCacheTTL)}
+	s.mu.Unlock()
+
+	return dashboard, nil
+}
+
+// buildDashboard loads the user's aggregates concurrently and combines them
+func (s *DashboardService) buildDashboard(userID uuid.UUID) (*DashboardResponse, error) {
+	var (
+		user        *models.User
+		apiKeys     []models.APIKey
+		credentials []models.PartnerCredential
+		sessions    []models.Session
+		quota       *models.Quota
+	)
+
+	var g errgroup.Group
+	g.Go(func() (err error) {
+		user, err = s.userRepo.FindByID(userID)
+		return err
+	})
+	g.Go(func() (err error) {
+		apiKeys, err = s.apiKeyRepo.FindByUserID(userID)
+		return err
+	})
+	g.Go(func() (err error) {
+		credentials, err = s.partnerCredRepo.FindByUserID(userID)
+		return err
+	})
+	g.Go(func() (err error) {
+		sessions, err = s.sessionRepo.FindRecentByUserID(userID, dashboardActivityLimit)
+		return err
+	})
+	g.Go(func() (err error) {
+		quota, err = s.quotas.ForOwner(userID, nil)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	dashboard := &DashboardResponse{
+		Profile:        user.ToResponse(),
+		RecentActivity: []DashboardActivity{},
+		Alerts:         []DashboardAlert{},
+		GeneratedAt:    time.Now(),
+	}
+
+	for _, session := range sessions {
+		dashboard.RecentActivity = append(dashboard.RecentActivity, DashboardActivity{
+			Type:        "login",
+			Description: "Signed in to the portal",
+			At:          session.CreatedAt,
+		})
+	}
+
+	// Revoked keys are not returned, so every key here is active
+	for _, key := range apiKeys {
+		dashboard.APIKeys.Active++
+
+		dashboard.RecentActivity = append(dashboard.RecentActivity, DashboardActivity{
+			Type:        "api_key_created",
+			Description: fmt.Sprintf("API key %q created", key.Name),
+			At:          key.CreatedAt,
+		})
+		if key.LastUsedAt != nil {
+			dashboard.RecentActivity = append(dashboard.RecentActivity, DashboardActivity{
+				Type:        "api_key_used",
+				Description: fmt.Sprintf("API key %q used", key.Name),
+				At:          *key.LastUsedAt,
+			})
+		}
+	}
+
+	for i := range credentials {
+		s.addCredential(dashboard, &credentials[i])
+	}
+
+	if !user.IsVerified {
+		dashboard.Alerts = append(dashboard.Alerts, DashboardAlert{
+			Severity: "warning",
+			Message:  "Verify your email address to unlock all portal features",
+		})
+	}
+
+	dashboard.Quota = DashboardQuota{
+		APIKeysUsed:      dashboard.APIKeys.Active,
+		APIKeysLimit:     quota.MaxAPIKeys,
+		CredentialsUsed:  dashboard.Credentials.Total,
+		CredentialsLimit: quota.MaxCredentials,
+	}
+	if dashboard.Quota.CredentialsUsed >= dashboard.Quota.CredentialsLimit {
+		dashboard.Alerts = append(dashboard.Alerts, DashboardAlert{
+			Severity: "info",
+			Message:  "You have reached the maximum number of partner credentials",
+		})
+	}
+
+	sort.Slice(dashboard.RecentActivity, func(i, j int) bool {
+		return dashboard.RecentActivity[i].At.After(dashboard.RecentActivity[j].At)
+	})
+	if len(dashboard.RecentActivity) > dashboardActivityLimit {
+		dashboard.RecentActivity = dashboard.RecentActivity[:dashboardActivityLimit]
+	}
+
+	return dashboard, nil
+}
+
+// addCredential counts a partner credential and records its activity and alerts
+func (s *DashboardService) addCredential(dashboard *DashboardResponse, credential *models.PartnerCredential) {
+	counts := &dashboard.Credentials
+	counts.Total++
+	if credential.IsActive {
+		counts.Active++
+	}
+	if credential.Environment == "production" {
+		counts.Production++
+	} else {
+		counts.Sandbox++
+	}
+
+	dashboard.RecentActivity = append(dashboard.RecentActivity, DashboardActivity{
+		Type:        "credential_created",
+		Description: fmt.Sprintf("Partner credential %q created", credential.PartnerName),
+		At:          credential.CreatedAt,
+	})
+	if credential.LastUsedAt != nil {
+		dashboard.RecentActivity = append(dashboard.RecentActivity, DashboardActivity{
+			Type:        "credential_used",
+			Description: fmt.Sprintf("Partner credential %q used", credential.PartnerName),
+			At:          *credential.LastUsedAt,
+		})
+	}
+
+	id := credential.ID
+	if credential.SuspendedAt != nil {
+		counts.Suspended++
+		dashboard.Alerts = append(dashboard.Alerts, DashboardAlert{
+			Severity:   "critical",
+			Message:    fmt.Sprintf("Partner credential %q is suspended for inactivity", credential.PartnerName),
+			ResourceID: &id,
+		})
+	}
+	for _, warning := range credential.CallbackWarnings() {
+		dashboard.Alerts = append(dashboard.Alerts, DashboardAlert{
+			Severity:   "warning",
+			Message:    fmt.Sprintf("%s: %s", credential.PartnerName, warning),
+			ResourceID: &id,
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/bankaceh/bas-portal-api/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	// OperationTypeDataExport builds a user's personal data export
+	OperationTypeDataExport = "user.data_export"
+
+	// DataExportRetention is how long a finished export can be downloaded
+	DataExportRetention = 7 * 24 * time.Hour
+
+	// dataExportAuditPageSize is how many audit log entries are read at a time
+	dataExportAuditPageSize = 500
+)
+
+var (
+	ErrDataExportNotFound   = errors.New("no data export has been requested")
+	ErrDataExportInProgress = errors.New("data export is still being prepared")
+	ErrDataExportFailed     = errors.New("data export failed")
+	ErrDataExportExpired    = errors.New("data export has expired")
+)
+
+// DataExportService builds downloadable archives of a user's personal data in the
+// background
+type DataExportService struct {
+	operations      *OperationService
+	userRepo        *repository.UserRepository
+	apiKeyRepo      *repository.APIKeyRepository
+	partnerCredRepo *repository.PartnerCredentialRepository
+	auditRepo       *repository.AuditLogRepository
+	files           storage.Storage
+}
+
+// NewDataExportService creates a new DataExportService and registers its operation.
+// files must be private storage.
+func NewDataExportService(operations *OperationService, userRepo *repository.UserRepository, apiKeyRepo *repository.APIKeyRepository, partnerCredRepo *repository.PartnerCredentialRepository, auditRepo *repository.AuditLogRepository, files storage.Storage) *DataExportService {
+	s := &DataExportService{
+		operations:      operations,
+		userRepo:        userRepo,
+		apiKeyRepo:      apiKeyRepo,
+		partnerCredRepo: partnerCredRepo,
+		auditRepo:       auditRepo,
+		files:           files,
+	}
+	operations.RegisterHandler(OperationTypeDataExport, s.build)
+	return s
+}
+
+// DataExportResult is the result of a finished export operation
+type DataExportResult struct {
+	FileName  string    `json:"fileName"`
+	Size      int       `json:"size"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// StartExport queues a new export of the user's data. A running export is returned
+// instead of starting another one.
+func (s *DataExportService) StartExport(userID uuid.UUID) (*models.Operation, error) {
+	if latest, err := s.operations.Latest(userID, OperationTypeDataExport); err == nil && !latest.IsDone() {
+		return latest, nil
+	}
+	return s.operations.Start(userID, OperationTypeDataExport, nil)
+}
+
+// Download returns the archive of the user's latest finished export
+func (s *DataExportService) Download(userID uuid.UUID) (string, []byte, error) {
+	operation, err := s.operations.Latest(userID, OperationTypeDataExport)
+	if err != nil {
+		return "", nil, ErrDataExportNotFound
+	}
+	switch operation.Status {
+	case models.OperationStatusFailed:
+		return "", nil, ErrDataExportFailed
+	case models.OperationStatusSucceeded:
+	default:
+		return "", nil, ErrDataExportInProgress
+	}
+
+	var result DataExportResult
+	if err := json.Unmarshal([]byte(operation.Result), &result); err != nil {
+		return "", nil, err
+	}
+	if time.Now().After(result.ExpiresAt) {
+		return "", nil, ErrDataExportExpired
+	}
+
+	data, err := s.files.Get(dataExportKey(userID))
+	if err != nil {
+		return "", nil, err
+	}
+	return result.FileName, data, nil
+}
+
+// build stores an archive of the user's data, replacing any earlier export. Failures are
+// logged and reported to the user without detail.
+func (s *DataExportService) build(ctx *OperationContext) (interface{}, error) {
+	userID := ctx.Operation.UserID
+	startedAt := time.Now()
+
+	archive, err := s.archive(ctx, startedAt)
+	if err == nil {
+		_, err = s.files.Put(dataExportKey(userID), "application/zip", archive)
+	}
+	if err != nil {
+		log.Printf("Data export %s failed: %v", ctx.Operation.ID, err)
+		return nil, errors.New("the export could not be prepared, please try again")
+	}
+
+	return DataExportResult{
+		FileName:  "bas-portal-export-" + startedAt.Format("20060102") + ".zip",
+		Size:      len(archive),
+		ExpiresAt: startedAt.Add(DataExportRetention),
+	}, nil
+}
+
+// archive collects the user's profile, API keys and partner credentials (metadata only)
+// and audit history into a ZIP of JSON files
+func (s *DataExportService) archive(ctx *OperationContext, startedAt time.Time) ([]byte, error) {
+	userID := ctx.Operation.UserID
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	profile := user.ToResponse()
+	ctx.ReportProgress(10)
+
+	keys, err := s.apiKeyRepo.FindAllCreatedBy(userID)
+	if err != nil {
+		return nil, err
+	}
+	keyResponses := make([]models.APIKeyResponse, len(keys))
+	for i := range keys {
+		keyResponses[i] = keys[i].ToResponse()
+	}
+	ctx.ReportProgress(20)
+
+	credentials, err := s.partnerCredRepo.FindAllCreatedBy(userID)
+	if err != nil {
+		return nil, err
+	}
+	credentialResponses := make([]models.PartnerCredentialResponse, len(credentials))
+	for i := range credentials {
+		credentialResponses[i] = credentials[i].ToResponse()
+	}
+	ctx.ReportProgress(30)
+
+	// Page through the audit history as it was when the export started
+	var auditLogs []models.AuditLogResponse
+	for offset := 0; ; offset += dataExportAuditPageSize {
+		entries, total, err := s.auditRepo.Find(repository.AuditLogFilter{
+			ActorID: &userID,
+			To:      &startedAt,
+			Limit:   dataExportAuditPageSize,
+			Offset:  offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i := range entries {
+			auditLogs = append(auditLogs, entries[i].ToResponse())
+		}
+		if total > 0 {
+			ctx.ReportProgress(30 + int(60*int64(len(auditLogs))/total))
+		}
+		if len(entries) < dataExportAuditPageSize {
+			break
+		}
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", profile},
+		{"api_keys.json", keyResponses},
+		{"partner_credentials.json", credentialResponses},
+		{"audit_logs.json", auditLogs},
+	}
+	for _, file := range files {
+		f, err := w.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// dataExportKey is where a user's export is stored; each export overwrites the last
+func dataExportKey(userID uuid.UUID) string {
+	return "exports/" + userID.String() + ".zip"
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	_ "embed"
+	"errors"
+	"strings"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEmailDomainNotAllowed = errors.New("email domain is not allowed to register")
+	ErrDomainRuleNotFound    = errors.New("email domain rule not found")
+	ErrDomainRuleExists      = errors.New("email domain rule already exists")
+	ErrInvalidDomain         = errors.New("invalid email domain")
+)
+
+// disposableDomainList is the built-in list of throwaway email providers
+//
+//go:embed disposable_domains.txt
+var disposableDomainList string
+
+// disposableDomains parses disposableDomainList, skipping blanks and # comments
+var disposableDomains = func() []string {
+	var domains []string
+	for _, line := range strings.Split(disposableDomainList, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			domains = append(domains, line)
+		}
+	}
+	return domains
+}()
+
+// DomainPolicyService decides which email domains may sign up. Rules come from
+// REGISTRATION_ALLOWED_DOMAINS / REGISTRATION_BLOCKED_DOMAINS, the built-in disposable
+// provider list and admin-managed rules. When any allow rule exists, only allowed domains
+// may register; block rules always win.
+type DomainPolicyService struct {
+	repo *repository.EmailDomainRuleRepository
+	bus  *events.Bus
+	cfg  *config.Config
+}
+
+// NewDomainPolicyService creates a new DomainPolicyService
+func NewDomainPolicyService(repo *repository.EmailDomainRuleRepository, bus *events.Bus, cfg *config.Config) *DomainPolicyService {
+	return &DomainPolicyService{repo: repo, bus: bus, cfg: cfg}
+}
+
+// CreateDomainRuleInput represents a new sign-up domain rule
+type CreateDomainRuleInput struct {
+	Domain string `json:"domain" validate:"required,fqdn"`
+	Type   string `json:"type" validate:"required,oneof=allow block"`
+	Note   string `json:"note" validate:"max=255"`
+}
+
+// DomainPolicy lists the rules in force, split by where they come from
+type DomainPolicy struct {
+	ConfigAllowed   []string                 `json:"configAllowed"`
+	ConfigBlocked   []string                 `json:"configBlocked"`
+	BlockDisposable bool                     `json:"blockDisposable"`
+	DisposableCount int                      `json:"disposableCount"`
+	AdminDomains    []string                 `json:"adminDomains"`
+	Rules           []models.EmailDomainRule `json:"rules"`
+}
+
+// CheckEmail returns ErrEmailDomainNotAllowed if the email's domain may not register
+func (s *DomainPolicyService) CheckEmail(email string) error {
+	_, domain, found := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	if !found || domain == "" {
+		return ErrEmailDomainNotAllowed
+	}
+
+	allowed := append([]string{}, s.cfg.RegistrationAllowedDomains...)
+	blocked := append([]string{}, s.cfg.RegistrationBlockedDomains...)
+
+	rules, err := s.repo.FindActive()
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if rule.Type == models.DomainRuleAllow {
+			allowed = append(allowed, rule.Domain)
+		} else {
+			blocked = append(blocked, rule.Domain)
+		}
+	}
+
+	if matchesDomain(domain, blocked) {
+		return ErrEmailDomainNotAllowed
+	}
+	if s.cfg.BlockDisposableEmailDomains && matchesDomain(domain, disposableDomains) {
+		return ErrEmailDomainNotAllowed
+	}
+	if len(allowed) > 0 && !matchesDomain(domain, allowed) {
+		return ErrEmailDomainNotAllowed
+	}
+	return nil
+}
+
+// GetPolicy returns the configured and admin-managed rules
+func (s *DomainPolicyService) GetPolicy() (*DomainPolicy, error) {
+	rules, err := s.repo.FindActive()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DomainPolicy{
+		ConfigAllowed:   s.cfg.RegistrationAllowedDomains,
+		ConfigBlocked:   s.cfg.RegistrationBlockedDomains,
+		BlockDisposable: s.cfg.BlockDisposableEmailDomains,
+		DisposableCount: len(disposableDomains),
+		AdminDomains:    s.cfg.AdminEmailDomains,
+		Rules:           rules,
+	}, nil
+}
+
+// ListHistory returns every rule including removed ones, as the change history
+func (s *DomainPolicyService) ListHistory() ([]models.EmailDomainRule, error) {
+	return s.repo.FindAllWithDeleted()
+}
+
+// AddRule adds an admin-managed sign-up domain rule
+func (s *DomainPolicyService) AddRule(adminID uuid.UUID, input CreateDomainRuleInput) (*models.EmailDomainRule, error) {
+	domain := normalizeDomain(input.Domain)
+	if domain == "" || !strings.Contains(domain, ".") || strings.ContainsAny(domain, "@ /") {
+		return nil, ErrInvalidDomain
+	}
+	if input.Type != models.DomainRuleAllow && input.Type != models.DomainRuleBlock {
+		return nil, ErrInvalidDomain
+	}
+
+	exists, err := s.repo.ExistsActive(domain, input.Type)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrDomainRuleExists
+	}
+
+	rule := &models.EmailDomainRule{
+		Domain:    domain,
+		Type:      input.Type,
+		Note:      input.Note,
+		CreatedBy: adminID,
+	}
+	if err := s.repo.Create(rule); err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.DomainRuleAdded{AdminID: adminID, RuleID: rule.ID, Domain: rule.Domain, Type: rule.Type})
+	return rule, nil
+}
+
+// DeleteRule removes an admin-managed sign-up domain rule
+func (s *DomainPolicyService) DeleteRule(adminID, id uuid.UUID) error {
+	rule, err := s.repo.Delete(id, adminID)
+	if err != nil {
+		return ErrDomainRuleNotFound
+	}
+
+	s.bus.Publish(events.DomainRuleRemoved{AdminID: adminID, RuleID: rule.ID, Domain: rule.Domain, Type: rule.Type})
+	return nil
+}
+
+// normalizeDomain lowercases a domain and strips a leading "@" or "."
+func normalizeDomain(domain string) string {
+	return strings.TrimLeft(strings.ToLower(strings.TrimSpace(domain)), "@.")
+}
+
+// matchesDomain reports whether domain equals, or is a subdomain of, any rule domain
+func matchesDomain(domain string, ruleDomains []string) bool {
+	for _, rule := range ruleDomains {
+		rule = normalizeDomain(rule)
+		if domain == rule || strings.HasSuffix(domain, "."+rule) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/mailer"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ExpiryReminderDays are how many days before expiry owners are reminded, longest first
+var ExpiryReminderDays = []int{30, 7, 1}
+
+// ExpiryReminderService emails owners of API keys and partner credentials that are about
+// to expire, once per reminder
+type ExpiryReminderService struct {
+	reminderRepo    *repository.ExpiryReminderRepository
+	apiKeyRepo      *repository.APIKeyRepository
+	partnerCredRepo *repository.PartnerCredentialRepository
+	preferences     *NotificationPreferenceService
+	mailer          mailer.Mailer
+	cfg             *config.Config
+}
+
+// NewExpiryReminderService creates a new ExpiryReminderService
+func NewExpiryReminderService(reminderRepo *repository.ExpiryReminderRepository, apiKeyRepo *repository.APIKeyRepository, partnerCredRepo *repository.PartnerCredentialRepository, preferences *NotificationPreferenceService, mailer mailer.Mailer, cfg *config.Config) *ExpiryReminderService {
+	return &ExpiryReminderService{
+		reminderRepo:    reminderRepo,
+		apiKeyRepo:      apiKeyRepo,
+		partnerCredRepo: partnerCredRepo,
+		preferences:     preferences,
+		mailer:          mailer,
+		cfg:             cfg,
+	}
+}
+
+// SendReminders reminds owners of keys and credentials entering a reminder window (run by
+// the scheduler). Only the closest window is sent, so a key created a few days before its
+// expiry gets one reminder rather than three.
+func (s *ExpiryReminderService) SendReminders() error {
+	now := time.Now()
+	until := now.AddDate(0, 0, ExpiryReminderDays[0])
+
+	keys, err := s.apiKeyRepo.FindExpiring(now, until)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		days := reminderWindow(now, *key.ExpiresAt)
+		due, err := s.record(models.ExpiryReminderAPIKey, key.ID, *key.ExpiresAt, days)
+		if err != nil {
+			return err
+		}
+		if !due {
+			continue
+		}
+		s.notify(key.UserID, key.User.Email, fmt.Sprintf("Your API key %q expires %s", key.Name, inDays(days)), fmt.Sprintf(
+			"Your %s API key %q (%s...) expires on %s.\n\n"+
+				"Rotate it before then so your integration keeps working:\n\n%s/api-keys",
+			key.Environment, key.Name, key.KeyPrefix, key.ExpiresAt.UTC().Format(time.RFC1123), s.cfg.FrontendURL,
+		))
+	}
+
+	credentials, err := s.partnerCredRepo.FindExpiring(now, until)
+	if err != nil {
+		return err
+	}
+	for _, credential := range credentials {
+		days := reminderWindow(now, *credential.ExpiresAt)
+		due, err := s.record(models.ExpiryReminderPartnerCredential, credential.ID, *credential.ExpiresAt, days)
+		if err != nil {
+			return err
+		}
+		if !due {
+			continue
+		}
+		s.notify(credential.UserID, credential.User.Email, fmt.Sprintf("Your partner credential for %s expires %s", credential.PartnerName, inDays(days)), fmt.Sprintf(
+			"Your %s partner credential for %s (client ID %s) expires on %s.\n\n"+
+				"Create a replacement before then so your integration keeps working:\n\n%s/partner-credentials",
+			credential.Environment, credential.PartnerName, credential.ClientID, credential.ExpiresAt.UTC().Format(time.RFC1123), s.cfg.FrontendURL,
+		))
+	}
+
+	return nil
+}
+
+// record marks a reminder as sent before it goes out, reporting whether it was due. A
+// failed email is not retried rather than risking duplicates.
+func (s *ExpiryReminderService) record(resourceType string, resourceID uuid.UUID, expiresAt time.Time, days int) (bool, error) {
+	return s.reminderRepo.Record(&models.ExpiryReminder{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		ExpiresAt:    expiresAt,
+		DaysBefore:   days,
+		SentAt:       time.Now(),
+	})
+}
+
+// notify emails an owner unless they opted out of expiry emails, logging failures
+func (s *ExpiryReminderService) notify(userID uuid.UUID, email, subject, body string) {
+	if !s.preferences.Allows(userID, models.NotificationKeyExpiry) {
+		return
+	}
+	if err := s.mailer.Send(email, subject, body); err != nil {
+		log.Printf("Failed to send expiry reminder to %s: %v", email, err)
+	}
+}
+
+// reminderWindow returns the shortest reminder window that expiresAt falls in
+func reminderWindow(now, expiresAt time.Time) int {
+	remaining := expiresAt.Sub(now)
+	for i := len(ExpiryReminderDays) - 1; i > 0; i-- {
+		if remaining <= time.Duration(ExpiryReminderDays[i])*24*time.Hour {
+			return ExpiryReminderDays[i]
+		}
+	}
+	return ExpiryReminderDays[0]
+}
+
+// inDays describes a reminder window in a subject line
+func inDays(days int) string {
+	if days == 1 {
+		return "within a day"
+	}
+	return fmt.Sprintf("in %d days", days)
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/breach"
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLinkRequired          = errors.New("an account with this email already exists; sign in and link it from account settings")
+	ErrIdentityAlreadyLinked = errors.New("a login for this provider is already linked")
+	ErrIdentityInUse         = errors.New("this login is linked to another account")
+	ErrIdentityNotFound      = errors.New("no login for this provider is linked")
+	ErrLastIdentity          = errors.New("cannot remove the only way to sign in")
+	ErrUnsupportedProvider   = errors.New("unsupported identity provider")
+)
+
+// IdentityService manages the sign-in methods linked to an account: a password and
+// external logins such as Google. Google is linked through AuthService.StartGoogleLink
+// because it needs the OAuth flow.
+type IdentityService struct {
+	userRepo       *repository.UserRepository
+	identityRepo   *repository.UserIdentityRepository
+	sessionService *SessionService
+	breachChecker  breach.Checker
+	bus            *events.Bus
+}
+
+// NewIdentityService creates a new IdentityService
+func NewIdentityService(userRepo *repository.UserRepository, identityRepo *repository.UserIdentityRepository, sessionService *SessionService, breachChecker breach.Checker, bus *events.Bus) *IdentityService {
+	return &IdentityService{
+		userRepo:       userRepo,
+		identityRepo:   identityRepo,
+		sessionService: sessionService,
+		breachChecker:  breachChecker,
+		bus:            bus,
+	}
+}
+
+// LinkIdentityInput represents a request to add a sign-in method
+type LinkIdentityInput struct {
+	Provider string `json:"provider" validate:"required,oneof=local google"`
+
+	// Local: the new password. Google: the current password, if the account has one.
+	Password string `json:"password,omitempty"`
+}
+
+// UnlinkIdentityInput confirms the removal of a sign-in method
+type UnlinkIdentityInput struct {
+	Password string `json:"password"`
+}
+
+// IdentityResponse describes a sign-in method linked to the account
+type IdentityResponse struct {
+	Provider   string     `json:"provider"`
+	Email      string     `json:"email,omitempty"`
+	LinkedAt   *time.Time `json:"linkedAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// ListIdentities returns the account's sign-in methods, starting with its password
+func (s *IdentityService) ListIdentities(userID uuid.UUID) ([]IdentityResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	identities, err := s.identityRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]IdentityResponse, 0, len(identities)+1)
+	if user.PasswordHash != "" {
+		response = append(response, IdentityResponse{Provider: models.IdentityProviderLocal, Email: user.Email})
+	}
+	for _, identity := range identities {
+		linkedAt := identity.CreatedAt
+		response = append(response, IdentityResponse{
+			Provider:   identity.Provider,
+			Email:      identity.Email,
+			LinkedAt:   &linkedAt,
+			LastUsedAt: identity.LastUsedAt,
+		})
+	}
+	return response, nil
+}
+
+// SetPassword adds password sign-in to an account that only signs in with an external
+// login. There is no password to confirm, so the session must be a fresh sign-in.
+func (s *IdentityService) SetPassword(userID, sessionID uuid.UUID, password string) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.PasswordHash != "" {
+		return ErrIdentityAlreadyLinked
+	}
+	if err := confirmStepUp(user, "", sessionID, s.sessionService); err != nil {
+		return err
+	}
+
+	hashedPassword, err := hashPassword(password, s.breachChecker)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return err
+	}
+
+	s.bus.Publish(events.IdentityLinked{UserID: user.ID, Provider: models.IdentityProviderLocal, Email: user.Email})
+	return nil
+}
+
+// Unlink removes a sign-in method after confirming the password. The last remaining
+// method cannot be removed, and since the password is what confirms the removal, an
+// account must keep it to unlink anything else.
+func (s *IdentityService) Unlink(userID uuid.UUID, provider string, input UnlinkIdentityInput) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	switch provider {
+	case models.IdentityProviderLocal:
+		if user.PasswordHash == "" {
+			return ErrIdentityNotFound
+		}
+		identities, err := s.identityRepo.FindByUserID(user.ID)
+		if err != nil {
+			return err
+		}
+		if len(identities) == 0 {
+			return ErrLastIdentity
+		}
+		if err := confirmPassword(user, input.Password); err != nil {
+			return err
+		}
+		if err := s.userRepo.ClearPassword(user.ID); err != nil {
+			return err
+		}
+
+	case models.IdentityProviderGoogle:
+		linked, err := s.identityRepo.ExistsForUser(user.ID, provider)
+		if err != nil {
+			return err
+		}
+		if !linked {
+			return ErrIdentityNotFound
+		}
+		if user.PasswordHash == "" {
+			return ErrLastIdentity
+		}
+		if err := confirmPassword(user, input.Password); err != nil {
+			return err
+		}
+		if _, err := s.identityRepo.Delete(user.ID, provider); err != nil {
+			return err
+		}
+
+	default:
+		return ErrUnsupportedProvider
+	}
+
+	s.bus.Publish(events.IdentityUnlinked{UserID: user.ID, Provider: provider})
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/mailer"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrIncidentReasonLength   = errors.New("reason must be at most 500 characters")
+	ErrServiceAccountNoSignIn = errors.New("service accounts do not sign in; revoke their tokens instead")
+	ErrCannotResetOwnPassword = errors.New("you cannot require a reset of your own password")
+)
+
+// IncidentService lets admins contain a compromised account: sign it out everywhere and
+// force a password reset
+type IncidentService struct {
+	userRepo       *repository.UserRepository
+	sessionService *SessionService
+	auth           *AuthService
+	mailer         mailer.Mailer
+	bus            *events.Bus
+}
+
+// NewIncidentService creates a new IncidentService
+func NewIncidentService(userRepo *repository.UserRepository, sessionService *SessionService, auth *AuthService, mailer mailer.Mailer, bus *events.Bus) *IncidentService {
+	return &IncidentService{
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		auth:           auth,
+		mailer:         mailer,
+		bus:            bus,
+	}
+}
+
+// RequirePasswordResetInput carries an optional reason that is recorded and emailed
+type RequirePasswordResetInput struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// ForceLogout revokes every session of a user, invalidating their refresh tokens and
+// access tokens at once
+func (s *IncidentService) ForceLogout(adminID, userID uuid.UUID) error {
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return ErrUserNotFound
+	}
+
+	if err := s.sessionService.RevokeAll(userID); err != nil {
+		return err
+	}
+
+	s.bus.Publish(events.UserSessionsRevoked{UserID: userID, RevokedBy: adminID})
+	return nil
+}
+
+// RequirePasswordReset signs a user out everywhere and refuses sign-in until they reset
+// their password. A reset link is emailed; forgot-password issues a new one if it expires.
+func (s *IncidentService) RequirePasswordReset(adminID, userID uuid.UUID, input RequirePasswordResetInput) (*models.UserResponse, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if len(reason) > 500 {
+		return nil, ErrIncidentReasonLength
+	}
+	if adminID == userID {
+		return nil, ErrCannotResetOwnPassword
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.IsServiceAccount() {
+		return nil, ErrServiceAccountNoSignIn
+	}
+
+	if err := s.userRepo.RequirePasswordReset(userID); err != nil {
+		return nil, err
+	}
+	if err := s.sessionService.RevokeAll(userID); err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.PasswordResetRequired{UserID: userID, Reason: reason, RequiredBy: adminID})
+
+	link, err := s.auth.PasswordResetLink(user)
+	if err != nil {
+		log.Printf("Failed to issue required password reset link for %s: %v", user.Email, err)
+	} else {
+		s.notify(user, link, reason)
+	}
+
+	user.PasswordResetRequired = true
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// notify emails the reset link with the reason, logging failures
+func (s *IncidentService) notify(user *models.User, link, reason string) {
+	body := fmt.Sprintf(
+		"Hi %s,\n\nFor your security, the BAS team has signed your BAS Developer Portal account out of every "+
+			"device and requires a new password before you can sign in again.\n\n",
+		user.FullName,
+	)
+	if reason != "" {
+		body += "Reason: " + reason + "\n\n"
+	}
+	body += "Use the link below to choose a new password. If it has expired, request a new one from the " +
+		"sign-in page with \"Forgot password\".\n\n" + link
+
+	if err := s.mailer.Send(user.Email, "Reset your BAS Developer Portal password", body); err != nil {
+		log.Printf("Failed to send required password reset email to %s: %v", user.Email, err)
+	}
+}
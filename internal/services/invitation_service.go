@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Invitation code limits
+const (
+	DefaultInvitationExpiryDays = 14
+	MaxInvitationExpiryDays     = 365
+	MaxInvitationUses           = 1000
+)
+
+var (
+	ErrInvitationRequired     = errors.New("an invitation code is required to register")
+	ErrInvalidInvitation      = errors.New("invitation code is invalid, expired or used up")
+	ErrInvitationNotFound     = errors.New("invitation code not found")
+	ErrInvalidInvitationInput = errors.New("invalid invitation code settings")
+)
+
+// InvitationService manages the invitation codes used in invitation-only mode
+type InvitationService struct {
+	repo *repository.InvitationCodeRepository
+	bus  *events.Bus
+}
+
+// NewInvitationService creates a new InvitationService
+func NewInvitationService(repo *repository.InvitationCodeRepository, bus *events.Bus) *InvitationService {
+	return &InvitationService{repo: repo, bus: bus}
+}
+
+// CreateInvitationInput represents a new invitation code
+type CreateInvitationInput struct {
+	Note       string `json:"note" validate:"max=255"`
+	MaxUses    int    `json:"maxUses" validate:"omitempty,min=1,max=1000"`
+	ExpiryDays int    `json:"expiryDays" validate:"omitempty,min=1,max=365"`
+}
+
+// CreatedInvitation is returned once on creation and is the only time the code is visible
+type CreatedInvitation struct {
+	models.InvitationCode
+	Code string `json:"code"`
+}
+
+// CreateInvitation issues a new invitation code
+func (s *InvitationService) CreateInvitation(adminID uuid.UUID, input CreateInvitationInput) (*CreatedInvitation, error) {
+	if input.MaxUses == 0 {
+		input.MaxUses = 1
+	}
+	if input.ExpiryDays == 0 {
+		input.ExpiryDays = DefaultInvitationExpiryDays
+	}
+	if input.MaxUses < 1 || input.MaxUses > MaxInvitationUses ||
+		input.ExpiryDays < 1 || input.ExpiryDays > MaxInvitationExpiryDays {
+		return nil, ErrInvalidInvitationInput
+	}
+
+	code, prefix, err := models.GenerateInvitationCode()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &models.InvitationCode{
+		CodePrefix: prefix,
+		CodeHash:   models.HashInvitationCode(code),
+		Note:       input.Note,
+		MaxUses:    input.MaxUses,
+		ExpiresAt:  time.Now().AddDate(0, 0, input.ExpiryDays),
+		CreatedBy:  adminID,
+	}
+	if err := s.repo.Create(invitation); err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.InvitationCreated{AdminID: adminID, InvitationID: invitation.ID, CodePrefix: invitation.CodePrefix, MaxUses: invitation.MaxUses})
+	return &CreatedInvitation{InvitationCode: *invitation, Code: code}, nil
+}
+
+// ListInvitations returns all invitation codes without the codes themselves
+func (s *InvitationService) ListInvitations() ([]models.InvitationCode, error) {
+	return s.repo.FindAll()
+}
+
+// RevokeInvitation stops an invitation code from being redeemed
+func (s *InvitationService) RevokeInvitation(adminID, id uuid.UUID) error {
+	revoked, err := s.repo.Revoke(id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInvitationNotFound
+	}
+
+	s.bus.Publish(events.InvitationRevoked{AdminID: adminID, InvitationID: id})
+	return nil
+}
+
+// Redeem uses up one redemption of a code. Call Release if the registration then fails.
+func (s *InvitationService) Redeem(code string) error {
+	if code == "" {
+		return ErrInvitationRequired
+	}
+
+	redeemed, err := s.repo.Redeem(models.HashInvitationCode(code), time.Now())
+	if err != nil {
+		return err
+	}
+	if !redeemed {
+		return ErrInvalidInvitation
+	}
+	return nil
+}
+
+// Release returns a redemption taken by Redeem
+func (s *InvitationService) Release(code string) {
+	if err := s.repo.Release(models.HashInvitationCode(code)); err != nil {
+		log.Printf("Failed to release invitation code redemption: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"log"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// NotificationPreferenceService manages which optional emails users receive
+type NotificationPreferenceService struct {
+	repo     *repository.NotificationPreferenceRepository
+	userRepo *repository.UserRepository
+}
+
+// NewNotificationPreferenceService creates a new NotificationPreferenceService
+func NewNotificationPreferenceService(repo *repository.NotificationPreferenceRepository, userRepo *repository.UserRepository) *NotificationPreferenceService {
+	return &NotificationPreferenceService{repo: repo, userRepo: userRepo}
+}
+
+// UpdateNotificationPreferencesInput changes notification preferences. Omitted fields
+// are left unchanged.
+type UpdateNotificationPreferencesInput struct {
+	KeyExpiry            *bool `json:"keyExpiry"`
+	SecurityAlerts       *bool `json:"securityAlerts"`
+	ProductAnnouncements *bool `json:"productAnnouncements"`
+}
+
+// GetPreferences returns the user's notification preferences
+func (s *NotificationPreferenceService) GetPreferences(userID uuid.UUID) (*models.NotificationPreferencesResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	preference, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NotificationPreferencesResponse{
+		KeyExpiry:            preference.KeyExpiry,
+		SecurityAlerts:       preference.SecurityAlerts,
+		ProductAnnouncements: user.UnsubscribedAt == nil,
+	}, nil
+}
+
+// UpdatePreferences changes the user's notification preferences
+func (s *NotificationPreferenceService) UpdatePreferences(userID uuid.UUID, input UpdateNotificationPreferencesInput) (*models.NotificationPreferencesResponse, error) {
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if input.KeyExpiry != nil || input.SecurityAlerts != nil {
+		preference, err := s.repo.FindByUserID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if input.KeyExpiry != nil {
+			preference.KeyExpiry = *input.KeyExpiry
+		}
+		if input.SecurityAlerts != nil {
+			preference.SecurityAlerts = *input.SecurityAlerts
+		}
+		if err := s.repo.Save(preference); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.ProductAnnouncements != nil {
+		var err error
+		if *input.ProductAnnouncements {
+			err = s.userRepo.Resubscribe(userID)
+		} else {
+			err = s.userRepo.Unsubscribe(userID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetPreferences(userID)
+}
+
+// Allows reports whether the user wants emails of a category. Lookup failures allow the
+// email, so a database hiccup never silently drops a security alert.
+func (s *NotificationPreferenceService) Allows(userID uuid.UUID, category string) bool {
+	if category == models.NotificationProductAnnouncements {
+		user, err := s.userRepo.FindByID(userID)
+		return err != nil || user.UnsubscribedAt == nil
+	}
+
+	preference, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		log.Printf("Failed to load notification preferences for %s: %v", userID, err)
+		return true
+	}
+
+	switch category {
+	case models.NotificationKeyExpiry:
+		return preference.KeyExpiry
+	case models.NotificationSecurityAlerts:
+		return preference.SecurityAlerts
+	}
+	return true
+}
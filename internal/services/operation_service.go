@@ -0,0 +1,192 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// operationBatchSize is how many pending operations a worker tick claims
+	operationBatchSize = 10
+
+	// operationTimeout fails operations left running this long, e.g. after a restart
+	operationTimeout = time.Hour
+)
+
+var (
+	ErrOperationNotFound    = errors.New("operation not found")
+	ErrUnknownOperationType = errors.New("unknown operation type")
+)
+
+// OperationContext is handed to an operation handler while it runs
+type OperationContext struct {
+	Operation *models.Operation
+	repo      *repository.OperationRepository
+}
+
+// DecodeParams unmarshals the parameters the operation was started with
+func (c *OperationContext) DecodeParams(v interface{}) error {
+	if c.Operation.Params == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(c.Operation.Params), v)
+}
+
+// ReportProgress records completion as a percentage (0-100)
+func (c *OperationContext) ReportProgress(percent int) {
+	percent = max(0, min(percent, 99)) // 100 is reserved for success
+	if err := c.repo.UpdateProgress(c.Operation.ID, percent); err != nil {
+		log.Printf("Failed to record progress for operation %s: %v", c.Operation.ID, err)
+	}
+}
+
+// OperationHandler performs an operation and returns a JSON-serialisable result.
+// Errors are shown to the user, so they should not leak internal detail.
+type OperationHandler func(ctx *OperationContext) (interface{}, error)
+
+// OperationService runs long-running work in the background and reports its status for polling
+type OperationService struct {
+	repo *repository.OperationRepository
+
+	mu       sync.RWMutex
+	handlers map[string]OperationHandler
+}
+
+// NewOperationService creates a new OperationService
+func NewOperationService(repo *repository.OperationRepository) *OperationService {
+	return &OperationService{
+		repo:     repo,
+		handlers: make(map[string]OperationHandler),
+	}
+}
+
+// RegisterHandler makes an operation type runnable
+func (s *OperationService) RegisterHandler(opType string, handler OperationHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[opType] = handler
+}
+
+// Start records a pending operation for the user; a background worker picks it up shortly
+func (s *OperationService) Start(userID uuid.UUID, opType string, params interface{}) (*models.Operation, error) {
+	s.mu.RLock()
+	_, ok := s.handlers[opType]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownOperationType
+	}
+
+	operation := &models.Operation{
+		UserID: userID,
+		Type:   opType,
+		Status: models.OperationStatusPending,
+	}
+	if params != nil {
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		operation.Params = string(encoded)
+	}
+
+	if err := s.repo.Create(operation); err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+// GetOperation returns the status of one of the user's operations
+func (s *OperationService) GetOperation(id, userID uuid.UUID) (*models.OperationResponse, error) {
+	operation, err := s.repo.FindByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, ErrOperationNotFound
+	}
+
+	response := operation.ToResponse()
+	return &response, nil
+}
+
+// Latest returns the user's most recent operation of a type
+func (s *OperationService) Latest(userID uuid.UUID, opType string) (*models.Operation, error) {
+	operation, err := s.repo.FindLatest(userID, opType)
+	if err != nil {
+		return nil, ErrOperationNotFound
+	}
+	return operation, nil
+}
+
+// RunPending claims pending operations and runs them (run by the scheduler)
+func (s *OperationService) RunPending() error {
+	if stale, err := s.repo.FailStale(time.Now().Add(-operationTimeout), "Operation timed out"); err != nil {
+		return err
+	} else if stale > 0 {
+		log.Printf("Failed %d operation(s) that exceeded %s", stale, operationTimeout)
+	}
+
+	operations, err := s.repo.ClaimPending(operationBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range operations {
+		s.run(&operations[i])
+	}
+	return nil
+}
+
+// run executes one claimed operation and records its outcome
+func (s *OperationService) run(operation *models.Operation) {
+	s.mu.RLock()
+	handler, ok := s.handlers[operation.Type]
+	s.mu.RUnlock()
+	if !ok {
+		_ = s.repo.Fail(operation.ID, "Unsupported operation type")
+		return
+	}
+
+	result, err := s.safeRun(handler, &OperationContext{Operation: operation, repo: s.repo})
+	if err != nil {
+		log.Printf("Operation %s (%s) failed: %v", operation.ID, operation.Type, err)
+		if err := s.repo.Fail(operation.ID, truncate(err.Error(), 500)); err != nil {
+			log.Printf("Failed to record failure of operation %s: %v", operation.ID, err)
+		}
+		return
+	}
+
+	var encoded []byte
+	if result != nil {
+		if encoded, err = json.Marshal(result); err != nil {
+			_ = s.repo.Fail(operation.ID, "Operation result could not be encoded")
+			return
+		}
+	}
+	if err := s.repo.Succeed(operation.ID, string(encoded)); err != nil {
+		log.Printf("Failed to record result of operation %s: %v", operation.ID, err)
+	}
+}
+
+// safeRun calls the handler, turning a panic into an error
+func (s *OperationService) safeRun(handler OperationHandler, ctx *OperationContext) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("operation panicked: %v", r)
+		}
+	}()
+	return handler(ctx)
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
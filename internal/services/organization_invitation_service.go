@@ -0,0 +1,261 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/mailer"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// OrgInvitationTTL is how long an organization invitation link stays valid
+const OrgInvitationTTL = 7 * 24 * time.Hour
+
+var (
+	ErrOrgInvitationInvalid       = errors.New("invitation is invalid, expired or no longer pending")
+	ErrOrgInvitationNotFound      = errors.New("invitation not found")
+	ErrOrgInvitationEmailMismatch = errors.New("invitation was sent to a different email address")
+)
+
+// OrganizationInvitationService invites people to organizations by email and handles
+// their answers
+type OrganizationInvitationService struct {
+	repo     *repository.OrganizationInvitationRepository
+	orgs     *OrganizationService
+	userRepo *repository.UserRepository
+	mailer   mailer.Mailer
+	bus      *events.Bus
+	cfg      *config.Config
+}
+
+// NewOrganizationInvitationService creates a new OrganizationInvitationService
+func NewOrganizationInvitationService(repo *repository.OrganizationInvitationRepository, orgs *OrganizationService, userRepo *repository.UserRepository, mailer mailer.Mailer, bus *events.Bus, cfg *config.Config) *OrganizationInvitationService {
+	return &OrganizationInvitationService{
+		repo:     repo,
+		orgs:     orgs,
+		userRepo: userRepo,
+		mailer:   mailer,
+		bus:      bus,
+		cfg:      cfg,
+	}
+}
+
+// InviteMemberInput represents an invitation to join an organization
+type InviteMemberInput struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+// OrgInvitationTokenInput carries the token from an invitation email
+type OrgInvitationTokenInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// OrgInvitationPreview tells the invitee what they were invited to before they answer
+type OrgInvitationPreview struct {
+	OrganizationName string    `json:"organizationName"`
+	Email            string    `json:"email"`
+	Role             string    `json:"role"`
+	InvitedBy        string    `json:"invitedBy"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	AccountExists    bool      `json:"accountExists"` // Sign in to accept, otherwise sign up
+}
+
+// Invite emails an invitation to join the organization with a role (owners only). Inviting
+// the same address again replaces the earlier link.
+func (s *OrganizationInvitationService) Invite(userID, orgID uuid.UUID, input InviteMemberInput) (*models.OrganizationInvitationResponse, error) {
+	if !models.IsValidOrgRole(input.Role) {
+		return nil, ErrInvalidOrgRole
+	}
+	membership, err := s.orgs.Authorize(userID, orgID, models.OrgRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(input.Email)
+	if invitee, err := s.userRepo.FindByEmail(email); err == nil {
+		if _, err := s.orgs.repo.FindMembership(orgID, invitee.ID); err == nil {
+			return nil, ErrOrgMemberExists
+		}
+	}
+
+	if err := s.repo.RevokePendingForEmail(orgID, email); err != nil {
+		return nil, err
+	}
+
+	token, err := models.GenerateOrgInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+	invitation := &models.OrganizationInvitation{
+		OrganizationID: orgID,
+		Email:          email,
+		Role:           input.Role,
+		TokenHash:      models.HashOrgInvitationToken(token),
+		InvitedBy:      userID,
+		ExpiresAt:      time.Now().Add(OrgInvitationTTL),
+	}
+	if err := s.repo.Create(invitation); err != nil {
+		return nil, err
+	}
+
+	inviter, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	link := s.cfg.FrontendURL + "/organizations/invitation?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf(
+		"Hi,\n\n%s invited you to join %s on the BAS Developer Portal as %s.\n\n"+
+			"Use the link below to accept or decline. It expires in %d days.\n\n%s\n\n"+
+			"If you were not expecting this invitation, you can safely ignore it.",
+		inviter.FullName, membership.Organization.Name, input.Role, int(OrgInvitationTTL.Hours()/24), link,
+	)
+	if err := s.mailer.Send(email, "You're invited to join "+membership.Organization.Name, body); err != nil {
+		log.Printf("Failed to send organization invitation to %s: %v", email, err)
+		return nil, err
+	}
+
+	s.bus.Publish(events.OrganizationInvitationSent{OrganizationID: orgID, InvitationID: invitation.ID, Email: email, Role: input.Role, InvitedBy: userID})
+
+	response := invitation.ToResponse()
+	return &response, nil
+}
+
+// ListInvitations returns an organization's pending invitations (owners only)
+func (s *OrganizationInvitationService) ListInvitations(userID, orgID uuid.UUID) ([]models.OrganizationInvitationResponse, error) {
+	if _, err := s.orgs.Authorize(userID, orgID, models.OrgRoleOwner); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.repo.FindPendingByOrganizationID(orgID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]models.OrganizationInvitationResponse, len(invitations))
+	for i := range invitations {
+		response[i] = invitations[i].ToResponse()
+	}
+	return response, nil
+}
+
+// RevokeInvitation cancels a pending invitation (owners only)
+func (s *OrganizationInvitationService) RevokeInvitation(userID, orgID, invitationID uuid.UUID) error {
+	if _, err := s.orgs.Authorize(userID, orgID, models.OrgRoleOwner); err != nil {
+		return err
+	}
+
+	revoked, err := s.repo.Revoke(invitationID, orgID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrOrgInvitationNotFound
+	}
+	return nil
+}
+
+// PreviewInvitation describes a pending invitation to whoever holds its token
+func (s *OrganizationInvitationService) PreviewInvitation(input OrgInvitationTokenInput) (*OrgInvitationPreview, error) {
+	invitation, err := s.pending(input.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &OrgInvitationPreview{
+		OrganizationName: invitation.Organization.Name,
+		Email:            invitation.Email,
+		Role:             invitation.Role,
+		ExpiresAt:        invitation.ExpiresAt,
+		AccountExists:    s.userRepo.EmailExists(invitation.Email),
+	}
+	if inviter, err := s.userRepo.FindByID(invitation.InvitedBy); err == nil {
+		preview.InvitedBy = inviter.FullName
+	}
+	return preview, nil
+}
+
+// AcceptInvitation adds the signed-in user to the organization. The invitation must have
+// been sent to the user's email address.
+func (s *OrganizationInvitationService) AcceptInvitation(userID uuid.UUID, input OrgInvitationTokenInput) (*models.OrganizationResponse, error) {
+	invitation, err := s.pending(input.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, ErrOrgInvitationEmailMismatch
+	}
+	if _, err := s.orgs.repo.FindMembership(invitation.OrganizationID, user.ID); err == nil {
+		return nil, ErrOrgMemberExists
+	}
+
+	if err := s.accept(invitation, user); err != nil {
+		return nil, err
+	}
+
+	return &models.OrganizationResponse{
+		ID:        invitation.Organization.ID,
+		Name:      invitation.Organization.Name,
+		Role:      invitation.Role,
+		CreatedAt: invitation.Organization.CreatedAt,
+	}, nil
+}
+
+// DeclineInvitation turns down an invitation. Holding the emailed token is enough.
+func (s *OrganizationInvitationService) DeclineInvitation(input OrgInvitationTokenInput) error {
+	invitation, err := s.pending(input.Token)
+	if err != nil {
+		return err
+	}
+
+	declined, err := s.repo.Decline(invitation.ID)
+	if err != nil {
+		return err
+	}
+	if !declined {
+		return ErrOrgInvitationInvalid
+	}
+
+	s.bus.Publish(events.OrganizationInvitationDeclined{OrganizationID: invitation.OrganizationID, InvitationID: invitation.ID, Email: invitation.Email})
+	return nil
+}
+
+// pending loads the pending invitation a token belongs to
+func (s *OrganizationInvitationService) pending(token string) (*models.OrganizationInvitation, error) {
+	if token == "" {
+		return nil, ErrOrgInvitationInvalid
+	}
+	invitation, err := s.repo.FindPendingByTokenHash(models.HashOrgInvitationToken(token), time.Now())
+	if err != nil {
+		return nil, ErrOrgInvitationInvalid
+	}
+	return invitation, nil
+}
+
+// accept turns a pending invitation into a membership for user
+func (s *OrganizationInvitationService) accept(invitation *models.OrganizationInvitation, user *models.User) error {
+	member := &models.OrganizationMember{OrganizationID: invitation.OrganizationID, UserID: user.ID, Role: invitation.Role}
+	accepted, err := s.repo.Accept(invitation.ID, member)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return ErrOrgInvitationInvalid
+	}
+
+	s.bus.Publish(events.OrganizationMemberChanged{OrganizationID: invitation.OrganizationID, UserID: user.ID, Role: invitation.Role, ChangedBy: invitation.InvitedBy})
+	return nil
+}
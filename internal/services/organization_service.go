@@ -0,0 +1,267 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrOrgPermissionDenied   = errors.New("your organization role does not allow this")
+	ErrInvalidOrgRole        = errors.New("invalid organization role")
+	ErrOrgMemberNotFound     = errors.New("organization member not found")
+	ErrOrgMemberExists       = errors.New("user is already a member of this organization")
+	ErrLastOrgOwner          = errors.New("an organization must keep at least one owner")
+	ErrOrganizationNotEmpty  = errors.New("organization still owns API keys or partner credentials")
+	ErrInvalidOrganization   = errors.New("organization name must be 2-100 characters")
+	errResourceNotAccessible = errors.New("resource is not accessible")
+)
+
+// OrganizationService manages partner organizations, their members, and who may act on
+// the API keys and partner credentials they own
+type OrganizationService struct {
+	repo            *repository.OrganizationRepository
+	userRepo        *repository.UserRepository
+	apiKeyRepo      *repository.APIKeyRepository
+	partnerCredRepo *repository.PartnerCredentialRepository
+	bus             *events.Bus
+}
+
+// NewOrganizationService creates a new OrganizationService
+func NewOrganizationService(repo *repository.OrganizationRepository, userRepo *repository.UserRepository, apiKeyRepo *repository.APIKeyRepository, partnerCredRepo *repository.PartnerCredentialRepository, bus *events.Bus) *OrganizationService {
+	return &OrganizationService{
+		repo:            repo,
+		userRepo:        userRepo,
+		apiKeyRepo:      apiKeyRepo,
+		partnerCredRepo: partnerCredRepo,
+		bus:             bus,
+	}
+}
+
+// OrganizationInput represents organization create and rename data
+type OrganizationInput struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+}
+
+// UpdateMemberInput represents a member role change
+type UpdateMemberInput struct {
+	Role string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+// OrganizationDetail is an organization with its members
+type OrganizationDetail struct {
+	models.OrganizationResponse
+	Members []models.OrganizationMemberResponse `json:"members"`
+}
+
+// CreateOrganization creates an organization owned by the user
+func (s *OrganizationService) CreateOrganization(userID uuid.UUID, input OrganizationInput) (*models.OrganizationResponse, error) {
+	if len(input.Name) < 2 || len(input.Name) > 100 {
+		return nil, ErrInvalidOrganization
+	}
+
+	org := &models.Organization{Name: input.Name, CreatedBy: userID}
+	owner := &models.OrganizationMember{UserID: userID, Role: models.OrgRoleOwner}
+	if err := s.repo.Create(org, owner); err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.OrganizationCreated{OrganizationID: org.ID, UserID: userID, Name: org.Name})
+
+	owner.Organization = *org
+	response := owner.ToResponse()
+	return &response, nil
+}
+
+// ListOrganizations returns the organizations the user belongs to
+func (s *OrganizationService) ListOrganizations(userID uuid.UUID) ([]models.OrganizationResponse, error) {
+	memberships, err := s.repo.FindMembershipsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]models.OrganizationResponse, len(memberships))
+	for i := range memberships {
+		response[i] = memberships[i].ToResponse()
+	}
+	return response, nil
+}
+
+// GetOrganization returns an organization and its members to any member
+func (s *OrganizationService) GetOrganization(userID, orgID uuid.UUID) (*OrganizationDetail, error) {
+	membership, err := s.Authorize(userID, orgID, models.OrgRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.repo.FindMembers(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &OrganizationDetail{
+		OrganizationResponse: membership.ToResponse(),
+		Members:              make([]models.OrganizationMemberResponse, len(members)),
+	}
+	for i := range members {
+		detail.Members[i] = members[i].ToMemberResponse()
+	}
+	return detail, nil
+}
+
+// RenameOrganization changes an organization's name (owners only)
+func (s *OrganizationService) RenameOrganization(userID, orgID uuid.UUID, input OrganizationInput) (*models.OrganizationResponse, error) {
+	if len(input.Name) < 2 || len(input.Name) > 100 {
+		return nil, ErrInvalidOrganization
+	}
+
+	membership, err := s.Authorize(userID, orgID, models.OrgRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	membership.Organization.Name = input.Name
+	if err := s.repo.Update(&membership.Organization); err != nil {
+		return nil, err
+	}
+
+	response := membership.ToResponse()
+	return &response, nil
+}
+
+// DeleteOrganization deletes an organization (owners only). Its keys and credentials must
+// be revoked or deleted first so nothing is left without an owner.
+func (s *OrganizationService) DeleteOrganization(userID, orgID uuid.UUID) error {
+	if _, err := s.Authorize(userID, orgID, models.OrgRoleOwner); err != nil {
+		return err
+	}
+
+	keys, err := s.apiKeyRepo.CountByOrganizationID(orgID)
+	if err != nil {
+		return err
+	}
+	credentials, err := s.partnerCredRepo.CountByOrganizationID(orgID)
+	if err != nil {
+		return err
+	}
+	if keys > 0 || credentials > 0 {
+		return ErrOrganizationNotEmpty
+	}
+
+	if err := s.repo.Delete(orgID); err != nil {
+		return err
+	}
+
+	s.bus.Publish(events.OrganizationDeleted{OrganizationID: orgID, UserID: userID})
+	return nil
+}
+
+// UpdateMemberRole changes a member's role (owners only). The last owner cannot be demoted.
+func (s *OrganizationService) UpdateMemberRole(userID, orgID, memberID uuid.UUID, input UpdateMemberInput) error {
+	if !models.IsValidOrgRole(input.Role) {
+		return ErrInvalidOrgRole
+	}
+	if _, err := s.Authorize(userID, orgID, models.OrgRoleOwner); err != nil {
+		return err
+	}
+
+	member, err := s.repo.FindMembership(orgID, memberID)
+	if err != nil {
+		return ErrOrgMemberNotFound
+	}
+	if member.Role == input.Role {
+		return nil
+	}
+	if member.Role == models.OrgRoleOwner {
+		if err := s.ensureAnotherOwner(orgID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.UpdateMemberRole(orgID, memberID, input.Role); err != nil {
+		return err
+	}
+
+	s.bus.Publish(events.OrganizationMemberChanged{OrganizationID: orgID, UserID: memberID, Role: input.Role, ChangedBy: userID})
+	return nil
+}
+
+// RemoveMember removes a member (owners only), or lets a member leave. The last owner
+// cannot leave; delete the organization instead.
+func (s *OrganizationService) RemoveMember(userID, orgID, memberID uuid.UUID) error {
+	minRole := models.OrgRoleOwner
+	if userID == memberID {
+		minRole = models.OrgRoleViewer
+	}
+	if _, err := s.Authorize(userID, orgID, minRole); err != nil {
+		return err
+	}
+
+	member, err := s.repo.FindMembership(orgID, memberID)
+	if err != nil {
+		return ErrOrgMemberNotFound
+	}
+	if member.Role == models.OrgRoleOwner {
+		if err := s.ensureAnotherOwner(orgID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.RemoveMember(orgID, memberID); err != nil {
+		return err
+	}
+
+	s.bus.Publish(events.OrganizationMemberChanged{OrganizationID: orgID, UserID: memberID, Role: "", ChangedBy: userID})
+	return nil
+}
+
+// Authorize returns the user's membership if their role in the organization is at least
+// minRole. Non-members get ErrOrganizationNotFound so organizations cannot be probed.
+func (s *OrganizationService) Authorize(userID, orgID uuid.UUID, minRole string) (*models.OrganizationMember, error) {
+	membership, err := s.repo.FindMembership(orgID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	if !models.OrgRoleAtLeast(membership.Role, minRole) {
+		return nil, ErrOrgPermissionDenied
+	}
+	return membership, nil
+}
+
+// authorizeResource checks the user may act on an API key or partner credential: its
+// creator for personal resources, or a member with at least minRole for organization
+// resources. Callers report errResourceNotAccessible as their own not-found error.
+func (s *OrganizationService) authorizeResource(userID, ownerID uuid.UUID, orgID *uuid.UUID, minRole string) error {
+	if orgID == nil {
+		if ownerID != userID {
+			return errResourceNotAccessible
+		}
+		return nil
+	}
+
+	_, err := s.Authorize(userID, *orgID, minRole)
+	if errors.Is(err, ErrOrganizationNotFound) {
+		return errResourceNotAccessible
+	}
+	return err
+}
+
+// ensureAnotherOwner fails if removing or demoting an owner would leave none
+func (s *OrganizationService) ensureAnotherOwner(orgID uuid.UUID) error {
+	owners, err := s.repo.CountOwners(orgID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOrgOwner
+	}
+	return nil
+}
@@ -1,29 +1,70 @@
 package services
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
+	"net/url"
 	"time"
 
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/mailer"
 	"github.com/bankaceh/bas-portal-api/internal/models"
 	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/bankaceh/bas-portal-api/internal/tlscheck"
 	"github.com/google/uuid"
 )
 
+// callbackProbeTimeout bounds the TLS handshake with a partner callback endpoint
+const callbackProbeTimeout = 5 * time.Second
+
 var (
 	ErrCredentialNotFound     = errors.New("partner credential not found")
 	ErrMaxCredentialsReached  = errors.New("maximum number of credentials reached")
 	ErrInvalidPublicKey       = errors.New("invalid public key format")
 	ErrClientIDExists         = errors.New("client ID already exists")
+	ErrInvalidCallbackURL     = errors.New("invalid callback URL")
+	ErrInsecureCallbackURL    = errors.New("callback URL does not meet the TLS policy")
+	ErrCredentialSuspended    = errors.New("partner credential is suspended")
+	ErrCredentialNotSuspended = errors.New("partner credential is not suspended")
 )
 
 // PartnerCredentialService handles business logic for partner credentials
 type PartnerCredentialService struct {
-	repo *repository.PartnerCredentialRepository
+	repo        *repository.PartnerCredentialRepository
+	userRepo    *repository.UserRepository
+	sessions    *SessionService
+	orgs        *OrganizationService
+	quotas      *QuotaService
+	links       *SecretLinkService
+	companies   *CompanyService
+	preferences *NotificationPreferenceService
+	mailer      mailer.Mailer
+	bus         *events.Bus
+	cfg         *config.Config
 }
 
 // NewPartnerCredentialService creates a new PartnerCredentialService
-func NewPartnerCredentialService(repo *repository.PartnerCredentialRepository) *PartnerCredentialService {
-	return &PartnerCredentialService{repo: repo}
+func NewPartnerCredentialService(repo *repository.PartnerCredentialRepository, userRepo *repository.UserRepository, sessions *SessionService, orgs *OrganizationService, quotas *QuotaService, links *SecretLinkService, companies *CompanyService, preferences *NotificationPreferenceService, mailer mailer.Mailer, bus *events.Bus, cfg *config.Config) *PartnerCredentialService {
+	return &PartnerCredentialService{
+		repo:        repo,
+		userRepo:    userRepo,
+		sessions:    sessions,
+		orgs:        orgs,
+		quotas:      quotas,
+		links:       links,
+		companies:   companies,
+		preferences: preferences,
+		mailer:      mailer,
+		bus:         bus,
+		cfg:         cfg,
+	}
 }
 
 // CreateCredentialInput represents the input for creating a partner credential
@@ -33,16 +74,41 @@ type CreateCredentialInput struct {
 	CallbackURL string   `json:"callbackUrl"`
 	IPWhitelist []string `json:"ipWhitelist"`
 	PublicKey   string   `json:"publicKey"`
+
+	// Creates the credential for an organization (editor role required) instead of the user
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+
+	// Returns a single-use link to the client secret instead of the secret itself
+	RetrievalLink bool `json:"retrievalLink,omitempty"`
+}
+
+// RegenerateSecretInput carries the options for a new client secret
+type RegenerateSecretInput struct {
+	// Returns a single-use link to the new secret instead of the secret itself
+	RetrievalLink bool `json:"retrievalLink,omitempty"`
 }
 
 // CreateCredential creates a new partner credential with auto-generated client ID and secret
 func (s *PartnerCredentialService) CreateCredential(userID uuid.UUID, input CreateCredentialInput) (*models.PartnerCredentialCreateResponse, error) {
-	// Check max credentials limit (5 per user)
-	count, err := s.repo.CountByUserID(userID)
+	// Check the owner's quota
+	var count int64
+	var err error
+	if input.OrganizationID != nil {
+		if _, err := s.orgs.Authorize(userID, *input.OrganizationID, models.OrgRoleEditor); err != nil {
+			return nil, err
+		}
+		count, err = s.repo.CountByOrganizationID(*input.OrganizationID)
+	} else {
+		count, err = s.repo.CountByUserID(userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	quota, err := s.quotas.ForOwner(userID, input.OrganizationID)
 	if err != nil {
 		return nil, err
 	}
-	if count >= 5 {
+	if count >= int64(quota.MaxCredentials) {
 		return nil, ErrMaxCredentialsReached
 	}
 
@@ -59,10 +125,10 @@ func (s *PartnerCredentialService) CreateCredential(userID uuid.UUID, input Crea
 	}
 
 	// Validate public key if provided
-	var fingerprint string
+	var fingerprint, algorithm string
 	var publicKeyAddedAt *time.Time
 	if input.PublicKey != "" {
-		fingerprint, err = models.ValidatePublicKey(input.PublicKey)
+		fingerprint, algorithm, err = models.ValidatePublicKey(input.PublicKey)
 		if err != nil {
 			return nil, ErrInvalidPublicKey
 		}
@@ -74,15 +140,21 @@ func (s *PartnerCredentialService) CreateCredential(userID uuid.UUID, input Crea
 	if input.Environment == "" {
 		input.Environment = "sandbox"
 	}
+	if input.Environment == "production" {
+		if err := s.requireVerifiedCompany(userID, input.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
 
 	// Create credential
 	credential := &models.PartnerCredential{
+		ID:                   models.NewLegacyID(),
 		UserID:               userID,
+		OrganizationID:       input.OrganizationID,
 		ClientID:             clientID,
-		ClientSecret:         clientSecret, // TODO: Encrypt before storing
-		ClientSecretPrefix:   secretPrefix,
 		PublicKey:            input.PublicKey,
 		PublicKeyFingerprint: fingerprint,
+		PublicKeyAlgorithm:   algorithm,
 		PublicKeyAddedAt:     publicKeyAddedAt,
 		PartnerName:          input.PartnerName,
 		ChannelID:            channelID,
@@ -92,22 +164,34 @@ func (s *PartnerCredentialService) CreateCredential(userID uuid.UUID, input Crea
 		IsActive:             true,
 	}
 
-	if err := s.repo.Create(credential); err != nil {
+	if err := s.setClientSecret(credential, clientSecret, secretPrefix); err != nil {
+		return nil, err
+	}
+	if err := s.applyCallbackTLS(credential); err != nil {
 		return nil, err
 	}
 
-	// Return response with full secret (only shown once)
-	response := &models.PartnerCredentialCreateResponse{
-		PartnerCredentialResponse: credential.ToResponse(),
-		ClientSecret:              clientSecret,
+	if err := s.repo.Create(credential); err != nil {
+		return nil, err
 	}
 
-	return response, nil
+	// Return response with full secret (only shown once)
+	return s.createResponse(userID, credential, clientSecret, input.RetrievalLink)
 }
 
-// ListCredentials returns all credentials for a user
-func (s *PartnerCredentialService) ListCredentials(userID uuid.UUID) ([]models.PartnerCredentialResponse, error) {
-	credentials, err := s.repo.FindByUserID(userID)
+// ListCredentials returns the user's personal credentials, or an organization's
+// credentials when orgID is set and the user is a member
+func (s *PartnerCredentialService) ListCredentials(userID uuid.UUID, orgID *uuid.UUID) ([]models.PartnerCredentialResponse, error) {
+	var credentials []models.PartnerCredential
+	var err error
+	if orgID != nil {
+		if _, err := s.orgs.Authorize(userID, *orgID, models.OrgRoleViewer); err != nil {
+			return nil, err
+		}
+		credentials, err = s.repo.FindByOrganizationID(*orgID)
+	} else {
+		credentials, err = s.repo.FindByUserID(userID)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -122,9 +206,9 @@ func (s *PartnerCredentialService) ListCredentials(userID uuid.UUID) ([]models.P
 
 // GetCredential returns a single credential with details
 func (s *PartnerCredentialService) GetCredential(id, userID uuid.UUID) (*models.PartnerCredentialDetailResponse, error) {
-	credential, err := s.repo.FindByIDAndUserID(id, userID)
+	credential, err := s.findAuthorized(id, userID, models.OrgRoleViewer)
 	if err != nil {
-		return nil, ErrCredentialNotFound
+		return nil, err
 	}
 
 	response := credential.ToDetailResponse()
@@ -141,9 +225,9 @@ type UpdateCredentialInput struct {
 
 // UpdateCredential updates an existing credential
 func (s *PartnerCredentialService) UpdateCredential(id, userID uuid.UUID, input UpdateCredentialInput) (*models.PartnerCredentialResponse, error) {
-	credential, err := s.repo.FindByIDAndUserID(id, userID)
+	credential, err := s.findAuthorized(id, userID, models.OrgRoleEditor)
 	if err != nil {
-		return nil, ErrCredentialNotFound
+		return nil, err
 	}
 
 	// Update fields
@@ -151,11 +235,20 @@ func (s *PartnerCredentialService) UpdateCredential(id, userID uuid.UUID, input
 		credential.PartnerName = input.PartnerName
 	}
 	if input.Environment != "" {
+		if input.Environment == "production" && credential.Environment != "production" {
+			if err := s.requireVerifiedCompany(credential.UserID, credential.OrganizationID); err != nil {
+				return nil, err
+			}
+		}
 		credential.Environment = input.Environment
 	}
 	credential.CallbackURL = input.CallbackURL
 	credential.IPWhitelist = input.IPWhitelist
 
+	if err := s.applyCallbackTLS(credential); err != nil {
+		return nil, err
+	}
+
 	if err := s.repo.Update(credential); err != nil {
 		return nil, err
 	}
@@ -171,45 +264,53 @@ type UpdatePublicKeyInput struct {
 
 // UpdatePublicKey updates the public key for a credential
 func (s *PartnerCredentialService) UpdatePublicKey(id, userID uuid.UUID, input UpdatePublicKeyInput) (*models.PartnerCredentialResponse, error) {
-	// Verify credential exists and belongs to user
-	credential, err := s.repo.FindByIDAndUserID(id, userID)
+	credential, err := s.findAuthorized(id, userID, models.OrgRoleEditor)
 	if err != nil {
-		return nil, ErrCredentialNotFound
+		return nil, err
 	}
 
 	// Validate public key
-	fingerprint, err := models.ValidatePublicKey(input.PublicKey)
+	fingerprint, algorithm, err := models.ValidatePublicKey(input.PublicKey)
 	if err != nil {
 		return nil, ErrInvalidPublicKey
 	}
 
 	// Update public key
-	if err := s.repo.UpdatePublicKey(id, userID, input.PublicKey, fingerprint); err != nil {
+	if err := s.repo.UpdatePublicKey(id, credential.UserID, input.PublicKey, fingerprint, algorithm); err != nil {
 		return nil, err
 	}
 
+	s.bus.Publish(events.PublicKeyChanged{
+		UserID:       userID,
+		CredentialID: credential.ID,
+		ClientID:     credential.ClientID,
+		Fingerprint:  fingerprint,
+	})
+
 	// Refresh credential
-	credential, _ = s.repo.FindByIDAndUserID(id, userID)
+	credential, err = s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
 	response := credential.ToResponse()
 	return &response, nil
 }
 
 // DeleteCredential soft deletes a credential
 func (s *PartnerCredentialService) DeleteCredential(id, userID uuid.UUID) error {
-	// Verify credential exists and belongs to user
-	_, err := s.repo.FindByIDAndUserID(id, userID)
+	credential, err := s.findAuthorized(id, userID, models.OrgRoleEditor)
 	if err != nil {
-		return ErrCredentialNotFound
+		return err
 	}
 
-	return s.repo.Delete(id, userID)
+	return s.repo.Delete(id, credential.UserID)
 }
 
 // RegenerateSecret generates a new client secret for a credential
-func (s *PartnerCredentialService) RegenerateSecret(id, userID uuid.UUID) (*models.PartnerCredentialCreateResponse, error) {
-	credential, err := s.repo.FindByIDAndUserID(id, userID)
+func (s *PartnerCredentialService) RegenerateSecret(id, userID uuid.UUID, input RegenerateSecretInput) (*models.PartnerCredentialCreateResponse, error) {
+	credential, err := s.findAuthorized(id, userID, models.OrgRoleEditor)
 	if err != nil {
-		return nil, ErrCredentialNotFound
+		return nil, err
 	}
 
 	// Generate new secret
@@ -219,19 +320,43 @@ func (s *PartnerCredentialService) RegenerateSecret(id, userID uuid.UUID) (*mode
 	}
 
 	// Update credential with new secret
-	credential.ClientSecret = clientSecret // TODO: Encrypt before storing
-	credential.ClientSecretPrefix = secretPrefix
-
+	if err := s.setClientSecret(credential, clientSecret, secretPrefix); err != nil {
+		return nil, err
+	}
 	if err := s.repo.Update(credential); err != nil {
 		return nil, err
 	}
 
+	s.bus.Publish(events.ClientSecretRegenerated{
+		UserID:       userID,
+		CredentialID: credential.ID,
+		ClientID:     credential.ClientID,
+	})
+
 	// Return response with full new secret
-	response := &models.PartnerCredentialCreateResponse{
-		PartnerCredentialResponse: credential.ToResponse(),
-		ClientSecret:              clientSecret,
+	return s.createResponse(userID, credential, clientSecret, input.RetrievalLink)
+}
+
+// createResponse returns a new client secret once, or a single-use link to it when asked
+// for one
+func (s *PartnerCredentialService) createResponse(userID uuid.UUID, credential *models.PartnerCredential, clientSecret string, retrievalLink bool) (*models.PartnerCredentialCreateResponse, error) {
+	response := &models.PartnerCredentialCreateResponse{PartnerCredentialResponse: credential.ToResponse()}
+	if !retrievalLink {
+		response.ClientSecret = clientSecret
+		return response, nil
 	}
 
+	link, err := s.links.Issue(userID, SecretRetrieval{
+		ResourceType: models.SecretLinkPartnerCredential,
+		ResourceID:   credential.ID,
+		Name:         credential.PartnerName,
+		ClientID:     credential.ClientID,
+		Secret:       clientSecret,
+	})
+	if err != nil {
+		return nil, err
+	}
+	response.RetrievalLink = link
 	return response, nil
 }
 
@@ -242,13 +367,240 @@ func (s *PartnerCredentialService) ValidateCredential(clientID, clientSecret str
 		return nil, ErrCredentialNotFound
 	}
 
-	// Compare secret (TODO: Use constant-time comparison and encrypted storage)
-	if credential.ClientSecret != clientSecret {
+	// Compare digests in constant time so the response time says nothing about the secret
+	if subtle.ConstantTimeCompare([]byte(hashClientSecret(s.cfg, clientSecret)), []byte(credential.ClientSecretHash)) != 1 {
 		return nil, ErrCredentialNotFound
 	}
 
+	if credential.SuspendedAt != nil {
+		return nil, ErrCredentialSuspended
+	}
+
 	// Update last used timestamp
 	_ = s.repo.UpdateLastUsed(credential.ID)
 
 	return credential, nil
 }
+
+// HashLegacySecrets replaces the plaintext secrets of credentials created before secrets
+// were hashed with their digests (run at startup)
+func (s *PartnerCredentialService) HashLegacySecrets() error {
+	credentials, err := s.repo.FindWithPlaintextSecret()
+	if err != nil {
+		return err
+	}
+	for i := range credentials {
+		sealed, err := sealClientSecret(s.cfg, credentials[i].ID, credentials[i].ClientSecret)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.SetSecretHash(credentials[i].ID, hashClientSecret(s.cfg, credentials[i].ClientSecret), sealed); err != nil {
+			return err
+		}
+	}
+	if len(credentials) > 0 {
+		log.Printf("Hashed the client secrets of %d partner credentials", len(credentials))
+	}
+	return nil
+}
+
+// setClientSecret stores a new secret's digest, encrypted copy and display prefix on a
+// credential whose ID is set
+func (s *PartnerCredentialService) setClientSecret(credential *models.PartnerCredential, secret, prefix string) error {
+	return SetClientSecret(s.cfg, credential, secret, prefix)
+}
+
+// SetClientSecret stores a secret's digest, encrypted copy and display prefix on a
+// credential whose ID is set, keyed as configured. It is exported for tools that create
+// credentials without the service, such as the load test seeder.
+func SetClientSecret(cfg *config.Config, credential *models.PartnerCredential, secret, prefix string) error {
+	sealed, err := sealClientSecret(cfg, credential.ID, secret)
+	if err != nil {
+		return err
+	}
+	credential.ClientSecret = ""
+	credential.ClientSecretHash = hashClientSecret(cfg, secret)
+	credential.ClientSecretSealed = sealed
+	credential.ClientSecretPrefix = prefix
+	return nil
+}
+
+// sealClientSecret encrypts a secret with CLIENT_SECRET_ENCRYPTION_KEY, bound to its
+// credential. It is kept so SNAP signatures can be verified once SNAP endpoints exist.
+func sealClientSecret(cfg *config.Config, id uuid.UUID, secret string) ([]byte, error) {
+	key := sha256.Sum256([]byte(cfg.ClientSecretEncryptionKey))
+	return sealSecret(key[:], id, []byte(secret))
+}
+
+// hashClientSecret returns the HMAC-SHA256 digest a client secret is stored as, keyed with
+// CLIENT_SECRET_HASH_KEY. Secrets are 256 random bits, so a fast hash is enough; keying it
+// means a copy of the database alone cannot confirm a secret.
+func hashClientSecret(cfg *config.Config, secret string) string {
+	mac := hmac.New(sha256.New, []byte(cfg.ClientSecretHashKey))
+	mac.Write([]byte(secret))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ReactivateCredentialInput represents the step-up confirmation for reactivating a credential
+type ReactivateCredentialInput struct {
+	Password string `json:"password"` // Required for accounts with a password
+}
+
+// ReactivateCredential lifts an inactivity suspension after step-up auth: the user's
+// password, or for accounts without one, a recent sign-in
+func (s *PartnerCredentialService) ReactivateCredential(id, userID, sessionID uuid.UUID, input ReactivateCredentialInput) (*models.PartnerCredentialResponse, error) {
+	credential, err := s.findAuthorized(id, userID, models.OrgRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	if credential.SuspendedAt == nil {
+		return nil, ErrCredentialNotSuspended
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if err := confirmStepUp(user, input.Password, sessionID, s.sessions); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Reactivate(id, credential.UserID); err != nil {
+		return nil, err
+	}
+
+	credential, err = s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	response := credential.ToResponse()
+	return &response, nil
+}
+
+// EnforceInactivityPolicy warns owners of unused production credentials and
+// suspends them once the inactivity limit is reached (run by the scheduler)
+func (s *PartnerCredentialService) EnforceInactivityPolicy() error {
+	now := time.Now()
+	warnCutoff := now.AddDate(0, 0, -s.cfg.CredentialInactivityWarnDays)
+	suspendCutoff := now.AddDate(0, 0, -s.cfg.CredentialInactivitySuspendDays)
+
+	toSuspend, err := s.repo.FindInactive(suspendCutoff)
+	if err != nil {
+		return err
+	}
+	for _, credential := range toSuspend {
+		if err := s.repo.Suspend(credential.ID); err != nil {
+			return err
+		}
+		s.notifyOwner(&credential, "Production credential suspended", fmt.Sprintf(
+			"Your production credential for %s (client ID %s) has not been used for %d days and has been suspended.\n\n"+
+				"You can reactivate it from the BAS Developer Portal after confirming your password or signing in again.",
+			credential.PartnerName, credential.ClientID, s.cfg.CredentialInactivitySuspendDays,
+		))
+	}
+
+	toWarn, err := s.repo.FindInactiveUnwarned(warnCutoff)
+	if err != nil {
+		return err
+	}
+	for _, credential := range toWarn {
+		if err := s.repo.MarkInactivityWarned(credential.ID); err != nil {
+			return err
+		}
+		s.notifyOwner(&credential, "Unused production credential", fmt.Sprintf(
+			"Your production credential for %s (client ID %s) has not been used for %d days.\n\n"+
+				"It will be suspended automatically after %d days of inactivity.",
+			credential.PartnerName, credential.ClientID, s.cfg.CredentialInactivityWarnDays, s.cfg.CredentialInactivitySuspendDays,
+		))
+	}
+
+	return nil
+}
+
+// findAuthorized loads a credential the user may act on: their own, or one owned by an
+// organization where their role is at least minRole
+func (s *PartnerCredentialService) findAuthorized(id, userID uuid.UUID, minRole string) (*models.PartnerCredential, error) {
+	credential, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, ErrCredentialNotFound
+	}
+
+	if err := s.orgs.authorizeResource(userID, credential.UserID, credential.OrganizationID, minRole); err != nil {
+		if errors.Is(err, errResourceNotAccessible) {
+			return nil, ErrCredentialNotFound
+		}
+		return nil, err
+	}
+	return credential, nil
+}
+
+// requireVerifiedCompany fails unless the owner's company, or the organization's, passed
+// KYC review. Production credentials are only issued to verified companies.
+func (s *PartnerCredentialService) requireVerifiedCompany(userID uuid.UUID, orgID *uuid.UUID) error {
+	verified, err := s.companies.IsVerified(userID, orgID)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return ErrCompanyNotVerified
+	}
+	return nil
+}
+
+// notifyOwner emails the owner of a credential about its expiry or inactivity, unless they
+// opted out, logging delivery failures
+func (s *PartnerCredentialService) notifyOwner(credential *models.PartnerCredential, subject, body string) {
+	if !s.preferences.Allows(credential.UserID, models.NotificationKeyExpiry) {
+		return
+	}
+	if err := s.mailer.Send(credential.User.Email, subject, body); err != nil {
+		log.Printf("Failed to notify owner of credential %s: %v", credential.ID, err)
+	}
+}
+
+// applyCallbackTLS probes the credential's callback URL and records its TLS setup.
+// Production callbacks must use HTTPS with TLS 1.2 or newer and a valid certificate;
+// sandbox callbacks are recorded but never rejected for their TLS setup.
+func (s *PartnerCredentialService) applyCallbackTLS(credential *models.PartnerCredential) error {
+	credential.CallbackTLSVersion = ""
+	credential.CallbackCertExpiresAt = nil
+	credential.CallbackCheckedAt = nil
+
+	if credential.CallbackURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(credential.CallbackURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return ErrInvalidCallbackURL
+	}
+
+	production := credential.Environment == "production"
+	if u.Scheme != "https" {
+		if production {
+			return fmt.Errorf("%w: plain HTTP is not allowed in production", ErrInsecureCallbackURL)
+		}
+		return nil
+	}
+
+	now := time.Now()
+	credential.CallbackCheckedAt = &now
+
+	result, err := tlscheck.Probe(credential.CallbackURL, callbackProbeTimeout)
+	if err != nil {
+		if production {
+			return fmt.Errorf("%w: %v", ErrInsecureCallbackURL, err)
+		}
+		return nil
+	}
+
+	if production && result.Version < tls.VersionTLS12 {
+		return fmt.Errorf("%w: %s is not allowed in production", ErrInsecureCallbackURL, result.VersionName())
+	}
+
+	credential.CallbackTLSVersion = result.VersionName()
+	credential.CallbackCertExpiresAt = &result.CertExpiresAt
+	return nil
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/database"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var benchConfig = &config.Config{
+	ClientSecretHashKey:       "benchmark-hash-key",
+	ClientSecretEncryptionKey: "benchmark-encryption-key",
+}
+
+func BenchmarkHashClientSecret(b *testing.B) {
+	_, secret, _, err := models.GenerateClientCredentials()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hashClientSecret(benchConfig, secret)
+	}
+}
+
+func BenchmarkSetClientSecret(b *testing.B) {
+	_, secret, prefix, err := models.GenerateClientCredentials()
+	if err != nil {
+		b.Fatal(err)
+	}
+	credential := &models.PartnerCredential{ID: uuid.New()}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := SetClientSecret(benchConfig, credential, secret, prefix); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkValidateCredential measures a credential lookup against the database in
+// TEST_DATABASE_URL, seeded with cmd/seed for realistic table sizes
+func BenchmarkValidateCredential(b *testing.B) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		b.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		b.Fatalf("connect: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		b.Fatalf("migrate: %v", err)
+	}
+
+	service := &PartnerCredentialService{repo: repository.NewPartnerCredentialRepository(db), cfg: benchConfig}
+
+	user := &models.User{Email: uuid.NewString() + "@example.com", FullName: "Benchmark"}
+	if err := db.Create(user).Error; err != nil {
+		b.Fatalf("create user: %v", err)
+	}
+	b.Cleanup(func() { db.Unscoped().Delete(user) })
+
+	clientID, secret, prefix, err := models.GenerateClientCredentials()
+	if err != nil {
+		b.Fatal(err)
+	}
+	credential := &models.PartnerCredential{
+		ID:          models.NewLegacyID(),
+		UserID:      user.ID,
+		ClientID:    clientID,
+		PartnerName: "Benchmark Partner",
+		IsActive:    true,
+	}
+	if err := SetClientSecret(benchConfig, credential, secret, prefix); err != nil {
+		b.Fatal(err)
+	}
+	if err := db.Create(credential).Error; err != nil {
+		b.Fatalf("create credential: %v", err)
+	}
+	b.Cleanup(func() { db.Unscoped().Delete(credential) })
+
+	b.Run("valid", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := service.ValidateCredential(clientID, secret); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("wrong secret", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := service.ValidateCredential(clientID, "wrong"); !errors.Is(err, ErrCredentialNotFound) {
+				b.Fatalf("err = %v, want ErrCredentialNotFound", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/breach"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password policy
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72 // bcrypt only uses the first 72 bytes
+)
+
+var (
+	ErrPasswordConfirmationFailed = errors.New("password confirmation failed")
+	ErrWeakPassword               = errors.New("password must be between 8 and 72 characters")
+	ErrSamePassword               = errors.New("new password must differ from the current password")
+	ErrBreachedPassword           = errors.New("password has appeared in a data breach")
+)
+
+// checkPasswordPolicy rejects passwords that do not meet the portal's password policy
+func checkPasswordPolicy(password string) error {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+// hashPassword checks the password policy, rejects known-breached passwords and returns
+// the bcrypt hash to store
+func hashPassword(password string, checker breach.Checker) (string, error) {
+	if err := checkPasswordPolicy(password); err != nil {
+		return "", err
+	}
+
+	breached, err := checker.IsBreached(password)
+	if err != nil {
+		log.Printf("Breached password check failed: %v", err)
+	} else if breached {
+		return "", ErrBreachedPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// confirmPassword re-checks a user's password before a sensitive action (step-up auth)
+func confirmPassword(user *models.User, password string) error {
+	if user.PasswordHash == "" || password == "" {
+		return ErrPasswordConfirmationFailed
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return ErrPasswordConfirmationFailed
+	}
+	return nil
+}
+
+// confirmStepUp re-checks the user before a sensitive action: their password, or for
+// accounts without one, a sign-in within RecentLoginWindow
+func confirmStepUp(user *models.User, password string, sessionID uuid.UUID, sessions *SessionService) error {
+	if user.PasswordHash != "" {
+		return confirmPassword(user, password)
+	}
+	session, err := sessions.Validate(sessionID)
+	if err != nil || time.Since(session.CreatedAt) > RecentLoginWindow {
+		return ErrRecentLoginRequired
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/bankaceh/bas-portal-api/internal/sms"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// SMSCodeTTL is how long a texted code can be used
+	SMSCodeTTL = 5 * time.Minute
+
+	// SMSCodeMaxAttempts is how many wrong guesses invalidate a code
+	SMSCodeMaxAttempts = 5
+
+	// SMSResendInterval is the minimum time between two codes for the same purpose
+	SMSResendInterval = time.Minute
+)
+
+var (
+	ErrInvalidPhone        = errors.New("phone number must be a valid international or Indonesian mobile number")
+	ErrPhoneRequiredForMFA = errors.New("a verified phone number is required for SMS two-factor authentication")
+	ErrPhoneInUseForMFA    = errors.New("turn off SMS two-factor authentication before changing the phone number")
+	ErrSMSCodeTooSoon      = errors.New("a code was sent recently, please wait before requesting another")
+	ErrInvalidSMSCode      = errors.New("invalid or expired code")
+	ErrSMSDeliveryFailed   = errors.New("the text message could not be sent")
+)
+
+// PhoneService verifies users' phone numbers and sends SMS two-factor codes
+type PhoneService struct {
+	userRepo       *repository.UserRepository
+	codeRepo       *repository.SMSCodeRepository
+	sessionService *SessionService
+	sender         sms.Sender
+}
+
+// NewPhoneService creates a new PhoneService
+func NewPhoneService(userRepo *repository.UserRepository, codeRepo *repository.SMSCodeRepository, sessionService *SessionService, sender sms.Sender) *PhoneService {
+	return &PhoneService{
+		userRepo:       userRepo,
+		codeRepo:       codeRepo,
+		sessionService: sessionService,
+		sender:         sender,
+	}
+}
+
+// SetPhoneInput represents a new phone number for the profile
+type SetPhoneInput struct {
+	Phone string `json:"phone" validate:"required"`
+}
+
+// VerifyPhoneInput represents the code texted to a new phone number
+type VerifyPhoneInput struct {
+	Code string `json:"code" validate:"required,len=6"`
+}
+
+// SMSMFAInput re-confirms the user before two-factor sign-in is turned on or off
+type SMSMFAInput struct {
+	Password string `json:"password"`
+}
+
+// SetPhone saves the user's phone number and texts a code to verify it. Sending the
+// same unverified number again resends the code.
+func (s *PhoneService) SetPhone(userID uuid.UUID, input SetPhoneInput) (*models.UserResponse, error) {
+	phone, err := normalizePhone(input.Phone)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if phone == user.Phone && user.PhoneVerifiedAt != nil {
+		response := user.ToResponse()
+		return &response, nil
+	}
+	if user.MFAMethod == models.MFAMethodSMS {
+		return nil, ErrPhoneInUseForMFA
+	}
+
+	if phone != user.Phone {
+		if err := s.userRepo.UpdatePhone(user.ID, phone); err != nil {
+			return nil, err
+		}
+		user.Phone = phone
+		user.PhoneVerifiedAt = nil
+	}
+
+	if _, err := s.sendCode(user, models.SMSPurposePhoneVerification,
+		"Your BAS Developer Portal verification code is %s. It expires in 5 minutes."); err != nil {
+		return nil, err
+	}
+
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// VerifyPhone confirms the user's phone number with the latest code texted to it
+func (s *PhoneService) VerifyPhone(userID uuid.UUID, input VerifyPhoneInput) (*models.UserResponse, error) {
+	latest, err := s.codeRepo.FindLatest(userID, models.SMSPurposePhoneVerification)
+	if err != nil {
+		return nil, ErrInvalidSMSCode
+	}
+	code, err := s.checkCode(latest.ID, userID, models.SMSPurposePhoneVerification, input.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	verified, err := s.userRepo.MarkPhoneVerified(userID, code.Phone)
+	if err != nil {
+		return nil, err
+	}
+	if !verified {
+		// The number was changed after this code was sent
+		return nil, ErrInvalidSMSCode
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// EnableSMSMFA turns on SMS two-factor sign-in after re-confirming the user
+func (s *PhoneService) EnableSMSMFA(userID, sessionID uuid.UUID, input SMSMFAInput) (*models.UserResponse, error) {
+	return s.setMFAMethod(userID, sessionID, input, models.MFAMethodSMS)
+}
+
+// DisableSMSMFA turns off SMS two-factor sign-in after re-confirming the user
+func (s *PhoneService) DisableSMSMFA(userID, sessionID uuid.UUID, input SMSMFAInput) (*models.UserResponse, error) {
+	return s.setMFAMethod(userID, sessionID, input, "")
+}
+
+// setMFAMethod changes the user's two-factor method once they pass step-up auth
+func (s *PhoneService) setMFAMethod(userID, sessionID uuid.UUID, input SMSMFAInput, method string) (*models.UserResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if err := confirmStepUp(user, input.Password, sessionID, s.sessionService); err != nil {
+		return nil, err
+	}
+	if method == models.MFAMethodSMS && (user.Phone == "" || user.PhoneVerifiedAt == nil) {
+		return nil, ErrPhoneRequiredForMFA
+	}
+
+	if user.MFAMethod != method {
+		if err := s.userRepo.UpdateMFAMethod(user.ID, method); err != nil {
+			return nil, err
+		}
+		user.MFAMethod = method
+	}
+
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// SendMFACode texts a sign-in code to the user's verified phone and returns the code's ID
+func (s *PhoneService) SendMFACode(user *models.User) (uuid.UUID, error) {
+	if user.Phone == "" || user.PhoneVerifiedAt == nil {
+		return uuid.Nil, ErrPhoneRequiredForMFA
+	}
+	return s.sendCode(user, models.SMSPurposeMFALogin,
+		"Your BAS Developer Portal sign-in code is %s. Never share it with anyone, including bank staff.")
+}
+
+// VerifyMFACode checks a sign-in code sent by SendMFACode
+func (s *PhoneService) VerifyMFACode(codeID, userID uuid.UUID, code string) error {
+	_, err := s.checkCode(codeID, userID, models.SMSPurposeMFALogin, code)
+	return err
+}
+
+// sendCode generates a six-digit code, records its hash and texts it to the user's phone.
+// message must contain one %s for the code.
+func (s *PhoneService) sendCode(user *models.User, purpose, message string) (uuid.UUID, error) {
+	latest, err := s.codeRepo.FindLatest(user.ID, purpose)
+	if err == nil && time.Since(latest.CreatedAt) < SMSResendInterval {
+		return uuid.Nil, ErrSMSCodeTooSoon
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return uuid.Nil, err
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	plain := fmt.Sprintf("%06d", n.Int64())
+
+	record := &models.SMSCode{
+		ID:        models.NewID(),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Phone:     user.Phone,
+		ExpiresAt: time.Now().Add(SMSCodeTTL),
+	}
+	record.CodeHash = hashSMSCode(record.ID, plain)
+	if err := s.codeRepo.Create(record); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := s.sender.Send(user.Phone, fmt.Sprintf(message, plain)); err != nil {
+		log.Printf("Failed to send %s code to user %s: %v", purpose, user.ID, err)
+		return uuid.Nil, ErrSMSDeliveryFailed
+	}
+	return record.ID, nil
+}
+
+// checkCode compares a guess with a live code and consumes it on a match. Wrong guesses
+// are counted, and a code stops working after SMSCodeMaxAttempts of them.
+func (s *PhoneService) checkCode(codeID, userID uuid.UUID, purpose, guess string) (*models.SMSCode, error) {
+	code, err := s.codeRepo.FindActive(codeID, userID, purpose)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidSMSCode
+		}
+		return nil, err
+	}
+	if code.Attempts >= SMSCodeMaxAttempts {
+		return nil, ErrInvalidSMSCode
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashSMSCode(code.ID, strings.TrimSpace(guess))), []byte(code.CodeHash)) != 1 {
+		if err := s.codeRepo.RecordAttempt(code.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidSMSCode
+	}
+
+	consumed, err := s.codeRepo.Consume(code.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidSMSCode
+	}
+	return code, nil
+}
+
+// hashSMSCode hashes a code with its record ID so equal codes hash differently
+func hashSMSCode(id uuid.UUID, code string) string {
+	sum := sha256.Sum256([]byte(id.String() + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizePhone converts a phone number to E.164. Indonesian numbers may be written
+// locally (0812...) or without the plus (62812...).
+func normalizePhone(phone string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "0"):
+		digits = "62" + digits[1:]
+	}
+
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidPhone
+		}
+	}
+	return "+" + digits, nil
+}
+
+// maskPhone hides all but the last three digits of a phone number
+func maskPhone(phone string) string {
+	if len(phone) <= 3 {
+		return phone
+	}
+	return strings.Repeat("*", len(phone)-3) + phone[len(phone)-3:]
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	// ProfilePictureSize bounds the stored picture, larger images are scaled down
+	ProfilePictureSize = 512
+	// ProfilePictureThumbnailSize is the side of the square thumbnail
+	ProfilePictureThumbnailSize = 128
+	// ProfilePictureMaxPixels rejects images too large to decode safely
+	ProfilePictureMaxPixels = 4096
+)
+
+var (
+	ErrProfilePictureTooLarge = errors.New("profile picture is too large")
+	ErrUnsupportedImageType   = errors.New("profile picture must be a JPEG or PNG image")
+	ErrInvalidImage           = errors.New("profile picture could not be read as an image")
+)
+
+// ProfilePictureService validates uploaded profile pictures and stores them with a thumbnail
+type ProfilePictureService struct {
+	storage storage.Storage
+	cfg     *config.Config
+}
+
+// NewProfilePictureService creates a new ProfilePictureService
+func NewProfilePictureService(store storage.Storage, cfg *config.Config) *ProfilePictureService {
+	return &ProfilePictureService{storage: store, cfg: cfg}
+}
+
+// ProfilePictureResponse holds the URLs of a stored profile picture
+type ProfilePictureResponse struct {
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+}
+
+// MaxBytes is the largest upload accepted
+func (s *ProfilePictureService) MaxBytes() int64 {
+	return int64(s.cfg.ProfilePictureMaxMB) * 1024 * 1024
+}
+
+// Upload checks the image type, size and dimensions, then stores the picture scaled to at
+// most ProfilePictureSize and a square thumbnail. Images are re-encoded, which also drops
+// any embedded metadata such as GPS coordinates.
+func (s *ProfilePictureService) Upload(userID uuid.UUID, data []byte) (*ProfilePictureResponse, error) {
+	if int64(len(data)) > s.MaxBytes() {
+		return nil, ErrProfilePictureTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	var ext string
+	switch contentType {
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/png":
+		ext = ".png"
+	default:
+		return nil, ErrUnsupportedImageType
+	}
+
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if imgConfig.Width > ProfilePictureMaxPixels || imgConfig.Height > ProfilePictureMaxPixels {
+		return nil, ErrProfilePictureTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	bounds := img.Bounds()
+	width, height := fitWithin(bounds.Dx(), bounds.Dy(), ProfilePictureSize)
+	picture, err := encodeImage(scaleImage(img, bounds, width, height), contentType)
+	if err != nil {
+		return nil, err
+	}
+	thumbnail, err := encodeImage(scaleImage(img, centerSquare(bounds), ProfilePictureThumbnailSize, ProfilePictureThumbnailSize), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return nil, err
+	}
+	key := "profile-pictures/" + userID.String() + "/" + hex.EncodeToString(name)
+
+	pictureURL, err := s.storage.Put(key+ext, contentType, picture)
+	if err != nil {
+		return nil, err
+	}
+	thumbnailURL, err := s.storage.Put(key+"_thumb"+ext, contentType, thumbnail)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProfilePictureResponse{URL: pictureURL, ThumbnailURL: thumbnailURL}, nil
+}
+
+// fitWithin scales width and height down to fit a square of size, keeping the aspect ratio
+func fitWithin(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// centerSquare returns the largest square in the middle of r
+func centerSquare(r image.Rectangle) image.Rectangle {
+	side := min(r.Dx(), r.Dy())
+	x := r.Min.X + (r.Dx()-side)/2
+	y := r.Min.Y + (r.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// scaleImage resamples the src area of img to width x height, averaging the source pixels
+// that fall into each destination pixel
+func scaleImage(img image.Image, src image.Rectangle, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := src.Min.Y + y*src.Dy()/height
+		y1 := max(y0+1, src.Min.Y+(y+1)*src.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := src.Min.X + x*src.Dx()/width
+			x1 := max(x0+1, src.Min.X+(x+1)*src.Dx()/width)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// encodeImage writes img in the format of the upload
+func encodeImage(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxQuota is the highest limit staff can set on a quota
+const MaxQuota = 1000
+
+var ErrInvalidQuota = errors.New("quota limits must be between 0 and 1000")
+
+// QuotaService manages how many API keys and partner credentials users and organizations
+// may hold
+type QuotaService struct {
+	repo     *repository.QuotaRepository
+	userRepo *repository.UserRepository
+	orgRepo  *repository.OrganizationRepository
+	cfg      *config.Config
+}
+
+// NewQuotaService creates a new QuotaService
+func NewQuotaService(repo *repository.QuotaRepository, userRepo *repository.UserRepository, orgRepo *repository.OrganizationRepository, cfg *config.Config) *QuotaService {
+	return &QuotaService{repo: repo, userRepo: userRepo, orgRepo: orgRepo, cfg: cfg}
+}
+
+// UpdateQuotaInput sets an owner's limits
+type UpdateQuotaInput struct {
+	MaxAPIKeys     int `json:"maxApiKeys" validate:"min=0,max=1000"`
+	MaxCredentials int `json:"maxCredentials" validate:"min=0,max=1000"`
+}
+
+// ForOwner returns the quota that a new key or credential counts against: the
+// organization's when orgID is set, otherwise the user's
+func (s *QuotaService) ForOwner(userID uuid.UUID, orgID *uuid.UUID) (*models.Quota, error) {
+	if orgID != nil {
+		return s.find(models.QuotaOwnerOrganization, *orgID)
+	}
+	return s.find(models.QuotaOwnerUser, userID)
+}
+
+// GetUserQuota returns a user's quota
+func (s *QuotaService) GetUserQuota(userID uuid.UUID) (*models.Quota, error) {
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.find(models.QuotaOwnerUser, userID)
+}
+
+// SetUserQuota replaces a user's quota
+func (s *QuotaService) SetUserQuota(staffID, userID uuid.UUID, input UpdateQuotaInput) (*models.Quota, error) {
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.save(staffID, models.QuotaOwnerUser, userID, input)
+}
+
+// GetOrganizationQuota returns an organization's quota
+func (s *QuotaService) GetOrganizationQuota(orgID uuid.UUID) (*models.Quota, error) {
+	if _, err := s.orgRepo.FindByID(orgID); err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return s.find(models.QuotaOwnerOrganization, orgID)
+}
+
+// SetOrganizationQuota replaces an organization's quota
+func (s *QuotaService) SetOrganizationQuota(staffID, orgID uuid.UUID, input UpdateQuotaInput) (*models.Quota, error) {
+	if _, err := s.orgRepo.FindByID(orgID); err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return s.save(staffID, models.QuotaOwnerOrganization, orgID, input)
+}
+
+// find returns an owner's quota, or the configured defaults if staff never set one
+func (s *QuotaService) find(ownerType string, ownerID uuid.UUID) (*models.Quota, error) {
+	quota, err := s.repo.Find(ownerType, ownerID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.DefaultQuota(ownerType, ownerID, s.cfg.DefaultMaxAPIKeys, s.cfg.DefaultMaxCredentials), nil
+	}
+	return quota, err
+}
+
+// save validates and stores an owner's quota. Lowering a quota below what the owner
+// already holds keeps their keys and credentials but blocks new ones.
+func (s *QuotaService) save(staffID uuid.UUID, ownerType string, ownerID uuid.UUID, input UpdateQuotaInput) (*models.Quota, error) {
+	if input.MaxAPIKeys < 0 || input.MaxAPIKeys > MaxQuota || input.MaxCredentials < 0 || input.MaxCredentials > MaxQuota {
+		return nil, ErrInvalidQuota
+	}
+
+	quota := &models.Quota{
+		OwnerType:      ownerType,
+		OwnerID:        ownerID,
+		MaxAPIKeys:     input.MaxAPIKeys,
+		MaxCredentials: input.MaxCredentials,
+		UpdatedBy:      &staffID,
+		UpdatedAt:      time.Now(),
+	}
+	if err := s.repo.Save(quota); err != nil {
+		return nil, err
+	}
+	return quota, nil
+}
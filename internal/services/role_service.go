@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRole          = errors.New("invalid role")
+	ErrRoleDomainNotAllowed = errors.New("staff roles are limited to ADMIN_EMAIL_DOMAINS")
+	ErrCannotChangeOwnRole  = errors.New("you cannot change your own role")
+)
+
+// RoleService assigns and resolves user roles
+type RoleService struct {
+	userRepo *repository.UserRepository
+	bus      *events.Bus
+	cfg      *config.Config
+}
+
+// NewRoleService creates a new RoleService
+func NewRoleService(userRepo *repository.UserRepository, bus *events.Bus, cfg *config.Config) *RoleService {
+	return &RoleService{userRepo: userRepo, bus: bus, cfg: cfg}
+}
+
+// AssignRoleInput represents a role change
+type AssignRoleInput struct {
+	Role string `json:"role" validate:"required,oneof=developer operator admin"`
+}
+
+// EffectiveRole returns the role a user acts with. Staff roles held by an email outside
+// ADMIN_EMAIL_DOMAINS count as developer, so narrowing the domains takes effect at once.
+func (s *RoleService) EffectiveRole(userID uuid.UUID) (string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return "", ErrUserNotFound
+	}
+	return effectiveRole(user, s.cfg), nil
+}
+
+// ListStaff returns every operator and admin
+func (s *RoleService) ListStaff() ([]models.UserResponse, error) {
+	users, err := s.userRepo.FindByRoles([]string{models.RoleOperator, models.RoleAdmin})
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]models.UserResponse, len(users))
+	for i := range users {
+		response[i] = users[i].ToResponse()
+	}
+	return response, nil
+}
+
+// AssignRole changes a user's role. Admins cannot change their own role, which also
+// keeps at least one admin in place.
+func (s *RoleService) AssignRole(adminID, userID uuid.UUID, input AssignRoleInput) (*models.UserResponse, error) {
+	if !models.IsValidRole(input.Role) {
+		return nil, ErrInvalidRole
+	}
+	if adminID == userID {
+		return nil, ErrCannotChangeOwnRole
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if input.Role != models.RoleDeveloper && !staffEmail(user.Email, s.cfg) {
+		return nil, ErrRoleDomainNotAllowed
+	}
+	if user.Role == input.Role {
+		response := user.ToResponse()
+		return &response, nil
+	}
+
+	if err := s.setRole(user, input.Role, adminID); err != nil {
+		return nil, err
+	}
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// BootstrapAdmins promotes the existing accounts listed in ADMIN_EMAILS to admin. It runs
+// at startup so a fresh deployment has an admin who can assign further roles.
+func (s *RoleService) BootstrapAdmins() error {
+	for _, email := range s.cfg.AdminEmails {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if !staffEmail(email, s.cfg) {
+			log.Printf("⚠️  Ignoring admin %s, not in ADMIN_EMAIL_DOMAINS", email)
+			continue
+		}
+
+		user, err := s.userRepo.FindByEmail(email)
+		if err != nil {
+			continue // Promoted on the first start after they register
+		}
+		if user.Role == models.RoleAdmin {
+			continue
+		}
+		if err := s.setRole(user, models.RoleAdmin, uuid.Nil); err != nil {
+			return err
+		}
+		log.Printf("Promoted %s to admin from ADMIN_EMAILS", email)
+	}
+	return nil
+}
+
+// setRole stores the new role and publishes the change
+func (s *RoleService) setRole(user *models.User, role string, changedBy uuid.UUID) error {
+	if err := s.userRepo.UpdateRole(user.ID, role); err != nil {
+		return err
+	}
+
+	previous := user.Role
+	user.Role = role
+	s.bus.Publish(events.RoleChanged{UserID: user.ID, Role: role, PreviousRole: previous, ChangedBy: changedBy})
+	return nil
+}
+
+// effectiveRole returns the user's role, or developer for a staff role held by an email
+// outside ADMIN_EMAIL_DOMAINS
+func effectiveRole(user *models.User, cfg *config.Config) string {
+	if user.Role != models.RoleDeveloper && !staffEmail(user.Email, cfg) {
+		return models.RoleDeveloper
+	}
+	return user.Role
+}
+
+// staffEmail reports whether the email may hold an operator or admin role
+func staffEmail(email string, cfg *config.Config) bool {
+	if len(cfg.AdminEmailDomains) == 0 {
+		return true
+	}
+	_, domain, _ := strings.Cut(strings.ToLower(email), "@")
+	return matchesDomain(domain, cfg.AdminEmailDomains)
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/mailer"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SecretLinkTTL is how long a secret retrieval link can be opened
+const SecretLinkTTL = 24 * time.Hour
+
+// secretLinkKeySize is the length of the AES-256 key carried in the link
+const secretLinkKeySize = 32
+
+var ErrInvalidSecretLink = errors.New("invalid, expired or already used secret link")
+
+// SecretLinkService hands new API keys and client secrets over through single-use links,
+// so whoever creates a secret can pass it to a teammate without copying it into chat or
+// email
+type SecretLinkService struct {
+	repo     *repository.SecretLinkRepository
+	userRepo *repository.UserRepository
+	mailer   mailer.Mailer
+	bus      *events.Bus
+	cfg      *config.Config
+}
+
+// NewSecretLinkService creates a new SecretLinkService
+func NewSecretLinkService(repo *repository.SecretLinkRepository, userRepo *repository.UserRepository, mailer mailer.Mailer, bus *events.Bus, cfg *config.Config) *SecretLinkService {
+	return &SecretLinkService{
+		repo:     repo,
+		userRepo: userRepo,
+		mailer:   mailer,
+		bus:      bus,
+		cfg:      cfg,
+	}
+}
+
+// RetrieveSecretInput carries the token from a secret retrieval link
+type RetrieveSecretInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// SecretRetrieval is what a secret retrieval link reveals, once
+type SecretRetrieval struct {
+	ResourceType string    `json:"resourceType"` // api_key, partner_credential
+	ResourceID   uuid.UUID `json:"resourceId"`
+	Name         string    `json:"name"`               // Key name or partner name
+	ClientID     string    `json:"clientId,omitempty"` // Partner credentials only
+	Secret       string    `json:"secret"`             // Full API key or client secret
+}
+
+// Issue stores a secret behind a new retrieval link and returns the link. The token in
+// the link is the link's ID followed by the key that encrypts the secret; only its ID is
+// stored. The token is put in the URL fragment so it never reaches server logs.
+func (s *SecretLinkService) Issue(userID uuid.UUID, secret SecretRetrieval) (*models.SecretRetrievalLink, error) {
+	key := make([]byte, secretLinkKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	link := &models.SecretLink{
+		ID:           models.NewID(),
+		UserID:       userID,
+		ResourceType: secret.ResourceType,
+		ResourceID:   secret.ResourceID,
+		ExpiresAt:    time.Now().Add(SecretLinkTTL),
+	}
+
+	plaintext, err := json.Marshal(secret)
+	if err != nil {
+		return nil, err
+	}
+	if link.Ciphertext, err = sealSecret(key, link.ID, plaintext); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(link); err != nil {
+		return nil, err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(append(link.ID[:], key...))
+	return &models.SecretRetrievalLink{
+		URL:       s.cfg.FrontendURL + "/secrets/retrieve#" + token,
+		ExpiresAt: link.ExpiresAt,
+	}, nil
+}
+
+// Retrieve opens a retrieval link, revealing its secret exactly once. The link's creator
+// is told that it was opened.
+func (s *SecretLinkService) Retrieve(input RetrieveSecretInput, ip string) (*SecretRetrieval, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(input.Token))
+	if err != nil || len(raw) != len(uuid.UUID{})+secretLinkKeySize {
+		return nil, ErrInvalidSecretLink
+	}
+	id, err := uuid.FromBytes(raw[:len(uuid.UUID{})])
+	if err != nil {
+		return nil, ErrInvalidSecretLink
+	}
+	key := raw[len(uuid.UUID{}):]
+
+	link, err := s.repo.Claim(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidSecretLink
+		}
+		return nil, err
+	}
+
+	// A token with the right ID but the wrong key has still used up the link, so a
+	// guessed or tampered link cannot be retried
+	plaintext, err := openSecret(key, link.ID, link.Ciphertext)
+	if err != nil {
+		return nil, ErrInvalidSecretLink
+	}
+	var secret SecretRetrieval
+	if err := json.Unmarshal(plaintext, &secret); err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.SecretLinkRetrieved{
+		UserID:       link.UserID,
+		ResourceType: link.ResourceType,
+		ResourceID:   link.ResourceID,
+		IP:           ip,
+	})
+	s.notifyRetrieved(link, secret.Name, ip)
+
+	return &secret, nil
+}
+
+// DeleteExpired removes links that were never opened (run by the scheduler)
+func (s *SecretLinkService) DeleteExpired() error {
+	return s.repo.DeleteExpired()
+}
+
+// notifyRetrieved emails the link's creator, logging failures
+func (s *SecretLinkService) notifyRetrieved(link *models.SecretLink, name, ip string) {
+	user, err := s.userRepo.FindByID(link.UserID)
+	if err != nil {
+		return
+	}
+
+	what := "API key"
+	if link.ResourceType == models.SecretLinkPartnerCredential {
+		what = "partner credential client secret"
+	}
+	body := fmt.Sprintf(
+		"Hi %s,\n\nThe retrieval link you created for the %s \"%s\" was opened from %s at %s. "+
+			"The link cannot be used again.\n\nIf you did not expect this, revoke the secret in the "+
+			"BAS Developer Portal:\n\n%s",
+		user.FullName, what, name, ip, time.Now().UTC().Format(time.RFC1123), s.cfg.FrontendURL,
+	)
+	if err := s.mailer.Send(user.Email, "Your secret retrieval link was opened", body); err != nil {
+		log.Printf("Failed to send secret link notification to %s: %v", user.Email, err)
+	}
+}
+
+// sealSecret encrypts plaintext with AES-256-GCM, binding it to the ID of the record that
+// stores it. The nonce is prepended to the ciphertext.
+func sealSecret(key []byte, id uuid.UUID, plaintext []byte) ([]byte, error) {
+	gcm, err := newSecretCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, id[:]), nil
+}
+
+// openSecret decrypts a secret sealed by sealSecret
+func openSecret(key []byte, id uuid.UUID, ciphertext []byte) ([]byte, error) {
+	gcm, err := newSecretCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, id[:])
+}
+
+// newSecretCipher returns an AES-GCM cipher for a 32-byte key
+func newSecretCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// passwordMaxAge is how old a password may get before the checkup flags it
+	passwordMaxAge = 180 * 24 * time.Hour
+
+	// staleKeyAge is how long an API key may go unused before the checkup flags it
+	staleKeyAge = 90 * 24 * time.Hour
+)
+
+// Security check statuses
+const (
+	CheckStatusPassed      = "passed"
+	CheckStatusFailed      = "failed"
+	CheckStatusUnavailable = "unavailable" // Not scored, e.g. a feature the portal does not offer yet
+)
+
+// SecurityCheckupService scores how well an account follows security best practice
+type SecurityCheckupService struct {
+	userRepo        *repository.UserRepository
+	apiKeyRepo      *repository.APIKeyRepository
+	partnerCredRepo *repository.PartnerCredentialRepository
+	cfg             *config.Config
+}
+
+// NewSecurityCheckupService creates a new SecurityCheckupService
+func NewSecurityCheckupService(userRepo *repository.UserRepository, apiKeyRepo *repository.APIKeyRepository, partnerCredRepo *repository.PartnerCredentialRepository, cfg *config.Config) *SecurityCheckupService {
+	return &SecurityCheckupService{
+		userRepo:        userRepo,
+		apiKeyRepo:      apiKeyRepo,
+		partnerCredRepo: partnerCredRepo,
+		cfg:             cfg,
+	}
+}
+
+// SecurityCheck is one item of the checkup report
+type SecurityCheck struct {
+	ID              string   `json:"id"`
+	Title           string   `json:"title"`
+	Status          string   `json:"status"` // passed, failed, unavailable
+	Weight          int      `json:"weight"`
+	Details         string   `json:"details"`
+	AffectedIDs     []string `json:"affectedIds,omitempty"`
+	RemediationURL  string   `json:"remediationUrl,omitempty"`
+	RemediationText string   `json:"remediationText,omitempty"`
+}
+
+// SecurityCheckupResponse is the scored checkup report
+type SecurityCheckupResponse struct {
+	Score       int             `json:"score"` // 0-100, weighted over scored checks
+	Checks      []SecurityCheck `json:"checks"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+}
+
+// GetCheckup evaluates the user's account, API keys and partner credentials
+func (s *SecurityCheckupService) GetCheckup(userID uuid.UUID) (*SecurityCheckupResponse, error) {
+	var (
+		user        *models.User
+		apiKeys     []models.APIKey
+		credentials []models.PartnerCredential
+	)
+
+	var g errgroup.Group
+	g.Go(func() (err error) {
+		user, err = s.userRepo.FindByID(userID)
+		return err
+	})
+	g.Go(func() (err error) {
+		apiKeys, err = s.apiKeyRepo.FindByUserID(userID)
+		return err
+	})
+	g.Go(func() (err error) {
+		credentials, err = s.partnerCredRepo.FindByUserID(userID)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	checks := []SecurityCheck{
+		s.checkTwoFactor(user),
+		s.checkEmailVerified(user),
+		s.checkPasswordAge(user),
+		s.checkStaleKeys(apiKeys),
+		s.checkIPWhitelists(credentials),
+		s.checkCallbackURLs(credentials),
+	}
+
+	return &SecurityCheckupResponse{
+		Score:       scoreChecks(checks),
+		Checks:      checks,
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+// checkTwoFactor flags accounts that sign in without a second factor
+func (s *SecurityCheckupService) checkTwoFactor(user *models.User) SecurityCheck {
+	check := SecurityCheck{
+		ID:      "two_factor",
+		Title:   "Two-factor authentication",
+		Status:  CheckStatusPassed,
+		Weight:  20,
+		Details: "A code is texted to your phone at every sign-in",
+	}
+	if user.MFAMethod == "" {
+		check.Status = CheckStatusFailed
+		check.Details = "Signing in only needs your password or a Google account"
+		check.RemediationURL = s.cfg.FrontendURL + "/settings/security"
+		check.RemediationText = "Verify your phone number and turn on SMS two-factor authentication"
+	}
+	return check
+}
+
+// checkEmailVerified flags accounts whose email address is unconfirmed
+func (s *SecurityCheckupService) checkEmailVerified(user *models.User) SecurityCheck {
+	check := SecurityCheck{
+		ID:      "email_verified",
+		Title:   "Verified email address",
+		Status:  CheckStatusPassed,
+		Weight:  15,
+		Details: "Your email address is verified",
+	}
+	if !user.IsVerified {
+		check.Status = CheckStatusFailed
+		check.Details = "Your email address has not been verified"
+		check.RemediationURL = s.cfg.FrontendURL + "/settings/profile"
+		check.RemediationText = "Resend the verification email and follow its link"
+	}
+	return check
+}
+
+// checkPasswordAge flags passwords that have not been changed for a long time
+func (s *SecurityCheckupService) checkPasswordAge(user *models.User) SecurityCheck {
+	check := SecurityCheck{
+		ID:     "password_age",
+		Title:  "Recent password",
+		Status: CheckStatusPassed,
+		Weight: 15,
+	}
+
+	if user.PasswordHash == "" {
+		check.Status = CheckStatusUnavailable
+		check.Weight = 0
+		check.Details = "You sign in with Google, so there is no portal password"
+		return check
+	}
+
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+
+	age := time.Since(changedAt)
+	days := int(age.Hours() / 24)
+	check.Details = fmt.Sprintf("Your password was last changed %d day(s) ago", days)
+	if age > passwordMaxAge {
+		check.Status = CheckStatusFailed
+		check.RemediationURL = s.cfg.FrontendURL + "/settings/security"
+		check.RemediationText = "Change your password"
+	}
+	return check
+}
+
+// checkStaleKeys flags API keys that have not been used recently
+func (s *SecurityCheckupService) checkStaleKeys(apiKeys []models.APIKey) SecurityCheck {
+	check := SecurityCheck{
+		ID:      "stale_api_keys",
+		Title:   "No unused API keys",
+		Status:  CheckStatusPassed,
+		Weight:  20,
+		Details: "All of your API keys have been used in the last 90 days",
+	}
+
+	cutoff := time.Now().Add(-staleKeyAge)
+	for _, key := range apiKeys {
+		lastActivity := key.CreatedAt
+		if key.LastUsedAt != nil {
+			lastActivity = *key.LastUsedAt
+		}
+		if lastActivity.Before(cutoff) {
+			check.AffectedIDs = append(check.AffectedIDs, key.ID.String())
+		}
+	}
+
+	if len(check.AffectedIDs) > 0 {
+		check.Status = CheckStatusFailed
+		check.Details = fmt.Sprintf("%d API key(s) have not been used in the last 90 days", len(check.AffectedIDs))
+		check.RemediationURL = s.cfg.FrontendURL + "/api-keys"
+		check.RemediationText = "Revoke API keys you no longer need"
+	}
+	return check
+}
+
+// checkIPWhitelists flags partner credentials that accept requests from any address
+func (s *SecurityCheckupService) checkIPWhitelists(credentials []models.PartnerCredential) SecurityCheck {
+	check := SecurityCheck{
+		ID:      "credential_ip_whitelist",
+		Title:   "IP whitelist on partner credentials",
+		Status:  CheckStatusPassed,
+		Weight:  25,
+		Details: "Every partner credential restricts the source IPs it accepts",
+	}
+
+	for _, credential := range credentials {
+		if len(credential.IPWhitelist) == 0 {
+			check.AffectedIDs = append(check.AffectedIDs, credential.ID.String())
+		}
+	}
+
+	if len(check.AffectedIDs) > 0 {
+		check.Status = CheckStatusFailed
+		check.Details = fmt.Sprintf("%d partner credential(s) accept requests from any IP address", len(check.AffectedIDs))
+		check.RemediationURL = s.cfg.FrontendURL + "/partner-credentials"
+		check.RemediationText = "Add the IP addresses of your servers to each credential's whitelist"
+	}
+	return check
+}
+
+// checkCallbackURLs flags callback URLs that have not passed the TLS check
+func (s *SecurityCheckupService) checkCallbackURLs(credentials []models.PartnerCredential) SecurityCheck {
+	check := SecurityCheck{
+		ID:      "callback_url_tls",
+		Title:   "Verified callback URLs",
+		Status:  CheckStatusPassed,
+		Weight:  25,
+		Details: "Every callback URL passed the TLS check",
+	}
+
+	for _, credential := range credentials {
+		if credential.CallbackURL == "" {
+			continue
+		}
+		if credential.CallbackCheckedAt == nil || len(credential.CallbackWarnings()) > 0 {
+			check.AffectedIDs = append(check.AffectedIDs, credential.ID.String())
+		}
+	}
+
+	if len(check.AffectedIDs) > 0 {
+		check.Status = CheckStatusFailed
+		check.Details = fmt.Sprintf("%d callback URL(s) are unverified or have TLS warnings", len(check.AffectedIDs))
+		check.RemediationURL = s.cfg.FrontendURL + "/partner-credentials"
+		check.RemediationText = "Serve callbacks over TLS 1.2+ with a valid certificate and save the credential again to re-check"
+	}
+	return check
+}
+
+// scoreChecks returns the weighted percentage of passed checks, ignoring unavailable ones
+func scoreChecks(checks []SecurityCheck) int {
+	var total, passed int
+	for _, check := range checks {
+		if check.Status == CheckStatusUnavailable {
+			continue
+		}
+		total += check.Weight
+		if check.Status == CheckStatusPassed {
+			passed += check.Weight
+		}
+	}
+	if total == 0 {
+		return 100
+	}
+	return passed * 100 / total
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/mailer"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// SecurityNotificationService emails account owners about sensitive changes, each with
+// a "this wasn't me" link that locks the account. It runs as event bus subscribers.
+type SecurityNotificationService struct {
+	userRepo    *repository.UserRepository
+	deviceRepo  *repository.KnownDeviceRepository
+	authService *AuthService
+	preferences *NotificationPreferenceService
+	mailer      mailer.Mailer
+}
+
+// NewSecurityNotificationService creates a new SecurityNotificationService
+func NewSecurityNotificationService(userRepo *repository.UserRepository, deviceRepo *repository.KnownDeviceRepository, authService *AuthService, preferences *NotificationPreferenceService, mailer mailer.Mailer) *SecurityNotificationService {
+	return &SecurityNotificationService{
+		userRepo:    userRepo,
+		deviceRepo:  deviceRepo,
+		authService: authService,
+		preferences: preferences,
+		mailer:      mailer,
+	}
+}
+
+// Subscribe registers the notification handlers on the bus
+func (s *SecurityNotificationService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.LoginSucceeded{}.EventName(), s.onLogin)
+	bus.Subscribe(events.APIKeyCreated{}.EventName(), s.onAPIKeyCreated)
+	bus.Subscribe(events.APIKeyForceRevoked{}.EventName(), s.onAPIKeyForceRevoked)
+	bus.Subscribe(events.APIKeyReactivated{}.EventName(), s.onAPIKeyReactivated)
+	bus.Subscribe(events.ClientSecretRegenerated{}.EventName(), s.onClientSecretRegenerated)
+	bus.Subscribe(events.PublicKeyChanged{}.EventName(), s.onPublicKeyChanged)
+	bus.Subscribe(events.IdentityLinked{}.EventName(), s.onIdentityLinked)
+	bus.Subscribe(events.IdentityUnlinked{}.EventName(), s.onIdentityUnlinked)
+}
+
+// onLogin remembers the device and warns the owner when it is one they haven't used
+// before. The first device on an account is recorded silently.
+func (s *SecurityNotificationService) onLogin(envelope events.Envelope) error {
+	event := envelope.Event.(events.LoginSucceeded)
+
+	known, err := s.deviceRepo.CountByUserID(event.UserID)
+	if err != nil {
+		return err
+	}
+
+	fingerprint := sha256.Sum256([]byte(event.UserAgent))
+	isNew, err := s.deviceRepo.Record(&models.KnownDevice{
+		UserID:      event.UserID,
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		UserAgent:   truncate(event.UserAgent, 512),
+		LastIP:      event.IP,
+		LastSeenAt:  envelope.OccurredAt,
+	})
+	if err != nil || !isNew || known == 0 {
+		return err
+	}
+
+	return s.notify(event.UserID, "New sign-in to your BAS Developer Portal account", fmt.Sprintf(
+		"We noticed a sign-in from a new device.\n\nTime: %s\nIP address: %s\nDevice: %s\nMethod: %s",
+		envelope.OccurredAt.Format(time.RFC1123), event.IP, event.UserAgent, event.Method,
+	))
+}
+
+func (s *SecurityNotificationService) onAPIKeyCreated(envelope events.Envelope) error {
+	event := envelope.Event.(events.APIKeyCreated)
+	return s.notify(event.UserID, "A new API key was created", fmt.Sprintf(
+		"A new %s API key was created on your account at %s.",
+		event.Environment, envelope.OccurredAt.Format(time.RFC1123),
+	))
+}
+
+func (s *SecurityNotificationService) onAPIKeyForceRevoked(envelope events.Envelope) error {
+	event := envelope.Event.(events.APIKeyForceRevoked)
+	return s.notify(event.UserID, "An API key was revoked by BAS staff", fmt.Sprintf(
+		"BAS staff revoked an API key on your account at %s and it no longer works.\n\nReason: %s",
+		envelope.OccurredAt.Format(time.RFC1123), event.Reason,
+	))
+}
+
+func (s *SecurityNotificationService) onAPIKeyReactivated(envelope events.Envelope) error {
+	event := envelope.Event.(events.APIKeyReactivated)
+	return s.notify(event.UserID, "A revoked API key was reactivated", fmt.Sprintf(
+		"BAS staff reactivated a revoked API key on your account at %s and it works again.\n\nReason: %s",
+		envelope.OccurredAt.Format(time.RFC1123), event.Reason,
+	))
+}
+
+func (s *SecurityNotificationService) onClientSecretRegenerated(envelope events.Envelope) error {
+	event := envelope.Event.(events.ClientSecretRegenerated)
+	return s.notify(event.UserID, "A partner client secret was regenerated", fmt.Sprintf(
+		"The client secret for partner credential %s was regenerated at %s. The previous secret no longer works.",
+		event.ClientID, envelope.OccurredAt.Format(time.RFC1123),
+	))
+}
+
+func (s *SecurityNotificationService) onPublicKeyChanged(envelope events.Envelope) error {
+	event := envelope.Event.(events.PublicKeyChanged)
+	return s.notify(event.UserID, "A partner public key was changed", fmt.Sprintf(
+		"The public key for partner credential %s was replaced at %s.\nNew key fingerprint: %s",
+		event.ClientID, envelope.OccurredAt.Format(time.RFC1123), event.Fingerprint,
+	))
+}
+
+func (s *SecurityNotificationService) onIdentityLinked(envelope events.Envelope) error {
+	event := envelope.Event.(events.IdentityLinked)
+	if event.Provider == models.IdentityProviderLocal {
+		return s.notify(event.UserID, "A password was added to your account", fmt.Sprintf(
+			"Password sign-in was enabled on your account at %s.",
+			envelope.OccurredAt.Format(time.RFC1123),
+		))
+	}
+	return s.notify(event.UserID, "A new sign-in method was linked", fmt.Sprintf(
+		"The %s account %s was linked to your account at %s and can now be used to sign in.",
+		event.Provider, event.Email, envelope.OccurredAt.Format(time.RFC1123),
+	))
+}
+
+func (s *SecurityNotificationService) onIdentityUnlinked(envelope events.Envelope) error {
+	event := envelope.Event.(events.IdentityUnlinked)
+	return s.notify(event.UserID, "A sign-in method was removed", fmt.Sprintf(
+		"The %s sign-in method was removed from your account at %s.",
+		event.Provider, envelope.OccurredAt.Format(time.RFC1123),
+	))
+}
+
+// notify emails the account owner about a change with a link to lock the account, unless
+// they turned security alerts off
+func (s *SecurityNotificationService) notify(userID uuid.UUID, subject, details string) error {
+	if !s.preferences.Allows(userID, models.NotificationSecurityAlerts) {
+		return nil
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	lockLink, err := s.authService.AccountLockLink(user)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\n%s\n\nIf this was you, no action is needed.\n\n"+
+			"If this wasn't you, lock your account now. This signs out every session "+
+			"until you reset your password:\n\n%s",
+		user.FullName, details, lockLink,
+	)
+	return s.mailer.Send(user.Email, subject, body)
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SecurityWebhookEvents are the auth events delivered to the security webhook
+var SecurityWebhookEvents = []string{
+	events.LoginSucceeded{}.EventName(),
+	events.LoginFailed{}.EventName(),
+	events.PasswordChanged{}.EventName(),
+	events.RoleChanged{}.EventName(),
+	events.UserSuspended{}.EventName(),
+	events.UserReactivated{}.EventName(),
+	events.UserSessionsRevoked{}.EventName(),
+	events.PasswordResetRequired{}.EventName(),
+}
+
+// securityWebhookAttempts is how many times a webhook delivery is tried before it is dropped
+const securityWebhookAttempts = 4
+
+var (
+	ErrSecurityWebhookNotFound = errors.New("security webhook is not configured")
+	ErrInvalidWebhookURL       = errors.New("webhook URL must be an absolute https URL")
+)
+
+// SecurityWebhookService delivers signed auth events to the SOC's endpoint
+type SecurityWebhookService struct {
+	repo   *repository.SecurityWebhookRepository
+	client *http.Client
+	cfg    *config.Config
+}
+
+// NewSecurityWebhookService creates a new SecurityWebhookService
+func NewSecurityWebhookService(repo *repository.SecurityWebhookRepository, cfg *config.Config) *SecurityWebhookService {
+	return &SecurityWebhookService{
+		repo:   repo,
+		client: &http.Client{Timeout: 10 * time.Second},
+		cfg:    cfg,
+	}
+}
+
+// ConfigureSecurityWebhookInput sets the webhook endpoint
+type ConfigureSecurityWebhookInput struct {
+	URL          string `json:"url" validate:"required,url"`
+	RotateSecret bool   `json:"rotateSecret"`
+}
+
+// SecurityWebhookResponse describes the webhook; Secret is only set when newly generated
+type SecurityWebhookResponse struct {
+	models.SecurityWebhook
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+// GetWebhook returns the configured webhook
+func (s *SecurityWebhookService) GetWebhook() (*SecurityWebhookResponse, error) {
+	webhook, err := s.repo.Get()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSecurityWebhookNotFound
+		}
+		return nil, err
+	}
+	return &SecurityWebhookResponse{SecurityWebhook: *webhook, Events: SecurityWebhookEvents}, nil
+}
+
+// ConfigureWebhook sets the endpoint. A signing secret is generated the first time, or
+// when rotation is requested, and returned only in that response.
+func (s *SecurityWebhookService) ConfigureWebhook(adminID uuid.UUID, input ConfigureSecurityWebhookInput) (*SecurityWebhookResponse, error) {
+	if err := validateWebhookURL(input.URL, s.cfg); err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.repo.Get()
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		webhook = &models.SecurityWebhook{}
+	}
+
+	var secret string
+	if webhook.Secret == "" || input.RotateSecret {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+		webhook.Secret = secret
+	}
+
+	webhook.URL = input.URL
+	webhook.UpdatedBy = adminID
+	if err := s.repo.Save(webhook); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Security webhook set to %s by admin %s (secret rotated: %t)", webhook.URL, adminID, secret != "")
+	return &SecurityWebhookResponse{SecurityWebhook: *webhook, Events: SecurityWebhookEvents, Secret: secret}, nil
+}
+
+// DeleteWebhook stops security event delivery
+func (s *SecurityWebhookService) DeleteWebhook(adminID uuid.UUID) error {
+	deleted, err := s.repo.DeleteAll()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrSecurityWebhookNotFound
+	}
+
+	log.Printf("Security webhook removed by admin %s", adminID)
+	return nil
+}
+
+// SendTest delivers a test event synchronously so admins can check the endpoint
+func (s *SecurityWebhookService) SendTest() error {
+	webhook, err := s.repo.Get()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSecurityWebhookNotFound
+		}
+		return err
+	}
+
+	return postSignedEvent(s.client, webhook.URL, webhook.Secret, testWebhookEnvelope())
+}
+
+// Deliver is an event bus handler that forwards the event to the webhook, retrying
+// in the background so the bus isn't held up by a slow endpoint
+func (s *SecurityWebhookService) Deliver(envelope events.Envelope) error {
+	webhook, err := s.repo.Get()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	go deliverWithRetry("Security webhook", envelope, func() error {
+		return postSignedEvent(s.client, webhook.URL, webhook.Secret, envelope)
+	})
+	return nil
+}
+
+// validateWebhookURL accepts absolute https URLs, and http outside production
+func validateWebhookURL(rawURL string, cfg *config.Config) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && (cfg.Env == "production" || parsed.Scheme != "http")) {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// newWebhookSecret generates a signing secret for a webhook
+func newWebhookSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(bytes), nil
+}
+
+// testWebhookEnvelope is the event sent when a webhook is tested
+func testWebhookEnvelope() events.Envelope {
+	return events.Envelope{
+		ID:         uuid.New(),
+		Name:       "webhook.test",
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// deliverWithRetry calls send until it succeeds, backing off between attempts, and
+// drops the delivery after securityWebhookAttempts tries
+func deliverWithRetry(label string, envelope events.Envelope, send func() error) {
+	backoff := 2 * time.Second
+	for attempt := 1; attempt <= securityWebhookAttempts; attempt++ {
+		err := send()
+		if err == nil {
+			return
+		}
+		log.Printf("%s delivery %s attempt %d failed: %v", label, envelope.ID, attempt, err)
+		time.Sleep(backoff)
+		backoff *= 4
+	}
+	log.Printf("%s delivery %s dropped after %d attempts", label, envelope.ID, securityWebhookAttempts)
+}
+
+// postSignedEvent sends one signed delivery. The signature is HMAC-SHA256 over
+// "<timestamp>.<body>" with the webhook secret, so receivers can reject replays outside a
+// time window.
+func postSignedEvent(client *http.Client, endpoint, secret string, envelope events.Envelope) error {
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bas-portal-api")
+	req.Header.Set("X-BAS-Event", envelope.Name)
+	req.Header.Set("X-BAS-Delivery", envelope.ID.String())
+	req.Header.Set("X-BAS-Signature", "t="+timestamp+",v1="+signature)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
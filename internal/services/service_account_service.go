@@ -0,0 +1,253 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultServiceAccountTokenDays is how long a service account token lasts unless
+	// another lifetime is asked for
+	DefaultServiceAccountTokenDays = 90
+
+	// MaxServiceAccountTokenDays is the longest lifetime of a service account token
+	MaxServiceAccountTokenDays = 365
+)
+
+var (
+	ErrServiceAccountNotFound      = errors.New("service account not found")
+	ErrInvalidServiceAccount       = errors.New("service account name must be 1-100 characters and its role editor or viewer")
+	ErrInvalidServiceAccountToken  = errors.New("token name must be 1-100 characters, with at least one valid scope and a lifetime of 1-365 days")
+	ErrServiceAccountTokenNotFound = errors.New("service account token not found")
+	ErrInvalidServiceToken         = errors.New("invalid or expired service account token")
+)
+
+// ServiceAccountService manages organizations' service accounts and their tokens
+type ServiceAccountService struct {
+	repo *repository.ServiceAccountRepository
+	orgs *OrganizationService
+	bus  *events.Bus
+}
+
+// NewServiceAccountService creates a new ServiceAccountService
+func NewServiceAccountService(repo *repository.ServiceAccountRepository, orgs *OrganizationService, bus *events.Bus) *ServiceAccountService {
+	return &ServiceAccountService{repo: repo, orgs: orgs, bus: bus}
+}
+
+// CreateServiceAccountInput represents a new service account
+type CreateServiceAccountInput struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+	Role string `json:"role" validate:"required,oneof=editor viewer"`
+}
+
+// CreateServiceAccountTokenInput represents a new service account token
+type CreateServiceAccountTokenInput struct {
+	Name          string   `json:"name" validate:"required,min=1,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1"`
+	ExpiresInDays int      `json:"expiresInDays" validate:"omitempty,min=1,max=365"` // Defaults to 90
+}
+
+// Subscribe removes the service accounts of deleted organizations
+func (s *ServiceAccountService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.OrganizationDeleted{}.EventName(), s.onOrganizationDeleted)
+}
+
+// onOrganizationDeleted deletes the organization's service accounts and revokes their tokens
+func (s *ServiceAccountService) onOrganizationDeleted(envelope events.Envelope) error {
+	event := envelope.Event.(events.OrganizationDeleted)
+
+	accounts, err := s.repo.FindByOrganizationID(event.OrganizationID)
+	if err != nil {
+		return err
+	}
+	for _, account := range accounts {
+		if err := s.repo.Delete(account.UserID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListServiceAccounts lists an organization's service accounts (any member)
+func (s *ServiceAccountService) ListServiceAccounts(userID, orgID uuid.UUID) ([]models.ServiceAccountResponse, error) {
+	if _, err := s.orgs.Authorize(userID, orgID, models.OrgRoleViewer); err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.repo.FindByOrganizationID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.orgs.repo.FindMembers(orgID)
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[uuid.UUID]string, len(members))
+	for _, member := range members {
+		roles[member.UserID] = member.Role
+	}
+
+	response := make([]models.ServiceAccountResponse, 0, len(accounts))
+	for i := range accounts {
+		role, ok := roles[accounts[i].UserID]
+		if !ok {
+			continue // Removed from the organization, so it can no longer act in it
+		}
+		response = append(response, accounts[i].ToResponse(role))
+	}
+	return response, nil
+}
+
+// CreateServiceAccount adds a service account to an organization (owners only)
+func (s *ServiceAccountService) CreateServiceAccount(userID, orgID uuid.UUID, input CreateServiceAccountInput) (*models.ServiceAccountResponse, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > 100 || (input.Role != models.OrgRoleEditor && input.Role != models.OrgRoleViewer) {
+		return nil, ErrInvalidServiceAccount
+	}
+	if _, err := s.orgs.Authorize(userID, orgID, models.OrgRoleOwner); err != nil {
+		return nil, err
+	}
+
+	id := models.NewID()
+	user := &models.User{
+		ID:          id,
+		Email:       id.String() + "@service-accounts.invalid", // Never receives mail
+		FullName:    name,
+		IsVerified:  true,
+		Role:        models.RoleDeveloper,
+		AccountType: models.AccountTypeService,
+	}
+	account := &models.ServiceAccount{OrganizationID: orgID, CreatedBy: userID}
+	member := &models.OrganizationMember{OrganizationID: orgID, Role: input.Role}
+	if err := s.repo.Create(user, account, member); err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.OrganizationMemberChanged{OrganizationID: orgID, UserID: id, Role: input.Role, ChangedBy: userID})
+
+	account.User = *user
+	response := account.ToResponse(input.Role)
+	return &response, nil
+}
+
+// DeleteServiceAccount removes a service account and revokes its tokens (owners only).
+// Keys and credentials it created stay with the organization.
+func (s *ServiceAccountService) DeleteServiceAccount(userID, orgID, accountID uuid.UUID) error {
+	if _, err := s.authorizeAccount(userID, orgID, accountID); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(accountID); err != nil {
+		return err
+	}
+
+	s.bus.Publish(events.OrganizationMemberChanged{OrganizationID: orgID, UserID: accountID, Role: "", ChangedBy: userID})
+	return nil
+}
+
+// ListTokens lists a service account's tokens (owners only)
+func (s *ServiceAccountService) ListTokens(userID, orgID, accountID uuid.UUID) ([]models.ServiceAccountToken, error) {
+	if _, err := s.authorizeAccount(userID, orgID, accountID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindTokens(accountID)
+}
+
+// CreateToken issues a scoped token for a service account (owners only). The token is
+// only returned here.
+func (s *ServiceAccountService) CreateToken(userID, orgID, accountID uuid.UUID, input CreateServiceAccountTokenInput) (*models.ServiceAccountTokenCreateResponse, error) {
+	name := strings.TrimSpace(input.Name)
+	if input.ExpiresInDays == 0 {
+		input.ExpiresInDays = DefaultServiceAccountTokenDays
+	}
+	if name == "" || len(name) > 100 || len(input.Scopes) == 0 ||
+		input.ExpiresInDays < 1 || input.ExpiresInDays > MaxServiceAccountTokenDays {
+		return nil, ErrInvalidServiceAccountToken
+	}
+	scopes := make(models.StringArray, 0, len(input.Scopes))
+	for _, scope := range input.Scopes {
+		if !models.IsValidServiceAccountScope(scope) {
+			return nil, ErrInvalidServiceAccountToken
+		}
+		scopes = append(scopes, scope)
+	}
+
+	if _, err := s.authorizeAccount(userID, orgID, accountID); err != nil {
+		return nil, err
+	}
+
+	plain, prefix, hash, err := models.GenerateServiceAccountToken()
+	if err != nil {
+		return nil, err
+	}
+	token := &models.ServiceAccountToken{
+		ServiceAccountID: accountID,
+		Name:             name,
+		TokenPrefix:      prefix,
+		TokenHash:        hash,
+		Scopes:           scopes,
+		ExpiresAt:        time.Now().AddDate(0, 0, input.ExpiresInDays),
+		CreatedBy:        userID,
+	}
+	if err := s.repo.CreateToken(token); err != nil {
+		return nil, err
+	}
+
+	return &models.ServiceAccountTokenCreateResponse{ServiceAccountToken: *token, Token: plain}, nil
+}
+
+// RevokeToken revokes a service account token (owners only)
+func (s *ServiceAccountService) RevokeToken(userID, orgID, accountID, tokenID uuid.UUID) error {
+	if _, err := s.authorizeAccount(userID, orgID, accountID); err != nil {
+		return err
+	}
+
+	revoked, err := s.repo.RevokeToken(accountID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrServiceAccountTokenNotFound
+	}
+	return nil
+}
+
+// Authenticate returns the live token matching a bearer token and records its use
+func (s *ServiceAccountService) Authenticate(plain string) (*models.ServiceAccountToken, error) {
+	token, err := s.repo.FindActiveTokenByHash(models.HashServiceAccountToken(plain))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidServiceToken
+		}
+		return nil, err
+	}
+
+	if err := s.repo.TouchToken(token.ID); err != nil {
+		log.Printf("Failed to record use of service account token %s: %v", token.ID, err)
+	}
+	return token, nil
+}
+
+// authorizeAccount checks the user owns the organization and returns its service account
+func (s *ServiceAccountService) authorizeAccount(userID, orgID, accountID uuid.UUID) (*models.ServiceAccount, error) {
+	if _, err := s.orgs.Authorize(userID, orgID, models.OrgRoleOwner); err != nil {
+		return nil, err
+	}
+
+	account, err := s.repo.FindByID(orgID, accountID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrServiceAccountNotFound
+		}
+		return nil, err
+	}
+	return account, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSessionExpired = errors.New("session expired")
+)
+
+// SessionService tracks login sessions and enforces the idle timeout
+type SessionService struct {
+	sessionRepo *repository.SessionRepository
+	cfg         *config.Config
+}
+
+// NewSessionService creates a new SessionService
+func NewSessionService(sessionRepo *repository.SessionRepository, cfg *config.Config) *SessionService {
+	return &SessionService{
+		sessionRepo: sessionRepo,
+		cfg:         cfg,
+	}
+}
+
+// Start opens a new session for a user. Beyond the configured maximum, the oldest
+// sessions are revoked, which also invalidates their refresh tokens.
+func (s *SessionService) Start(userID uuid.UUID) (*models.Session, error) {
+	session := &models.Session{
+		UserID:         userID,
+		LastActivityAt: time.Now(),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	if s.cfg.MaxSessionsPerUser > 0 {
+		idleCutoff := time.Time{}
+		if s.cfg.SessionIdleTimeoutMinutes > 0 {
+			idleCutoff = time.Now().Add(-time.Duration(s.cfg.SessionIdleTimeoutMinutes) * time.Minute)
+		}
+
+		evicted, err := s.sessionRepo.RevokeAllButNewest(userID, s.cfg.MaxSessionsPerUser, idleCutoff)
+		if err != nil {
+			log.Printf("Failed to enforce session limit for user %s: %v", userID, err)
+		} else if evicted > 0 {
+			log.Printf("Session limit reached for user %s, revoked %d oldest session(s)", userID, evicted)
+		}
+	}
+
+	return session, nil
+}
+
+// Validate returns the session if it is still active. Sessions idle beyond
+// the configured timeout are revoked and rejected.
+func (s *SessionService) Validate(sessionID uuid.UUID) (*models.Session, error) {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		return nil, ErrSessionExpired
+	}
+
+	if session.RevokedAt != nil {
+		return nil, ErrSessionExpired
+	}
+
+	idleTimeout := time.Duration(s.cfg.SessionIdleTimeoutMinutes) * time.Minute
+	if session.IsIdle(idleTimeout) {
+		_ = s.sessionRepo.Revoke(session.ID)
+		return nil, ErrSessionExpired
+	}
+
+	return session, nil
+}
+
+// Touch records activity on a session
+func (s *SessionService) Touch(sessionID uuid.UUID) error {
+	return s.sessionRepo.Touch(sessionID)
+}
+
+// Revoke ends a single session, invalidating its refresh token
+func (s *SessionService) Revoke(sessionID uuid.UUID) error {
+	return s.sessionRepo.Revoke(sessionID)
+}
+
+// RevokeAll ends every session for a user, invalidating their refresh tokens
+func (s *SessionService) RevokeAll(userID uuid.UUID) error {
+	return s.sessionRepo.RevokeAllForUser(userID)
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bankaceh/bas-portal-api/internal/events"
+	"github.com/bankaceh/bas-portal-api/internal/mailer"
+	"github.com/bankaceh/bas-portal-api/internal/models"
+	"github.com/bankaceh/bas-portal-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAccountSuspended       = errors.New("account is suspended")
+	ErrAlreadySuspended       = errors.New("account is already suspended")
+	ErrNotSuspended           = errors.New("account is not suspended")
+	ErrCannotSuspendSelf      = errors.New("you cannot suspend your own account")
+	ErrSuspensionReasonLength = errors.New("reason must be 3-500 characters")
+)
+
+// SuspensionService lets admins suspend and reactivate accounts
+type SuspensionService struct {
+	userRepo       *repository.UserRepository
+	sessionService *SessionService
+	mailer         mailer.Mailer
+	bus            *events.Bus
+}
+
+// NewSuspensionService creates a new SuspensionService
+func NewSuspensionService(userRepo *repository.UserRepository, sessionService *SessionService, mailer mailer.Mailer, bus *events.Bus) *SuspensionService {
+	return &SuspensionService{
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		mailer:         mailer,
+		bus:            bus,
+	}
+}
+
+// SuspensionInput carries the reason recorded with a suspension or reactivation
+type SuspensionInput struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"`
+}
+
+// Suspend blocks sign-in to an account and signs out all of its sessions
+func (s *SuspensionService) Suspend(adminID, userID uuid.UUID, input SuspensionInput) (*models.UserResponse, error) {
+	reason, err := suspensionReason(input)
+	if err != nil {
+		return nil, err
+	}
+	if adminID == userID {
+		return nil, ErrCannotSuspendSelf
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	suspended, err := s.userRepo.Suspend(&models.UserSuspension{
+		UserID:  userID,
+		Action:  models.SuspensionActionSuspended,
+		Reason:  reason,
+		ActorID: adminID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !suspended {
+		return nil, ErrAlreadySuspended
+	}
+
+	if err := s.sessionService.RevokeAll(userID); err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.UserSuspended{UserID: userID, Reason: reason, SuspendedBy: adminID})
+
+	s.notify(user, "Your BAS Developer Portal account is suspended", fmt.Sprintf(
+		"Hi %s,\n\nYour BAS Developer Portal account has been suspended and all sessions were signed out.\n\n"+
+			"Reason: %s\n\nPlease contact support if you have questions.",
+		user.FullName, reason,
+	))
+
+	return s.reload(userID)
+}
+
+// Reactivate lifts a suspension so the user can sign in again
+func (s *SuspensionService) Reactivate(adminID, userID uuid.UUID, input SuspensionInput) (*models.UserResponse, error) {
+	reason, err := suspensionReason(input)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	reactivated, err := s.userRepo.Reactivate(&models.UserSuspension{
+		UserID:  userID,
+		Action:  models.SuspensionActionReactivated,
+		Reason:  reason,
+		ActorID: adminID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !reactivated {
+		return nil, ErrNotSuspended
+	}
+
+	s.bus.Publish(events.UserReactivated{UserID: userID, Reason: reason, ReactivatedBy: adminID})
+
+	s.notify(user, "Your BAS Developer Portal account is reactivated", fmt.Sprintf(
+		"Hi %s,\n\nYour BAS Developer Portal account has been reactivated. You can sign in again.",
+		user.FullName,
+	))
+
+	return s.reload(userID)
+}
+
+// History lists an account's suspensions and reactivations, newest first
+func (s *SuspensionService) History(userID uuid.UUID) ([]models.UserSuspension, error) {
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.userRepo.FindSuspensions(userID)
+}
+
+// IsSuspended reports whether the user's account is suspended
+func (s *SuspensionService) IsSuspended(userID uuid.UUID) (bool, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return false, err
+	}
+	return user.IsSuspended, nil
+}
+
+// reload returns the user as it is after a change
+func (s *SuspensionService) reload(userID uuid.UUID) (*models.UserResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// notify emails the account holder, logging failures
+func (s *SuspensionService) notify(user *models.User, subject, body string) {
+	if err := s.mailer.Send(user.Email, subject, body); err != nil {
+		log.Printf("Failed to send suspension email to %s: %v", user.Email, err)
+	}
+}
+
+// suspensionReason validates and trims the recorded reason
+func suspensionReason(input SuspensionInput) (string, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if len(reason) < 3 || len(reason) > 500 {
+		return "", ErrSuspensionReasonLength
+	}
+	return reason, nil
+}
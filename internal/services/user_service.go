@@ -1,11 +1,30 @@
 package services
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/url"
+
 	"github.com/bankaceh/bas-portal-api/internal/models"
 	"github.com/bankaceh/bas-portal-api/internal/repository"
 	"github.com/google/uuid"
 )
 
+// Metadata limits
+const (
+	MaxMetadataKeys      = 50
+	MaxMetadataKeyLength = 64
+	MaxMetadataBytes     = 16 * 1024 // Encoded size of the whole object
+)
+
+var (
+	ErrProfileFieldTooLong   = errors.New("first and last name must be at most 100 characters")
+	ErrInvalidProfilePicture = errors.New("profile picture must be an http(s) URL of at most 1024 characters")
+	ErrInvalidMetadataKey    = errors.New("metadata keys must be 1-64 characters")
+	ErrMetadataTooLarge      = errors.New("metadata has too many keys or is too large")
+)
+
 // UserService handles user-related business logic
 type UserService struct {
 	userRepo *repository.UserRepository
@@ -37,8 +56,27 @@ func (s *UserService) GetProfile(userID uuid.UUID) (*models.UserResponse, error)
 	return &response, nil
 }
 
+// IsVerified reports whether the user has verified their email address
+func (s *UserService) IsVerified(userID uuid.UUID) (bool, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return false, err
+	}
+	return user.IsVerified, nil
+}
+
 // UpdateProfile updates a user's profile
 func (s *UserService) UpdateProfile(userID uuid.UUID, input UpdateProfileInput) (*models.UserResponse, error) {
+	if len(input.FirstName) > 100 || len(input.LastName) > 100 {
+		return nil, ErrProfileFieldTooLong
+	}
+	if input.ProfilePicture != "" {
+		u, err := url.Parse(input.ProfilePicture)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(input.ProfilePicture) > 1024 {
+			return nil, ErrInvalidProfilePicture
+		}
+	}
+
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
 		return nil, err
@@ -70,3 +108,47 @@ func (s *UserService) UpdateProfile(userID uuid.UUID, input UpdateProfileInput)
 	response := user.ToResponse()
 	return &response, nil
 }
+
+// UpdateMetadata merges a patch into the user's metadata and returns the result. Keys
+// set to null are removed; other keys are added or replaced whole, as in a JSON merge
+// patch of the top-level object.
+func (s *UserService) UpdateMetadata(userID uuid.UUID, patch models.JSONMap) (models.JSONMap, error) {
+	for key := range patch {
+		if key == "" || len(key) > MaxMetadataKeyLength {
+			return nil, ErrInvalidMetadataKey
+		}
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := models.JSONMap{}
+	for key, value := range user.Metadata {
+		metadata[key] = value
+	}
+	for key, value := range patch {
+		if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+			delete(metadata, key)
+			continue
+		}
+		metadata[key] = value
+	}
+
+	if len(metadata) > MaxMetadataKeys {
+		return nil, ErrMetadataTooLarge
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	if len(encoded) > MaxMetadataBytes {
+		return nil, ErrMetadataTooLarge
+	}
+
+	if err := s.userRepo.UpdateMetadata(userID, metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
@@ -0,0 +1,83 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+)
+
+// Sender delivers text messages to phone numbers in E.164 format
+type Sender interface {
+	Send(to, message string) error
+}
+
+// New returns an HTTP gateway client when a gateway is configured, otherwise a sender
+// that only logs
+func New(cfg *config.Config) Sender {
+	if cfg.SMSGatewayURL == "" {
+		log.Println("SMS_GATEWAY_URL not set, text messages will be logged instead of sent")
+		return &LogSender{}
+	}
+
+	return &HTTPGateway{
+		url:    cfg.SMSGatewayURL,
+		token:  cfg.SMSGatewayToken,
+		from:   cfg.SMSSender,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// HTTPGateway posts messages as JSON to an SMS provider's HTTP API, or to an adapter in
+// front of one. The token is sent as a bearer token.
+type HTTPGateway struct {
+	url    string
+	token  string
+	from   string
+	client *http.Client
+}
+
+// Send delivers a text message
+func (g *HTTPGateway) Send(to, message string) error {
+	payload, err := json.Marshal(map[string]string{
+		"from":    g.from,
+		"to":      to,
+		"message": message,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, g.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms gateway request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sms gateway returned %s", resp.Status)
+	}
+	return nil
+}
+
+// LogSender writes messages to the application log (development only)
+type LogSender struct{}
+
+// Send logs the message instead of delivering it
+func (s *LogSender) Send(to, message string) error {
+	log.Printf("📱 SMS to %s: %s", to, message)
+	return nil
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bankaceh/bas-portal-api/internal/config"
+)
+
+// Storage keeps uploaded files. Put returns the URL a file is served from, which is only
+// meaningful for public storage.
+type Storage interface {
+	Put(key, contentType string, data []byte) (string, error)
+	Get(key string) ([]byte, error)
+}
+
+// New returns public storage: S3 when an S3 endpoint is configured, otherwise a local
+// directory the API serves at /uploads
+func New(cfg *config.Config) Storage {
+	if cfg.S3Endpoint == "" {
+		log.Printf("S3_ENDPOINT not set, uploads will be stored in %s", cfg.UploadDir)
+		publicURL := cfg.UploadPublicURL
+		if publicURL == "" {
+			publicURL = "http://localhost:" + cfg.Port + "/uploads"
+		}
+		return &LocalStorage{dir: cfg.UploadDir, publicURL: strings.TrimRight(publicURL, "/")}
+	}
+
+	endpoint := strings.TrimRight(cfg.S3Endpoint, "/")
+	publicURL := cfg.UploadPublicURL
+	if publicURL == "" {
+		publicURL = endpoint + "/" + cfg.S3Bucket
+	}
+	return newS3Storage(cfg, endpoint, publicURL)
+}
+
+// NewPrivate returns storage for files only the API may read, such as KYC documents: the
+// same S3 bucket (keep its non-public prefixes private) or a local directory that is
+// never served
+func NewPrivate(cfg *config.Config) Storage {
+	if cfg.S3Endpoint == "" {
+		return &LocalStorage{dir: cfg.PrivateUploadDir}
+	}
+	return newS3Storage(cfg, strings.TrimRight(cfg.S3Endpoint, "/"), "")
+}
+
+func newS3Storage(cfg *config.Config, endpoint, publicURL string) *S3Storage {
+	return &S3Storage{
+		endpoint:  endpoint,
+		region:    cfg.S3Region,
+		bucket:    cfg.S3Bucket,
+		accessKey: cfg.S3AccessKey,
+		secretKey: cfg.S3SecretKey,
+		publicURL: strings.TrimRight(publicURL, "/"),
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// LocalStorage keeps files in a local directory
+type LocalStorage struct {
+	dir       string
+	publicURL string
+}
+
+// Put writes the file, creating parent directories as needed
+func (s *LocalStorage) Put(key, contentType string, data []byte) (string, error) {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write upload: %w", err)
+	}
+	return s.publicURL + "/" + key, nil
+}
+
+// Get reads a stored file
+func (s *LocalStorage) Get(key string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(key)))
+}
+
+// S3Storage uploads files to an S3-compatible bucket (AWS S3 or MinIO) with path-style
+// requests signed with Signature Version 4
+type S3Storage struct {
+	endpoint  string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	publicURL string
+	client    *http.Client
+}
+
+// Put uploads the file to the bucket
+func (s *S3Storage) Put(key, contentType string, data []byte) (string, error) {
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, data, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to S3: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("S3 upload failed with status %d: %s", resp.StatusCode, body)
+	}
+	return s.publicURL + "/" + key, nil
+}
+
+// Get downloads a file from the bucket
+func (s *S3Storage) Get(key string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, s.objectURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, nil, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download from S3: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("S3 download failed with status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (s *S3Storage) objectURL(key string) string {
+	return s.endpoint + "/" + s.bucket + "/" + key
+}
+
+// sign adds the AWS Signature Version 4 headers to an S3 request. Keys are generated by
+// the API and only contain URL-safe characters, so the path needs no further escaping.
+func (s *S3Storage) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Canonical headers, sorted by name
+	var names, headers []string
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		names = append(names, "content-type")
+		headers = append(headers, "content-type:"+contentType)
+	}
+	names = append(names, "host", "x-amz-content-sha256", "x-amz-date")
+	headers = append(headers, "host:"+req.URL.Host, "x-amz-content-sha256:"+payloadHash, "x-amz-date:"+amzDate)
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		strings.Join(headers, "\n") + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
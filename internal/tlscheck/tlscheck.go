@@ -0,0 +1,82 @@
+package tlscheck
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrNonPublicAddress = errors.New("host resolves to a non-public address")
+)
+
+// Result describes the TLS setup of a remote endpoint
+type Result struct {
+	Version       uint16
+	CertExpiresAt time.Time
+}
+
+// VersionName returns the negotiated TLS version, e.g. "TLS 1.3"
+func (r *Result) VersionName() string {
+	return tls.VersionName(r.Version)
+}
+
+// Probe performs a TLS handshake with the host of an https URL and reports the
+// negotiated version and leaf certificate expiry. Legacy TLS versions are offered
+// so they can be detected; the certificate chain is verified as usual.
+// Connections to loopback, private and link-local addresses are refused.
+func Probe(rawURL string, timeout time.Duration) (*Result, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: rejectNonPublic,
+	}
+
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, port), &tls.Config{
+		ServerName: host,
+		MinVersion: tls.VersionTLS10,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil, errors.New("server presented no certificate")
+	}
+
+	return &Result{
+		Version:       state.Version,
+		CertExpiresAt: state.PeerCertificates[0].NotAfter,
+	}, nil
+}
+
+// rejectNonPublic stops the dialer from reaching internal networks
+func rejectNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return ErrNonPublicAddress
+	}
+	return nil
+}